@@ -0,0 +1,122 @@
+package archive
+
+import (
+	"app/src/model"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ManifestName is the archive entry describing everything else in it.
+const ManifestName = "manifest.json"
+
+// Format and Version identify archives this package reads. Version goes up
+// when a change would make older readers restore an archive wrongly.
+const (
+	Format  = "pdf-summarizer-archive"
+	Version = 1
+)
+
+// Manifest lists the documents of an exported library. Identifiers are
+// those of the exporting instance; importing gives everything new ones.
+type Manifest struct {
+	Format     string    `json:"format"`
+	Version    int       `json:"version"`
+	ExportedAt time.Time `json:"exported_at"`
+	Account    Account   `json:"account"`
+	PDFs       []PDF     `json:"pdfs"`
+}
+
+// Account is who made the export, for reference only.
+type Account struct {
+	ID    uuid.UUID `json:"id"`
+	Name  string    `json:"name"`
+	Email string    `json:"email"`
+}
+
+// PDF is a document with what was generated from it. What can be read from
+// its file again, such as pages, outline and tables, is left out.
+type PDF struct {
+	ID               uuid.UUID              `json:"id"`
+	ParentID         *uuid.UUID             `json:"parent_id,omitempty"`
+	OriginalFilename string                 `json:"original_filename"`
+	SourceURL        *string                `json:"source_url,omitempty"`
+	Version          int                    `json:"version"`
+	Summary          *string                `json:"summary,omitempty"`
+	Language         string                 `json:"language"`
+	OutputType       string                 `json:"output_type"`
+	SummaryStatus    string                 `json:"summary_status"`
+	ChapterSummaries model.ChapterSummaries `json:"chapter_summaries,omitempty"`
+	RedactionReport  *model.RedactionReport `json:"redaction_report,omitempty"`
+	UploadDate       time.Time              `json:"upload_date"`
+	CreatedAt        time.Time              `json:"created_at"`
+	Versions         []FileVersion          `json:"versions"`
+	Logs             []Log                  `json:"logs,omitempty"`
+}
+
+// FileVersion is one uploaded file of a document. File is the entry holding
+// it, and is empty when the file couldn't be exported, such as one
+// quarantined as infected.
+type FileVersion struct {
+	Version          int       `json:"version"`
+	OriginalFilename string    `json:"original_filename"`
+	FileSize         int64     `json:"file_size"`
+	ContentHash      string    `json:"content_hash"`
+	File             string    `json:"file,omitempty"`
+	CreatedAt        time.Time `json:"created_at"`
+}
+
+// Log is an earlier summary, from the pdf_logs history.
+type Log struct {
+	Version    int       `json:"version"`
+	Summary    string    `json:"summary"`
+	Language   string    `json:"language"`
+	OutputType string    `json:"output_type"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// FileEntry names the entry holding a version of a document.
+func FileEntry(id uuid.UUID, version int) string {
+	return fmt.Sprintf("files/%s/v%d.pdf", id, version)
+}
+
+// Current returns the version the document is at.
+func (p *PDF) Current() *FileVersion {
+	for i := range p.Versions {
+		if p.Versions[i].Version == p.Version {
+			return &p.Versions[i]
+		}
+	}
+	return nil
+}
+
+var ErrUnknownFormat = errors.New("not an archive made by this service")
+
+// Check rejects manifests this version can't restore: other formats, newer
+// versions and inconsistent entries. Documents whose current file is
+// missing pass, to be reported one by one when importing.
+func (m *Manifest) Check() error {
+	if m.Format != Format {
+		return ErrUnknownFormat
+	}
+	if m.Version < 1 || m.Version > Version {
+		return fmt.Errorf("archive version %d is not supported, this service reads up to %d", m.Version, Version)
+	}
+
+	seen := make(map[uuid.UUID]bool, len(m.PDFs))
+	for _, pdf := range m.PDFs {
+		if pdf.ID == uuid.Nil || seen[pdf.ID] {
+			return fmt.Errorf("missing or repeated document id %s", pdf.ID)
+		}
+		seen[pdf.ID] = true
+
+		for _, v := range pdf.Versions {
+			if v.File != "" && v.File != FileEntry(pdf.ID, v.Version) {
+				return fmt.Errorf("document %s has an unexpected file entry %q", pdf.ID, v.File)
+			}
+		}
+	}
+	return nil
+}
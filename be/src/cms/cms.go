@@ -0,0 +1,292 @@
+// Package cms reads CMS (PKCS #7) SignedData, the format PDF signature
+// fields hold their signatures in, and verifies it against signed content.
+// Only DER encodings are read, which is what PDF signers produce.
+package cms
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	_ "crypto/sha1"
+	_ "crypto/sha256"
+	_ "crypto/sha512"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+)
+
+var (
+	ErrMalformed      = errors.New("malformed CMS signature")
+	ErrUnsupported    = errors.New("unsupported CMS signature")
+	ErrSignerNotFound = errors.New("signer certificate not included")
+	ErrDigestMismatch = errors.New("signed content does not match its digest")
+	ErrBadSignature   = errors.New("signature does not match the signer's key")
+)
+
+var (
+	oidSignedData    = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 2}
+	oidMessageDigest = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 4}
+	oidSigningTime   = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 5}
+	oidRSAPSS        = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 10}
+
+	digestAlgorithms = map[string]crypto.Hash{
+		"1.3.14.3.2.26":          crypto.SHA1,
+		"2.16.840.1.101.3.4.2.4": crypto.SHA224,
+		"2.16.840.1.101.3.4.2.1": crypto.SHA256,
+		"2.16.840.1.101.3.4.2.2": crypto.SHA384,
+		"2.16.840.1.101.3.4.2.3": crypto.SHA512,
+	}
+)
+
+type contentInfo struct {
+	ContentType asn1.ObjectIdentifier
+	Content     asn1.RawValue `asn1:"explicit,tag:0"`
+}
+
+type signedData struct {
+	Version          int
+	DigestAlgorithms []pkix.AlgorithmIdentifier `asn1:"set"`
+	EncapContentInfo encapContentInfo
+	Certificates     asn1.RawValue `asn1:"optional,tag:0"`
+	CRLs             asn1.RawValue `asn1:"optional,tag:1"`
+	SignerInfos      []signerInfo  `asn1:"set"`
+}
+
+type encapContentInfo struct {
+	ContentType asn1.ObjectIdentifier
+	Content     []byte `asn1:"explicit,optional,tag:0"`
+}
+
+type signerInfo struct {
+	Version            int
+	SID                asn1.RawValue
+	DigestAlgorithm    pkix.AlgorithmIdentifier
+	SignedAttrs        asn1.RawValue `asn1:"optional,tag:0"`
+	SignatureAlgorithm pkix.AlgorithmIdentifier
+	Signature          []byte
+	UnsignedAttrs      asn1.RawValue `asn1:"optional,tag:1"`
+}
+
+type issuerAndSerial struct {
+	Issuer asn1.RawValue
+	Serial *big.Int
+}
+
+type attribute struct {
+	Type   asn1.ObjectIdentifier
+	Values []asn1.RawValue `asn1:"set"`
+}
+
+// SignedData is a parsed signature with a single signer.
+type SignedData struct {
+	// Certificates are all certificates the signature carries, the
+	// signer's included.
+	Certificates []*x509.Certificate
+	// Signer is the certificate of the signing key.
+	Signer *x509.Certificate
+	// SigningTime is the time the signer claims to have signed at, if the
+	// signature says.
+	SigningTime *time.Time
+	// Hash is the digest algorithm the content was signed with.
+	Hash crypto.Hash
+
+	info          signerInfo
+	encapsulated  []byte
+	messageDigest []byte
+}
+
+// Parse reads DER-encoded SignedData. Bytes after the structure, such as
+// the zero padding of a PDF signature's Contents, are ignored.
+func Parse(der []byte) (*SignedData, error) {
+	var ci contentInfo
+	if _, err := asn1.Unmarshal(der, &ci); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrMalformed, err)
+	}
+	if !ci.ContentType.Equal(oidSignedData) {
+		return nil, fmt.Errorf("%w: content type %v is not signed data", ErrUnsupported, ci.ContentType)
+	}
+
+	var sd signedData
+	if _, err := asn1.Unmarshal(ci.Content.Bytes, &sd); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrMalformed, err)
+	}
+	if len(sd.SignerInfos) != 1 {
+		return nil, fmt.Errorf("%w: %d signers", ErrUnsupported, len(sd.SignerInfos))
+	}
+
+	s := &SignedData{info: sd.SignerInfos[0], encapsulated: sd.EncapContentInfo.Content}
+
+	hash, ok := digestAlgorithms[s.info.DigestAlgorithm.Algorithm.String()]
+	if !ok {
+		return nil, fmt.Errorf("%w: digest algorithm %v", ErrUnsupported, s.info.DigestAlgorithm.Algorithm)
+	}
+	s.Hash = hash
+
+	if len(sd.Certificates.Bytes) > 0 {
+		certs, err := x509.ParseCertificates(sd.Certificates.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrMalformed, err)
+		}
+		s.Certificates = certs
+	}
+
+	signer, err := s.findSigner()
+	if err != nil {
+		return nil, err
+	}
+	s.Signer = signer
+
+	if err := s.readSignedAttributes(); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+func (s *SignedData) findSigner() (*x509.Certificate, error) {
+	sid := s.info.SID
+
+	if sid.Class == asn1.ClassContextSpecific && sid.Tag == 0 {
+		for _, cert := range s.Certificates {
+			if len(cert.SubjectKeyId) > 0 && bytes.Equal(cert.SubjectKeyId, sid.Bytes) {
+				return cert, nil
+			}
+		}
+		return nil, ErrSignerNotFound
+	}
+
+	var id issuerAndSerial
+	if _, err := asn1.Unmarshal(sid.FullBytes, &id); err != nil {
+		return nil, fmt.Errorf("%w: signer identifier: %v", ErrMalformed, err)
+	}
+	for _, cert := range s.Certificates {
+		if bytes.Equal(cert.RawIssuer, id.Issuer.FullBytes) && cert.SerialNumber.Cmp(id.Serial) == 0 {
+			return cert, nil
+		}
+	}
+	return nil, ErrSignerNotFound
+}
+
+func (s *SignedData) readSignedAttributes() error {
+	rest := s.info.SignedAttrs.Bytes
+	for len(rest) > 0 {
+		var attr attribute
+		var err error
+		if rest, err = asn1.Unmarshal(rest, &attr); err != nil {
+			return fmt.Errorf("%w: signed attributes: %v", ErrMalformed, err)
+		}
+		if len(attr.Values) == 0 {
+			continue
+		}
+
+		switch {
+		case attr.Type.Equal(oidMessageDigest):
+			if _, err := asn1.Unmarshal(attr.Values[0].FullBytes, &s.messageDigest); err != nil {
+				return fmt.Errorf("%w: message digest: %v", ErrMalformed, err)
+			}
+		case attr.Type.Equal(oidSigningTime):
+			var t time.Time
+			if _, err := asn1.Unmarshal(attr.Values[0].FullBytes, &t); err == nil {
+				s.SigningTime = &t
+			}
+		}
+	}
+
+	if len(s.info.SignedAttrs.FullBytes) > 0 && s.messageDigest == nil {
+		return fmt.Errorf("%w: signed attributes have no message digest", ErrMalformed)
+	}
+	return nil
+}
+
+// Verify checks that the signature covers content and was made with the
+// signer's key. Detached signatures sign content itself; signatures that
+// encapsulate a SHA-1 digest of it, as adbe.pkcs7.sha1 does, sign that.
+func (s *SignedData) Verify(content []byte) error {
+	if s.encapsulated != nil {
+		digest := crypto.SHA1.New()
+		digest.Write(content)
+		if !bytes.Equal(digest.Sum(nil), s.encapsulated) {
+			return ErrDigestMismatch
+		}
+		content = s.encapsulated
+	}
+
+	h := s.Hash.New()
+	h.Write(content)
+	contentDigest := h.Sum(nil)
+
+	// With signed attributes, the signature covers them and they carry
+	// the digest of the content
+	signed := contentDigest
+	if s.messageDigest != nil {
+		if !bytes.Equal(s.messageDigest, contentDigest) {
+			return ErrDigestMismatch
+		}
+
+		attrs := make([]byte, len(s.info.SignedAttrs.FullBytes))
+		copy(attrs, s.info.SignedAttrs.FullBytes)
+		// Signed as an explicit SET OF, not the implicit [0] they are
+		// stored with
+		attrs[0] = 0x31
+
+		h := s.Hash.New()
+		h.Write(attrs)
+		signed = h.Sum(nil)
+		content = attrs
+	}
+
+	return s.checkSignature(content, signed)
+}
+
+func (s *SignedData) checkSignature(message, digest []byte) error {
+	var err error
+	switch pub := s.Signer.PublicKey.(type) {
+	case *rsa.PublicKey:
+		if s.info.SignatureAlgorithm.Algorithm.Equal(oidRSAPSS) {
+			err = rsa.VerifyPSS(pub, s.Hash, digest, s.info.Signature, nil)
+		} else {
+			err = rsa.VerifyPKCS1v15(pub, s.Hash, digest, s.info.Signature)
+		}
+	case *ecdsa.PublicKey:
+		if !ecdsa.VerifyASN1(pub, digest, s.info.Signature) {
+			err = ErrBadSignature
+		}
+	case ed25519.PublicKey:
+		if !ed25519.Verify(pub, message, s.info.Signature) {
+			err = ErrBadSignature
+		}
+	default:
+		return fmt.Errorf("%w: public key type %T", ErrUnsupported, pub)
+	}
+
+	if err != nil {
+		return ErrBadSignature
+	}
+	return nil
+}
+
+// VerifyChain builds a chain from the signer's certificate to one of roots,
+// using the other certificates of the signature as intermediates. The chain
+// is checked as of at, which the caller chooses: the signing time can only
+// be used when something it trusts, such as a timestamp, vouches for it.
+func (s *SignedData) VerifyChain(roots *x509.CertPool, at time.Time) ([][]*x509.Certificate, error) {
+	intermediates := x509.NewCertPool()
+	for _, cert := range s.Certificates {
+		if cert != s.Signer {
+			intermediates.AddCert(cert)
+		}
+	}
+
+	return s.Signer.Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		CurrentTime:   at,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	})
+}
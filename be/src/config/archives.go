@@ -0,0 +1,15 @@
+package config
+
+import "time"
+
+const (
+	// AccountExportExpiration is how long a finished export can be downloaded.
+	AccountExportExpiration = 24 * time.Hour
+
+	ArchiveImportMaxSize    = 500 * 1024 * 1024
+	ArchiveImportMaxEntries = 5000
+	ArchiveManifestMaxSize  = 50 * 1024 * 1024
+	// JSON compresses far better than PDFs, so the manifest gets its own
+	// ratio limit.
+	ArchiveManifestMaxRatio = 1000
+)
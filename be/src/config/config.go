@@ -2,6 +2,7 @@ package config
 
 import (
 	"app/src/utils"
+	"strings"
 
 	"github.com/spf13/viper"
 )
@@ -25,10 +26,18 @@ var (
 	SMTPUsername        string
 	SMTPPassword        string
 	EmailFrom           string
+	FrontendURL         string
 	GoogleClientID      string
 	GoogleClientSecret  string
 	RedirectURL         string
 	SummaryServiceURL 	string
+	OCRProvider         string
+	OCRLanguages        string
+	ClamdAddress        string
+	SignatureTrustStore string
+	RedactDetectors     []string
+	RedactPatternsFile  string
+	RedactRestore       bool
 )
 
 func init() {
@@ -49,6 +58,23 @@ func init() {
 	// summary
 	SummaryServiceURL = viper.GetString("SUMMARY_SERVICE_URL")
 
+	// ocr configuration
+	OCRProvider = viper.GetString("OCR_PROVIDER")
+	OCRLanguages = viper.GetString("OCR_LANGUAGES")
+
+	// malware scanning configuration
+	ClamdAddress = viper.GetString("CLAMD_ADDRESS")
+
+	// signature verification configuration
+	SignatureTrustStore = viper.GetString("SIGNATURE_TRUST_STORE")
+
+	// pii redaction configuration
+	RedactDetectors = strings.FieldsFunc(viper.GetString("REDACT_DETECTORS"), func(r rune) bool {
+		return r == ',' || r == ' '
+	})
+	RedactPatternsFile = viper.GetString("REDACT_PATTERNS_FILE")
+	RedactRestore = viper.GetBool("REDACT_RESTORE")
+
 	// jwt configuration
 	JWTSecret = viper.GetString("JWT_SECRET")
 	JWTAccessExp = viper.GetInt("JWT_ACCESS_EXP_MINUTES")
@@ -63,6 +89,9 @@ func init() {
 	SMTPPassword = viper.GetString("SMTP_PASSWORD")
 	EmailFrom = viper.GetString("EMAIL_FROM")
 
+	// links in emails point to the frontend app
+	FrontendURL = strings.TrimSuffix(viper.GetString("FRONTEND_URL"), "/")
+
 	// oauth2 configuration
 	GoogleClientID = viper.GetString("GOOGLE_CLIENT_ID")
 	GoogleClientSecret = viper.GetString("GOOGLE_CLIENT_SECRET")
@@ -0,0 +1,24 @@
+package config
+
+import "time"
+
+const (
+	// Sending limits per user, failed sends included.
+	SummaryEmailHourlyLimit         = 20
+	SummaryEmailDailyRecipientLimit = 100
+
+	SummaryEmailAttachmentMaxSize = 5 * 1024 * 1024
+
+	EmailOutboxPollInterval = 2 * time.Second
+	EmailOutboxBatchSize    = 10
+	// EmailSendLease is how long a sender holds an email before another
+	// may take it over, such as after a crash mid-send.
+	EmailSendLease = 2 * time.Minute
+	// Retries wait twice as long each time, from EmailRetryBaseDelay up to
+	// EmailRetryMaxDelay. About a day passes before an email is given up.
+	EmailOutboxMaxAttempts = 30
+	EmailRetryBaseDelay    = 30 * time.Second
+	EmailRetryMaxDelay     = time.Hour
+	// Sent emails are deleted after EmailOutboxRetention.
+	EmailOutboxRetention = 30 * 24 * time.Hour
+)
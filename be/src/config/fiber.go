@@ -16,5 +16,11 @@ func FiberConfig() fiber.Config {
 		ErrorHandler:  utils.ErrorHandler,
 		JSONEncoder:   sonic.Marshal,
 		JSONDecoder:   sonic.Unmarshal,
+		// Stream bodies so uploads go straight to disk instead of memory.
+		// BodyLimit then only decides how much is pre-read; the real limits
+		// are enforced by middleware.BodyLimitConfig and the upload handlers.
+		StreamRequestBody:            true,
+		DisablePreParseMultipartForm: true,
+		BodyLimit:                    RequestBodyLimit,
 	}
 }
@@ -0,0 +1,10 @@
+package config
+
+import "time"
+
+const (
+	// OCRMinPageText is how many non-space characters a page's text layer
+	// needs before the page is trusted without OCR.
+	OCRMinPageText = 16
+	OCRPageTimeout = 2 * time.Minute
+)
@@ -0,0 +1,9 @@
+package config
+
+import "time"
+
+const (
+	ShareDefaultExpiration = 7 * 24 * time.Hour
+	// SharePasswordHeader carries the password of a protected share link.
+	SharePasswordHeader = "X-Share-Password"
+)
@@ -0,0 +1,12 @@
+package config
+
+import "time"
+
+const (
+	TableDetectionWorkers = 2
+	// TableDetectionTimeout and TableDetectionMaxContent bound the work
+	// spent looking for tables in one file. Pages past either limit are
+	// left out and the file's tables are marked partial.
+	TableDetectionTimeout    = time.Minute
+	TableDetectionMaxContent = 32 * 1024 * 1024
+)
@@ -0,0 +1,13 @@
+package config
+
+import "time"
+
+const (
+	ThumbnailDefaultWidth = 256
+	// ThumbnailWidthStep rounds requested widths up so arbitrary widths
+	// can't fill the cache with near-identical images.
+	ThumbnailWidthStep     = 32
+	ThumbnailRenderers     = 2
+	ThumbnailRenderTimeout = 30 * time.Second
+	ThumbnailMaxAge        = time.Hour
+)
@@ -0,0 +1,32 @@
+package config
+
+import "time"
+
+const (
+	RequestBodyLimit = 4 * 1024 * 1024
+	PDFMaxSize       = 10 * 1024 * 1024
+	UploadExpiration = 24 * time.Hour
+	TusVersion       = "1.0.0"
+
+	BulkMaxFiles            = 50
+	BulkMaxArchiveSize      = 100 * 1024 * 1024
+	BulkMaxArchiveEntries   = 1000
+	BulkMaxCompressionRatio = 100
+
+	ImportTimeout      = 60 * time.Second
+	ImportMaxRedirects = 5
+
+	PDFUnlockExpiration = 30 * time.Minute
+	// Failed unlock attempts allowed per client and per PDF in every
+	// PDFUnlockAttemptWindow.
+	PDFUnlockMaxAttemptsPerIP  = 10
+	PDFUnlockMaxAttemptsPerPDF = 30
+	PDFUnlockAttemptWindow     = 15 * time.Minute
+
+	ScanTimeout = 2 * time.Minute
+	// Scans that failed are retried every so often, waiting twice as long
+	// after each failure, from ScanRetryBaseDelay up to ScanRetryMaxDelay.
+	ScanRetryPollInterval = time.Minute
+	ScanRetryBaseDelay    = time.Minute
+	ScanRetryMaxDelay     = 6 * time.Hour
+)
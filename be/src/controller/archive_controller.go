@@ -0,0 +1,147 @@
+package controller
+
+import (
+	"app/src/model"
+	"app/src/response"
+	"app/src/service"
+	"app/src/validation"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type ArchiveController struct {
+	PDFService service.PDFService
+}
+
+func NewArchiveController(pdfService service.PDFService) *ArchiveController {
+	return &ArchiveController{
+		PDFService: pdfService,
+	}
+}
+
+// @Tags         Archives
+// @Summary      Export the library
+// @Description  Start building a ZIP with every PDF, its earlier versions and its summary history, plus a manifest.json describing them. Poll the export until it is completed, then download it within 24 hours. PDFs are shared by every user, so only admins can export them.
+// @Security BearerAuth
+// @Produce      json
+// @Router       /exports [post]
+// @Success      202  {object}  response.AccountExportResponse
+// @Failure      401  {object}  example.Unauthorized  "Unauthorized"
+// @Failure      403  {object}  example.Forbidden  "Forbidden"
+func (a *ArchiveController) CreateExport(c *fiber.Ctx) error {
+	user, _ := c.Locals("user").(*model.User)
+
+	export, err := a.PDFService.CreateExport(c, user)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusAccepted).
+		JSON(response.AccountExportResponse{
+			Code:    fiber.StatusAccepted,
+			Status:  "success",
+			Message: "Export queued",
+			Data:    *export,
+		})
+}
+
+// @Tags         Archives
+// @Summary      Get an export
+// @Description  Check how an export is getting on
+// @Security BearerAuth
+// @Produce      json
+// @Param        id  path  string  true  "Export id"
+// @Router       /exports/{id} [get]
+// @Success      200  {object}  response.AccountExportResponse
+// @Failure      400  {object}  response.Common  "Bad Request"
+// @Failure      401  {object}  example.Unauthorized  "Unauthorized"
+// @Failure      403  {object}  example.Forbidden  "Forbidden"
+// @Failure      404  {object}  response.Common  "Not Found"
+func (a *ArchiveController) GetExport(c *fiber.Ctx) error {
+	user, _ := c.Locals("user").(*model.User)
+	exportID := c.Params("exportId")
+
+	if _, err := uuid.Parse(exportID); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid export ID")
+	}
+
+	export, err := a.PDFService.GetExport(c, user.ID, exportID)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).
+		JSON(response.AccountExportResponse{
+			Code:    fiber.StatusOK,
+			Status:  "success",
+			Message: "Get export successfully",
+			Data:    *export,
+		})
+}
+
+// @Tags         Archives
+// @Summary      Download an export
+// @Description  Download a completed export as a ZIP archive
+// @Security BearerAuth
+// @Produce      application/zip
+// @Param        id  path  string  true  "Export id"
+// @Router       /exports/{id}/download [get]
+// @Success      200  {file}    file
+// @Failure      400  {object}  response.Common  "Bad Request"
+// @Failure      401  {object}  example.Unauthorized  "Unauthorized"
+// @Failure      403  {object}  example.Forbidden  "Forbidden"
+// @Failure      404  {object}  response.Common  "Not Found"
+// @Failure      409  {object}  response.Common  "Export is not ready yet"
+// @Failure      410  {object}  response.Common  "Export has expired"
+func (a *ArchiveController) DownloadExport(c *fiber.Ctx) error {
+	user, _ := c.Locals("user").(*model.User)
+	exportID := c.Params("exportId")
+
+	if _, err := uuid.Parse(exportID); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid export ID")
+	}
+
+	return a.PDFService.SendExport(c, user.ID, exportID)
+}
+
+// @Tags         Archives
+// @Summary      Import an archive
+// @Description  Restore an archive made by an export, here or on another instance. Every document gets a new id. Documents whose file is already in the library are skipped unless on_conflict is duplicate. Like exports, only admins can import.
+// @Security BearerAuth
+// @Accept       multipart/form-data
+// @Produce      json
+// @Param        file         formData  file    true   "Export archive"
+// @Param        on_conflict  query     string  false  "What to do with documents already in the library"  Enums(skip, duplicate)
+// @Router       /imports [post]
+// @Success      200  {object}  response.ArchiveImportResponse
+// @Failure      400  {object}  response.Common  "Bad Request"
+// @Failure      401  {object}  example.Unauthorized  "Unauthorized"
+// @Failure      403  {object}  example.Forbidden  "Forbidden"
+func (a *ArchiveController) ImportArchive(c *fiber.Ctx) error {
+	query := &validation.QueryImportArchive{
+		OnConflict: c.Query("on_conflict"),
+	}
+
+	results, err := a.PDFService.ImportArchive(c, query)
+	if err != nil {
+		return err
+	}
+
+	res := response.ArchiveImportResponse{
+		Data:    results,
+		Message: "Archive imported",
+	}
+	for _, result := range results {
+		switch result.Status {
+		case service.ImportStatusImported:
+			res.Imported++
+		case service.ImportStatusSkipped:
+			res.Skipped++
+		case service.ImportStatusFailed:
+			res.Failed++
+		}
+	}
+
+	return c.Status(fiber.StatusOK).JSON(res)
+}
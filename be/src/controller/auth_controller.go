@@ -19,18 +19,15 @@ type AuthController struct {
 	AuthService  service.AuthService
 	UserService  service.UserService
 	TokenService service.TokenService
-	EmailService service.EmailService
 }
 
 func NewAuthController(
-	authService service.AuthService, userService service.UserService,
-	tokenService service.TokenService, emailService service.EmailService,
+	authService service.AuthService, userService service.UserService, tokenService service.TokenService,
 ) *AuthController {
 	return &AuthController{
 		AuthService:  authService,
 		UserService:  userService,
 		TokenService: tokenService,
-		EmailService: emailService,
 	}
 }
 
@@ -175,15 +172,10 @@ func (a *AuthController) ForgotPassword(c *fiber.Ctx) error {
 		return fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
 	}
 
-	resetPasswordToken, err := a.TokenService.GenerateResetPasswordToken(c, req)
-	if err != nil {
+	if err := a.AuthService.ForgotPassword(c, req); err != nil {
 		return err
 	}
 
-	if errEmail := a.EmailService.SendResetPasswordEmail(req.Email, resetPasswordToken); errEmail != nil {
-		return errEmail
-	}
-
 	return c.Status(fiber.StatusOK).
 		JSON(response.Common{
 			Code:    fiber.StatusOK,
@@ -234,15 +226,10 @@ func (a *AuthController) ResetPassword(c *fiber.Ctx) error {
 func (a *AuthController) SendVerificationEmail(c *fiber.Ctx) error {
 	user, _ := c.Locals("user").(*model.User)
 
-	verifyEmailToken, err := a.TokenService.GenerateVerifyEmailToken(c, user)
-	if err != nil {
+	if err := a.AuthService.SendVerificationEmail(c, user); err != nil {
 		return err
 	}
 
-	if errEmail := a.EmailService.SendVerificationEmail(user.Email, *verifyEmailToken); errEmail != nil {
-		return errEmail
-	}
-
 	return c.Status(fiber.StatusOK).
 		JSON(response.Common{
 			Code:    fiber.StatusOK,
@@ -0,0 +1,55 @@
+package controller
+
+import (
+	"app/src/response"
+	"app/src/service"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type BulkUploadController struct {
+	BulkUploadService service.BulkUploadService
+}
+
+func NewBulkUploadController(bulkUploadService service.BulkUploadService) *BulkUploadController {
+	return &BulkUploadController{
+		BulkUploadService: bulkUploadService,
+	}
+}
+
+// @Tags         PDFs
+// @Summary      Upload many PDFs at once
+// @Description  Upload several PDF files and/or ZIP archives of PDFs. Each file is validated on its own and reported as created, duplicate or rejected. Invalid summarization options fail the request when sent before the files, and are reported as summary_error of each created PDF when sent after them.
+// @Accept       multipart/form-data
+// @Produce      json
+// @Param        files        formData  file    true   "PDF files or ZIP archives"
+// @Param        summarize    formData  bool    false  "Queue summarization for created PDFs"
+// @Param        language     formData  string  false  "Summary language"  Enums(auto, id, en, ja)
+// @Param        output_type  formData  string  false  "Summary format"    Enums(paragraph, bullet, pointer)
+// @Router       /pdfs/bulk [post]
+// @Success      200  {object}  response.BulkUploadResponse
+// @Failure      400  {object}  response.Common  "Bad Request"
+// @Failure      500  {object}  response.Common  "Internal Server Error"
+func (b *BulkUploadController) BulkUpload(c *fiber.Ctx) error {
+	results, err := b.BulkUploadService.BulkUpload(c)
+	if err != nil {
+		return err
+	}
+
+	res := response.BulkUploadResponse{
+		Data:    results,
+		Message: "Bulk upload processed",
+	}
+	for _, result := range results {
+		switch result.Status {
+		case service.BulkStatusCreated:
+			res.Created++
+		case service.BulkStatusDuplicate:
+			res.Duplicates++
+		case service.BulkStatusRejected:
+			res.Rejected++
+		}
+	}
+
+	return c.Status(fiber.StatusOK).JSON(res)
+}
@@ -0,0 +1,208 @@
+package controller
+
+import (
+	"app/src/mail"
+	"app/src/model"
+	"app/src/response"
+	"app/src/service"
+	"app/src/validation"
+	"errors"
+	"math"
+	"slices"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type EmailController struct {
+	EmailService service.EmailService
+}
+
+func NewEmailController(emailService service.EmailService) *EmailController {
+	return &EmailController{
+		EmailService: emailService,
+	}
+}
+
+// @Tags         Emails
+// @Summary      List email templates
+// @Description  Only admins can list the templates and the locales they are written in.
+// @Security BearerAuth
+// @Produce      json
+// @Router       /emails/templates [get]
+// @Success      200  {object}  response.EmailTemplatesResponse
+// @Failure      401  {object}  example.Unauthorized  "Unauthorized"
+// @Failure      403  {object}  example.Forbidden  "Forbidden"
+func (e *EmailController) GetTemplates(c *fiber.Ctx) error {
+	return c.Status(fiber.StatusOK).
+		JSON(response.EmailTemplatesResponse{
+			Code:    fiber.StatusOK,
+			Status:  "success",
+			Message: "Get email templates successfully",
+			Data: response.EmailTemplates{
+				Templates: mail.Names,
+				Locales:   mail.Locales,
+			},
+		})
+}
+
+// @Tags         Emails
+// @Summary      Preview an email template
+// @Description  Only admins can preview emails. The template is rendered for a made-up user, as JSON or as the HTML or plain text part on its own.
+// @Security BearerAuth
+// @Produce      json,html,plain
+// @Param        name    path   string  true   "Template name"  Enums(reset_password, verify_email)
+// @Param        locale  query  string  false  "Locale"         Enums(en, id, ja)
+// @Param        format  query  string  false  "Format"         Enums(json, html, text)
+// @Router       /emails/templates/{name}/preview [get]
+// @Success      200  {object}  response.EmailPreviewResponse
+// @Failure      400  {object}  response.Common  "Bad Request"
+// @Failure      401  {object}  example.Unauthorized  "Unauthorized"
+// @Failure      403  {object}  example.Forbidden  "Forbidden"
+// @Failure      404  {object}  response.Common  "Not Found"
+func (e *EmailController) PreviewTemplate(c *fiber.Ctx) error {
+	name := c.Params("name")
+	locale := c.Query("locale", mail.DefaultLocale)
+	format := c.Query("format", "json")
+
+	if !slices.Contains(mail.Locales, locale) {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid locale")
+	}
+
+	message, err := e.EmailService.Preview(name, locale)
+	if errors.Is(err, mail.ErrUnknownTemplate) {
+		return fiber.NewError(fiber.StatusNotFound, "Email template not found")
+	}
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "Failed to render email template")
+	}
+
+	switch format {
+	case "html":
+		c.Set(fiber.HeaderContentType, fiber.MIMETextHTMLCharsetUTF8)
+		return c.Status(fiber.StatusOK).SendString(message.HTML)
+	case "text":
+		c.Set(fiber.HeaderContentType, fiber.MIMETextPlainCharsetUTF8)
+		return c.Status(fiber.StatusOK).SendString(message.Text)
+	case "json":
+		return c.Status(fiber.StatusOK).
+			JSON(response.EmailPreviewResponse{
+				Code:    fiber.StatusOK,
+				Status:  "success",
+				Message: "Preview email template successfully",
+				Data: response.EmailPreview{
+					Template: name,
+					Locale:   locale,
+					Subject:  message.Subject,
+					Text:     message.Text,
+					HTML:     message.HTML,
+				},
+			})
+	}
+	return fiber.NewError(fiber.StatusBadRequest, "Invalid format, use json, html or text")
+}
+
+// @Tags         Emails
+// @Summary      List outgoing emails
+// @Description  Only admins can list the outbox, newest first. Dead emails ran out of attempts or were refused by the mail server, and can be retried.
+// @Security BearerAuth
+// @Produce      json
+// @Param        status  query  string  false  "Status"          Enums(pending, sending, sent, dead)
+// @Param        page    query  int     false  "Page number"     default(1)
+// @Param        limit   query  int     false  "Items per page"  default(10)
+// @Router       /emails/outbox [get]
+// @Success      200  {object}  response.SuccessWithPaginate[model.EmailOutbox]
+// @Failure      400  {object}  response.Common  "Bad Request"
+// @Failure      401  {object}  example.Unauthorized  "Unauthorized"
+// @Failure      403  {object}  example.Forbidden  "Forbidden"
+func (e *EmailController) GetOutbox(c *fiber.Ctx) error {
+	query := &validation.QueryEmailOutbox{
+		Status: c.Query("status"),
+		Page:   c.QueryInt("page", 1),
+		Limit:  c.QueryInt("limit", 10),
+	}
+
+	emails, totalResults, err := e.EmailService.GetOutbox(c, query)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).
+		JSON(response.SuccessWithPaginate[model.EmailOutbox]{
+			Code:         fiber.StatusOK,
+			Status:       "success",
+			Message:      "Get outbox emails successfully",
+			Results:      emails,
+			Page:         query.Page,
+			Limit:        query.Limit,
+			TotalPages:   int64(math.Ceil(float64(totalResults) / float64(query.Limit))),
+			TotalResults: totalResults,
+		})
+}
+
+// @Tags         Emails
+// @Summary      Get an outgoing email
+// @Description  Only admins can see outgoing emails.
+// @Security BearerAuth
+// @Produce      json
+// @Param        id  path  string  true  "Email id"
+// @Router       /emails/outbox/{id} [get]
+// @Success      200  {object}  response.EmailOutboxResponse
+// @Failure      400  {object}  response.Common  "Bad Request"
+// @Failure      401  {object}  example.Unauthorized  "Unauthorized"
+// @Failure      403  {object}  example.Forbidden  "Forbidden"
+// @Failure      404  {object}  response.Common  "Not Found"
+func (e *EmailController) GetOutboxEmail(c *fiber.Ctx) error {
+	emailID := c.Params("emailId")
+
+	if _, err := uuid.Parse(emailID); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid email ID")
+	}
+
+	email, err := e.EmailService.GetOutboxEmail(c, emailID)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).
+		JSON(response.EmailOutboxResponse{
+			Code:    fiber.StatusOK,
+			Status:  "success",
+			Message: "Get outbox email successfully",
+			Data:    *email,
+		})
+}
+
+// @Tags         Emails
+// @Summary      Retry a dead email
+// @Description  Only admins can retry emails. The email is queued again with a fresh set of attempts.
+// @Security BearerAuth
+// @Produce      json
+// @Param        id  path  string  true  "Email id"
+// @Router       /emails/outbox/{id}/retry [post]
+// @Success      200  {object}  response.EmailOutboxResponse
+// @Failure      400  {object}  response.Common  "Bad Request"
+// @Failure      401  {object}  example.Unauthorized  "Unauthorized"
+// @Failure      403  {object}  example.Forbidden  "Forbidden"
+// @Failure      404  {object}  response.Common  "Not Found"
+// @Failure      409  {object}  response.Common  "Email is not dead"
+func (e *EmailController) RetryOutboxEmail(c *fiber.Ctx) error {
+	emailID := c.Params("emailId")
+
+	if _, err := uuid.Parse(emailID); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid email ID")
+	}
+
+	email, err := e.EmailService.RetryOutboxEmail(c, emailID)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).
+		JSON(response.EmailOutboxResponse{
+			Code:    fiber.StatusOK,
+			Status:  "success",
+			Message: "Email queued for sending again",
+			Data:    *email,
+		})
+}
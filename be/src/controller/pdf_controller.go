@@ -1,10 +1,13 @@
 package controller
 
 import (
+	"app/src/model"
 	"app/src/response"
 	"app/src/service"
 	"app/src/validation"
+	"fmt"
 	"math"
+	"strings"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
@@ -41,11 +44,47 @@ func (p *PDFController) UploadPDF(c *fiber.Ctx) error {
 			ID:               pdf.ID,
 			OriginalFilename: pdf.OriginalFilename,
 			FileSize:         pdf.FileSize,
+			PageCount:        pdf.PageCount,
+			ScanStatus:       pdf.ScanStatus,
 			UploadDate:       pdf.UploadDate,
 			Message:          "PDF uploaded successfully",
 		})
 }
 
+// @Tags         PDFs
+// @Summary      Import a PDF from a URL
+// @Description  Download a PDF from a public http(s) URL and store it like an uploaded file
+// @Accept       json
+// @Produce      json
+// @Param        request  body  validation.ImportPDF  true  "Request body"
+// @Router       /pdfs/import [post]
+// @Success      201  {object}  response.UploadPDFResponse
+// @Failure      400  {object}  response.Common  "Bad Request"
+// @Failure      502  {object}  response.Common  "Bad Gateway"
+func (p *PDFController) ImportPDF(c *fiber.Ctx) error {
+	req := new(validation.ImportPDF)
+
+	if err := c.BodyParser(req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	pdf, err := p.PDFService.ImportURL(c, req)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusCreated).
+		JSON(response.UploadPDFResponse{
+			ID:               pdf.ID,
+			OriginalFilename: pdf.OriginalFilename,
+			FileSize:         pdf.FileSize,
+			PageCount:        pdf.PageCount,
+			ScanStatus:       pdf.ScanStatus,
+			UploadDate:       pdf.UploadDate,
+			Message:          "PDF imported successfully",
+		})
+}
+
 // @Tags         PDFs
 // @Summary      Get all PDFs
 // @Description  Retrieve all uploaded PDFs with pagination and search
@@ -73,15 +112,35 @@ func (p *PDFController) GetPDFs(c *fiber.Ctx) error {
 	pdfResponses := make([]response.PDFResponse, len(pdfs))
 	for i, pdf := range pdfs {
 		pdfResponses[i] = response.PDFResponse{
-			ID:               pdf.ID,
-			OriginalFilename: pdf.OriginalFilename,
-			FileSize:         pdf.FileSize,
-			Summary:          pdf.Summary,
-			Language:         pdf.Language,
-			OutputType:       pdf.OutputType,
-			SummaryStatus:    pdf.SummaryStatus,
-			SummaryError:     pdf.SummaryError,
-			UploadDate:       pdf.UploadDate,
+			ID:                pdf.ID,
+			OriginalFilename:  pdf.OriginalFilename,
+			FileSize:          pdf.FileSize,
+			Version:           pdf.Version,
+			SourceURL:         pdf.SourceURL,
+			ParentID:          pdf.ParentID,
+			SpecVersion:       pdf.SpecVersion,
+			PageCount:         pdf.PageCount,
+			TableCount:        pdf.TableCount,
+			TableStatus:       pdf.TableStatus,
+			Encrypted:         pdf.Encrypted,
+			PasswordProtected: pdf.PasswordProtected,
+			OCRUsed:           pdf.OCRUsed,
+			ScanStatus:        pdf.ScanStatus,
+			ScanSignature:     pdf.ScanSignature,
+			Title:             pdf.Title,
+			Author:            pdf.Author,
+			Subject:           pdf.Subject,
+			Producer:          pdf.Producer,
+			CreationDate:      pdf.CreationDate,
+			Summary:           pdf.Summary,
+			Language:          pdf.Language,
+			OutputType:        pdf.OutputType,
+			SummaryStatus:     pdf.SummaryStatus,
+			SummaryError:      pdf.SummaryError,
+			ChapterSummaries:  pdf.ChapterSummaries,
+			RedactionReport:   pdf.RedactionReport,
+			ThumbnailURL:      "/v1/pdfs/" + pdf.ID.String() + "/pages/1/thumbnail",
+			UploadDate:        pdf.UploadDate,
 		}
 	}
 
@@ -118,15 +177,35 @@ func (p *PDFController) GetPDFByID(c *fiber.Ctx) error {
 
 	return c.Status(fiber.StatusOK).
 		JSON(response.PDFResponse{
-			ID:               pdf.ID,
-			OriginalFilename: pdf.OriginalFilename,
-			FileSize:         pdf.FileSize,
-			Summary:          pdf.Summary,
-			Language:         pdf.Language,
-			OutputType:       pdf.OutputType,
-			SummaryStatus:    pdf.SummaryStatus,
-			SummaryError:     pdf.SummaryError,
-			UploadDate:       pdf.UploadDate,
+			ID:                pdf.ID,
+			OriginalFilename:  pdf.OriginalFilename,
+			FileSize:          pdf.FileSize,
+			Version:           pdf.Version,
+			SourceURL:         pdf.SourceURL,
+			ParentID:          pdf.ParentID,
+			SpecVersion:       pdf.SpecVersion,
+			PageCount:         pdf.PageCount,
+			TableCount:        pdf.TableCount,
+			TableStatus:       pdf.TableStatus,
+			Encrypted:         pdf.Encrypted,
+			PasswordProtected: pdf.PasswordProtected,
+			OCRUsed:           pdf.OCRUsed,
+			ScanStatus:        pdf.ScanStatus,
+			ScanSignature:     pdf.ScanSignature,
+			Title:             pdf.Title,
+			Author:            pdf.Author,
+			Subject:           pdf.Subject,
+			Producer:          pdf.Producer,
+			CreationDate:      pdf.CreationDate,
+			Summary:           pdf.Summary,
+			Language:          pdf.Language,
+			OutputType:        pdf.OutputType,
+			SummaryStatus:     pdf.SummaryStatus,
+			SummaryError:      pdf.SummaryError,
+			ChapterSummaries:  pdf.ChapterSummaries,
+			RedactionReport:   pdf.RedactionReport,
+			ThumbnailURL:      "/v1/pdfs/" + pdf.ID.String() + "/pages/1/thumbnail",
+			UploadDate:        pdf.UploadDate,
 		})
 }
 
@@ -161,11 +240,12 @@ func (p *PDFController) DeletePDF(c *fiber.Ctx) error {
 
 // @Tags         PDFs
 // @Summary      Summarize a PDF
-// @Description  Generate a summary of the PDF content using AI
+// @Description  Generate a summary of the PDF content using AI. With mode "chapters", every top-level outline item is also summarized on its own. PDFs with scanned pages that need OCR are summarized in the background instead: the response is 202 and summary_status follows progress.
 // @Produce      json
 // @Param        id  path  string  true  "PDF id"
 // @Router       /pdfs/{id}/summarize [post]
 // @Success      200  {object}  response.SummaryResponse
+// @Success      202  {object}  response.Common
 // @Failure      400  {object}  response.Common  "Bad Request"
 // @Failure      404  {object}  response.Common  "Not Found"
 // @Failure      500  {object}  response.Common  "Internal Server Error"
@@ -187,12 +267,57 @@ func (p *PDFController) SummarizePDF(c *fiber.Ctx) error {
 		return err
 	}
 
+	if result == nil {
+		return c.Status(fiber.StatusAccepted).
+			JSON(response.Common{
+				Code:    fiber.StatusAccepted,
+				Status:  "success",
+				Message: "PDF has scanned pages, summarization was queued",
+			})
+	}
+
 	return c.Status(fiber.StatusOK).JSON(fiber.Map{
 		"success": true,
 		"data":    result,
 	})
 }
 
+// @Tags         PDFs
+// @Summary      Unlock a password-protected PDF
+// @Description  Check the document password and return a short-lived token to pass as unlock_token when summarizing. Failed attempts are limited per client and per PDF.
+// @Accept       json
+// @Produce      json
+// @Param        id       path  string              true  "PDF id"
+// @Param        request  body  validation.UnlockPDF  true  "Request body"
+// @Router       /pdfs/{id}/unlock [post]
+// @Success      200  {object}  response.UnlockPDFResponse
+// @Failure      400  {object}  response.Common  "Bad Request"
+// @Failure      404  {object}  response.Common  "Not Found"
+// @Failure      429  {object}  response.Common  "Too Many Requests"
+func (p *PDFController) UnlockPDF(c *fiber.Ctx) error {
+	pdfID := c.Params("pdfId")
+
+	if _, err := uuid.Parse(pdfID); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid PDF ID")
+	}
+
+	req := new(validation.UnlockPDF)
+	if err := c.BodyParser(req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	token, expiresAt, err := p.PDFService.UnlockPDF(c, pdfID, req)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(response.UnlockPDFResponse{
+		UnlockToken: token,
+		ExpiresAt:   expiresAt,
+		Message:     "PDF unlocked successfully",
+	})
+}
+
 // @Tags         PDFs
 // @Summary      Cancel PDF summarization
 // @Description  Cancel ongoing PDF summarization process
@@ -220,3 +345,470 @@ func (p *PDFController) CancelSummarization(c *fiber.Ctx) error {
 		Message: "Summarization cancelled successfully",
 	})
 }
+
+// @Tags         PDFs
+// @Summary      Upload a new version of a PDF
+// @Description  Attach a new file revision to an existing PDF. Prior files and their summaries are kept.
+// @Accept       multipart/form-data
+// @Produce      json
+// @Param        id    path      string  true  "PDF id"
+// @Param        file  formData  file    true  "PDF file to upload"
+// @Router       /pdfs/{id}/versions [post]
+// @Success      201  {object}  response.UploadPDFVersionResponse
+// @Failure      400  {object}  response.Common  "Bad Request"
+// @Failure      404  {object}  response.Common  "Not Found"
+// @Failure      500  {object}  response.Common  "Internal Server Error"
+func (p *PDFController) UploadVersion(c *fiber.Ctx) error {
+	pdfID := c.Params("pdfId")
+
+	if _, err := uuid.Parse(pdfID); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid PDF ID")
+	}
+
+	version, err := p.PDFService.UploadVersion(c, pdfID)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusCreated).
+		JSON(response.UploadPDFVersionResponse{
+			ID:               version.PDFID,
+			Version:          version.Version,
+			OriginalFilename: version.OriginalFilename,
+			FileSize:         version.FileSize,
+			ScanStatus:       version.ScanStatus,
+			UploadDate:       version.CreatedAt,
+			Message:          "PDF version uploaded successfully",
+		})
+}
+
+// @Tags         PDFs
+// @Summary      Get all versions of a PDF
+// @Description  Retrieve every file revision of a PDF, newest first
+// @Produce      json
+// @Param        id  path  string  true  "PDF id"
+// @Router       /pdfs/{id}/versions [get]
+// @Success      200  {object}  response.PDFVersionListResponse
+// @Failure      400  {object}  response.Common  "Bad Request"
+// @Failure      404  {object}  response.Common  "Not Found"
+func (p *PDFController) GetVersions(c *fiber.Ctx) error {
+	pdfID := c.Params("pdfId")
+
+	if _, err := uuid.Parse(pdfID); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid PDF ID")
+	}
+
+	versions, err := p.PDFService.GetVersions(c, pdfID)
+	if err != nil {
+		return err
+	}
+
+	versionResponses := make([]response.PDFVersionResponse, len(versions))
+	for i, version := range versions {
+		versionResponses[i] = response.PDFVersionResponse{
+			ID:               version.ID,
+			PDFID:            version.PDFID,
+			Version:          version.Version,
+			OriginalFilename: version.OriginalFilename,
+			FileSize:         version.FileSize,
+			ScanStatus:       version.ScanStatus,
+			CreatedAt:        version.CreatedAt,
+		}
+	}
+
+	return c.Status(fiber.StatusOK).
+		JSON(response.PDFVersionListResponse{Data: versionResponses})
+}
+
+// @Tags         PDFs
+// @Summary      Get the outline of a PDF
+// @Description  Retrieve the bookmarks of a PDF in reading order, with their nesting level and target page (0 when it is not in the document)
+// @Produce      json
+// @Param        id            path   string  true   "PDF id"
+// @Param        unlock_token  query  string  false  "Unlock token of a password-protected PDF"
+// @Router       /pdfs/{id}/outline [get]
+// @Success      200  {object}  response.OutlineResponse
+// @Failure      400  {object}  response.Common  "Bad Request"
+// @Failure      404  {object}  response.Common  "Not Found"
+func (p *PDFController) GetOutline(c *fiber.Ctx) error {
+	pdfID := c.Params("pdfId")
+
+	if _, err := uuid.Parse(pdfID); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid PDF ID")
+	}
+
+	query := &validation.QueryUnlockToken{
+		UnlockToken: c.Query("unlock_token"),
+	}
+
+	outline, err := p.PDFService.GetOutline(c, pdfID, query)
+	if err != nil {
+		return err
+	}
+
+	items := make([]response.OutlineItemResponse, len(outline))
+	for i, item := range outline {
+		items[i] = response.OutlineItemResponse{
+			Title: item.Title,
+			Level: item.Level,
+			Page:  item.Page,
+		}
+	}
+
+	return c.Status(fiber.StatusOK).
+		JSON(response.OutlineResponse{Data: items})
+}
+
+// @Tags         PDFs
+// @Summary      Get the form fields of a PDF
+// @Description  Retrieve the fields of a PDF's interactive form with their type, value and page (0 when it has no widget on a page)
+// @Produce      json
+// @Param        id            path   string  true   "PDF id"
+// @Param        unlock_token  query  string  false  "Unlock token of a password-protected PDF"
+// @Router       /pdfs/{id}/form-fields [get]
+// @Success      200  {object}  response.FormFieldListResponse
+// @Failure      400  {object}  response.Common  "Bad Request"
+// @Failure      404  {object}  response.Common  "Not Found"
+func (p *PDFController) GetFormFields(c *fiber.Ctx) error {
+	pdfID := c.Params("pdfId")
+
+	if _, err := uuid.Parse(pdfID); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid PDF ID")
+	}
+
+	query := &validation.QueryUnlockToken{
+		UnlockToken: c.Query("unlock_token"),
+	}
+
+	fields, err := p.PDFService.GetFormFields(c, pdfID, query)
+	if err != nil {
+		return err
+	}
+
+	fieldResponses := make([]response.FormFieldResponse, len(fields))
+	for i, field := range fields {
+		fieldResponses[i] = response.FormFieldResponse{
+			Name:  field.Name,
+			Type:  field.Type,
+			Value: field.Value,
+			Page:  field.Page,
+		}
+	}
+
+	return c.Status(fiber.StatusOK).
+		JSON(response.FormFieldListResponse{Data: fieldResponses})
+}
+
+// @Tags         PDFs
+// @Summary      Check the signatures of a PDF
+// @Description  Verify every signed signature field: byte range integrity, the CMS signature and the signer's certificate chain against the configured trust store, as of now since signing times are not timestamped. Signatures of files updated after signing are reported as modified
+// @Produce      json
+// @Param        id            path   string  true   "PDF id"
+// @Param        unlock_token  query  string  false  "Unlock token of a password-protected PDF"
+// @Router       /pdfs/{id}/signatures [get]
+// @Success      200  {object}  response.SignatureListResponse
+// @Failure      400  {object}  response.Common  "Bad Request"
+// @Failure      401  {object}  response.Common  "Unauthorized"
+// @Failure      404  {object}  response.Common  "Not Found"
+func (p *PDFController) GetSignatures(c *fiber.Ctx) error {
+	pdfID := c.Params("pdfId")
+
+	if _, err := uuid.Parse(pdfID); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid PDF ID")
+	}
+
+	query := &validation.QuerySignatures{
+		UnlockToken: c.Query("unlock_token"),
+	}
+
+	signatures, err := p.PDFService.GetSignatures(c, pdfID, query)
+	if err != nil {
+		return err
+	}
+
+	signatureResponses := make([]response.SignatureResponse, len(signatures))
+	for i, sig := range signatures {
+		signatureResponses[i] = response.SignatureResponse{
+			FieldName:      sig.FieldName,
+			Page:           sig.Page,
+			SubFilter:      sig.SubFilter,
+			SignerName:     sig.SignerName,
+			Subject:        sig.Subject,
+			Issuer:         sig.Issuer,
+			Serial:         sig.Serial,
+			NotBefore:      sig.NotBefore,
+			NotAfter:       sig.NotAfter,
+			SigningTime:    sig.SigningTime,
+			Reason:         sig.Reason,
+			Location:       sig.Location,
+			ContactInfo:    sig.ContactInfo,
+			Status:         sig.Status,
+			Intact:         sig.Intact,
+			Trusted:        sig.Trusted,
+			CoversDocument: sig.CoversDocument,
+			Problems:       sig.Problems,
+		}
+	}
+
+	return c.Status(fiber.StatusOK).
+		JSON(response.SignatureListResponse{Data: signatureResponses})
+}
+
+// @Tags         PDFs
+// @Summary      Get the tables of a PDF
+// @Description  Retrieve the tables detected in the text of a PDF, in reading order. Tables are detected in the background after upload; the PDF's table_status tells whether detection is still pending, completed, or stopped at its limits (partial)
+// @Produce      json
+// @Param        id            path   string  true   "PDF id"
+// @Param        unlock_token  query  string  false  "Unlock token of a password-protected PDF"
+// @Router       /pdfs/{id}/tables [get]
+// @Success      200  {object}  response.TableListResponse
+// @Failure      400  {object}  response.Common  "Bad Request"
+// @Failure      404  {object}  response.Common  "Not Found"
+func (p *PDFController) GetTables(c *fiber.Ctx) error {
+	pdfID := c.Params("pdfId")
+
+	if _, err := uuid.Parse(pdfID); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid PDF ID")
+	}
+
+	query := &validation.QueryUnlockToken{
+		UnlockToken: c.Query("unlock_token"),
+	}
+
+	tables, err := p.PDFService.GetTables(c, pdfID, query)
+	if err != nil {
+		return err
+	}
+
+	tableResponses := make([]response.TableResponse, len(tables))
+	for i, table := range tables {
+		tableResponses[i] = tableResponse(pdfID, table)
+	}
+
+	return c.Status(fiber.StatusOK).
+		JSON(response.TableListResponse{Data: tableResponses})
+}
+
+// @Tags         PDFs
+// @Summary      Get one table of a PDF
+// @Description  Retrieve a single detected table as JSON, or download it with format=csv
+// @Produce      json,text/csv
+// @Param        id      path   string  true   "PDF id"
+// @Param        n       path   int     true   "Table index, from 1"
+// @Param        format  query  string  false  "json or csv"  default(json)
+// @Param        unlock_token  query  string  false  "Unlock token of a password-protected PDF"
+// @Router       /pdfs/{id}/tables/{n} [get]
+// @Success      200  {object}  response.TableResponse
+// @Failure      400  {object}  response.Common  "Bad Request"
+// @Failure      404  {object}  response.Common  "Not Found"
+func (p *PDFController) GetTable(c *fiber.Ctx) error {
+	pdfID := c.Params("pdfId")
+
+	if _, err := uuid.Parse(pdfID); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid PDF ID")
+	}
+
+	index, err := c.ParamsInt("n")
+	if err != nil || index < 1 {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid table number")
+	}
+
+	query := &validation.QueryUnlockToken{
+		UnlockToken: c.Query("unlock_token"),
+	}
+
+	switch c.Query("format", "json") {
+	case "json":
+	case "csv":
+		return p.PDFService.SendTableCSV(c, pdfID, index, query)
+	default:
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid format")
+	}
+
+	table, err := p.PDFService.GetTable(c, pdfID, index, query)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(tableResponse(pdfID, *table))
+}
+
+func tableResponse(pdfID string, table model.PDFTable) response.TableResponse {
+	return response.TableResponse{
+		Index:       table.TableIndex,
+		PageNumber:  table.PageNumber,
+		RowCount:    table.RowCount,
+		ColumnCount: table.ColumnCount,
+		Rows:        table.Rows,
+		CSVURL:      fmt.Sprintf("/v1/pdfs/%s/tables/%d?format=csv", pdfID, table.TableIndex),
+	}
+}
+
+// @Tags         PDFs
+// @Summary      Export a summary
+// @Description  Download the summary of a PDF as a document with its metadata, highlights in bold, citations and generation details
+// @Produce      text/markdown,application/vnd.openxmlformats-officedocument.wordprocessingml.document,application/pdf,text/html
+// @Param        id      path   string  true   "PDF id"
+// @Param        format  query  string  false  "md, docx, pdf or html"  default(md)
+// @Router       /pdfs/{id}/summary/export [get]
+// @Success      200
+// @Failure      400  {object}  response.Common  "Bad Request"
+// @Failure      404  {object}  response.Common  "Not Found"
+func (p *PDFController) ExportSummary(c *fiber.Ctx) error {
+	pdfID := c.Params("pdfId")
+
+	if _, err := uuid.Parse(pdfID); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid PDF ID")
+	}
+
+	query := &validation.QueryExportSummaries{
+		PDFIDs: []string{pdfID},
+		Format: c.Query("format", "md"),
+	}
+
+	return p.PDFService.ExportSummaries(c, query)
+}
+
+// @Tags         PDFs
+// @Summary      Export several summaries
+// @Description  Download the summaries of several PDFs, in the given order, as one document
+// @Produce      text/markdown,application/vnd.openxmlformats-officedocument.wordprocessingml.document,application/pdf,text/html
+// @Param        ids     query  string  true   "Comma-separated PDF ids"
+// @Param        format  query  string  false  "md, docx, pdf or html"  default(md)
+// @Router       /pdfs/summaries/export [get]
+// @Success      200
+// @Failure      400  {object}  response.Common  "Bad Request"
+// @Failure      404  {object}  response.Common  "Not Found"
+func (p *PDFController) ExportSummaries(c *fiber.Ctx) error {
+	var ids []string
+	for _, id := range strings.Split(c.Query("ids"), ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			ids = append(ids, id)
+		}
+	}
+
+	query := &validation.QueryExportSummaries{
+		PDFIDs: ids,
+		Format: c.Query("format", "md"),
+	}
+
+	return p.PDFService.ExportSummaries(c, query)
+}
+
+// @Tags         PDFs
+// @Summary      Split a PDF by page ranges
+// @Description  Copy each page range into a new PDF linked to the original. Protected PDFs need an unlock_token.
+// @Accept       json
+// @Produce      json
+// @Param        id       path  string               true  "PDF id"
+// @Param        request  body  validation.SplitPDF  true  "Request body"
+// @Router       /pdfs/{id}/split [post]
+// @Success      201  {object}  response.SplitPDFResponse
+// @Failure      400  {object}  response.Common  "Bad Request"
+// @Failure      404  {object}  response.Common  "Not Found"
+// @Failure      409  {object}  response.Common  "Conflict"
+func (p *PDFController) SplitPDF(c *fiber.Ctx) error {
+	pdfID := c.Params("pdfId")
+
+	if _, err := uuid.Parse(pdfID); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid PDF ID")
+	}
+
+	req := new(validation.SplitPDF)
+	if err := c.BodyParser(req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	parts, err := p.PDFService.SplitPDF(c, pdfID, req)
+	if err != nil {
+		return err
+	}
+
+	partResponses := make([]response.UploadPDFResponse, len(parts))
+	for i, part := range parts {
+		partResponses[i] = uploadResponse(&part, "PDF created from page range")
+	}
+
+	return c.Status(fiber.StatusCreated).
+		JSON(response.SplitPDFResponse{
+			Data:    partResponses,
+			Message: fmt.Sprintf("PDF split into %d files", len(parts)),
+		})
+}
+
+// @Tags         PDFs
+// @Summary      Merge PDFs
+// @Description  Concatenate the pages of several PDFs, in the given order, into a new PDF. Protected PDFs need an entry in unlock_tokens.
+// @Accept       json
+// @Produce      json
+// @Param        request  body  validation.MergePDFs  true  "Request body"
+// @Router       /pdfs/merge [post]
+// @Success      201  {object}  response.UploadPDFResponse
+// @Failure      400  {object}  response.Common  "Bad Request"
+// @Failure      404  {object}  response.Common  "Not Found"
+// @Failure      409  {object}  response.Common  "Conflict"
+func (p *PDFController) MergePDFs(c *fiber.Ctx) error {
+	req := new(validation.MergePDFs)
+
+	if err := c.BodyParser(req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	pdf, err := p.PDFService.MergePDFs(c, req)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusCreated).
+		JSON(uploadResponse(pdf, "PDFs merged successfully"))
+}
+
+// @Tags         PDFs
+// @Summary      Redact a PDF
+// @Description  Create a copy of a PDF with personal data found by the given detectors, the given texts and the given regions removed from its content and blacked out. Annotations on redacted pages are removed too. The copy is linked to the original and carries a report of what was removed, without the removed values.
+// @Accept       json
+// @Produce      json
+// @Param        id       path  string                true  "PDF id"
+// @Param        request  body  validation.RedactPDF  true  "Request body"
+// @Router       /pdfs/{id}/redact [post]
+// @Success      201  {object}  response.RedactPDFResponse
+// @Failure      400  {object}  response.Common  "Bad Request"
+// @Failure      404  {object}  response.Common  "Not Found"
+// @Failure      409  {object}  response.Common  "Conflict"
+// @Failure      422  {object}  response.Common  "Unprocessable Entity"
+func (p *PDFController) RedactPDF(c *fiber.Ctx) error {
+	pdfID := c.Params("pdfId")
+
+	if _, err := uuid.Parse(pdfID); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid PDF ID")
+	}
+
+	req := new(validation.RedactPDF)
+	if err := c.BodyParser(req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	pdf, err := p.PDFService.RedactPDF(c, pdfID, req)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusCreated).
+		JSON(response.RedactPDFResponse{
+			UploadPDFResponse: uploadResponse(pdf, "Redacted PDF created"),
+			RedactionReport:   pdf.RedactionReport,
+		})
+}
+
+func uploadResponse(pdf *model.PDF, message string) response.UploadPDFResponse {
+	return response.UploadPDFResponse{
+		ID:               pdf.ID,
+		OriginalFilename: pdf.OriginalFilename,
+		FileSize:         pdf.FileSize,
+		PageCount:        pdf.PageCount,
+		ParentID:         pdf.ParentID,
+		ScanStatus:       pdf.ScanStatus,
+		UploadDate:       pdf.UploadDate,
+		Message:          message,
+	}
+}
@@ -47,6 +47,7 @@ func (ctrl *PDFLogController) GetAllLogs(c *fiber.Ctx) error {
 		logResponses = append(logResponses, response.PDFLogResponse{
 			ID:         log.ID,
 			PDFID:      log.PDFID,
+			Version:    log.Version,
 			Summary:    log.Summary,
 			Language:   log.Language,
 			OutputType: log.OutputType,
@@ -78,6 +79,7 @@ func (ctrl *PDFLogController) GetAllLogs(c *fiber.Ctx) error {
 // @Param pdf_id path string true "PDF ID"
 // @Param page query int false "Page number" default(1)
 // @Param limit query int false "Items per page" default(10)
+// @Param version query int false "Only logs generated from this file version"
 // @Success 200 {object} map[string]interface{} "Success response with logs data"
 // @Failure 400 {object} map[string]interface{} "Invalid query parameters"
 // @Failure 500 {object} map[string]interface{} "Internal server error"
@@ -87,6 +89,7 @@ func (ctrl *PDFLogController) GetLogsByPDFID(c *fiber.Ctx) error {
 	var params validation.QueryPDFLog
 	params.Page = c.QueryInt("page", 1)
 	params.Limit = c.QueryInt("limit", 10)
+	params.Version = c.QueryInt("version", 0)
 	params.SetDefaults()
 
 	logs, total, err := ctrl.PDFLogService.GetLogsByPDFID(c, pdfID, &params)
@@ -100,6 +103,7 @@ func (ctrl *PDFLogController) GetLogsByPDFID(c *fiber.Ctx) error {
 		logResponses = append(logResponses, response.PDFLogResponse{
 			ID:         log.ID,
 			PDFID:      log.PDFID,
+			Version:    log.Version,
 			Summary:    log.Summary,
 			Language:   log.Language,
 			OutputType: log.OutputType,
@@ -0,0 +1,259 @@
+package controller
+
+import (
+	"app/src/model"
+	"app/src/response"
+	"app/src/service"
+	"app/src/validation"
+	"math"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type ShareController struct {
+	ShareService service.ShareService
+}
+
+func NewShareController(shareService service.ShareService) *ShareController {
+	return &ShareController{
+		ShareService: shareService,
+	}
+}
+
+func (s *ShareController) shareResponse(share *model.PDFShare) (response.ShareResponse, error) {
+	token, err := s.ShareService.ShareToken(share)
+	if err != nil {
+		return response.ShareResponse{}, fiber.NewError(fiber.StatusInternalServerError, "Failed to sign share link")
+	}
+
+	return response.ShareResponse{
+		ID:                share.ID,
+		PDFID:             share.PDFID,
+		Token:             token,
+		URL:               "/v1/shared/" + token,
+		PasswordProtected: share.PasswordHash != nil,
+		ExpiresAt:         share.ExpiresAt,
+		MaxViews:          share.MaxViews,
+		ViewCount:         share.ViewCount,
+		RevokedAt:         share.RevokedAt,
+		CreatedAt:         share.CreatedAt,
+	}, nil
+}
+
+// @Tags         Shares
+// @Summary      Create a share link
+// @Description  Create a signed link that lets anyone holding it read the PDF and its summary until it expires, runs out of views or is revoked
+// @Accept       json
+// @Produce      json
+// @Param        id       path  string                  true  "PDF id"
+// @Param        request  body  validation.CreateShare  true  "Request body"
+// @Router       /pdfs/{id}/shares [post]
+// @Success      201  {object}  response.ShareResponse
+// @Failure      400  {object}  response.Common  "Bad Request"
+// @Failure      403  {object}  response.Common  "Forbidden"
+// @Failure      404  {object}  response.Common  "Not Found"
+func (s *ShareController) CreateShare(c *fiber.Ctx) error {
+	pdfID := c.Params("pdfId")
+
+	if _, err := uuid.Parse(pdfID); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid PDF ID")
+	}
+
+	req := new(validation.CreateShare)
+	if err := c.BodyParser(req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	share, err := s.ShareService.CreateShare(c, pdfID, req)
+	if err != nil {
+		return err
+	}
+
+	res, err := s.shareResponse(share)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(res)
+}
+
+// @Tags         Shares
+// @Summary      List share links
+// @Description  List every share link of a PDF, including expired and revoked ones
+// @Produce      json
+// @Param        id  path  string  true  "PDF id"
+// @Router       /pdfs/{id}/shares [get]
+// @Success      200  {object}  response.ShareListResponse
+// @Failure      400  {object}  response.Common  "Bad Request"
+// @Failure      404  {object}  response.Common  "Not Found"
+func (s *ShareController) GetShares(c *fiber.Ctx) error {
+	pdfID := c.Params("pdfId")
+
+	if _, err := uuid.Parse(pdfID); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid PDF ID")
+	}
+
+	shares, err := s.ShareService.GetShares(c, pdfID)
+	if err != nil {
+		return err
+	}
+
+	shareResponses := make([]response.ShareResponse, len(shares))
+	for i := range shares {
+		if shareResponses[i], err = s.shareResponse(&shares[i]); err != nil {
+			return err
+		}
+	}
+
+	return c.Status(fiber.StatusOK).JSON(response.ShareListResponse{Data: shareResponses})
+}
+
+// @Tags         Shares
+// @Summary      Revoke a share link
+// @Description  Stop a share link from working, for good
+// @Produce      json
+// @Param        id       path  string  true  "PDF id"
+// @Param        shareId  path  string  true  "Share link id"
+// @Router       /pdfs/{id}/shares/{shareId} [delete]
+// @Success      200  {object}  response.Common
+// @Failure      400  {object}  response.Common  "Bad Request"
+// @Failure      404  {object}  response.Common  "Not Found"
+func (s *ShareController) RevokeShare(c *fiber.Ctx) error {
+	pdfID := c.Params("pdfId")
+	shareID := c.Params("shareId")
+
+	if _, err := uuid.Parse(pdfID); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid PDF ID")
+	}
+	if _, err := uuid.Parse(shareID); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid share ID")
+	}
+
+	if err := s.ShareService.RevokeShare(c, pdfID, shareID); err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(response.Common{
+		Code:    fiber.StatusOK,
+		Status:  "success",
+		Message: "Share link revoked successfully",
+	})
+}
+
+// @Tags         Shares
+// @Summary      Get the access log of a share link
+// @Description  List views of a share link and refused attempts to open it, newest first
+// @Produce      json
+// @Param        id       path   string  true   "PDF id"
+// @Param        shareId  path   string  true   "Share link id"
+// @Param        page     query  int     false  "Page number"     default(1)
+// @Param        limit    query  int     false  "Items per page"  default(10)
+// @Router       /pdfs/{id}/shares/{shareId}/accesses [get]
+// @Success      200  {object}  response.SuccessWithPaginate[response.ShareAccessResponse]
+// @Failure      400  {object}  response.Common  "Bad Request"
+// @Failure      404  {object}  response.Common  "Not Found"
+func (s *ShareController) GetShareAccesses(c *fiber.Ctx) error {
+	pdfID := c.Params("pdfId")
+	shareID := c.Params("shareId")
+
+	if _, err := uuid.Parse(pdfID); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid PDF ID")
+	}
+	if _, err := uuid.Parse(shareID); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid share ID")
+	}
+
+	query := &validation.QueryShareAccess{
+		Page:  c.QueryInt("page", 1),
+		Limit: c.QueryInt("limit", 10),
+	}
+
+	accesses, totalResults, err := s.ShareService.GetShareAccesses(c, pdfID, shareID, query)
+	if err != nil {
+		return err
+	}
+
+	results := make([]response.ShareAccessResponse, len(accesses))
+	for i, access := range accesses {
+		results[i] = response.ShareAccessResponse{
+			ID:         access.ID,
+			Resource:   access.Resource,
+			Outcome:    access.Outcome,
+			IPAddress:  access.IPAddress,
+			UserAgent:  access.UserAgent,
+			AccessedAt: access.AccessedAt,
+		}
+	}
+
+	return c.Status(fiber.StatusOK).
+		JSON(response.SuccessWithPaginate[response.ShareAccessResponse]{
+			Code:         fiber.StatusOK,
+			Status:       "success",
+			Message:      "Share accesses retrieved successfully",
+			Results:      results,
+			Page:         query.Page,
+			Limit:        query.Limit,
+			TotalPages:   int64(math.Ceil(float64(totalResults) / float64(query.Limit))),
+			TotalResults: totalResults,
+		})
+}
+
+// @Tags         Shares
+// @Summary      Open a share link
+// @Description  Read the summary of a shared PDF without an account. Counts as a view.
+// @Produce      json
+// @Param        token             path    string  true   "Share token"
+// @Param        X-Share-Password  header  string  false  "Password of a protected share link"
+// @Router       /shared/{token} [get]
+// @Success      200  {object}  response.SharedPDFResponse
+// @Failure      401  {object}  response.Common  "Unauthorized"
+// @Failure      404  {object}  response.Common  "Not Found"
+// @Failure      410  {object}  response.Common  "Gone"
+func (s *ShareController) GetSharedPDF(c *fiber.Ctx) error {
+	token := c.Params("token")
+
+	pdf, share, err := s.ShareService.GetSharedPDF(c, token)
+	if err != nil {
+		return err
+	}
+
+	var viewsRemaining *int
+	if share.MaxViews != nil {
+		remaining := max(*share.MaxViews-share.ViewCount, 0)
+		viewsRemaining = &remaining
+	}
+
+	return c.Status(fiber.StatusOK).JSON(response.SharedPDFResponse{
+		OriginalFilename: pdf.OriginalFilename,
+		FileSize:         pdf.FileSize,
+		PageCount:        pdf.PageCount,
+		Title:            pdf.Title,
+		Author:           pdf.Author,
+		Summary:          pdf.Summary,
+		Language:         pdf.Language,
+		OutputType:       pdf.OutputType,
+		SummaryStatus:    pdf.SummaryStatus,
+		FileURL:          "/v1/shared/" + token + "/file",
+		ExpiresAt:        share.ExpiresAt,
+		ViewsRemaining:   viewsRemaining,
+		UpdatedAt:        pdf.UpdatedAt,
+	})
+}
+
+// @Tags         Shares
+// @Summary      Get the file behind a share link
+// @Description  Serve the shared PDF with range request support. Every response, including one to a range request, counts as a view.
+// @Produce      application/pdf
+// @Param        token             path    string  true   "Share token"
+// @Param        download          query   bool    false  "Send as an attachment"
+// @Param        X-Share-Password  header  string  false  "Password of a protected share link"
+// @Router       /shared/{token}/file [get]
+// @Success      200
+// @Success      206
+// @Failure      401  {object}  response.Common  "Unauthorized"
+// @Failure      404  {object}  response.Common  "Not Found"
+// @Failure      410  {object}  response.Common  "Gone"
+func (s *ShareController) GetSharedFile(c *fiber.Ctx) error {
+	return s.ShareService.SendSharedFile(c, c.Params("token"), c.QueryBool("download", false))
+}
@@ -0,0 +1,107 @@
+package controller
+
+import (
+	"app/src/model"
+	"app/src/response"
+	"app/src/service"
+	"app/src/validation"
+	"math"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type SummaryEmailController struct {
+	SummaryEmailService service.SummaryEmailService
+}
+
+func NewSummaryEmailController(summaryEmailService service.SummaryEmailService) *SummaryEmailController {
+	return &SummaryEmailController{
+		SummaryEmailService: summaryEmailService,
+	}
+}
+
+// @Tags         PDFs
+// @Summary      Email a summary
+// @Description  Queue the summary of a PDF as an HTML and plain text email, optionally with the PDF attached (up to 5 MB). Emails are sent in the background and retried if the mail server is unavailable; the delivery shows in the list of summary emails. Each user can send 20 emails an hour to at most 100 recipients a day.
+// @Security BearerAuth
+// @Accept       json
+// @Produce      json
+// @Param        id       path  string                   true  "PDF id"
+// @Param        request  body  validation.EmailSummary  true  "Request body"
+// @Router       /pdfs/{id}/summary/email [post]
+// @Success      202  {object}  response.SummaryEmailResponse
+// @Failure      400  {object}  response.Common  "Bad Request"
+// @Failure      401  {object}  example.Unauthorized  "Unauthorized"
+// @Failure      404  {object}  response.Common  "Not Found"
+// @Failure      429  {object}  response.Common  "Sending limit reached"
+func (s *SummaryEmailController) EmailSummary(c *fiber.Ctx) error {
+	user, _ := c.Locals("user").(*model.User)
+	pdfID := c.Params("pdfId")
+
+	if _, err := uuid.Parse(pdfID); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid PDF ID")
+	}
+
+	req := new(validation.EmailSummary)
+	if err := c.BodyParser(req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	email, err := s.SummaryEmailService.EmailSummary(c, user, pdfID, req)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusAccepted).
+		JSON(response.SummaryEmailResponse{
+			Code:    fiber.StatusAccepted,
+			Status:  "success",
+			Message: "Summary queued for sending",
+			Data:    *email,
+		})
+}
+
+// @Tags         PDFs
+// @Summary      List summary emails
+// @Description  List the emails you sent with the summary of a PDF, newest first, with how far each got in being delivered
+// @Security BearerAuth
+// @Produce      json
+// @Param        id     path   string  true   "PDF id"
+// @Param        page   query  int     false  "Page number"     default(1)
+// @Param        limit  query  int     false  "Items per page"  default(10)
+// @Router       /pdfs/{id}/summary/emails [get]
+// @Success      200  {object}  response.SuccessWithPaginate[model.SummaryEmail]
+// @Failure      400  {object}  response.Common  "Bad Request"
+// @Failure      401  {object}  example.Unauthorized  "Unauthorized"
+// @Failure      404  {object}  response.Common  "Not Found"
+func (s *SummaryEmailController) GetSummaryEmails(c *fiber.Ctx) error {
+	user, _ := c.Locals("user").(*model.User)
+	pdfID := c.Params("pdfId")
+
+	if _, err := uuid.Parse(pdfID); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid PDF ID")
+	}
+
+	query := &validation.QuerySummaryEmails{
+		Page:  c.QueryInt("page", 1),
+		Limit: c.QueryInt("limit", 10),
+	}
+
+	emails, totalResults, err := s.SummaryEmailService.GetSummaryEmails(c, user, pdfID, query)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).
+		JSON(response.SuccessWithPaginate[model.SummaryEmail]{
+			Code:         fiber.StatusOK,
+			Status:       "success",
+			Message:      "Summary emails retrieved successfully",
+			Results:      emails,
+			Page:         query.Page,
+			Limit:        query.Limit,
+			TotalPages:   int64(math.Ceil(float64(totalResults) / float64(query.Limit))),
+			TotalResults: totalResults,
+		})
+}
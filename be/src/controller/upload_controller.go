@@ -0,0 +1,203 @@
+package controller
+
+import (
+	"app/src/config"
+	"app/src/model"
+	"app/src/service"
+	"app/src/utils"
+	"app/src/validation"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+const tusContentType = "application/offset+octet-stream"
+
+type UploadController struct {
+	UploadService service.UploadService
+}
+
+func NewUploadController(uploadService service.UploadService) *UploadController {
+	return &UploadController{
+		UploadService: uploadService,
+	}
+}
+
+// TusResumable rejects requests that don't speak the tus version we support
+// and stamps every response with it.
+func (u *UploadController) TusResumable(c *fiber.Ctx) error {
+	c.Set("Tus-Resumable", config.TusVersion)
+
+	if c.Method() != fiber.MethodOptions && c.Get("Tus-Resumable") != config.TusVersion {
+		c.Set("Tus-Version", config.TusVersion)
+		return fiber.NewError(fiber.StatusPreconditionFailed, "Unsupported tus version")
+	}
+
+	return c.Next()
+}
+
+// @Tags         Uploads
+// @Summary      Discover tus server capabilities
+// @Router       /uploads [options]
+// @Success      204
+func (u *UploadController) Options(c *fiber.Ctx) error {
+	c.Set("Tus-Version", config.TusVersion)
+	c.Set("Tus-Max-Size", strconv.FormatInt(config.PDFMaxSize, 10))
+	c.Set("Tus-Extension", "creation,creation-with-upload,termination,expiration")
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// @Tags         Uploads
+// @Summary      Create a resumable upload
+// @Description  tus 1.0 creation. Upload-Metadata must contain a base64 encoded "filename" ending in .pdf.
+// @Param        Tus-Resumable    header  string  true   "tus version"  default(1.0.0)
+// @Param        Upload-Length    header  int     true   "Total size of the file in bytes"
+// @Param        Upload-Metadata  header  string  true   "tus metadata, e.g. filename ZG9jLnBkZg=="
+// @Router       /uploads [post]
+// @Success      201
+// @Failure      400  {object}  response.Common  "Bad Request"
+// @Failure      412  {object}  response.Common  "Precondition Failed"
+// @Failure      413  {object}  response.Common  "Request Entity Too Large"
+func (u *UploadController) CreateUpload(c *fiber.Ctx) error {
+	if c.Get("Upload-Defer-Length") != "" {
+		return fiber.NewError(fiber.StatusBadRequest, "Upload-Defer-Length is not supported")
+	}
+
+	length, err := strconv.ParseInt(c.Get("Upload-Length"), 10, 64)
+	if err != nil || length < 1 {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid Upload-Length")
+	}
+
+	rawMetadata := c.Get("Upload-Metadata")
+	metadata, err := utils.ParseTusMetadata(rawMetadata)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid Upload-Metadata")
+	}
+
+	req := &validation.CreateUpload{
+		Length:   length,
+		Filename: metadata["filename"],
+		Metadata: rawMetadata,
+	}
+
+	var chunk io.Reader
+	if c.Get(fiber.HeaderContentType) == tusContentType {
+		chunk = utils.BodyStream(c)
+	}
+
+	upload, err := u.UploadService.CreateUpload(c, req, chunk)
+	if err != nil {
+		return err
+	}
+
+	c.Location("/v1/uploads/" + upload.ID.String())
+	setUploadHeaders(c, upload)
+
+	return c.SendStatus(fiber.StatusCreated)
+}
+
+// @Tags         Uploads
+// @Summary      Get the offset of a resumable upload
+// @Param        id             path    string  true  "Upload id"
+// @Param        Tus-Resumable  header  string  true  "tus version"  default(1.0.0)
+// @Router       /uploads/{id} [head]
+// @Success      200
+// @Failure      404
+// @Failure      410
+func (u *UploadController) GetUpload(c *fiber.Ctx) error {
+	uploadID := c.Params("uploadId")
+
+	if _, err := uuid.Parse(uploadID); err != nil {
+		return fiber.NewError(fiber.StatusNotFound, "Upload not found")
+	}
+
+	upload, err := u.UploadService.GetUpload(c, uploadID)
+	if err != nil {
+		return err
+	}
+
+	c.Set(fiber.HeaderCacheControl, "no-store")
+	c.Set("Upload-Length", strconv.FormatInt(upload.Length, 10))
+	if upload.Metadata != "" {
+		c.Set("Upload-Metadata", upload.Metadata)
+	}
+	setUploadHeaders(c, upload)
+
+	return c.SendStatus(fiber.StatusOK)
+}
+
+// @Tags         Uploads
+// @Summary      Upload a chunk
+// @Description  Appends the request body at Upload-Offset. Once the last byte arrives the file is validated and stored as a PDF whose id is returned in Upload-PDF-ID.
+// @Accept       application/offset+octet-stream
+// @Param        id             path    string  true  "Upload id"
+// @Param        Tus-Resumable  header  string  true  "tus version"  default(1.0.0)
+// @Param        Upload-Offset  header  int     true  "Offset of this chunk"
+// @Router       /uploads/{id} [patch]
+// @Success      204
+// @Failure      400  {object}  response.Common  "Bad Request"
+// @Failure      404  {object}  response.Common  "Not Found"
+// @Failure      409  {object}  response.Common  "Offset mismatch"
+// @Failure      410  {object}  response.Common  "Upload expired"
+// @Failure      415  {object}  response.Common  "Unsupported Media Type"
+func (u *UploadController) PatchUpload(c *fiber.Ctx) error {
+	uploadID := c.Params("uploadId")
+
+	if _, err := uuid.Parse(uploadID); err != nil {
+		return fiber.NewError(fiber.StatusNotFound, "Upload not found")
+	}
+
+	if c.Get(fiber.HeaderContentType) != tusContentType {
+		return fiber.NewError(fiber.StatusUnsupportedMediaType, "Content-Type must be "+tusContentType)
+	}
+
+	offset, err := strconv.ParseInt(c.Get("Upload-Offset"), 10, 64)
+	if err != nil || offset < 0 {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid Upload-Offset")
+	}
+
+	upload, err := u.UploadService.WriteChunk(c, uploadID, offset, utils.BodyStream(c))
+	if err != nil {
+		return err
+	}
+
+	setUploadHeaders(c, upload)
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// @Tags         Uploads
+// @Summary      Terminate an upload
+// @Param        id             path    string  true  "Upload id"
+// @Param        Tus-Resumable  header  string  true  "tus version"  default(1.0.0)
+// @Router       /uploads/{id} [delete]
+// @Success      204
+// @Failure      404  {object}  response.Common  "Not Found"
+func (u *UploadController) TerminateUpload(c *fiber.Ctx) error {
+	uploadID := c.Params("uploadId")
+
+	if _, err := uuid.Parse(uploadID); err != nil {
+		return fiber.NewError(fiber.StatusNotFound, "Upload not found")
+	}
+
+	if err := u.UploadService.TerminateUpload(c, uploadID); err != nil {
+		return err
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+func setUploadHeaders(c *fiber.Ctx, upload *model.Upload) {
+	c.Set("Upload-Offset", strconv.FormatInt(upload.Offset, 10))
+
+	if upload.PDFID != nil {
+		c.Set("Upload-PDF-ID", upload.PDFID.String())
+		return
+	}
+
+	c.Set("Upload-Expires", upload.ExpiresAt.UTC().Format(http.TimeFormat))
+}
@@ -0,0 +1,15 @@
+CREATE OR REPLACE FUNCTION log_pdf_summary_changes()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF OLD.summary IS DISTINCT FROM NEW.summary AND OLD.summary IS NOT NULL THEN
+        INSERT INTO pdf_logs (pdf_id, summary, language, output_type, created_at)
+        VALUES (OLD.id, OLD.summary, OLD.language, OLD.output_type, OLD.updated_at);
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+ALTER TABLE pdf_logs DROP CONSTRAINT IF EXISTS fk_pdf_logs_pdf_version;
+ALTER TABLE pdf_logs DROP COLUMN IF EXISTS version;
+DROP TABLE IF EXISTS pdf_versions;
+ALTER TABLE pdfs DROP COLUMN IF EXISTS version;
@@ -0,0 +1,35 @@
+ALTER TABLE pdfs ADD COLUMN version INT NOT NULL DEFAULT 1;
+
+CREATE TABLE pdf_versions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    pdf_id UUID NOT NULL,
+    version INT NOT NULL,
+    filename VARCHAR(255) NOT NULL,
+    original_filename VARCHAR(255) NOT NULL,
+    file_path VARCHAR(500) NOT NULL,
+    file_size BIGINT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (pdf_id) REFERENCES pdfs(id) ON DELETE CASCADE
+);
+
+CREATE UNIQUE INDEX idx_pdf_versions_pdf_id_version ON pdf_versions(pdf_id, version);
+
+-- Every existing document becomes version 1 of itself
+INSERT INTO pdf_versions (pdf_id, version, filename, original_filename, file_path, file_size, created_at)
+SELECT id, 1, filename, original_filename, file_path, file_size, created_at FROM pdfs;
+
+ALTER TABLE pdf_logs ADD COLUMN version INT NOT NULL DEFAULT 1;
+ALTER TABLE pdf_logs
+    ADD CONSTRAINT fk_pdf_logs_pdf_version
+    FOREIGN KEY (pdf_id, version) REFERENCES pdf_versions(pdf_id, version) ON DELETE CASCADE;
+
+CREATE OR REPLACE FUNCTION log_pdf_summary_changes()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF OLD.summary IS DISTINCT FROM NEW.summary AND OLD.summary IS NOT NULL THEN
+        INSERT INTO pdf_logs (pdf_id, version, summary, language, output_type, created_at)
+        VALUES (OLD.id, OLD.version, OLD.summary, OLD.language, OLD.output_type, OLD.updated_at);
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
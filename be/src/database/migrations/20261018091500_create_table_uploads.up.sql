@@ -0,0 +1,15 @@
+CREATE TABLE uploads (
+    id UUID PRIMARY KEY,
+    original_filename VARCHAR(255) NOT NULL,
+    file_path VARCHAR(500) NOT NULL,
+    upload_length BIGINT NOT NULL,
+    upload_offset BIGINT NOT NULL DEFAULT 0,
+    metadata TEXT,
+    pdf_id UUID,
+    expires_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    FOREIGN KEY (pdf_id) REFERENCES pdfs(id) ON DELETE SET NULL
+);
+
+CREATE INDEX idx_uploads_expires_at ON uploads(expires_at);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_pdfs_content_hash;
+ALTER TABLE pdf_versions DROP COLUMN IF EXISTS content_hash;
+ALTER TABLE pdfs DROP COLUMN IF EXISTS content_hash;
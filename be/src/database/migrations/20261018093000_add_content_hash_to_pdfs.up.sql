@@ -0,0 +1,4 @@
+ALTER TABLE pdfs ADD COLUMN content_hash VARCHAR(64);
+ALTER TABLE pdf_versions ADD COLUMN content_hash VARCHAR(64);
+
+CREATE INDEX idx_pdfs_content_hash ON pdfs(content_hash);
@@ -0,0 +1 @@
+ALTER TABLE pdfs ADD COLUMN source_url TEXT;
@@ -0,0 +1,7 @@
+ALTER TABLE pdfs DROP COLUMN IF EXISTS creation_date;
+ALTER TABLE pdfs DROP COLUMN IF EXISTS producer;
+ALTER TABLE pdfs DROP COLUMN IF EXISTS subject;
+ALTER TABLE pdfs DROP COLUMN IF EXISTS author;
+ALTER TABLE pdfs DROP COLUMN IF EXISTS title;
+ALTER TABLE pdfs DROP COLUMN IF EXISTS page_count;
+ALTER TABLE pdfs DROP COLUMN IF EXISTS spec_version;
@@ -0,0 +1,7 @@
+ALTER TABLE pdfs ADD COLUMN spec_version VARCHAR(10);
+ALTER TABLE pdfs ADD COLUMN page_count INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE pdfs ADD COLUMN title TEXT;
+ALTER TABLE pdfs ADD COLUMN author TEXT;
+ALTER TABLE pdfs ADD COLUMN subject TEXT;
+ALTER TABLE pdfs ADD COLUMN producer TEXT;
+ALTER TABLE pdfs ADD COLUMN creation_date TIMESTAMP;
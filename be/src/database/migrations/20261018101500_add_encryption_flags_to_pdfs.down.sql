@@ -0,0 +1,2 @@
+ALTER TABLE pdfs DROP COLUMN IF EXISTS password_protected;
+ALTER TABLE pdfs DROP COLUMN IF EXISTS encrypted;
@@ -0,0 +1,2 @@
+ALTER TABLE pdfs ADD COLUMN encrypted BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE pdfs ADD COLUMN password_protected BOOLEAN NOT NULL DEFAULT FALSE;
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS pdf_pages;
+
+ALTER TABLE pdfs DROP COLUMN IF EXISTS ocr_used;
@@ -0,0 +1,15 @@
+ALTER TABLE pdfs ADD COLUMN ocr_used BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE TABLE pdf_pages (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    pdf_id UUID NOT NULL,
+    content_hash VARCHAR(64),
+    page_number INT NOT NULL,
+    text TEXT NOT NULL,
+    source VARCHAR(10) NOT NULL DEFAULT 'text',
+    confidence DOUBLE PRECISION,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (pdf_id) REFERENCES pdfs(id) ON DELETE CASCADE
+);
+
+CREATE UNIQUE INDEX idx_pdf_pages_pdf_id_page_number ON pdf_pages(pdf_id, page_number);
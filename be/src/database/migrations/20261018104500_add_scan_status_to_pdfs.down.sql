@@ -0,0 +1,3 @@
+ALTER TABLE pdf_versions DROP COLUMN IF EXISTS scan_status;
+ALTER TABLE pdfs DROP COLUMN IF EXISTS scan_signature;
+ALTER TABLE pdfs DROP COLUMN IF EXISTS scan_status;
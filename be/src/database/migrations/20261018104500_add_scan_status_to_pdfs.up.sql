@@ -0,0 +1,7 @@
+ALTER TABLE pdfs ADD COLUMN scan_status VARCHAR(20) NOT NULL DEFAULT 'pending';
+ALTER TABLE pdfs ADD COLUMN scan_signature TEXT;
+ALTER TABLE pdf_versions ADD COLUMN scan_status VARCHAR(20) NOT NULL DEFAULT 'pending';
+
+-- Files stored before scanning existed were never scanned
+UPDATE pdfs SET scan_status = 'skipped';
+UPDATE pdf_versions SET scan_status = 'skipped';
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS pdf_share_accesses;
+DROP TABLE IF EXISTS pdf_shares;
@@ -0,0 +1,27 @@
+CREATE TABLE pdf_shares (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    pdf_id UUID NOT NULL,
+    password_hash VARCHAR(255),
+    expires_at TIMESTAMP NOT NULL,
+    max_views INT,
+    view_count INT NOT NULL DEFAULT 0,
+    revoked_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (pdf_id) REFERENCES pdfs(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_pdf_shares_pdf_id ON pdf_shares(pdf_id);
+
+CREATE TABLE pdf_share_accesses (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    share_id UUID NOT NULL,
+    resource VARCHAR(20) NOT NULL,
+    outcome VARCHAR(20) NOT NULL,
+    ip_address VARCHAR(45),
+    user_agent VARCHAR(512),
+    accessed_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (share_id) REFERENCES pdf_shares(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_pdf_share_accesses_share_id ON pdf_share_accesses(share_id, accessed_at DESC);
@@ -0,0 +1,2 @@
+ALTER TABLE pdfs DROP COLUMN IF EXISTS chapter_summaries;
+ALTER TABLE pdfs DROP COLUMN IF EXISTS outline;
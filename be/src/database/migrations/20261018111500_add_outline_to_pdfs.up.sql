@@ -0,0 +1,2 @@
+ALTER TABLE pdfs ADD COLUMN outline JSONB;
+ALTER TABLE pdfs ADD COLUMN chapter_summaries JSONB;
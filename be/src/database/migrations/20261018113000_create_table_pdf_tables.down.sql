@@ -0,0 +1,2 @@
+ALTER TABLE pdfs DROP COLUMN IF EXISTS table_count;
+DROP TABLE IF EXISTS pdf_tables;
@@ -0,0 +1,15 @@
+CREATE TABLE pdf_tables (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    pdf_id UUID NOT NULL,
+    table_index INT NOT NULL,
+    page_number INT NOT NULL,
+    row_count INT NOT NULL,
+    column_count INT NOT NULL,
+    rows JSONB NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (pdf_id) REFERENCES pdfs(id) ON DELETE CASCADE
+);
+
+CREATE UNIQUE INDEX idx_pdf_tables_pdf_id_table_index ON pdf_tables(pdf_id, table_index);
+
+ALTER TABLE pdfs ADD COLUMN table_count INT NOT NULL DEFAULT 0;
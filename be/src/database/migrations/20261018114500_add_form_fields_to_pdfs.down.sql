@@ -0,0 +1 @@
+ALTER TABLE pdfs DROP COLUMN IF EXISTS form_fields;
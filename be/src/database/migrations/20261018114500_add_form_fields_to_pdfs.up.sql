@@ -0,0 +1 @@
+ALTER TABLE pdfs ADD COLUMN form_fields JSONB;
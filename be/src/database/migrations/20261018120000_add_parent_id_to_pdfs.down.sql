@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_pdfs_parent_id;
+
+ALTER TABLE pdfs DROP COLUMN IF EXISTS parent_id;
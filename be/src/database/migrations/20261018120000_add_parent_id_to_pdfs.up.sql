@@ -0,0 +1,3 @@
+ALTER TABLE pdfs ADD COLUMN parent_id UUID REFERENCES pdfs(id) ON DELETE SET NULL;
+
+CREATE INDEX idx_pdfs_parent_id ON pdfs(parent_id);
@@ -0,0 +1 @@
+ALTER TABLE pdfs ADD COLUMN redaction_report JSONB;
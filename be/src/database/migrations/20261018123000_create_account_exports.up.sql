@@ -0,0 +1,17 @@
+CREATE TABLE account_exports (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'queued',
+    file_path TEXT,
+    file_size BIGINT NOT NULL DEFAULT 0,
+    pdf_count INT NOT NULL DEFAULT 0,
+    error TEXT,
+    expires_at TIMESTAMP NOT NULL,
+    completed_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_account_exports_user_id ON account_exports(user_id);
+CREATE INDEX idx_account_exports_expires_at ON account_exports(expires_at);
@@ -0,0 +1,18 @@
+CREATE TABLE summary_emails (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    pdf_id UUID NOT NULL,
+    user_id UUID NOT NULL,
+    recipients JSONB NOT NULL,
+    recipient_count INT NOT NULL,
+    subject TEXT NOT NULL,
+    pdf_version INT NOT NULL,
+    attached BOOLEAN NOT NULL DEFAULT false,
+    status VARCHAR(20) NOT NULL,
+    error TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (pdf_id) REFERENCES pdfs(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_summary_emails_pdf_id ON summary_emails(pdf_id);
+CREATE INDEX idx_summary_emails_user_id_created_at ON summary_emails(user_id, created_at DESC);
@@ -0,0 +1 @@
+ALTER TABLE users ADD COLUMN locale VARCHAR(5) NOT NULL DEFAULT 'en';
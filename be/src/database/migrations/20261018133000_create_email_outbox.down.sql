@@ -0,0 +1,5 @@
+ALTER TABLE summary_emails DROP COLUMN IF EXISTS outbox_id;
+ALTER TABLE summary_emails ADD COLUMN status VARCHAR(20) NOT NULL DEFAULT 'sent';
+ALTER TABLE summary_emails ADD COLUMN error TEXT;
+
+DROP TABLE IF EXISTS email_outbox;
@@ -0,0 +1,24 @@
+CREATE TABLE email_outbox (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    recipients JSONB NOT NULL,
+    reply_to TEXT,
+    subject TEXT NOT NULL,
+    text_body TEXT NOT NULL,
+    html_body TEXT,
+    attachments JSONB,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    attempts INT NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    last_error TEXT,
+    sent_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_email_outbox_due ON email_outbox(next_attempt_at) WHERE status IN ('pending', 'sending');
+CREATE INDEX idx_email_outbox_status_created_at ON email_outbox(status, created_at DESC);
+
+-- Summary emails are sent through the outbox, which now tracks delivery
+ALTER TABLE summary_emails DROP COLUMN IF EXISTS status;
+ALTER TABLE summary_emails DROP COLUMN IF EXISTS error;
+ALTER TABLE summary_emails ADD COLUMN outbox_id UUID REFERENCES email_outbox(id) ON DELETE SET NULL;
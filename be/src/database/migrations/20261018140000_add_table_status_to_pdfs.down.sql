@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_pdfs_table_status;
+ALTER TABLE pdfs DROP COLUMN IF EXISTS table_status;
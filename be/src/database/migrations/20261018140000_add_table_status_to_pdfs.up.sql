@@ -0,0 +1,8 @@
+-- Tables are detected in the background; files inspected before were
+-- already searched in full
+ALTER TABLE pdfs ADD COLUMN table_status VARCHAR(20) NOT NULL DEFAULT 'completed';
+ALTER TABLE pdfs ALTER COLUMN table_status SET DEFAULT 'pending';
+-- except protected ones that were never unlocked
+UPDATE pdfs SET table_status = 'pending' WHERE password_protected AND page_count = 0;
+
+CREATE INDEX idx_pdfs_table_status ON pdfs(table_status) WHERE table_status = 'pending';
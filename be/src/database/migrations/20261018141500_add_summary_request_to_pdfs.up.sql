@@ -0,0 +1 @@
+ALTER TABLE pdfs ADD COLUMN summary_request JSONB;
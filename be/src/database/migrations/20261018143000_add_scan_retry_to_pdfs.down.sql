@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_pdfs_next_scan_at;
+ALTER TABLE pdfs DROP COLUMN IF EXISTS next_scan_at;
+ALTER TABLE pdfs DROP COLUMN IF EXISTS scan_failures;
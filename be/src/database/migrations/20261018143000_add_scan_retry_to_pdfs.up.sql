@@ -0,0 +1,4 @@
+ALTER TABLE pdfs ADD COLUMN scan_failures INT NOT NULL DEFAULT 0;
+ALTER TABLE pdfs ADD COLUMN next_scan_at TIMESTAMP;
+
+CREATE INDEX idx_pdfs_next_scan_at ON pdfs(next_scan_at) WHERE scan_status = 'error';
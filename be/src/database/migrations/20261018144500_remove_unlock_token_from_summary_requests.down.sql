@@ -0,0 +1 @@
+-- Removed unlock tokens can't be restored
@@ -0,0 +1,2 @@
+-- Unlock tokens hold the document password and must not be stored
+UPDATE pdfs SET summary_request = summary_request - 'unlock_token' WHERE summary_request ? 'unlock_token';
@@ -0,0 +1,7 @@
+CREATE TABLE rate_limits (
+    key VARCHAR(255) PRIMARY KEY,
+    hits INT NOT NULL DEFAULT 0,
+    expires_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX idx_rate_limits_expires_at ON rate_limits(expires_at);
@@ -0,0 +1,13 @@
+ALTER TABLE pdfs ADD COLUMN scan_failures INT NOT NULL DEFAULT 0;
+ALTER TABLE pdfs ADD COLUMN next_scan_at TIMESTAMP;
+
+UPDATE pdfs p
+SET scan_failures = v.scan_failures, next_scan_at = v.next_scan_at
+FROM pdf_versions v
+WHERE v.pdf_id = p.id AND v.version = p.version;
+
+CREATE INDEX idx_pdfs_next_scan_at ON pdfs(next_scan_at) WHERE scan_status = 'error';
+
+DROP INDEX IF EXISTS idx_pdf_versions_next_scan_at;
+ALTER TABLE pdf_versions DROP COLUMN IF EXISTS next_scan_at;
+ALTER TABLE pdf_versions DROP COLUMN IF EXISTS scan_failures;
@@ -0,0 +1,13 @@
+ALTER TABLE pdf_versions ADD COLUMN scan_failures INT NOT NULL DEFAULT 0;
+ALTER TABLE pdf_versions ADD COLUMN next_scan_at TIMESTAMP;
+
+UPDATE pdf_versions v
+SET scan_failures = p.scan_failures, next_scan_at = p.next_scan_at
+FROM pdfs p
+WHERE v.pdf_id = p.id AND v.version = p.version;
+
+CREATE INDEX idx_pdf_versions_next_scan_at ON pdf_versions(next_scan_at) WHERE scan_status = 'error';
+
+DROP INDEX IF EXISTS idx_pdfs_next_scan_at;
+ALTER TABLE pdfs DROP COLUMN IF EXISTS next_scan_at;
+ALTER TABLE pdfs DROP COLUMN IF EXISTS scan_failures;
@@ -0,0 +1,166 @@
+package export
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+const xmlHeader = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` + "\n"
+
+// The fixed parts of a WordprocessingML package. Bullet points use the
+// list defined in numbering.xml; everything else is plain styled
+// paragraphs.
+var docxParts = []struct{ name, content string }{
+	{"[Content_Types].xml", xmlHeader + `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+		`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+		`<Default Extension="xml" ContentType="application/xml"/>` +
+		`<Override PartName="/word/document.xml" ContentType="application/vnd.openxmlformats-officedocument.wordprocessingml.document.main+xml"/>` +
+		`<Override PartName="/word/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.wordprocessingml.styles+xml"/>` +
+		`<Override PartName="/word/numbering.xml" ContentType="application/vnd.openxmlformats-officedocument.wordprocessingml.numbering+xml"/>` +
+		`<Override PartName="/docProps/core.xml" ContentType="application/vnd.openxmlformats-package.core-properties+xml"/>` +
+		`</Types>`},
+	{"_rels/.rels", xmlHeader + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="word/document.xml"/>` +
+		`<Relationship Id="rId2" Type="http://schemas.openxmlformats.org/package/2006/relationships/metadata/core-properties" Target="docProps/core.xml"/>` +
+		`</Relationships>`},
+	{"word/_rels/document.xml.rels", xmlHeader + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>` +
+		`<Relationship Id="rId2" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/numbering" Target="numbering.xml"/>` +
+		`</Relationships>`},
+	{"word/styles.xml", xmlHeader + `<w:styles xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main">` +
+		`<w:docDefaults><w:rPrDefault><w:rPr><w:rFonts w:ascii="Calibri" w:hAnsi="Calibri" w:eastAsia="Yu Gothic"/><w:sz w:val="22"/></w:rPr></w:rPrDefault>` +
+		`<w:pPrDefault><w:pPr><w:spacing w:after="120" w:line="276" w:lineRule="auto"/></w:pPr></w:pPrDefault></w:docDefaults>` +
+		`<w:style w:type="paragraph" w:default="1" w:styleId="Normal"><w:name w:val="Normal"/></w:style>` +
+		`<w:style w:type="paragraph" w:styleId="Title"><w:name w:val="Title"/><w:basedOn w:val="Normal"/><w:next w:val="Normal"/>` +
+		`<w:pPr><w:spacing w:after="240"/><w:outlineLvl w:val="0"/></w:pPr><w:rPr><w:b/><w:sz w:val="40"/></w:rPr></w:style>` +
+		`<w:style w:type="paragraph" w:styleId="Heading1"><w:name w:val="heading 1"/><w:basedOn w:val="Normal"/><w:next w:val="Normal"/>` +
+		`<w:pPr><w:keepNext/><w:spacing w:before="360" w:after="120"/><w:outlineLvl w:val="1"/></w:pPr><w:rPr><w:b/><w:sz w:val="30"/></w:rPr></w:style>` +
+		`<w:style w:type="paragraph" w:styleId="Heading2"><w:name w:val="heading 2"/><w:basedOn w:val="Normal"/><w:next w:val="Normal"/>` +
+		`<w:pPr><w:keepNext/><w:spacing w:before="240" w:after="80"/><w:outlineLvl w:val="2"/></w:pPr><w:rPr><w:b/><w:sz w:val="24"/></w:rPr></w:style>` +
+		`<w:style w:type="paragraph" w:styleId="ListBullet"><w:name w:val="List Bullet"/><w:basedOn w:val="Normal"/>` +
+		`<w:pPr><w:numPr><w:ilvl w:val="0"/><w:numId w:val="1"/></w:numPr><w:spacing w:after="60"/></w:pPr></w:style>` +
+		`</w:styles>`},
+	{"word/numbering.xml", xmlHeader + `<w:numbering xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main">` +
+		`<w:abstractNum w:abstractNumId="0"><w:lvl w:ilvl="0"><w:start w:val="1"/><w:numFmt w:val="bullet"/><w:lvlText w:val="•"/>` +
+		`<w:lvlJc w:val="left"/><w:pPr><w:ind w:left="720" w:hanging="360"/></w:pPr></w:lvl></w:abstractNum>` +
+		`<w:num w:numId="1"><w:abstractNumId w:val="0"/></w:num>` +
+		`</w:numbering>`},
+}
+
+func writeDOCX(w io.Writer, summaries []Summary) error {
+	zw := zip.NewWriter(w)
+	for _, part := range docxParts {
+		if err := writeZipEntry(zw, part.name, part.content); err != nil {
+			return err
+		}
+	}
+
+	title := "Summaries"
+	if len(summaries) == 1 {
+		title = summaries[0].Title
+	}
+	core := xmlHeader + `<cp:coreProperties xmlns:cp="http://schemas.openxmlformats.org/package/2006/metadata/core-properties" ` +
+		`xmlns:dc="http://purl.org/dc/elements/1.1/" xmlns:dcterms="http://purl.org/dc/terms/" ` +
+		`xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance">` +
+		`<dc:title>` + xmlText(title) + `</dc:title>` +
+		`<dcterms:created xsi:type="dcterms:W3CDTF">` + time.Now().UTC().Format(time.RFC3339) + `</dcterms:created>` +
+		`</cp:coreProperties>`
+	if err := writeZipEntry(zw, "docProps/core.xml", core); err != nil {
+		return err
+	}
+
+	if err := writeZipEntry(zw, "word/document.xml", docxDocument(summaries)); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+func writeZipEntry(zw *zip.Writer, name, content string) error {
+	f, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(f, content)
+	return err
+}
+
+func docxDocument(summaries []Summary) string {
+	var b strings.Builder
+	b.WriteString(xmlHeader + `<w:document xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main"><w:body>`)
+
+	for i, s := range summaries {
+		if i > 0 {
+			b.WriteString(`<w:p><w:r><w:br w:type="page"/></w:r></w:p>`)
+		}
+
+		docxParagraph(&b, "Title", []run{{Text: s.Title}})
+		docxFields(&b, metadata(s))
+
+		docxParagraph(&b, "Heading1", []run{{Text: "Summary"}})
+		docxBlocks(&b, parseText(s.Text))
+
+		if len(s.Chapters) > 0 {
+			docxParagraph(&b, "Heading1", []run{{Text: "Chapters"}})
+			for _, chapter := range s.Chapters {
+				docxParagraph(&b, "Heading2", []run{{Text: chapterHeading(chapter)}})
+				docxBlocks(&b, parseText(chapter.Text))
+			}
+		}
+
+		docxParagraph(&b, "Heading1", []run{{Text: "Citations"}})
+		for n, cited := range citations(s) {
+			docxParagraph(&b, "", []run{{Text: fmt.Sprintf("%d. %s", n+1, cited)}})
+		}
+
+		docxParagraph(&b, "Heading1", []run{{Text: "Generation details"}})
+		docxFields(&b, details(s))
+	}
+
+	// A4 with 2.5 cm margins
+	b.WriteString(`<w:sectPr><w:pgSz w:w="11906" w:h="16838"/>` +
+		`<w:pgMar w:top="1417" w:right="1417" w:bottom="1417" w:left="1417" w:header="708" w:footer="708" w:gutter="0"/></w:sectPr>`)
+	b.WriteString(`</w:body></w:document>`)
+	return b.String()
+}
+
+func docxBlocks(b *strings.Builder, blocks []block) {
+	for _, blk := range blocks {
+		style := ""
+		if blk.Bullet {
+			style = "ListBullet"
+		}
+		docxParagraph(b, style, blk.Runs)
+	}
+}
+
+func docxFields(b *strings.Builder, fields []field) {
+	for _, f := range fields {
+		docxParagraph(b, "", []run{{Text: f.Label + ": ", Bold: true}, {Text: f.Value}})
+	}
+}
+
+func docxParagraph(b *strings.Builder, style string, runs []run) {
+	b.WriteString("<w:p>")
+	if style != "" {
+		b.WriteString(`<w:pPr><w:pStyle w:val="` + style + `"/></w:pPr>`)
+	}
+	for _, r := range runs {
+		b.WriteString("<w:r>")
+		if r.Bold {
+			b.WriteString("<w:rPr><w:b/></w:rPr>")
+		}
+		b.WriteString(`<w:t xml:space="preserve">` + xmlText(r.Text) + "</w:t></w:r>")
+	}
+	b.WriteString("</w:p>")
+}
+
+// xmlText escapes text for XML, replacing characters XML can't hold.
+func xmlText(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
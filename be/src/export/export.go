@@ -0,0 +1,231 @@
+package export
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Document formats summaries can be exported to.
+const (
+	FormatMarkdown = "md"
+	FormatDOCX     = "docx"
+	FormatPDF      = "pdf"
+	FormatHTML     = "html"
+)
+
+var ErrUnsupportedFormat = errors.New("unsupported export format")
+
+// Summary is one summarized PDF to export.
+type Summary struct {
+	Title     string
+	Filename  string
+	Author    string
+	PageCount int
+	Version   int
+	SourceURL string
+	// Text is the summary as the summarizer wrote it, with highlighted
+	// terms in <mark> tags.
+	Text        string
+	Chapters    []Chapter
+	Language    string
+	OutputType  string
+	GeneratedAt time.Time
+}
+
+// Chapter is the summary of a range of pages.
+type Chapter struct {
+	Title     string
+	StartPage int
+	EndPage   int
+	Text      string
+}
+
+// ContentType returns the media type of an export format.
+func ContentType(format string) string {
+	switch format {
+	case FormatDOCX:
+		return "application/vnd.openxmlformats-officedocument.wordprocessingml.document"
+	case FormatPDF:
+		return "application/pdf"
+	case FormatHTML:
+		return "text/html; charset=utf-8"
+	}
+	return "text/markdown; charset=utf-8"
+}
+
+// Write writes summaries to w as one document in format, each summary
+// starting on a page of its own where the format has pages.
+func Write(w io.Writer, format string, summaries []Summary) error {
+	switch format {
+	case FormatMarkdown:
+		return writeMarkdown(w, nil, summaries)
+	case FormatDOCX:
+		return writeDOCX(w, summaries)
+	case FormatPDF:
+		return writePDF(w, summaries)
+	case FormatHTML:
+		return writeHTML(w, nil, summaries)
+	}
+	return ErrUnsupportedFormat
+}
+
+// WriteEmail writes a summary as the plain text and HTML parts of an email,
+// after the intro paragraphs.
+func WriteEmail(textPart, htmlPart io.Writer, intro []string, s Summary) error {
+	if err := writeMarkdown(textPart, intro, []Summary{s}); err != nil {
+		return err
+	}
+	return writeHTML(htmlPart, intro, []Summary{s})
+}
+
+// block is a paragraph or bullet point of summary text.
+type block struct {
+	Bullet bool
+	Runs   []run
+}
+
+// run is text in one style; highlighted terms are bold.
+type run struct {
+	Text string
+	Bold bool
+}
+
+var (
+	bulletPattern = regexp.MustCompile(`^\s*(?:[-*•]|\d+[.)])\s+`)
+	// Highlights come as <mark> tags; Markdown bold is taken too, as the
+	// model sometimes falls back to it. Other tags are dropped.
+	markupPattern = regexp.MustCompile(`(?i)<mark\b[^>]*>|</mark\s*>|\*\*|<br\s*/?>|<[^>]+>`)
+)
+
+// parseText splits summary text into blocks: bullet points, and paragraphs
+// separated by blank lines.
+func parseText(text string) []block {
+	text = markupPattern.ReplaceAllStringFunc(text, func(tag string) string {
+		if strings.HasPrefix(strings.ToLower(tag), "<br") {
+			return "\n"
+		}
+		return tag
+	})
+
+	var blocks []block
+	var paragraph []string
+	flush := func() {
+		if len(paragraph) > 0 {
+			blocks = append(blocks, block{Runs: parseRuns(strings.Join(paragraph, " "))})
+			paragraph = nil
+		}
+	}
+
+	for _, line := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "":
+			flush()
+		case bulletPattern.MatchString(line):
+			flush()
+			blocks = append(blocks, block{Bullet: true, Runs: parseRuns(bulletPattern.ReplaceAllString(line, ""))})
+		default:
+			paragraph = append(paragraph, line)
+		}
+	}
+	flush()
+	return blocks
+}
+
+// parseRuns splits a line at highlight markup into plain and bold runs.
+func parseRuns(line string) []run {
+	var runs []run
+	bold := false
+	add := func(text string) {
+		text = html.UnescapeString(text)
+		if text == "" {
+			return
+		}
+		if n := len(runs); n > 0 && runs[n-1].Bold == bold {
+			runs[n-1].Text += text
+			return
+		}
+		runs = append(runs, run{Text: text, Bold: bold})
+	}
+
+	last := 0
+	for _, loc := range markupPattern.FindAllStringIndex(line, -1) {
+		add(line[last:loc[0]])
+		tag := strings.ToLower(line[loc[0]:loc[1]])
+		switch {
+		case tag == "**":
+			bold = !bold
+		case strings.HasPrefix(tag, "<mark"):
+			bold = true
+		case strings.HasPrefix(tag, "</mark"):
+			bold = false
+		}
+		last = loc[1]
+	}
+	add(line[last:])
+	return runs
+}
+
+// field is a labelled value shown in a summary's metadata or details.
+type field struct {
+	Label, Value string
+}
+
+func metadata(s Summary) []field {
+	fields := []field{{"File", s.Filename}}
+	if s.Author != "" {
+		fields = append(fields, field{"Author", s.Author})
+	}
+	fields = append(fields, field{"Pages", fmt.Sprint(s.PageCount)}, field{"Version", fmt.Sprint(s.Version)})
+	if s.SourceURL != "" {
+		fields = append(fields, field{"Source", s.SourceURL})
+	}
+	return fields
+}
+
+func details(s Summary) []field {
+	return []field{
+		{"Language", s.Language},
+		{"Output type", s.OutputType},
+		{"Generated", s.GeneratedAt.UTC().Format("2006-01-02 15:04 UTC")},
+	}
+}
+
+// citations lists what the summary draws on: the document, then the page
+// ranges of its chapters.
+func citations(s Summary) []string {
+	var b strings.Builder
+	if s.Author != "" {
+		b.WriteString(s.Author + ". ")
+	}
+	b.WriteString(s.Title + ". " + s.Filename)
+	if s.PageCount > 0 {
+		fmt.Fprintf(&b, ", %s", pageRange(1, s.PageCount))
+	}
+	b.WriteString(".")
+	if s.SourceURL != "" {
+		b.WriteString(" " + s.SourceURL)
+	}
+
+	cited := []string{b.String()}
+	for _, chapter := range s.Chapters {
+		cited = append(cited, fmt.Sprintf("%s, %s.", chapter.Title, pageRange(chapter.StartPage, chapter.EndPage)))
+	}
+	return cited
+}
+
+func pageRange(start, end int) string {
+	if start == end {
+		return fmt.Sprintf("p. %d", start)
+	}
+	return fmt.Sprintf("pp. %d–%d", start, end)
+}
+
+func chapterHeading(chapter Chapter) string {
+	return fmt.Sprintf("%s (%s)", chapter.Title, pageRange(chapter.StartPage, chapter.EndPage))
+}
@@ -0,0 +1,99 @@
+package export
+
+import "unicode"
+
+// Advance widths of the printable ASCII characters, from space to tilde,
+// in the standard Helvetica fonts.
+var (
+	helveticaWidths = [95]float64{
+		278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278,
+		556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556,
+		1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778,
+		667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556,
+		333, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556,
+		556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584,
+	}
+	helveticaBoldWidths = [95]float64{
+		278, 333, 474, 556, 556, 889, 722, 238, 333, 333, 389, 584, 278, 333, 278, 278,
+		556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 333, 333, 584, 584, 584, 611,
+		975, 722, 722, 722, 722, 667, 611, 778, 722, 278, 556, 722, 611, 833, 722, 778,
+		667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 333, 278, 333, 584, 556,
+		333, 556, 611, 556, 611, 556, 333, 611, 611, 278, 278, 556, 278, 889, 611, 611,
+		611, 611, 389, 556, 333, 611, 556, 778, 556, 556, 500, 389, 280, 389, 584,
+	}
+)
+
+// winAnsiSpecials are the characters WinAnsiEncoding puts at 0x80 to 0x9F,
+// where Latin-1 has control codes.
+var winAnsiSpecials = map[rune]byte{
+	'€': 0x80, '‚': 0x82, 'ƒ': 0x83, '„': 0x84, '…': 0x85, '†': 0x86, '‡': 0x87, 'ˆ': 0x88,
+	'‰': 0x89, 'Š': 0x8a, '‹': 0x8b, 'Œ': 0x8c, 'Ž': 0x8e, '‘': 0x91, '’': 0x92, '“': 0x93,
+	'”': 0x94, '•': 0x95, '–': 0x96, '—': 0x97, '˜': 0x98, '™': 0x99, 'š': 0x9a, '›': 0x9b,
+	'œ': 0x9c, 'ž': 0x9e, 'Ÿ': 0x9f,
+}
+
+// specialWidths are widths of the wider or narrower specials; the others
+// take the width of the letter they are based on.
+var specialWidths = map[rune]float64{
+	'…': 1000, '‰': 1000, '—': 1000, '™': 1000, 'Œ': 1000, 'œ': 944,
+	'‘': 222, '’': 222, '‚': 222, '“': 333, '”': 333, '„': 333, '•': 350, '‹': 333, '›': 333,
+}
+
+// winAnsiCode returns the WinAnsiEncoding code of c.
+func winAnsiCode(c rune) (byte, bool) {
+	switch {
+	case c >= 0x20 && c <= 0x7e, c >= 0xa0 && c <= 0xff:
+		return byte(c), true
+	}
+	code, ok := winAnsiSpecials[c]
+	return code, ok
+}
+
+// winAnsiRune returns the character at code in WinAnsiEncoding, or a space
+// for codes it leaves unused.
+func winAnsiRune(code byte) rune {
+	if code < 0x80 || code >= 0xa0 {
+		return rune(code)
+	}
+	for c, special := range winAnsiSpecials {
+		if special == code {
+			return c
+		}
+	}
+	return ' '
+}
+
+// glyphWidth returns the advance width of c in thousandths of the font
+// size. Characters outside ASCII get close estimates, which is all line
+// breaking needs.
+func glyphWidth(font pdfFont, c rune) float64 {
+	if font == fontCJK {
+		if c >= 0xff61 && c <= 0xff9f {
+			// Half-width katakana
+			return 500
+		}
+		return 1000
+	}
+
+	if c >= 0x20 && c <= 0x7e {
+		if font == fontBold {
+			return helveticaBoldWidths[c-0x20]
+		}
+		return helveticaWidths[c-0x20]
+	}
+	if w, ok := specialWidths[c]; ok {
+		return w
+	}
+	switch {
+	case c == 0xa0:
+		return 278
+	case unicode.IsUpper(c):
+		return 722
+	case unicode.IsLetter(c), unicode.IsDigit(c):
+		if font == fontBold {
+			return 611
+		}
+		return 556
+	}
+	return 584
+}
@@ -0,0 +1,119 @@
+package export
+
+import (
+	"html/template"
+	"io"
+)
+
+var htmlTemplate = template.Must(template.New("summaries").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", Helvetica, Arial, "Hiragino Sans", "Noto Sans CJK JP", sans-serif; line-height: 1.5; max-width: 48em; margin: 2em auto; padding: 0 1em; color: #222; }
+article + article { border-top: 1px solid #ccc; margin-top: 3em; page-break-before: always; }
+dl.fields { display: grid; grid-template-columns: max-content auto; gap: 0.2em 1em; }
+dl.fields dt { font-weight: bold; }
+dl.fields dd { margin: 0; }
+</style>
+</head>
+<body>
+{{range .Intro}}<p>{{.}}</p>
+{{end}}{{range .Summaries}}<article>
+<h1>{{.Title}}</h1>
+{{template "fields" .Metadata}}
+<h2>Summary</h2>
+{{template "blocks" .Blocks}}
+{{- if .Chapters}}
+<h2>Chapters</h2>
+{{range .Chapters}}<h3>{{.Heading}}</h3>
+{{template "blocks" .Blocks}}
+{{end}}
+{{- end}}
+<h2>Citations</h2>
+<ol>
+{{range .Citations}}<li>{{.}}</li>
+{{end}}</ol>
+<h2>Generation details</h2>
+{{template "fields" .Details}}
+</article>
+{{end}}</body>
+</html>
+{{define "fields"}}<dl class="fields">
+{{range .}}<dt>{{.Label}}</dt><dd>{{.Value}}</dd>
+{{end}}</dl>{{end}}
+{{define "blocks"}}{{range .}}{{if .Bullets}}<ul>
+{{range .Bullets}}<li>{{template "runs" .}}</li>
+{{end}}</ul>
+{{else}}<p>{{template "runs" .Runs}}</p>
+{{end}}{{end}}{{end}}
+{{define "runs"}}{{range .}}{{if .Bold}}<strong>{{.Text}}</strong>{{else}}{{.Text}}{{end}}{{end}}{{end}}`))
+
+// The template's view of a summary, with the text already parsed.
+type (
+	htmlDocument struct {
+		Title     string
+		Intro     []string
+		Summaries []htmlSummary
+	}
+	htmlSummary struct {
+		Title     string
+		Metadata  []field
+		Blocks    []htmlBlock
+		Chapters  []htmlChapter
+		Citations []string
+		Details   []field
+	}
+	htmlChapter struct {
+		Heading string
+		Blocks  []htmlBlock
+	}
+	// htmlBlock is a paragraph, or a list when Bullets is set.
+	htmlBlock struct {
+		Runs    []run
+		Bullets [][]run
+	}
+)
+
+func writeHTML(w io.Writer, intro []string, summaries []Summary) error {
+	view := make([]htmlSummary, len(summaries))
+	for i, s := range summaries {
+		view[i] = htmlSummary{
+			Title:     s.Title,
+			Metadata:  metadata(s),
+			Blocks:    htmlBlocks(parseText(s.Text)),
+			Citations: citations(s),
+			Details:   details(s),
+		}
+		for _, chapter := range s.Chapters {
+			view[i].Chapters = append(view[i].Chapters, htmlChapter{
+				Heading: chapterHeading(chapter),
+				Blocks:  htmlBlocks(parseText(chapter.Text)),
+			})
+		}
+	}
+
+	title := "Summaries"
+	if len(summaries) == 1 {
+		title = summaries[0].Title
+	}
+	return htmlTemplate.Execute(w, htmlDocument{Title: title, Intro: intro, Summaries: view})
+}
+
+// htmlBlocks groups consecutive bullet points into lists.
+func htmlBlocks(blocks []block) []htmlBlock {
+	var out []htmlBlock
+	for i, blk := range blocks {
+		switch {
+		case !blk.Bullet:
+			out = append(out, htmlBlock{Runs: blk.Runs})
+		case i > 0 && blocks[i-1].Bullet:
+			list := &out[len(out)-1]
+			list.Bullets = append(list.Bullets, blk.Runs)
+		default:
+			out = append(out, htmlBlock{Bullets: [][]run{blk.Runs}})
+		}
+	}
+	return out
+}
@@ -0,0 +1,77 @@
+package export
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`, "*", `\*`, "_", `\_`, "`", "\\`", "[", `\[`, "]", `\]`, "<", `\<`, "#", `\#`,
+)
+
+func writeMarkdown(w io.Writer, intro []string, summaries []Summary) error {
+	b := bufio.NewWriter(w)
+	for _, paragraph := range intro {
+		b.WriteString(markdownEscaper.Replace(paragraph) + "\n\n")
+	}
+	for i, s := range summaries {
+		if i > 0 {
+			b.WriteString("\n---\n\n")
+		}
+
+		fmt.Fprintf(b, "# %s\n\n", markdownEscaper.Replace(s.Title))
+		writeMarkdownFields(b, metadata(s))
+
+		b.WriteString("\n## Summary\n\n")
+		writeMarkdownBlocks(b, parseText(s.Text))
+
+		if len(s.Chapters) > 0 {
+			b.WriteString("\n## Chapters\n")
+			for _, chapter := range s.Chapters {
+				fmt.Fprintf(b, "\n### %s\n\n", markdownEscaper.Replace(chapterHeading(chapter)))
+				writeMarkdownBlocks(b, parseText(chapter.Text))
+			}
+		}
+
+		b.WriteString("\n## Citations\n\n")
+		for n, cited := range citations(s) {
+			fmt.Fprintf(b, "%d. %s\n", n+1, markdownEscaper.Replace(cited))
+		}
+
+		b.WriteString("\n## Generation details\n\n")
+		writeMarkdownFields(b, details(s))
+	}
+	return b.Flush()
+}
+
+func writeMarkdownFields(b *bufio.Writer, fields []field) {
+	for _, f := range fields {
+		fmt.Fprintf(b, "- **%s:** %s\n", f.Label, markdownEscaper.Replace(f.Value))
+	}
+}
+
+// writeMarkdownBlocks writes paragraphs separated by blank lines, keeping
+// consecutive bullet points in one list.
+func writeMarkdownBlocks(b *bufio.Writer, blocks []block) {
+	for i, blk := range blocks {
+		if i > 0 && !(blk.Bullet && blocks[i-1].Bullet) {
+			b.WriteString("\n")
+		}
+		if blk.Bullet {
+			b.WriteString("- ")
+		}
+		for _, r := range blk.Runs {
+			text := markdownEscaper.Replace(r.Text)
+			if r.Bold && strings.TrimSpace(text) != "" {
+				// Emphasis can't start or end with a space
+				trimmed := strings.TrimSpace(text)
+				lead := text[:strings.Index(text, trimmed)]
+				text = lead + "**" + trimmed + "**" + text[len(lead)+len(trimmed):]
+			}
+			b.WriteString(text)
+		}
+		b.WriteString("\n")
+	}
+}
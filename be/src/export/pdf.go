@@ -0,0 +1,390 @@
+package export
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf16"
+)
+
+// A4 in points, with 2 cm margins.
+const (
+	pageWidth  = 595.28
+	pageHeight = 841.89
+	margin     = 56.7
+	textWidth  = pageWidth - 2*margin
+)
+
+// Text is set in the standard Helvetica fonts, which every reader has, and
+// whatever they can't show in a Japanese CID font left to the reader to
+// substitute, so no font files need embedding.
+type pdfFont int
+
+const (
+	fontRegular pdfFont = iota
+	fontBold
+	fontCJK
+)
+
+var fontResources = [...]string{"F1", "F2", "F3"}
+
+// piece is text in one font.
+type piece struct {
+	font pdfFont
+	text string
+}
+
+// word is a unit a line can't be broken inside of. Latin words end at
+// spaces; CJK characters are words of their own.
+type word struct {
+	pieces []piece
+	// space is set when a space separates the word from the one before.
+	space bool
+}
+
+func writePDF(w io.Writer, summaries []Summary) error {
+	l := &pdfLayout{}
+	for _, s := range summaries {
+		l.newPage()
+
+		l.paragraph([]run{{Text: s.Title, Bold: true}}, 20, 0, false, 10)
+		for _, f := range metadata(s) {
+			l.paragraph([]run{{Text: f.Label + ": ", Bold: true}, {Text: f.Value}}, 10, 0, false, 0)
+		}
+
+		l.heading("Summary", 14)
+		l.blocks(parseText(s.Text))
+
+		if len(s.Chapters) > 0 {
+			l.heading("Chapters", 14)
+			for _, chapter := range s.Chapters {
+				l.heading(chapterHeading(chapter), 12)
+				l.blocks(parseText(chapter.Text))
+			}
+		}
+
+		l.heading("Citations", 14)
+		for n, cited := range citations(s) {
+			l.paragraph([]run{{Text: fmt.Sprintf("%d. %s", n+1, cited)}}, 10, 0, false, 4)
+		}
+
+		l.heading("Generation details", 14)
+		for _, f := range details(s) {
+			l.paragraph([]run{{Text: f.Label + ": ", Bold: true}, {Text: f.Value}}, 10, 0, false, 0)
+		}
+	}
+	l.finishPage()
+
+	title := "Summaries"
+	if len(summaries) == 1 {
+		title = summaries[0].Title
+	}
+	return writePDFFile(w, title, l.pages)
+}
+
+// pdfLayout lays text out top to bottom, starting new pages as they fill.
+type pdfLayout struct {
+	pages   [][]byte
+	content bytes.Buffer
+	// y is where the next line's top goes.
+	y float64
+}
+
+func (l *pdfLayout) newPage() {
+	l.finishPage()
+	l.y = pageHeight - margin
+}
+
+func (l *pdfLayout) finishPage() {
+	if l.content.Len() > 0 {
+		l.pages = append(l.pages, append([]byte(nil), l.content.Bytes()...))
+		l.content.Reset()
+	}
+}
+
+func (l *pdfLayout) heading(text string, size float64) {
+	// Keeps the heading with at least two lines of what follows
+	if l.y-size*1.4-size*0.6-2*11*1.4 < margin {
+		l.newPage()
+	} else {
+		l.y -= size * 0.6
+	}
+	l.paragraph([]run{{Text: text, Bold: true}}, size, 0, false, 2)
+}
+
+func (l *pdfLayout) blocks(blocks []block) {
+	for _, blk := range blocks {
+		if blk.Bullet {
+			l.paragraph(blk.Runs, 11, 14, true, 3)
+		} else {
+			l.paragraph(blk.Runs, 11, 0, false, 6)
+		}
+	}
+}
+
+// paragraph sets runs in lines filling the text width less indent, then
+// leaves spaceAfter below them.
+func (l *pdfLayout) paragraph(runs []run, size, indent float64, bullet bool, spaceAfter float64) {
+	leading := size * 1.4
+	for i, line := range wrap(splitWords(runs), (textWidth-indent)/size*1000) {
+		if l.y-leading < margin {
+			l.newPage()
+		}
+		l.y -= leading
+		baseline := l.y + (leading-size)/2 + size*0.2
+
+		l.content.WriteString("BT\n")
+		if bullet && i == 0 {
+			fmt.Fprintf(&l.content, "/F1 %s Tf %s %s Td (\x95) Tj ET BT\n",
+				formatNumber(size), formatNumber(margin+indent-10), formatNumber(baseline))
+		}
+		fmt.Fprintf(&l.content, "%s %s Td\n", formatNumber(margin+indent), formatNumber(baseline))
+		current := pdfFont(-1)
+		for _, p := range line {
+			if p.font != current {
+				fmt.Fprintf(&l.content, "/%s %s Tf\n", fontResources[p.font], formatNumber(size))
+				current = p.font
+			}
+			l.content.Write(encodeText(p))
+			l.content.WriteString(" Tj\n")
+		}
+		l.content.WriteString("ET\n")
+	}
+	l.y -= spaceAfter
+}
+
+// splitWords breaks runs into words, picking a font for each character.
+func splitWords(runs []run) []word {
+	var words []word
+	var current *word
+	space := false
+	for _, r := range runs {
+		for _, c := range r.Text {
+			if unicode.IsSpace(c) {
+				current, space = nil, true
+				continue
+			}
+
+			font := fontRegular
+			if r.Bold {
+				font = fontBold
+			}
+			if _, ok := winAnsiCode(c); !ok {
+				font = fontCJK
+				if c > 0xffff {
+					font, c = fontRegular, '?'
+				}
+			}
+
+			if font == fontCJK || current == nil {
+				words = append(words, word{space: space})
+				current = &words[len(words)-1]
+				space = false
+			}
+			if n := len(current.pieces); n > 0 && current.pieces[n-1].font == font {
+				current.pieces[n-1].text += string(c)
+			} else {
+				current.pieces = append(current.pieces, piece{font: font, text: string(c)})
+			}
+			if font == fontCJK {
+				current = nil
+			}
+		}
+	}
+	return words
+}
+
+// wrap fills lines no wider than width, in thousandths of the font size.
+// Words wider than a line are broken between characters.
+func wrap(words []word, width float64) [][]piece {
+	var lines [][]piece
+	var line []piece
+	used := 0.0
+	for _, w := range words {
+		ww := wordWidth(w)
+		gap := 0.0
+		if w.space && len(line) > 0 {
+			gap = glyphWidth(fontRegular, ' ')
+		}
+
+		if len(line) > 0 && used+gap+ww > width {
+			lines = append(lines, line)
+			line, used, gap = nil, 0, 0
+		}
+		if gap > 0 {
+			line = append(line, piece{font: fontRegular, text: " "})
+			used += gap
+		}
+
+		for _, p := range w.pieces {
+			for _, c := range p.text {
+				cw := glyphWidth(p.font, c)
+				if len(line) > 0 && used+cw > width {
+					lines = append(lines, line)
+					line, used = nil, 0
+				}
+				if n := len(line); n > 0 && line[n-1].font == p.font {
+					line[n-1].text += string(c)
+				} else {
+					line = append(line, piece{font: p.font, text: string(c)})
+				}
+				used += cw
+			}
+		}
+	}
+	if len(line) > 0 {
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+func wordWidth(w word) float64 {
+	width := 0.0
+	for _, p := range w.pieces {
+		for _, c := range p.text {
+			width += glyphWidth(p.font, c)
+		}
+	}
+	return width
+}
+
+// encodeText writes a piece as a PDF string in its font's encoding.
+func encodeText(p piece) []byte {
+	if p.font == fontCJK {
+		var b bytes.Buffer
+		b.WriteByte('<')
+		for _, unit := range utf16.Encode([]rune(p.text)) {
+			fmt.Fprintf(&b, "%04X", unit)
+		}
+		b.WriteByte('>')
+		return b.Bytes()
+	}
+
+	b := []byte{'('}
+	for _, c := range p.text {
+		code, _ := winAnsiCode(c)
+		if code == '(' || code == ')' || code == '\\' {
+			b = append(b, '\\')
+		}
+		b = append(b, code)
+	}
+	return append(b, ')')
+}
+
+// writePDFFile writes pages of content as a PDF with the fonts they use.
+func writePDFFile(w io.Writer, title string, pages [][]byte) error {
+	var objects []string
+	add := func(body string) int {
+		objects = append(objects, body)
+		return len(objects)
+	}
+
+	// Objects 1 and 2 are filled in once the pages are known
+	catalog := add("")
+	tree := add("")
+	info := add(fmt.Sprintf("<< /Title %s /Producer (PDF Summarizer) /CreationDate (D:%s) >>",
+		pdfTextString(title), time.Now().UTC().Format("20060102150405Z")))
+
+	widths := make([]string, 0, 224)
+	for code := 32; code <= 255; code++ {
+		widths = append(widths, formatNumber(glyphWidth(fontRegular, winAnsiRune(byte(code)))))
+	}
+	regular := add("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding" +
+		" /FirstChar 32 /LastChar 255 /Widths [" + strings.Join(widths, " ") + "] >>")
+	widths = widths[:0]
+	for code := 32; code <= 255; code++ {
+		widths = append(widths, formatNumber(glyphWidth(fontBold, winAnsiRune(byte(code)))))
+	}
+	bold := add("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding" +
+		" /FirstChar 32 /LastChar 255 /Widths [" + strings.Join(widths, " ") + "] >>")
+
+	descriptor := add("<< /Type /FontDescriptor /FontName /HeiseiKakuGo-W5 /Flags 4 /FontBBox [-92 -250 1010 922]" +
+		" /ItalicAngle 0 /Ascent 752 /Descent -221 /CapHeight 737 /StemV 114 >>")
+	cidFont := add(fmt.Sprintf("<< /Type /Font /Subtype /CIDFontType0 /BaseFont /HeiseiKakuGo-W5"+
+		" /CIDSystemInfo << /Registry (Adobe) /Ordering (Japan1) /Supplement 5 >> /FontDescriptor %d 0 R /DW 1000 >>", descriptor))
+	toUnicode := add(streamObject(identityToUnicode()))
+	cjk := add(fmt.Sprintf("<< /Type /Font /Subtype /Type0 /BaseFont /HeiseiKakuGo-W5 /Encoding /UniJIS-UCS2-H"+
+		" /DescendantFonts [%d 0 R] /ToUnicode %d 0 R >>", cidFont, toUnicode))
+	resources := fmt.Sprintf("<< /Font << /F1 %d 0 R /F2 %d 0 R /F3 %d 0 R >> >>", regular, bold, cjk)
+
+	kids := make([]string, len(pages))
+	for i, content := range pages {
+		contents := add(streamObject(content))
+		kids[i] = fmt.Sprintf("%d 0 R", add(fmt.Sprintf(
+			"<< /Type /Page /Parent %d 0 R /MediaBox [0 0 %s %s] /Resources %s /Contents %d 0 R >>",
+			tree, formatNumber(pageWidth), formatNumber(pageHeight), resources, contents)))
+	}
+	objects[catalog-1] = fmt.Sprintf("<< /Type /Catalog /Pages %d 0 R >>", tree)
+	objects[tree-1] = fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(pages))
+
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.7\n%\xe2\xe3\xcf\xd3\n")
+	offsets := make([]int, len(objects))
+	for i, body := range objects {
+		offsets[i] = buf.Len()
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", i+1, body)
+	}
+
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root %d 0 R /Info %d 0 R >>\nstartxref\n%d\n%%%%EOF\n",
+		len(objects)+1, catalog, info, xref)
+
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+func streamObject(data []byte) string {
+	var buf bytes.Buffer
+	zw := zlib.NewWriter(&buf)
+	zw.Write(data)
+	zw.Close()
+	return fmt.Sprintf("<< /Length %d /Filter /FlateDecode >>\nstream\n%s\nendstream", buf.Len(), buf.Bytes())
+}
+
+// identityToUnicode maps the UCS-2 codes of UniJIS-UCS2-H back to the
+// characters they are, so text can be copied and searched.
+func identityToUnicode() []byte {
+	var b bytes.Buffer
+	b.WriteString("/CIDInit /ProcSet findresource begin 12 dict begin begincmap\n" +
+		"/CMapName /Adobe-Identity-UCS def /CMapType 2 def\n" +
+		"1 begincodespacerange <0000> <FFFF> endcodespacerange\n")
+	// A range may only vary in its last byte, and a block holds at most 100
+	for start := 0; start < 256; start += 100 {
+		end := min(start+100, 256)
+		fmt.Fprintf(&b, "%d beginbfrange\n", end-start)
+		for high := start; high < end; high++ {
+			fmt.Fprintf(&b, "<%02X00> <%02XFF> <%02X00>\n", high, high, high)
+		}
+		b.WriteString("endbfrange\n")
+	}
+	b.WriteString("endcmap CMapName currentdict /CMap defineresource pop end end")
+	return b.Bytes()
+}
+
+// pdfTextString encodes text for the document information dictionary.
+func pdfTextString(s string) string {
+	var b strings.Builder
+	b.WriteString("<FEFF")
+	for _, unit := range utf16.Encode([]rune(s)) {
+		fmt.Fprintf(&b, "%04X", unit)
+	}
+	b.WriteString(">")
+	return b.String()
+}
+
+func formatNumber(n float64) string {
+	s := fmt.Sprintf("%.2f", n)
+	s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+	if s == "-0" {
+		return "0"
+	}
+	return s
+}
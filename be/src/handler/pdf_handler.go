@@ -3,8 +3,10 @@ package handler
 import (
 	"app/src/service"
 	"app/src/utils"
-	
+	"app/src/validation"
+
 	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
 	"github.com/sirupsen/logrus"
 )
 
@@ -22,5 +24,42 @@ func NewPDFHandler(service service.PDFService) *PDFHandler {
 
 func (h *PDFHandler) ViewPDF(c *fiber.Ctx) error {
 	id := c.Params("id")
-	return h.Service.ViewPDF(c, id)
-}
\ No newline at end of file
+
+	version := c.QueryInt("version", 0)
+	if version < 0 {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid version")
+	}
+
+	return h.Service.ViewPDF(c, id, version)
+}
+
+func (h *PDFHandler) DownloadPDF(c *fiber.Ctx) error {
+	id := c.Params("id")
+
+	version := c.QueryInt("version", 0)
+	if version < 0 {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid version")
+	}
+
+	return h.Service.DownloadPDF(c, id, version)
+}
+
+func (h *PDFHandler) Thumbnail(c *fiber.Ctx) error {
+	id := c.Params("pdfId")
+	if _, err := uuid.Parse(id); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid PDF ID")
+	}
+
+	page, err := c.ParamsInt("n")
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid page number")
+	}
+
+	query := &validation.QueryThumbnail{
+		Width:       c.QueryInt("width", 0),
+		Format:      c.Query("format"),
+		UnlockToken: c.Query("unlock_token"),
+	}
+
+	return h.Service.Thumbnail(c, id, page, query)
+}
@@ -0,0 +1,126 @@
+// Package mail renders the emails sent to users from embedded templates,
+// as a subject, a plain text body and an HTML body, in the user's language.
+package mail
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	htmltemplate "html/template"
+	"strings"
+	texttemplate "text/template"
+)
+
+// Templates of the emails this package renders.
+const (
+	TemplateResetPassword = "reset_password"
+	TemplateVerifyEmail   = "verify_email"
+)
+
+// Names lists every template, for previews.
+var Names = []string{TemplateResetPassword, TemplateVerifyEmail}
+
+// Locales emails are written in. DefaultLocale is used for anything else.
+var Locales = []string{"en", "id", "ja"}
+
+const DefaultLocale = "en"
+
+var ErrUnknownTemplate = errors.New("unknown email template")
+
+//go:embed templates
+var files embed.FS
+
+// Data fills in a template.
+type Data struct {
+	Name string
+	URL  string
+	// ExpiresIn is how many minutes URL stays valid.
+	ExpiresIn int
+}
+
+// Message is a rendered email.
+type Message struct {
+	Subject string
+	Text    string
+	HTML    string
+}
+
+type view struct {
+	Data
+	Locale  string
+	Subject string
+}
+
+type templates struct {
+	text *texttemplate.Template
+	html *htmltemplate.Template
+}
+
+// parsed holds every template by locale, then name. They are all parsed on
+// start up, so a broken template stops the service instead of an email.
+var parsed = parseAll()
+
+func parseAll() map[string]map[string]templates {
+	funcs := htmltemplate.FuncMap{
+		"button": func(url, label string) map[string]string {
+			return map[string]string{"URL": url, "Label": label}
+		},
+	}
+
+	all := make(map[string]map[string]templates, len(Locales))
+	for _, locale := range Locales {
+		all[locale] = make(map[string]templates, len(Names))
+		for _, name := range Names {
+			base := "templates/" + locale + "/" + name
+			all[locale][name] = templates{
+				text: texttemplate.Must(texttemplate.ParseFS(files, base+".txt")),
+				html: htmltemplate.Must(htmltemplate.New("").Funcs(funcs).ParseFS(files,
+					"templates/layout.html", "templates/"+locale+"/footer.html", base+".html")),
+			}
+		}
+	}
+	return all
+}
+
+// Locale returns the supported locale closest to a user's preference, such
+// as "ja" for "ja-JP".
+func Locale(preference string) string {
+	preference = strings.ToLower(strings.TrimSpace(preference))
+	if i := strings.IndexAny(preference, "-_"); i >= 0 {
+		preference = preference[:i]
+	}
+	for _, locale := range Locales {
+		if locale == preference {
+			return locale
+		}
+	}
+	return DefaultLocale
+}
+
+// Render renders the named template in the locale closest to locale.
+func Render(name, locale string, data Data) (*Message, error) {
+	locale = Locale(locale)
+	t, ok := parsed[locale][name]
+	if !ok {
+		return nil, ErrUnknownTemplate
+	}
+
+	var subject, text, html bytes.Buffer
+	if err := t.text.ExecuteTemplate(&subject, "subject", data); err != nil {
+		return nil, err
+	}
+	if err := t.text.ExecuteTemplate(&text, "body", data); err != nil {
+		return nil, err
+	}
+
+	v := view{Data: data, Locale: locale, Subject: strings.TrimSpace(subject.String())}
+	if err := t.html.ExecuteTemplate(&html, "layout", v); err != nil {
+		return nil, err
+	}
+
+	return &Message{
+		Subject: v.Subject,
+		Text:    strings.TrimSpace(text.String()) + "\n",
+		HTML:    html.String(),
+	}, nil
+}
@@ -0,0 +1,100 @@
+package mail
+
+import (
+	"errors"
+	"fmt"
+	"net/mail"
+	"net/textproto"
+	"os"
+
+	"gopkg.in/gomail.v2"
+)
+
+// Email is a message ready to be sent.
+type Email struct {
+	To          []string
+	ReplyTo     string
+	Subject     string
+	Text        string
+	HTML        string
+	Attachments []Attachment
+}
+
+// Attachment is a stored file sent along with an email.
+type Attachment struct {
+	Path        string
+	Filename    string
+	ContentType string
+}
+
+type Sender interface {
+	Send(email *Email) error
+}
+
+// SMTPSender delivers emails to an SMTP server, one connection per email.
+type SMTPSender struct {
+	From   string
+	Dialer *gomail.Dialer
+}
+
+func NewSMTPSender(host string, port int, username, password, from string) *SMTPSender {
+	return &SMTPSender{
+		From:   from,
+		Dialer: gomail.NewDialer(host, port, username, password),
+	}
+}
+
+func (s *SMTPSender) Send(email *Email) error {
+	message := gomail.NewMessage()
+	message.SetHeader("From", s.From)
+	message.SetHeader("To", email.To...)
+	if email.ReplyTo != "" {
+		message.SetHeader("Reply-To", email.ReplyTo)
+	}
+	message.SetHeader("Subject", email.Subject)
+	message.SetBody("text/plain", email.Text)
+	if email.HTML != "" {
+		message.AddAlternative("text/html", email.HTML)
+	}
+	for _, attachment := range email.Attachments {
+		// gomail only opens attachments while sending, too late to tell a
+		// missing file from a failed delivery
+		if _, err := os.Stat(attachment.Path); err != nil {
+			return err
+		}
+		message.Attach(attachment.Path,
+			gomail.Rename(attachment.Filename),
+			gomail.SetHeader(map[string][]string{"Content-Type": {attachment.ContentType}}),
+		)
+	}
+
+	from, err := mail.ParseAddress(s.From)
+	if err != nil {
+		return err
+	}
+
+	conn, err := s.Dialer.Dial()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	// Sent on an open connection rather than with gomail.Send, which
+	// flattens the server's reply into a string
+	err = conn.Send(from.Address, email.To, message)
+	var reply *textproto.Error
+	if errors.As(err, &reply) && reply.Code >= 500 {
+		return fmt.Errorf("%w: %v", ErrRejected, err)
+	}
+	return err
+}
+
+// ErrRejected is returned when the server refuses an email for good, such
+// as for an unknown recipient.
+var ErrRejected = errors.New("email rejected")
+
+// IsPermanent reports whether sending failed in a way retrying can't fix:
+// the server rejected the email, or an attachment is gone.
+func IsPermanent(err error) bool {
+	return errors.Is(err, ErrRejected) || errors.Is(err, os.ErrNotExist)
+}
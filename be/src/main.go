@@ -50,11 +50,20 @@ func setupFiberApp() *fiber.App {
 	app := fiber.New(config.FiberConfig())
 
 	// Middleware setup
+	// BodyLimitConfig goes first so every streamed body is consumed, even
+	// when a later middleware rejects the request.
+	app.Use(middleware.BodyLimitConfig())
 	app.Use("/v1/auth", middleware.LimiterConfig())
+	// Slows down guessing share tokens and passwords
+	app.Use("/v1/shared", middleware.LimiterConfig())
 	app.Use(middleware.LoggerConfig())
 	app.Use(helmet.New())
 	app.Use(compress.New())
-	app.Use(cors.New())
+	app.Use(cors.New(cors.Config{
+		// Let browser tus clients read the upload state
+		ExposeHeaders: "Location,Tus-Resumable,Tus-Version,Tus-Max-Size,Tus-Extension," +
+			"Upload-Offset,Upload-Length,Upload-Metadata,Upload-Expires,Upload-PDF-ID",
+	}))
 	app.Use(middleware.RecoverConfig())
 
 	return app
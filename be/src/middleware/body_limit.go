@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"app/src/config"
+	"app/src/utils"
+	"io"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// BodyLimitConfig caps request bodies now that the server streams them.
+// Uploads are passed through as a stream because their handlers enforce
+// limits while reading; whatever they leave unread is drained afterwards so
+// the next request on the connection parses correctly.
+func BodyLimitConfig() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		stream := c.Request().BodyStream()
+		if stream == nil {
+			return c.Next()
+		}
+
+		contentType := c.Get(fiber.HeaderContentType)
+		if strings.HasPrefix(contentType, fiber.MIMEMultipartForm) ||
+			strings.HasPrefix(contentType, "application/offset+octet-stream") {
+			err := c.Next()
+			utils.DrainBody(c, utils.BodyStream(c))
+			return err
+		}
+
+		if c.Request().Header.ContentLength() > config.RequestBodyLimit {
+			c.Response().SetConnectionClose()
+			return fiber.ErrRequestEntityTooLarge
+		}
+
+		body, err := io.ReadAll(io.LimitReader(stream, config.RequestBodyLimit+1))
+		if err != nil {
+			c.Response().SetConnectionClose()
+			return fiber.ErrBadRequest
+		}
+		if len(body) > config.RequestBodyLimit {
+			c.Response().SetConnectionClose()
+			return fiber.ErrRequestEntityTooLarge
+		}
+		c.Request().SetBody(body)
+
+		return c.Next()
+	}
+}
@@ -1,7 +1,9 @@
 package middleware
 
 import (
+	"app/src/config"
 	"app/src/response"
+	"app/src/service"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
@@ -10,16 +12,56 @@ import (
 
 func LimiterConfig() fiber.Handler {
 	return limiter.New(limiter.Config{
-		Max:        20,
-		Expiration: 15 * time.Minute,
-		LimitReached: func(c *fiber.Ctx) error {
-			return c.Status(fiber.StatusTooManyRequests).
-				JSON(response.Common{
-					Code:    fiber.StatusTooManyRequests,
-					Status:  "error",
-					Message: "Too many requests, please try again later",
-				})
-		},
+		Max:                    20,
+		Expiration:             15 * time.Minute,
+		LimitReached:           limitReached,
 		SkipSuccessfulRequests: true,
 	})
 }
+
+// UnlockLimiter bounds failed password attempts on protected PDFs, both
+// from one client and on one PDF from anywhere, so a password can't be
+// guessed by spreading attempts over many addresses either. The counters
+// live in the database, so the limits hold across prefork processes.
+func UnlockLimiter(rateLimitService service.RateLimitService) []fiber.Handler {
+	return []fiber.Handler{
+		failureLimiter(rateLimitService, config.PDFUnlockMaxAttemptsPerIP, config.PDFUnlockAttemptWindow,
+			func(c *fiber.Ctx) string { return "unlock:ip:" + c.IP() }),
+		failureLimiter(rateLimitService, config.PDFUnlockMaxAttemptsPerPDF, config.PDFUnlockAttemptWindow,
+			func(c *fiber.Ctx) string { return "unlock:pdf:" + c.Params("pdfId") }),
+	}
+}
+
+// failureLimiter refuses requests once limit of them failed within window.
+// Every request is counted up front, so concurrent ones can't slip past
+// the limit, and successful ones are taken back afterwards.
+func failureLimiter(
+	rateLimitService service.RateLimitService, limit int, window time.Duration, key func(c *fiber.Ctx) string,
+) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		limitKey := key(c)
+
+		hits, err := rateLimitService.Hit(c, limitKey, window)
+		if err != nil {
+			return err
+		}
+		if hits > limit {
+			return limitReached(c)
+		}
+
+		err = c.Next()
+		if err == nil && c.Response().StatusCode() < fiber.StatusBadRequest {
+			rateLimitService.Forgive(c, limitKey)
+		}
+		return err
+	}
+}
+
+func limitReached(c *fiber.Ctx) error {
+	return c.Status(fiber.StatusTooManyRequests).
+		JSON(response.Common{
+			Code:    fiber.StatusTooManyRequests,
+			Status:  "error",
+			Message: "Too many requests, please try again later",
+		})
+}
@@ -0,0 +1,47 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// States of an account export job.
+const (
+	ExportStatusQueued     = "queued"
+	ExportStatusProcessing = "processing"
+	ExportStatusCompleted  = "completed"
+	ExportStatusFailed     = "failed"
+)
+
+type AccountExport struct {
+	ID          uuid.UUID  `gorm:"primaryKey;not null" json:"id"`
+	UserID      uuid.UUID  `gorm:"type:uuid;not null;index" json:"user_id"`
+	Status      string     `gorm:"type:varchar(20);not null;default:'queued'" json:"status"`
+	FilePath    string     `gorm:"type:text" json:"-"`
+	FileSize    int64      `gorm:"not null;default:0" json:"file_size"`
+	PDFCount    int        `gorm:"not null;default:0" json:"pdf_count"`
+	Error       *string    `gorm:"type:text" json:"error,omitempty"`
+	ExpiresAt   time.Time  `gorm:"not null;index" json:"expires_at"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+	CreatedAt   time.Time  `gorm:"not null" json:"created_at"`
+	UpdatedAt   time.Time  `gorm:"not null" json:"updated_at"`
+}
+
+func (AccountExport) TableName() string {
+	return "account_exports"
+}
+
+func (export *AccountExport) BeforeCreate(_ *gorm.DB) error {
+	export.ID = uuid.New()
+	now := time.Now()
+	export.CreatedAt = now
+	export.UpdatedAt = now
+	return nil
+}
+
+func (export *AccountExport) BeforeUpdate(_ *gorm.DB) error {
+	export.UpdatedAt = time.Now()
+	return nil
+}
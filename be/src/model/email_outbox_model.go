@@ -0,0 +1,76 @@
+package model
+
+import (
+	"database/sql/driver"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// States of an outgoing email. A sending email is held by a sender until
+// its NextAttemptAt, after which another sender may pick it up. Dead emails
+// ran out of attempts or were refused for good, and stay until retried.
+const (
+	OutboxStatusPending = "pending"
+	OutboxStatusSending = "sending"
+	OutboxStatusSent    = "sent"
+	OutboxStatusDead    = "dead"
+)
+
+type EmailAttachment struct {
+	Path        string `json:"path"`
+	Filename    string `json:"filename"`
+	ContentType string `json:"content_type"`
+}
+
+// EmailAttachments are the files sent with an email, stored as JSONB.
+type EmailAttachments []EmailAttachment
+
+func (a EmailAttachments) Value() (driver.Value, error) {
+	return jsonValue(a, a == nil)
+}
+
+func (a *EmailAttachments) Scan(value interface{}) error {
+	return scanJSON(value, a)
+}
+
+// EmailOutbox is an email waiting to be sent, or sent already. It is written
+// in the same transaction as the change it tells about, so neither happens
+// without the other.
+type EmailOutbox struct {
+	ID            uuid.UUID        `gorm:"primaryKey;not null" json:"id"`
+	Recipients    EmailRecipients  `gorm:"type:jsonb;not null" json:"recipients"`
+	ReplyTo       string           `gorm:"type:text" json:"reply_to,omitempty"`
+	Subject       string           `gorm:"type:text;not null" json:"subject"`
+	TextBody      string           `gorm:"type:text;not null" json:"-"`
+	HTMLBody      string           `gorm:"type:text" json:"-"`
+	Attachments   EmailAttachments `gorm:"type:jsonb" json:"-"`
+	Status        string           `gorm:"type:varchar(20);not null;default:'pending'" json:"status"`
+	Attempts      int              `gorm:"not null;default:0" json:"attempts"`
+	NextAttemptAt time.Time        `gorm:"not null" json:"next_attempt_at"`
+	LastError     *string          `gorm:"type:text" json:"last_error,omitempty"`
+	SentAt        *time.Time       `json:"sent_at,omitempty"`
+	CreatedAt     time.Time        `gorm:"not null" json:"created_at"`
+	UpdatedAt     time.Time        `gorm:"not null" json:"updated_at"`
+}
+
+func (EmailOutbox) TableName() string {
+	return "email_outbox"
+}
+
+func (email *EmailOutbox) BeforeCreate(_ *gorm.DB) error {
+	email.ID = uuid.New()
+	now := time.Now()
+	email.CreatedAt = now
+	email.UpdatedAt = now
+	if email.NextAttemptAt.IsZero() {
+		email.NextAttemptAt = now
+	}
+	return nil
+}
+
+func (email *EmailOutbox) BeforeUpdate(_ *gorm.DB) error {
+	email.UpdatedAt = time.Now()
+	return nil
+}
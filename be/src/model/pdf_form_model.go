@@ -0,0 +1,23 @@
+package model
+
+import "database/sql/driver"
+
+// FormField is a filled-in field of a PDF's interactive form, see
+// pdfdoc.FormField.
+type FormField struct {
+	Name  string `json:"name"`
+	Type  string `json:"type"`
+	Value string `json:"value"`
+	Page  int    `json:"page"`
+}
+
+// FormFields is stored as a JSONB array on the pdfs table.
+type FormFields []FormField
+
+func (f FormFields) Value() (driver.Value, error) {
+	return jsonValue(f, f == nil)
+}
+
+func (f *FormFields) Scan(value interface{}) error {
+	return scanJSON(value, f)
+}
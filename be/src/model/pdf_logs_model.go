@@ -10,6 +10,7 @@ import (
 type PDFLog struct {
 	ID         uuid.UUID `gorm:"primaryKey;type:uuid;default:gen_random_uuid()" json:"id"`
 	PDFID      uuid.UUID `gorm:"not null;column:pdf_id;index" json:"pdf_id"`
+	Version    int       `gorm:"not null;default:1" json:"version"`
 	Summary    string    `gorm:"type:text;not null" json:"summary"`
 	Language   string    `gorm:"type:varchar(10);not null" json:"language"`
 	OutputType string    `gorm:"type:varchar(20);not null;column:output_type" json:"output_type"`
@@ -24,4 +25,4 @@ func (log *PDFLog) BeforeCreate(_ *gorm.DB) error {
 	log.ID = uuid.New()
 	log.CreatedAt = time.Now()
 	return nil
-}
\ No newline at end of file
+}
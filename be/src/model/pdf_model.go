@@ -7,20 +7,53 @@ import (
 	"gorm.io/gorm"
 )
 
+// Malware scan states of a stored file. Skipped means no scanner is
+// configured.
+const (
+	ScanStatusPending  = "pending"
+	ScanStatusClean    = "clean"
+	ScanStatusInfected = "infected"
+	ScanStatusError    = "error"
+	ScanStatusSkipped  = "skipped"
+)
+
 type PDF struct {
-	ID               uuid.UUID `gorm:"primaryKey;not null" json:"id"`
-	Filename         string    `gorm:"not null" json:"filename"`
-	OriginalFilename string    `gorm:"not null" json:"original_filename"`
-	FilePath         string    `gorm:"not null" json:"file_path"`
-	FileSize         int64     `gorm:"not null" json:"file_size"`
-	Summary          *string   `gorm:"type:text" json:"summary,omitempty"`
-	Language         string    `gorm:"type:varchar(10);default:'auto'" json:"language"`
-	OutputType       string    `gorm:"type:varchar(20);default:'paragraph'" json:"output_type"`
-	SummaryStatus    string    `gorm:"type:varchar(20);default:'pending'" json:"summary_status"`
-	SummaryError     *string   `gorm:"type:text" json:"summary_error,omitempty"`
-	UploadDate       time.Time `gorm:"not null;default:CURRENT_TIMESTAMP" json:"upload_date"`
-	CreatedAt        time.Time `gorm:"not null" json:"created_at"`
-	UpdatedAt        time.Time `gorm:"not null" json:"updated_at"`
+	ID                uuid.UUID        `gorm:"primaryKey;not null" json:"id"`
+	Filename          string           `gorm:"not null" json:"filename"`
+	OriginalFilename  string           `gorm:"not null" json:"original_filename"`
+	FilePath          string           `gorm:"not null" json:"file_path"`
+	FileSize          int64            `gorm:"not null" json:"file_size"`
+	ContentHash       string           `gorm:"type:varchar(64)" json:"content_hash"`
+	SourceURL         *string          `gorm:"type:text" json:"source_url,omitempty"`
+	ParentID          *uuid.UUID       `gorm:"type:uuid" json:"parent_id,omitempty"`
+	Version           int              `gorm:"not null;default:1" json:"version"`
+	SpecVersion       string           `gorm:"type:varchar(10)" json:"spec_version"`
+	PageCount         int              `gorm:"not null;default:0" json:"page_count"`
+	TableCount        int              `gorm:"not null;default:0" json:"table_count"`
+	TableStatus       string           `gorm:"type:varchar(20);not null;default:'pending'" json:"table_status"`
+	Encrypted         bool             `gorm:"not null;default:false" json:"encrypted"`
+	PasswordProtected bool             `gorm:"not null;default:false" json:"password_protected"`
+	OCRUsed           bool             `gorm:"not null;default:false" json:"ocr_used"`
+	ScanStatus        string           `gorm:"type:varchar(20);not null;default:'pending'" json:"scan_status"`
+	ScanSignature     *string          `gorm:"type:text" json:"scan_signature,omitempty"`
+	Title             *string          `gorm:"type:text" json:"title,omitempty"`
+	Author            *string          `gorm:"type:text" json:"author,omitempty"`
+	Subject           *string          `gorm:"type:text" json:"subject,omitempty"`
+	Producer          *string          `gorm:"type:text" json:"producer,omitempty"`
+	CreationDate      *time.Time       `json:"creation_date,omitempty"`
+	Outline           Outline          `gorm:"type:jsonb" json:"outline,omitempty"`
+	FormFields        FormFields       `gorm:"type:jsonb" json:"form_fields,omitempty"`
+	Summary           *string          `gorm:"type:text" json:"summary,omitempty"`
+	Language          string           `gorm:"type:varchar(10);default:'auto'" json:"language"`
+	OutputType        string           `gorm:"type:varchar(20);default:'paragraph'" json:"output_type"`
+	SummaryStatus     string           `gorm:"type:varchar(20);default:'pending'" json:"summary_status"`
+	SummaryError      *string          `gorm:"type:text" json:"summary_error,omitempty"`
+	SummaryRequest    *SummaryRequest  `gorm:"type:jsonb" json:"-"`
+	ChapterSummaries  ChapterSummaries `gorm:"type:jsonb" json:"chapter_summaries,omitempty"`
+	RedactionReport   *RedactionReport `gorm:"type:jsonb" json:"redaction_report,omitempty"`
+	UploadDate        time.Time        `gorm:"not null;default:CURRENT_TIMESTAMP" json:"upload_date"`
+	CreatedAt         time.Time        `gorm:"not null" json:"created_at"`
+	UpdatedAt         time.Time        `gorm:"not null" json:"updated_at"`
 }
 
 func (pdf *PDF) BeforeCreate(_ *gorm.DB) error {
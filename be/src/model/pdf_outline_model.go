@@ -0,0 +1,41 @@
+package model
+
+import "database/sql/driver"
+
+// OutlineItem is one bookmark of a PDF, see pdfdoc.OutlineItem.
+type OutlineItem struct {
+	Title string `json:"title"`
+	Level int    `json:"level"`
+	Page  int    `json:"page"`
+}
+
+// Outline is stored as a JSONB array on the pdfs table.
+type Outline []OutlineItem
+
+func (o Outline) Value() (driver.Value, error) {
+	return jsonValue(o, o == nil)
+}
+
+func (o *Outline) Scan(value interface{}) error {
+	return scanJSON(value, o)
+}
+
+// ChapterSummary is the summary of the pages between one top-level outline
+// item and the next.
+type ChapterSummary struct {
+	Title     string `json:"title"`
+	StartPage int    `json:"start_page"`
+	EndPage   int    `json:"end_page"`
+	Summary   string `json:"summary"`
+}
+
+// ChapterSummaries is stored as a JSONB array on the pdfs table.
+type ChapterSummaries []ChapterSummary
+
+func (cs ChapterSummaries) Value() (driver.Value, error) {
+	return jsonValue(cs, cs == nil)
+}
+
+func (cs *ChapterSummaries) Scan(value interface{}) error {
+	return scanJSON(value, cs)
+}
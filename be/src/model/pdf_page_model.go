@@ -0,0 +1,35 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Sources of a page's stored text.
+const (
+	PageSourceText = "text"
+	PageSourceOCR  = "ocr"
+)
+
+type PDFPage struct {
+	ID          uuid.UUID `gorm:"primaryKey;not null" json:"id"`
+	PDFID       uuid.UUID `gorm:"not null;column:pdf_id;uniqueIndex:idx_pdf_pages_pdf_id_page_number" json:"pdf_id"`
+	ContentHash string    `gorm:"type:varchar(64)" json:"content_hash"`
+	PageNumber  int       `gorm:"not null;uniqueIndex:idx_pdf_pages_pdf_id_page_number" json:"page_number"`
+	Text        string    `gorm:"type:text;not null" json:"text"`
+	Source      string    `gorm:"type:varchar(10);not null;default:'text'" json:"source"`
+	Confidence  *float64  `json:"confidence,omitempty"`
+	CreatedAt   time.Time `gorm:"not null" json:"created_at"`
+}
+
+func (PDFPage) TableName() string {
+	return "pdf_pages"
+}
+
+func (page *PDFPage) BeforeCreate(_ *gorm.DB) error {
+	page.ID = uuid.New()
+	page.CreatedAt = time.Now()
+	return nil
+}
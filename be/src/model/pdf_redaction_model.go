@@ -0,0 +1,41 @@
+package model
+
+import (
+	"database/sql/driver"
+	"time"
+)
+
+// RedactionReport records what was removed to make a redacted copy of a
+// PDF. It says where and what kind of data was found, never the data
+// itself, so the report is as safe to share as the copy.
+type RedactionReport struct {
+	SourceVersion      int            `json:"source_version"`
+	Detectors          []string       `json:"detectors,omitempty"`
+	Areas              []RedactedArea `json:"areas"`
+	GlyphsRemoved      int            `json:"glyphs_removed"`
+	ImagesRemoved      int            `json:"images_removed"`
+	AnnotationsRemoved int            `json:"annotations_removed"`
+	CreatedAt          time.Time      `json:"created_at"`
+}
+
+// RedactedArea is a blacked out area of a page, in PDF points from the
+// bottom-left corner. Kind is the detector label for found personal data,
+// TEXT for requested text or REGION for requested areas.
+type RedactedArea struct {
+	Page int     `json:"page"`
+	Kind string  `json:"kind"`
+	X0   float64 `json:"x0"`
+	Y0   float64 `json:"y0"`
+	X1   float64 `json:"x1"`
+	Y1   float64 `json:"y1"`
+}
+
+// Value stores the report as JSONB; documents that aren't redacted copies
+// have none.
+func (r RedactionReport) Value() (driver.Value, error) {
+	return jsonValue(r, false)
+}
+
+func (r *RedactionReport) Scan(value interface{}) error {
+	return scanJSON(value, r)
+}
@@ -0,0 +1,73 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Outcomes recorded in a share's access log.
+const (
+	ShareAccessGranted          = "granted"
+	ShareAccessPasswordRequired = "password_required"
+	ShareAccessWrongPassword    = "wrong_password"
+	ShareAccessExpired          = "expired"
+	ShareAccessRevoked          = "revoked"
+	ShareAccessViewLimit        = "view_limit"
+)
+
+// Shared resources recorded in a share's access log.
+const (
+	ShareResourceSummary = "summary"
+	ShareResourceFile    = "file"
+)
+
+type PDFShare struct {
+	ID           uuid.UUID  `gorm:"primaryKey;not null" json:"id"`
+	PDFID        uuid.UUID  `gorm:"not null;column:pdf_id;index" json:"pdf_id"`
+	PasswordHash *string    `gorm:"type:varchar(255)" json:"-"`
+	ExpiresAt    time.Time  `gorm:"not null" json:"expires_at"`
+	MaxViews     *int       `json:"max_views,omitempty"`
+	ViewCount    int        `gorm:"not null;default:0" json:"view_count"`
+	RevokedAt    *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt    time.Time  `gorm:"not null" json:"created_at"`
+	UpdatedAt    time.Time  `gorm:"not null" json:"updated_at"`
+}
+
+func (PDFShare) TableName() string {
+	return "pdf_shares"
+}
+
+func (share *PDFShare) BeforeCreate(_ *gorm.DB) error {
+	share.ID = uuid.New()
+	now := time.Now()
+	share.CreatedAt = now
+	share.UpdatedAt = now
+	return nil
+}
+
+func (share *PDFShare) BeforeUpdate(_ *gorm.DB) error {
+	share.UpdatedAt = time.Now()
+	return nil
+}
+
+type PDFShareAccess struct {
+	ID         uuid.UUID `gorm:"primaryKey;not null" json:"id"`
+	ShareID    uuid.UUID `gorm:"not null;column:share_id;index" json:"share_id"`
+	Resource   string    `gorm:"type:varchar(20);not null" json:"resource"`
+	Outcome    string    `gorm:"type:varchar(20);not null" json:"outcome"`
+	IPAddress  string    `gorm:"type:varchar(45)" json:"ip_address"`
+	UserAgent  string    `gorm:"type:varchar(512)" json:"user_agent"`
+	AccessedAt time.Time `gorm:"not null" json:"accessed_at"`
+}
+
+func (PDFShareAccess) TableName() string {
+	return "pdf_share_accesses"
+}
+
+func (access *PDFShareAccess) BeforeCreate(_ *gorm.DB) error {
+	access.ID = uuid.New()
+	access.AccessedAt = time.Now()
+	return nil
+}
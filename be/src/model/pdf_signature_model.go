@@ -0,0 +1,40 @@
+package model
+
+import "time"
+
+// Outcomes of checking a PDF signature. Untrusted signatures are intact but
+// their certificate doesn't chain to the trust store, or no longer does.
+// Modified signatures are intact but the file was updated after signing, so
+// what it shows now is not what was signed.
+const (
+	SignatureStatusValid     = "valid"
+	SignatureStatusModified  = "modified"
+	SignatureStatusUntrusted = "untrusted"
+	SignatureStatusInvalid   = "invalid"
+)
+
+// PDFSignature is the result of checking one signature of a PDF. It is
+// worked out on request, since it depends on the trust store of the time.
+type PDFSignature struct {
+	FieldName   string
+	Page        int
+	SubFilter   string
+	SignerName  string
+	Subject     string
+	Issuer      string
+	Serial      string
+	NotBefore   *time.Time
+	NotAfter    *time.Time
+	SigningTime *time.Time
+	Reason      string
+	Location    string
+	ContactInfo string
+	Status      string
+	// Intact reports whether the signed bytes match the signature.
+	Intact  bool
+	Trusted bool
+	// CoversDocument reports whether nothing was appended to the file
+	// after signing.
+	CoversDocument bool
+	Problems       []string
+}
@@ -0,0 +1,21 @@
+package model
+
+import "database/sql/driver"
+
+// SummaryRequest is how a queued summary was asked for. It is kept with the
+// PDF until the summary is done, so queued summaries survive a restart. The
+// unlock token of a protected PDF is left out: it holds the document
+// password, which is never stored.
+type SummaryRequest struct {
+	Language   string `json:"language"`
+	OutputType string `json:"output_type"`
+	Mode       string `json:"mode,omitempty"`
+}
+
+func (r SummaryRequest) Value() (driver.Value, error) {
+	return jsonValue(r, false)
+}
+
+func (r *SummaryRequest) Scan(value interface{}) error {
+	return scanJSON(value, r)
+}
@@ -0,0 +1,52 @@
+package model
+
+import (
+	"database/sql/driver"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Table detection states of a PDF's current file. Detection runs in the
+// background; partial means it stopped at its time or content limit and
+// later pages were not looked at.
+const (
+	TableStatusPending   = "pending"
+	TableStatusCompleted = "completed"
+	TableStatusPartial   = "partial"
+)
+
+// TableRows is stored as a JSONB array of rows, each an array of cells.
+type TableRows [][]string
+
+func (r TableRows) Value() (driver.Value, error) {
+	return jsonValue(r, r == nil)
+}
+
+func (r *TableRows) Scan(value interface{}) error {
+	return scanJSON(value, r)
+}
+
+// PDFTable is a table detected in a PDF's current file. Index numbers the
+// tables of the document from 1 in reading order.
+type PDFTable struct {
+	ID          uuid.UUID `gorm:"primaryKey;not null" json:"id"`
+	PDFID       uuid.UUID `gorm:"not null;column:pdf_id;uniqueIndex:idx_pdf_tables_pdf_id_table_index" json:"pdf_id"`
+	TableIndex  int       `gorm:"not null;uniqueIndex:idx_pdf_tables_pdf_id_table_index" json:"table_index"`
+	PageNumber  int       `gorm:"not null" json:"page_number"`
+	RowCount    int       `gorm:"not null" json:"row_count"`
+	ColumnCount int       `gorm:"not null" json:"column_count"`
+	Rows        TableRows `gorm:"type:jsonb;not null" json:"rows"`
+	CreatedAt   time.Time `gorm:"not null" json:"created_at"`
+}
+
+func (PDFTable) TableName() string {
+	return "pdf_tables"
+}
+
+func (table *PDFTable) BeforeCreate(_ *gorm.DB) error {
+	table.ID = uuid.New()
+	table.CreatedAt = time.Now()
+	return nil
+}
@@ -0,0 +1,33 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type PDFVersion struct {
+	ID               uuid.UUID  `gorm:"primaryKey;not null" json:"id"`
+	PDFID            uuid.UUID  `gorm:"not null;column:pdf_id;uniqueIndex:idx_pdf_versions_pdf_id_version" json:"pdf_id"`
+	Version          int        `gorm:"not null;uniqueIndex:idx_pdf_versions_pdf_id_version" json:"version"`
+	Filename         string     `gorm:"not null" json:"filename"`
+	OriginalFilename string     `gorm:"not null" json:"original_filename"`
+	FilePath         string     `gorm:"not null" json:"file_path"`
+	FileSize         int64      `gorm:"not null" json:"file_size"`
+	ContentHash      string     `gorm:"type:varchar(64)" json:"content_hash"`
+	ScanStatus       string     `gorm:"type:varchar(20);not null;default:'pending'" json:"scan_status"`
+	ScanFailures     int        `gorm:"not null;default:0" json:"-"`
+	NextScanAt       *time.Time `json:"-"`
+	CreatedAt        time.Time  `gorm:"not null" json:"created_at"`
+}
+
+func (PDFVersion) TableName() string {
+	return "pdf_versions"
+}
+
+func (version *PDFVersion) BeforeCreate(_ *gorm.DB) error {
+	version.ID = uuid.New()
+	version.CreatedAt = time.Now()
+	return nil
+}
@@ -0,0 +1,11 @@
+package model
+
+import "time"
+
+// RateLimit counts the hits on one rate limit key until ExpiresAt, when the
+// count starts over.
+type RateLimit struct {
+	Key       string    `gorm:"primaryKey;type:varchar(255)" json:"key"`
+	Hits      int       `gorm:"not null;default:0" json:"hits"`
+	ExpiresAt time.Time `gorm:"not null" json:"expires_at"`
+}
@@ -0,0 +1,47 @@
+package model
+
+import (
+	"database/sql/driver"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// EmailRecipients are the addresses an email went to, stored as JSONB.
+type EmailRecipients []string
+
+func (r EmailRecipients) Value() (driver.Value, error) {
+	return jsonValue(r, r == nil)
+}
+
+func (r *EmailRecipients) Scan(value interface{}) error {
+	return scanJSON(value, r)
+}
+
+// SummaryEmail records a summary emailed by a user, whether or not it could
+// be delivered. Recent records count towards the user's sending limits, and
+// Delivery tells how sending is getting on.
+type SummaryEmail struct {
+	ID             uuid.UUID       `gorm:"primaryKey;not null" json:"id"`
+	PDFID          uuid.UUID       `gorm:"not null;column:pdf_id;index" json:"pdf_id"`
+	UserID         uuid.UUID       `gorm:"type:uuid;not null" json:"user_id"`
+	Recipients     EmailRecipients `gorm:"type:jsonb;not null" json:"recipients"`
+	RecipientCount int             `gorm:"not null" json:"recipient_count"`
+	Subject        string          `gorm:"type:text;not null" json:"subject"`
+	PDFVersion     int             `gorm:"not null;column:pdf_version" json:"pdf_version"`
+	Attached       bool            `gorm:"not null;default:false" json:"attached"`
+	OutboxID       *uuid.UUID      `gorm:"type:uuid" json:"-"`
+	Delivery       *EmailOutbox    `gorm:"foreignKey:OutboxID" json:"delivery,omitempty"`
+	CreatedAt      time.Time       `gorm:"not null" json:"created_at"`
+}
+
+func (SummaryEmail) TableName() string {
+	return "summary_emails"
+}
+
+func (email *SummaryEmail) BeforeCreate(_ *gorm.DB) error {
+	email.ID = uuid.New()
+	email.CreatedAt = time.Now()
+	return nil
+}
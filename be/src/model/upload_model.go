@@ -0,0 +1,38 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type Upload struct {
+	ID               uuid.UUID  `gorm:"primaryKey;not null" json:"id"`
+	OriginalFilename string     `gorm:"not null" json:"original_filename"`
+	FilePath         string     `gorm:"not null" json:"file_path"`
+	Length           int64      `gorm:"not null;column:upload_length" json:"length"`
+	Offset           int64      `gorm:"not null;default:0;column:upload_offset" json:"offset"`
+	Metadata         string     `gorm:"type:text" json:"metadata"`
+	PDFID            *uuid.UUID `gorm:"column:pdf_id" json:"pdf_id,omitempty"`
+	ExpiresAt        time.Time  `gorm:"not null;index" json:"expires_at"`
+	CreatedAt        time.Time  `gorm:"not null" json:"created_at"`
+	UpdatedAt        time.Time  `gorm:"not null" json:"updated_at"`
+}
+
+func (upload *Upload) BeforeCreate(_ *gorm.DB) error {
+	upload.ID = uuid.New()
+	now := time.Now()
+	upload.CreatedAt = now
+	upload.UpdatedAt = now
+	return nil
+}
+
+func (upload *Upload) BeforeUpdate(_ *gorm.DB) error {
+	upload.UpdatedAt = time.Now()
+	return nil
+}
+
+func (upload *Upload) IsExpired() bool {
+	return upload.PDFID == nil && time.Now().After(upload.ExpiresAt)
+}
@@ -14,6 +14,7 @@ type User struct {
 	Password      string    `gorm:"not null" json:"-"`
 	Role          string    `gorm:"default:user;not null" json:"role"`
 	VerifiedEmail bool      `gorm:"default:false;not null" json:"verified_email"`
+	Locale        string    `gorm:"type:varchar(5);default:en;not null" json:"locale"`
 	CreatedAt     time.Time `gorm:"autoCreateTime:milli" json:"-"`
 	UpdatedAt     time.Time `gorm:"autoCreateTime:milli;autoUpdateTime:milli" json:"-"`
 	Token         []Token   `gorm:"foreignKey:user_id;references:id" json:"-"`
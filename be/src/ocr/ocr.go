@@ -0,0 +1,25 @@
+package ocr
+
+import "context"
+
+// Result is the text recognized on a single page.
+type Result struct {
+	Text string
+	// Confidence is the mean word confidence, from 0 to 100.
+	Confidence float64
+}
+
+// Provider recognizes the text of PDF pages that have no text layer.
+type Provider interface {
+	// Recognize reads page (1-based) of the PDF at path. Protected files
+	// are passed as a decrypted copy, so that no password ever ends up on
+	// a command line where other users of the machine can read it.
+	Recognize(ctx context.Context, path string, page int) (*Result, error)
+}
+
+// Noop recognizes nothing. It stands in where OCR is disabled and in tests.
+type Noop struct{}
+
+func (Noop) Recognize(_ context.Context, _ string, _ int) (*Result, error) {
+	return &Result{}, nil
+}
@@ -0,0 +1,128 @@
+package ocr
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Tesseract renders pages with pdftoppm and reads them with the tesseract
+// CLI. Both must be on the PATH unless the commands are set explicitly.
+type Tesseract struct {
+	RenderCommand string
+	Command       string
+	// Languages is a tesseract language list such as "eng+ind".
+	Languages string
+	DPI       int
+}
+
+func NewTesseract(languages string) *Tesseract {
+	return &Tesseract{
+		RenderCommand: "pdftoppm",
+		Command:       "tesseract",
+		Languages:     languages,
+		DPI:           300,
+	}
+}
+
+func (t *Tesseract) Recognize(ctx context.Context, path string, page int) (*Result, error) {
+	dir, err := os.MkdirTemp("", "ocr-*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	image := filepath.Join(dir, "page")
+	args := []string{
+		"-f", strconv.Itoa(page), "-l", strconv.Itoa(page),
+		"-r", strconv.Itoa(t.DPI), "-png", "-singlefile",
+		path, image,
+	}
+
+	if err := run(ctx, t.RenderCommand, args, nil); err != nil {
+		return nil, fmt.Errorf("failed to render page %d: %w", page, err)
+	}
+
+	var tsv bytes.Buffer
+	args = []string{image + ".png", "stdout", "-l", t.Languages, "tsv"}
+	if err := run(ctx, t.Command, args, &tsv); err != nil {
+		return nil, fmt.Errorf("failed to recognize page %d: %w", page, err)
+	}
+
+	return ParseTSV(&tsv)
+}
+
+func run(ctx context.Context, command string, args []string, stdout io.Writer) error {
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, command, args...)
+	cmd.Stdout = stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return fmt.Errorf("%s: %w: %s", command, err, msg)
+		}
+		return fmt.Errorf("%s: %w", command, err)
+	}
+	return nil
+}
+
+// ParseTSV rebuilds the text from tesseract's TSV output, one line per
+// recognized line with a blank line between paragraphs, and averages the
+// confidence of its words.
+func ParseTSV(r io.Reader) (*Result, error) {
+	var b strings.Builder
+	var total float64
+	var words int
+	var lastPar, lastLine string
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	for scanner.Scan() {
+		// level page block par line word left top width height conf text
+		fields := strings.SplitN(scanner.Text(), "\t", 12)
+		if len(fields) < 12 || fields[0] != "5" {
+			continue
+		}
+
+		text := strings.TrimSpace(fields[11])
+		conf, err := strconv.ParseFloat(fields[10], 64)
+		if text == "" || err != nil || conf < 0 {
+			continue
+		}
+
+		par := fields[2] + "." + fields[3]
+		line := par + "." + fields[4]
+		switch {
+		case b.Len() == 0:
+		case par != lastPar:
+			b.WriteString("\n\n")
+		case line != lastLine:
+			b.WriteByte('\n')
+		default:
+			b.WriteByte(' ')
+		}
+		lastPar, lastLine = par, line
+
+		b.WriteString(text)
+		total += conf
+		words++
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	result := &Result{Text: b.String()}
+	if words > 0 {
+		result.Confidence = total / float64(words)
+	}
+	return result, nil
+}
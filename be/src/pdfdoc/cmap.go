@@ -0,0 +1,166 @@
+package pdfdoc
+
+import (
+	"unicode/utf16"
+)
+
+// maxCMapRange bounds how many codes a single bfrange entry may expand to.
+const maxCMapRange = 65536
+
+// codespace is a range of character codes of one byte length.
+type codespace struct {
+	size      int
+	low, high uint32
+}
+
+// toUnicodeCMap maps character codes to text, as read from a font's
+// ToUnicode stream.
+type toUnicodeCMap struct {
+	codespaces []codespace
+	chars      map[uint32]string
+	// sizes lists the code lengths used by bfchar and bfrange entries, for
+	// CMaps that leave out their codespace ranges.
+	sizes map[int]bool
+}
+
+// parseToUnicode reads the codespace, bfchar and bfrange sections of a
+// ToUnicode CMap. Anything else in the stream is ignored.
+func parseToUnicode(data []byte) *toUnicodeCMap {
+	cmap := &toUnicodeCMap{chars: make(map[uint32]string), sizes: make(map[int]bool)}
+	l := newLexer(data, 0)
+
+	var args []Object
+	for {
+		obj, err := l.object()
+		if err != nil {
+			return cmap
+		}
+
+		op, isOperator := obj.(Keyword)
+		if !isOperator {
+			args = append(args, obj)
+			continue
+		}
+
+		switch op {
+		case "begincodespacerange", "beginbfchar", "beginbfrange":
+			args = args[:0]
+		case "endcodespacerange":
+			for i := 0; i+1 < len(args); i += 2 {
+				low, ok1 := args[i].(String)
+				high, ok2 := args[i+1].(String)
+				if ok1 && ok2 && len(low) == len(high) && len(low) > 0 && len(low) <= 4 {
+					cmap.codespaces = append(cmap.codespaces, codespace{size: len(low), low: codeValue(low), high: codeValue(high)})
+				}
+			}
+			args = args[:0]
+		case "endbfchar":
+			for i := 0; i+1 < len(args); i += 2 {
+				src, ok1 := args[i].(String)
+				dst, ok2 := args[i+1].(String)
+				if ok1 && ok2 && len(src) > 0 && len(src) <= 4 {
+					cmap.set(src, utf16Text(dst))
+				}
+			}
+			args = args[:0]
+		case "endbfrange":
+			for i := 0; i+2 < len(args); i += 3 {
+				cmap.addRange(args[i], args[i+1], args[i+2])
+			}
+			args = args[:0]
+		default:
+			args = args[:0]
+		}
+	}
+}
+
+func (c *toUnicodeCMap) set(code String, text string) {
+	c.chars[codeValue(code)] = text
+	c.sizes[len(code)] = true
+}
+
+func (c *toUnicodeCMap) addRange(lowObj, highObj, dst Object) {
+	low, ok1 := lowObj.(String)
+	high, ok2 := highObj.(String)
+	if !ok1 || !ok2 || len(low) == 0 || len(low) > 4 || len(low) != len(high) {
+		return
+	}
+
+	first, last := codeValue(low), codeValue(high)
+	if last < first || last-first >= maxCMapRange {
+		return
+	}
+	c.sizes[len(low)] = true
+
+	switch d := dst.(type) {
+	case String:
+		// Each code maps to the destination with its last byte incremented
+		units := utf16Units(d)
+		if len(units) == 0 {
+			return
+		}
+		for code := first; code <= last; code++ {
+			mapped := append([]uint16(nil), units...)
+			mapped[len(mapped)-1] += uint16(code - first)
+			c.chars[code] = string(utf16.Decode(mapped))
+		}
+	case Array:
+		for i, item := range d {
+			code := first + uint32(i)
+			if code > last {
+				break
+			}
+			if s, ok := item.(String); ok {
+				c.chars[code] = utf16Text(s)
+			}
+		}
+	}
+}
+
+// codeLength returns how many bytes the code at the start of s takes.
+func (c *toUnicodeCMap) codeLength(s []byte, fallback int) int {
+	for size := 1; size <= 4 && size <= len(s); size++ {
+		value := codeValue(s[:size])
+		for _, cs := range c.codespaces {
+			if cs.size == size && value >= cs.low && value <= cs.high {
+				return size
+			}
+		}
+	}
+
+	if len(c.codespaces) == 0 && len(c.sizes) == 1 {
+		for size := range c.sizes {
+			return size
+		}
+	}
+	return fallback
+}
+
+func (c *toUnicodeCMap) lookup(code uint32) (string, bool) {
+	text, ok := c.chars[code]
+	return text, ok
+}
+
+func codeValue(b []byte) uint32 {
+	var v uint32
+	for _, c := range b {
+		v = v<<8 | uint32(c)
+	}
+	return v
+}
+
+func utf16Units(s String) []uint16 {
+	units := make([]uint16, 0, len(s)/2)
+	for i := 0; i+1 < len(s); i += 2 {
+		units = append(units, uint16(s[i])<<8|uint16(s[i+1]))
+	}
+	if len(s)%2 == 1 {
+		// Some writers use single bytes for ASCII destinations
+		units = append(units, uint16(s[len(s)-1]))
+	}
+	return units
+}
+
+func utf16Text(s String) string {
+	return string(utf16.Decode(utf16Units(s)))
+}
@@ -0,0 +1,86 @@
+package pdfdoc
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// maxOperands bounds the operand stack so a stream of numbers without an
+// operator can't grow it without limit.
+const maxOperands = 4096
+
+// inlineImage holds the dictionary, data and closing EI of an inline image.
+type inlineImage []byte
+
+// parseContent reads a content stream and calls fn for every operator with
+// the operands that precede it. Inline images come as a BI operator with
+// the rest of the image as its only operand.
+func parseContent(data []byte, fn func(op Keyword, args []Object) error) error {
+	l := newLexer(data, 0)
+	var args []Object
+
+	for {
+		l.skipSpace()
+		if l.pos >= len(data) {
+			return nil
+		}
+
+		obj, err := l.object()
+		if err != nil {
+			return fmt.Errorf("%w: content stream: %v", ErrCorrupt, err)
+		}
+
+		op, isOperator := obj.(Keyword)
+		if !isOperator {
+			if len(args) >= maxOperands {
+				return fmt.Errorf("%w: content stream has too many operands", ErrCorrupt)
+			}
+			args = append(args, obj)
+			continue
+		}
+
+		if op == "BI" {
+			start := l.pos
+			if err := l.skipInlineImage(); err != nil {
+				return err
+			}
+			args = append(args[:0], inlineImage(data[start:l.pos]))
+		}
+
+		if err := fn(op, args); err != nil {
+			return err
+		}
+		args = args[:0]
+	}
+}
+
+// skipInlineImage moves past the dictionary and data of an inline image,
+// just after its "EI" operator.
+func (l *lexer) skipInlineImage() error {
+	for {
+		obj, err := l.object()
+		if err != nil {
+			return fmt.Errorf("%w: inline image: %v", ErrCorrupt, err)
+		}
+		if obj == Keyword("ID") {
+			break
+		}
+	}
+
+	// A single whitespace byte separates ID from the image data
+	l.pos++
+
+	// The data is binary, so EI only counts when it stands alone
+	for l.pos < len(l.data) {
+		i := bytes.Index(l.data[l.pos:], []byte("EI"))
+		if i < 0 {
+			break
+		}
+		end := l.pos + i
+		l.pos = end + 2
+		if (end == 0 || isSpace(l.data[end-1])) && (l.pos >= len(l.data) || !isRegular(l.data[l.pos])) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: inline image is not terminated", ErrCorrupt)
+}
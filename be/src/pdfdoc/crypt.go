@@ -0,0 +1,403 @@
+package pdfdoc
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/md5"
+	"crypto/rc4"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+)
+
+var (
+	ErrPasswordRequired      = errors.New("PDF is password protected")
+	ErrIncorrectPassword     = errors.New("incorrect PDF password")
+	ErrUnsupportedEncryption = errors.New("unsupported PDF encryption")
+)
+
+// passwordPadding is the fixed string passwords are padded with (Algorithm 2).
+var passwordPadding = []byte{
+	0x28, 0xbf, 0x4e, 0x5e, 0x4e, 0x75, 0x8a, 0x41, 0x64, 0x00, 0x4e, 0x56, 0xff, 0xfa, 0x01, 0x08,
+	0x2e, 0x2e, 0x00, 0xb6, 0xd0, 0x68, 0x3e, 0x80, 0x2f, 0x0c, 0xa9, 0xfe, 0x64, 0x53, 0x69, 0x7a,
+}
+
+type cryptMethod int
+
+const (
+	cryptNone cryptMethod = iota
+	cryptRC4
+	cryptAESV2
+	cryptAESV3
+)
+
+// decrypter implements the standard security handler, revisions 2 to 6.
+type decrypter struct {
+	key             []byte
+	stringMethod    cryptMethod
+	streamMethod    cryptMethod
+	encryptMetadata bool
+	encryptRef      Ref
+}
+
+type securityHandler struct {
+	v, r            int
+	length          int
+	o, u, oe, ue    []byte
+	p               uint32
+	id              []byte
+	encryptMetadata bool
+}
+
+// setupEncryption checks password against the /Encrypt dictionary and, when
+// it matches either the user or the owner password, enables decryption of
+// every object loaded afterwards.
+func (d *Document) setupEncryption(password string) error {
+	encryptRef, _ := d.Trailer["Encrypt"].(Ref)
+	dict := d.Dict(d.Trailer["Encrypt"])
+	if dict == nil {
+		return fmt.Errorf("%w: encryption dictionary is missing", ErrCorrupt)
+	}
+	if d.Name(dict["Filter"]) != "Standard" {
+		return fmt.Errorf("%w: %s security handler", ErrUnsupportedEncryption, d.Name(dict["Filter"]))
+	}
+
+	h := securityHandler{encryptMetadata: true, length: 40}
+	v, _ := d.Int(dict["V"])
+	r, _ := d.Int(dict["R"])
+	h.v, h.r = int(v), int(r)
+	if length, ok := d.Int(dict["Length"]); ok {
+		h.length = int(length)
+	}
+	o, _ := d.String(dict["O"])
+	u, _ := d.String(dict["U"])
+	oe, _ := d.String(dict["OE"])
+	ue, _ := d.String(dict["UE"])
+	h.o, h.u, h.oe, h.ue = o, u, oe, ue
+	p, _ := d.Int(dict["P"])
+	h.p = uint32(int32(p))
+	if em, ok := d.Resolve(dict["EncryptMetadata"]).(bool); ok {
+		h.encryptMetadata = em
+	}
+	if ids := d.Array(d.Trailer["ID"]); len(ids) > 0 {
+		id, _ := d.String(ids[0])
+		h.id = id
+	}
+
+	dec := &decrypter{encryptMetadata: h.encryptMetadata, encryptRef: encryptRef}
+
+	switch h.v {
+	case 1, 2:
+		dec.stringMethod, dec.streamMethod = cryptRC4, cryptRC4
+	case 4, 5:
+		cf := d.Dict(dict["CF"])
+		dec.streamMethod = d.cryptFilter(cf, d.Name(dict["StmF"]))
+		dec.stringMethod = d.cryptFilter(cf, d.Name(dict["StrF"]))
+	default:
+		return fmt.Errorf("%w: algorithm version %d", ErrUnsupportedEncryption, h.v)
+	}
+
+	if h.v == 1 || h.r == 2 {
+		h.length = 40
+	}
+	if h.length < 40 || h.length > 128 || h.length%8 != 0 {
+		h.length = 128
+	}
+
+	var key []byte
+	var err error
+	switch h.r {
+	case 2, 3, 4:
+		if len(h.o) < 32 || len(h.u) < 16 {
+			return fmt.Errorf("%w: invalid /O or /U entry", ErrCorrupt)
+		}
+		key, err = h.legacyKey([]byte(password))
+	case 5, 6:
+		if len(h.o) < 48 || len(h.u) < 48 || len(h.oe) < 32 || len(h.ue) < 32 {
+			return fmt.Errorf("%w: invalid /O or /U entry", ErrCorrupt)
+		}
+		key, err = h.aes256Key([]byte(password))
+	default:
+		return fmt.Errorf("%w: revision %d", ErrUnsupportedEncryption, h.r)
+	}
+	if err != nil {
+		if password == "" {
+			return ErrPasswordRequired
+		}
+		return err
+	}
+
+	dec.key = key
+	d.crypt = dec
+	d.cache = make(map[int]Object)
+	d.objStms = make(map[int]*objectStream)
+	return nil
+}
+
+func (d *Document) cryptFilter(cf Dict, name Name) cryptMethod {
+	if name == "" || name == "Identity" {
+		return cryptNone
+	}
+	switch d.Name(d.Dict(cf[name])["CFM"]) {
+	case "V2":
+		return cryptRC4
+	case "AESV2":
+		return cryptAESV2
+	case "AESV3":
+		return cryptAESV3
+	}
+	return cryptNone
+}
+
+// legacyKey tries password as the user password and then as the owner
+// password for revisions 2 to 4 (Algorithms 2, 6 and 7).
+func (h *securityHandler) legacyKey(password []byte) ([]byte, error) {
+	if key := h.userKey(password); key != nil {
+		return key, nil
+	}
+
+	// The owner password decrypts /O into the user password
+	ownerKey := md5.Sum(padPassword(password))
+	n := h.length / 8
+	if h.r >= 3 {
+		for i := 0; i < 50; i++ {
+			ownerKey = md5.Sum(ownerKey[:n])
+		}
+	}
+
+	userPassword := append([]byte(nil), h.o[:32]...)
+	if h.r == 2 {
+		rc4XOR(ownerKey[:5], userPassword)
+	} else {
+		for i := 19; i >= 0; i-- {
+			rc4XOR(xorKey(ownerKey[:n], byte(i)), userPassword)
+		}
+	}
+
+	if key := h.userKey(userPassword); key != nil {
+		return key, nil
+	}
+	return nil, ErrIncorrectPassword
+}
+
+// userKey derives the file key from a user password and returns it when the
+// password checks out against /U.
+func (h *securityHandler) userKey(password []byte) []byte {
+	n := h.length / 8
+
+	digest := md5.New()
+	digest.Write(padPassword(password))
+	digest.Write(h.o[:32])
+	binary.Write(digest, binary.LittleEndian, h.p)
+	digest.Write(h.id)
+	if h.r >= 4 && !h.encryptMetadata {
+		digest.Write([]byte{0xff, 0xff, 0xff, 0xff})
+	}
+	key := digest.Sum(nil)
+	if h.r >= 3 {
+		for i := 0; i < 50; i++ {
+			sum := md5.Sum(key[:n])
+			key = sum[:]
+		}
+	}
+	key = key[:n]
+
+	if h.r == 2 {
+		check := append([]byte(nil), passwordPadding...)
+		rc4XOR(key, check)
+		if bytes.Equal(check, h.u[:32]) {
+			return key
+		}
+		return nil
+	}
+
+	sum := md5.Sum(append(append([]byte(nil), passwordPadding...), h.id...))
+	check := sum[:]
+	for i := 0; i < 20; i++ {
+		rc4XOR(xorKey(key, byte(i)), check)
+	}
+	if bytes.Equal(check, h.u[:16]) {
+		return key
+	}
+	return nil
+}
+
+// aes256Key checks password for revisions 5 and 6 and unwraps the file key
+// from /UE or /OE.
+func (h *securityHandler) aes256Key(password []byte) ([]byte, error) {
+	if len(password) > 127 {
+		password = password[:127]
+	}
+
+	var wrapped, intermediate []byte
+	switch {
+	case bytes.Equal(h.hash(password, h.u[32:40], nil), h.u[:32]):
+		intermediate = h.hash(password, h.u[40:48], nil)
+		wrapped = h.ue[:32]
+	case bytes.Equal(h.hash(password, h.o[32:40], h.u[:48]), h.o[:32]):
+		intermediate = h.hash(password, h.o[40:48], h.u[:48])
+		wrapped = h.oe[:32]
+	default:
+		return nil, ErrIncorrectPassword
+	}
+
+	block, err := aes.NewCipher(intermediate)
+	if err != nil {
+		return nil, err
+	}
+	key := make([]byte, 32)
+	cipher.NewCBCDecrypter(block, make([]byte, aes.BlockSize)).CryptBlocks(key, wrapped)
+	return key, nil
+}
+
+// hash is Algorithm 2.B, or plain SHA-256 for revision 5.
+func (h *securityHandler) hash(password, salt, userKey []byte) []byte {
+	sum := sha256.New()
+	sum.Write(password)
+	sum.Write(salt)
+	sum.Write(userKey)
+	k := sum.Sum(nil)
+	if h.r == 5 {
+		return k
+	}
+
+	for i := 0; ; i++ {
+		k1 := bytes.Repeat(append(append(append([]byte(nil), password...), k...), userKey...), 64)
+
+		block, _ := aes.NewCipher(k[:16])
+		e := make([]byte, len(k1))
+		cipher.NewCBCEncrypter(block, k[16:32]).CryptBlocks(e, k1)
+
+		mod := 0
+		for _, b := range e[:16] {
+			mod += int(b)
+		}
+
+		var next hash.Hash
+		switch mod % 3 {
+		case 0:
+			next = sha256.New()
+		case 1:
+			next = sha512.New384()
+		default:
+			next = sha512.New()
+		}
+		next.Write(e)
+		k = next.Sum(nil)
+
+		// At least 64 rounds, then until the last byte allows stopping
+		if i >= 63 && int(e[len(e)-1]) <= i-31 {
+			break
+		}
+	}
+	return k[:32]
+}
+
+func padPassword(password []byte) []byte {
+	padded := make([]byte, 32)
+	n := copy(padded, password)
+	copy(padded[n:], passwordPadding)
+	return padded
+}
+
+func xorKey(key []byte, v byte) []byte {
+	out := make([]byte, len(key))
+	for i := range key {
+		out[i] = key[i] ^ v
+	}
+	return out
+}
+
+func rc4XOR(key, data []byte) {
+	c, _ := rc4.NewCipher(key)
+	c.XORKeyStream(data, data)
+}
+
+// objectKey is Algorithm 1: the file key extended with the object number.
+func (c *decrypter) objectKey(ref Ref, method cryptMethod) []byte {
+	if method == cryptAESV3 {
+		return c.key
+	}
+
+	digest := md5.New()
+	digest.Write(c.key)
+	digest.Write([]byte{byte(ref.Num), byte(ref.Num >> 8), byte(ref.Num >> 16), byte(ref.Gen), byte(ref.Gen >> 8)})
+	if method == cryptAESV2 {
+		digest.Write([]byte("sAlT"))
+	}
+	key := digest.Sum(nil)
+
+	n := len(c.key) + 5
+	if n > 16 {
+		n = 16
+	}
+	return key[:n]
+}
+
+func (c *decrypter) decrypt(ref Ref, method cryptMethod, data []byte) ([]byte, error) {
+	switch method {
+	case cryptNone:
+		return data, nil
+	case cryptRC4:
+		out := append([]byte(nil), data...)
+		rc4XOR(c.objectKey(ref, method), out)
+		return out, nil
+	}
+
+	if len(data) < aes.BlockSize || len(data)%aes.BlockSize != 0 {
+		return nil, errors.New("invalid AES data length")
+	}
+	block, err := aes.NewCipher(c.objectKey(ref, method))
+	if err != nil {
+		return nil, err
+	}
+	out := make([]byte, len(data)-aes.BlockSize)
+	cipher.NewCBCDecrypter(block, data[:aes.BlockSize]).CryptBlocks(out, data[aes.BlockSize:])
+
+	if len(out) > 0 {
+		pad := int(out[len(out)-1])
+		if pad > 0 && pad <= aes.BlockSize && pad <= len(out) {
+			out = out[:len(out)-pad]
+		}
+	}
+	return out, nil
+}
+
+// decryptObject decrypts the strings and stream data of an object loaded
+// from the file. The encryption dictionary, cross-reference streams and, when
+// /EncryptMetadata is false, metadata streams are stored in the clear.
+func (c *decrypter) decryptObject(ref Ref, obj Object) Object {
+	if ref.Num == c.encryptRef.Num {
+		return obj
+	}
+
+	switch v := obj.(type) {
+	case String:
+		out, err := c.decrypt(ref, c.stringMethod, v)
+		if err != nil {
+			return String(nil)
+		}
+		return String(out)
+	case Array:
+		for i := range v {
+			v[i] = c.decryptObject(ref, v[i])
+		}
+	case Dict:
+		for key, value := range v {
+			v[key] = c.decryptObject(ref, value)
+		}
+	case *Stream:
+		c.decryptObject(ref, v.Dict)
+		if v.Dict["Type"] == Name("XRef") || v.Dict["Type"] == Name("Metadata") && !c.encryptMetadata {
+			return v
+		}
+		if raw, err := c.decrypt(ref, c.streamMethod, v.Raw); err == nil {
+			v.Raw = raw
+		}
+	}
+	return obj
+}
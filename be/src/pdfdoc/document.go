@@ -0,0 +1,307 @@
+// Package pdfdoc reads the structure of PDF files: cross-reference data,
+// objects, streams, the page tree and document metadata. It is deliberately
+// lenient about the small defects real-world writers produce, while
+// reporting files it cannot make sense of with errors that say why.
+package pdfdoc
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+)
+
+var (
+	ErrNotPDF    = errors.New("file is not a PDF")
+	ErrTruncated = errors.New("PDF is truncated")
+	ErrCorrupt   = errors.New("PDF is corrupted")
+)
+
+type Document struct {
+	// Version is the version from the file header, such as "1.7".
+	Version string
+	Trailer Dict
+
+	data  []byte
+	xref  map[int]xrefEntry
+	cache map[int]Object
+
+	objStms   map[int]*objectStream
+	resolving map[int]bool
+	decoded   map[int]decodedStream
+	// decodedSize is how much stream data has been decoded so far, held
+	// under maxDocumentDecodedSize
+	decodedSize int
+	pages       []*Page
+	crypt       *decrypter
+}
+
+type objectStream struct {
+	nums    []int
+	offsets []int
+	data    []byte
+}
+
+// Open parses data as a PDF. The cross-reference data is rebuilt from a scan
+// of the file when it is missing or damaged. Encrypted files open as long as
+// they have an empty user password, otherwise ErrPasswordRequired is returned.
+func Open(data []byte) (*Document, error) {
+	return OpenWithPassword(data, "")
+}
+
+// OpenWithPassword is like Open but decrypts the file with password, which
+// may be either the user or the owner password.
+func OpenWithPassword(data []byte, password string) (*Document, error) {
+	header := data
+	if len(header) > 1024 {
+		header = header[:1024]
+	}
+	start := bytes.Index(header, []byte("%PDF-"))
+	if start < 0 {
+		return nil, fmt.Errorf("%w: missing %%PDF header", ErrNotPDF)
+	}
+
+	d := &Document{
+		data:      data,
+		xref:      make(map[int]xrefEntry),
+		cache:     make(map[int]Object),
+		objStms:   make(map[int]*objectStream),
+		resolving: make(map[int]bool),
+		decoded:   make(map[int]decodedStream),
+	}
+
+	versionEnd := start + 5
+	for versionEnd < len(data) && (data[versionEnd] == '.' || data[versionEnd] >= '0' && data[versionEnd] <= '9') {
+		versionEnd++
+	}
+	d.Version = string(data[start+5 : versionEnd])
+	if _, err := strconv.ParseFloat(d.Version, 64); err != nil {
+		return nil, fmt.Errorf("%w: invalid version in header", ErrNotPDF)
+	}
+
+	if !bytes.Contains(data[max(0, len(data)-4096):], []byte("%%EOF")) {
+		return nil, fmt.Errorf("%w: missing end-of-file marker", ErrTruncated)
+	}
+
+	xrefErr := d.loadXref()
+	if xrefErr != nil {
+		if err := d.rebuildXref(); err != nil {
+			return nil, xrefErr
+		}
+	}
+
+	if err := d.setupCatalog(password); err != nil {
+		if xrefErr != nil || errors.Is(err, ErrPasswordRequired) || errors.Is(err, ErrIncorrectPassword) {
+			return nil, err
+		}
+
+		// The cross-reference data parsed but doesn't lead anywhere
+		if rebuildErr := d.rebuildXref(); rebuildErr != nil {
+			return nil, err
+		}
+		if err := d.setupCatalog(password); err != nil {
+			return nil, err
+		}
+	}
+
+	return d, nil
+}
+
+func (d *Document) setupCatalog(password string) error {
+	if _, ok := d.Trailer["Encrypt"]; ok {
+		if err := d.setupEncryption(password); err != nil {
+			return err
+		}
+	}
+
+	if d.Catalog() == nil {
+		return fmt.Errorf("%w: document catalog not found", ErrCorrupt)
+	}
+	return nil
+}
+
+// OpenFile reads and parses the PDF at path.
+func OpenFile(path string) (*Document, error) {
+	return OpenFileWithPassword(path, "")
+}
+
+func OpenFileWithPassword(path, password string) (*Document, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return OpenWithPassword(data, password)
+}
+
+// Encrypted reports whether the document has an /Encrypt dictionary.
+func (d *Document) Encrypted() bool {
+	_, ok := d.Trailer["Encrypt"]
+	return ok
+}
+
+// Catalog returns the document's root dictionary.
+func (d *Document) Catalog() Dict {
+	return d.Dict(d.Trailer["Root"])
+}
+
+// object loads an indirect object, returning nil for missing or unreadable
+// objects as the spec asks readers to treat them as null.
+func (d *Document) object(ref Ref) Object {
+	if obj, ok := d.cache[ref.Num]; ok {
+		return obj
+	}
+
+	entry, ok := d.xref[ref.Num]
+	if !ok || entry.offset < 0 && !entry.compressed {
+		return nil
+	}
+
+	if d.resolving[ref.Num] {
+		return nil
+	}
+	d.resolving[ref.Num] = true
+	defer delete(d.resolving, ref.Num)
+
+	var obj Object
+	if entry.compressed {
+		obj = d.compressedObject(entry)
+	} else if entry.offset < int64(len(d.data)) {
+		got, o, err := d.readIndirect(int(entry.offset))
+		if err == nil && got.Num == ref.Num {
+			obj = o
+			if d.crypt != nil {
+				obj = d.crypt.decryptObject(got, obj)
+			}
+		}
+	}
+
+	d.cache[ref.Num] = obj
+	return obj
+}
+
+// readIndirect parses "num gen obj ... endobj" at offset.
+func (d *Document) readIndirect(offset int) (Ref, Object, error) {
+	l := newLexer(d.data, offset)
+
+	numTok, _ := l.token()
+	genTok, _ := l.token()
+	objTok, _ := l.token()
+	num, ok1 := numTok.(int64)
+	gen, ok2 := genTok.(int64)
+	if !ok1 || !ok2 || objTok != Keyword("obj") {
+		return Ref{}, nil, fmt.Errorf("no object at offset %d", offset)
+	}
+	ref := Ref{Num: int(num), Gen: int(gen)}
+
+	obj, err := l.object()
+	if err != nil {
+		return ref, nil, err
+	}
+	if obj == Keyword("endobj") {
+		return ref, nil, nil
+	}
+	if _, isKeyword := obj.(Keyword); isKeyword {
+		return ref, nil, fmt.Errorf("unexpected %q in object %d", obj, num)
+	}
+
+	dict, ok := obj.(Dict)
+	if !ok {
+		return ref, obj, nil
+	}
+
+	save := l.pos
+	if tok, err := l.token(); err != nil || tok != Keyword("stream") {
+		l.pos = save
+		return ref, dict, nil
+	}
+	l.skipEOL()
+
+	raw, err := d.streamData(l.pos, dict)
+	if err != nil {
+		return ref, nil, fmt.Errorf("object %d: %w", num, err)
+	}
+	return ref, &Stream{Dict: dict, Ref: ref, Raw: raw}, nil
+}
+
+// streamData returns the stream bytes starting at start. /Length is used when
+// it checks out, otherwise the data runs up to the next endstream.
+func (d *Document) streamData(start int, dict Dict) ([]byte, error) {
+	if length, ok := d.Int(dict["Length"]); ok && length >= 0 {
+		end := start + int(length)
+		if end <= len(d.data) && hasKeywordAt(d.data, end, "endstream") {
+			return d.data[start:end], nil
+		}
+	}
+
+	end := bytes.Index(d.data[start:], []byte("endstream"))
+	if end < 0 {
+		return nil, errors.New("stream is not terminated")
+	}
+	end += start
+
+	// The EOL before endstream is not part of the data
+	if end > start && d.data[end-1] == '\n' {
+		end--
+	}
+	if end > start && d.data[end-1] == '\r' {
+		end--
+	}
+	return d.data[start:end], nil
+}
+
+func (d *Document) compressedObject(entry xrefEntry) Object {
+	objStm, err := d.objectStream(entry.stream)
+	if err != nil || entry.index >= len(objStm.offsets) {
+		return nil
+	}
+
+	l := newLexer(objStm.data, objStm.offsets[entry.index])
+	obj, err := l.object()
+	if err != nil {
+		return nil
+	}
+	if _, isKeyword := obj.(Keyword); isKeyword {
+		return nil
+	}
+	return obj
+}
+
+func (d *Document) objectStream(num int) (*objectStream, error) {
+	if objStm, ok := d.objStms[num]; ok {
+		return objStm, nil
+	}
+
+	stream := d.Stream(Ref{Num: num})
+	if stream == nil || stream.Dict["Type"] != Name("ObjStm") {
+		return nil, fmt.Errorf("object %d is not an object stream", num)
+	}
+
+	data, err := d.decode(stream)
+	if err != nil {
+		return nil, err
+	}
+
+	n, _ := d.Int(stream.Dict["N"])
+	first, _ := d.Int(stream.Dict["First"])
+	if n < 0 || first < 0 || first > int64(len(data)) || n > int64(len(data)) {
+		return nil, fmt.Errorf("object stream %d has an invalid header", num)
+	}
+
+	objStm := &objectStream{data: data}
+	l := newLexer(data[:first], 0)
+	for i := int64(0); i < n; i++ {
+		numTok, err1 := l.token()
+		offTok, err2 := l.token()
+		objNum, ok1 := numTok.(int64)
+		offset, ok2 := offTok.(int64)
+		if err1 != nil || err2 != nil || !ok1 || !ok2 || first+offset > int64(len(data)) {
+			return nil, fmt.Errorf("object stream %d has an invalid header", num)
+		}
+		objStm.nums = append(objStm.nums, int(objNum))
+		objStm.offsets = append(objStm.offsets, int(first+offset))
+	}
+
+	d.objStms[num] = objStm
+	return objStm, nil
+}
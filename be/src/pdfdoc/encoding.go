@@ -0,0 +1,171 @@
+package pdfdoc
+
+import (
+	"strconv"
+	"strings"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// simpleEncoding maps the single-byte codes of a simple font to text. Codes
+// without a mapping are zero.
+type simpleEncoding [256]rune
+
+var (
+	winAnsiEncoding  = buildWinAnsi()
+	macRomanEncoding = buildMacRoman()
+	standardEncoding = buildStandard()
+)
+
+func latinEncoding() simpleEncoding {
+	var enc simpleEncoding
+	for c := 0x20; c < 0x7f; c++ {
+		enc[c] = rune(c)
+	}
+	return enc
+}
+
+func buildWinAnsi() simpleEncoding {
+	enc := latinEncoding()
+	for c := 0xa0; c <= 0xff; c++ {
+		enc[c] = rune(c)
+	}
+	high := []rune("€\x00‚ƒ„…†‡ˆ‰Š‹Œ\x00Ž\x00\x00‘’“”•–—˜™š›œ\x00žŸ")
+	for i, r := range high {
+		enc[0x80+i] = r
+	}
+	// WinAnsi shows a bullet for the remaining undefined codes
+	for _, c := range []int{0x81, 0x8d, 0x8f, 0x90, 0x9d} {
+		enc[c] = '•'
+	}
+	return enc
+}
+
+func buildMacRoman() simpleEncoding {
+	enc := latinEncoding()
+	high := []rune("ÄÅÇÉÑÖÜáàâäãåçéèêëíìîïñóòôöõúùûü†°¢£§•¶ß®©™´¨≠ÆØ∞±≤≥¥µ∂∑∏π∫ªºΩæø¿¡¬√ƒ≈∆«»… ÀÃÕŒœ–—“”‘’÷◊ÿŸ⁄€‹›ﬁﬂ‡·‚„‰ÂÊÁËÈÍÎÏÌÓÔÒÚÛÙıˆ˜¯˘˙˚¸˝˛ˇ")
+	for i, r := range high {
+		enc[0x80+i] = r
+	}
+	return enc
+}
+
+func buildStandard() simpleEncoding {
+	enc := latinEncoding()
+	enc['\''] = '’'
+	enc['`'] = '‘'
+	high := map[int]rune{
+		0xa1: '¡', 0xa2: '¢', 0xa3: '£', 0xa4: '⁄', 0xa5: '¥', 0xa6: 'ƒ', 0xa7: '§', 0xa8: '¤',
+		0xa9: '\'', 0xaa: '“', 0xab: '«', 0xac: '‹', 0xad: '›', 0xae: 'ﬁ', 0xaf: 'ﬂ', 0xb1: '–',
+		0xb2: '†', 0xb3: '‡', 0xb4: '·', 0xb6: '¶', 0xb7: '•', 0xb8: '‚', 0xb9: '„', 0xba: '”',
+		0xbb: '»', 0xbc: '…', 0xbd: '‰', 0xbf: '¿', 0xc1: '`', 0xc2: '´', 0xc3: 'ˆ', 0xc4: '˜',
+		0xc5: '¯', 0xc6: '˘', 0xc7: '˙', 0xc8: '¨', 0xca: '˚', 0xcb: '¸', 0xcd: '˝', 0xce: '˛',
+		0xcf: 'ˇ', 0xd0: '—', 0xe1: 'Æ', 0xe3: 'ª', 0xe8: 'Ł', 0xe9: 'Ø', 0xea: 'Œ', 0xeb: 'º',
+		0xf1: 'æ', 0xf5: 'ı', 0xf8: 'ł', 0xf9: 'ø', 0xfa: 'œ', 0xfb: 'ß',
+	}
+	for c, r := range high {
+		enc[c] = r
+	}
+	return enc
+}
+
+// namedEncoding returns the predefined encoding called name.
+func namedEncoding(name Name) (simpleEncoding, bool) {
+	switch name {
+	case "WinAnsiEncoding":
+		return winAnsiEncoding, true
+	case "MacRomanEncoding", "MacExpertEncoding":
+		return macRomanEncoding, true
+	case "StandardEncoding":
+		return standardEncoding, true
+	}
+	return simpleEncoding{}, false
+}
+
+// glyphNames maps the glyph names that appear in Differences arrays to text,
+// beyond single letters and the uniXXXX forms handled by glyphText.
+var glyphNames = map[string]string{
+	"space": " ", "exclam": "!", "quotedbl": "\"", "numbersign": "#", "dollar": "$",
+	"percent": "%", "ampersand": "&", "quotesingle": "'", "quoteright": "’", "parenleft": "(",
+	"parenright": ")", "asterisk": "*", "plus": "+", "comma": ",", "hyphen": "-",
+	"period": ".", "slash": "/", "zero": "0", "one": "1", "two": "2", "three": "3",
+	"four": "4", "five": "5", "six": "6", "seven": "7", "eight": "8", "nine": "9",
+	"colon": ":", "semicolon": ";", "less": "<", "equal": "=", "greater": ">",
+	"question": "?", "at": "@", "bracketleft": "[", "backslash": "\\", "bracketright": "]",
+	"asciicircum": "^", "underscore": "_", "grave": "`", "quoteleft": "‘", "braceleft": "{",
+	"bar": "|", "braceright": "}", "asciitilde": "~", "exclamdown": "¡", "cent": "¢",
+	"sterling": "£", "yen": "¥", "florin": "ƒ", "section": "§", "currency": "¤",
+	"quotedblleft": "“", "quotedblright": "”", "quotesinglbase": "‚", "quotedblbase": "„",
+	"guillemotleft": "«", "guillemotright": "»", "guilsinglleft": "‹", "guilsinglright": "›",
+	"endash": "–", "emdash": "—", "dagger": "†", "daggerdbl": "‡", "periodcentered": "·",
+	"paragraph": "¶", "bullet": "•", "ellipsis": "…", "perthousand": "‰", "questiondown": "¿",
+	"fi": "fi", "fl": "fl", "ff": "ff", "ffi": "ffi", "ffl": "ffl", "AE": "Æ", "ae": "æ",
+	"OE": "Œ", "oe": "œ", "Oslash": "Ø", "oslash": "ø", "Lslash": "Ł", "lslash": "ł",
+	"germandbls": "ß", "dotlessi": "ı", "ordfeminine": "ª", "ordmasculine": "º",
+	"trademark": "™", "registered": "®", "copyright": "©", "degree": "°", "plusminus": "±",
+	"multiply": "×", "divide": "÷", "minus": "−", "mu": "µ", "logicalnot": "¬",
+	"brokenbar": "¦", "dieresis": "¨", "acute": "´", "cedilla": "¸", "macron": "¯",
+	"circumflex": "ˆ", "tilde": "˜", "breve": "˘", "dotaccent": "˙", "ring": "˚",
+	"hungarumlaut": "˝", "ogonek": "˛", "caron": "ˇ", "fraction": "⁄", "Euro": "€",
+	"onehalf": "½", "onequarter": "¼", "threequarters": "¾", "onesuperior": "¹",
+	"twosuperior": "²", "threesuperior": "³", "nbspace": " ", "sfthyphen": "­",
+	"Eth": "Ð", "eth": "ð", "Thorn": "Þ", "thorn": "þ", "Scaron": "Š", "scaron": "š",
+	"Zcaron": "Ž", "zcaron": "ž", "Ydieresis": "Ÿ", "arrowright": "→", "arrowleft": "←",
+	"lessequal": "≤", "greaterequal": "≥", "notequal": "≠", "infinity": "∞",
+	"summation": "∑", "product": "∏", "radical": "√", "integral": "∫", "partialdiff": "∂",
+	"approxequal": "≈", "Delta": "∆", "Omega": "Ω", "pi": "π", "lozenge": "◊",
+}
+
+// accentedGlyphs lists the suffixes of accented letter names such as
+// "eacute" with the combining mark they add.
+var accentedGlyphs = map[string]rune{
+	"acute": '́', "grave": '̀', "circumflex": '̂', "dieresis": '̈',
+	"tilde": '̃', "ring": '̊', "cedilla": '̧', "caron": '̌',
+}
+
+// glyphText returns the text a glyph name stands for, or "" when the name is
+// unknown.
+func glyphText(name Name) string {
+	s := string(name)
+	if i := strings.IndexByte(s, '.'); i > 0 {
+		// Variants such as "a.sc" or "one.oldstyle"
+		s = s[:i]
+	}
+
+	if text, ok := glyphNames[s]; ok {
+		return text
+	}
+	if len(s) == 1 {
+		return s
+	}
+
+	if strings.HasPrefix(s, "uni") && len(s) >= 7 && (len(s)-3)%4 == 0 {
+		var units []rune
+		for i := 3; i < len(s); i += 4 {
+			v, err := strconv.ParseUint(s[i:i+4], 16, 16)
+			if err != nil {
+				return ""
+			}
+			units = append(units, rune(v))
+		}
+		return string(units)
+	}
+	if strings.HasPrefix(s, "u") && len(s) >= 5 && len(s) <= 7 {
+		if v, err := strconv.ParseUint(s[1:], 16, 32); err == nil {
+			return string(rune(v))
+		}
+	}
+
+	for suffix, mark := range accentedGlyphs {
+		if len(s) == len(suffix)+1 && strings.HasSuffix(s, suffix) {
+			return composeAccent(rune(s[0]), mark)
+		}
+	}
+	return ""
+}
+
+// composeAccent combines a base letter with an accent into a single
+// precomposed letter where Unicode has one.
+func composeAccent(base, mark rune) string {
+	return norm.NFC.String(string([]rune{base, mark}))
+}
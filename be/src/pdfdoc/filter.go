@@ -0,0 +1,387 @@
+package pdfdoc
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"io"
+)
+
+var ErrUnsupportedFilter = errors.New("unsupported stream filter")
+
+// maxDecodedSize caps how much a single stream may expand to, which keeps
+// compression bombs from exhausting memory.
+const maxDecodedSize = 64 * 1024 * 1024
+
+// maxDocumentDecodedSize caps how much all the streams of a document may
+// expand to together, so a small file can't cost minutes of decoding.
+const maxDocumentDecodedSize = 256 * 1024 * 1024
+
+type decodedStream struct {
+	data []byte
+	err  error
+}
+
+// StreamData returns the decoded contents of a stream. Image filters such as
+// DCTDecode are not decoded and return ErrUnsupportedFilter. Streams are
+// decoded once per document, so the returned data is shared and must not be
+// modified.
+func (d *Document) StreamData(s *Stream) ([]byte, error) {
+	if s.Ref.Num <= 0 {
+		return d.decode(s)
+	}
+	if cached, ok := d.decoded[s.Ref.Num]; ok {
+		return cached.data, cached.err
+	}
+
+	data, err := d.decode(s)
+	d.decoded[s.Ref.Num] = decodedStream{data: data, err: err}
+	return data, err
+}
+
+func (d *Document) decode(s *Stream) ([]byte, error) {
+	if d.decodedSize > maxDocumentDecodedSize {
+		return nil, fmt.Errorf("%w: decoded streams exceed %d MB", ErrCorrupt, maxDocumentDecodedSize>>20)
+	}
+
+	data, err := d.decodeFilters(s)
+	d.decodedSize += len(data)
+	if d.decodedSize > maxDocumentDecodedSize {
+		return nil, fmt.Errorf("%w: decoded streams exceed %d MB", ErrCorrupt, maxDocumentDecodedSize>>20)
+	}
+	return data, err
+}
+
+func (d *Document) decodeFilters(s *Stream) ([]byte, error) {
+	data := s.Raw
+
+	filters, params := d.filters(s.Dict)
+	for i, filter := range filters {
+		var err error
+		switch filter {
+		case "FlateDecode", "Fl":
+			data, err = inflate(data)
+			if err == nil {
+				data, err = d.unpredict(data, params[i])
+			}
+		case "LZWDecode", "LZW":
+			earlyChange := int64(1)
+			if v, ok := d.Int(params[i]["EarlyChange"]); ok {
+				earlyChange = v
+			}
+			data, err = lzwDecode(data, earlyChange == 1)
+			if err == nil {
+				data, err = d.unpredict(data, params[i])
+			}
+		case "ASCIIHexDecode", "AHx":
+			data, err = asciiHexDecode(data)
+		case "ASCII85Decode", "A85":
+			data, err = ascii85Decode(data)
+		case "RunLengthDecode", "RL":
+			data, err = runLengthDecode(data)
+		default:
+			return nil, fmt.Errorf("%w: %s", ErrUnsupportedFilter, filter)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", filter, err)
+		}
+	}
+
+	return data, nil
+}
+
+func (d *Document) filters(dict Dict) ([]Name, []Dict) {
+	var filters []Name
+	var params []Dict
+
+	switch f := d.Resolve(dict["Filter"]).(type) {
+	case Name:
+		filters = []Name{f}
+		params = []Dict{d.Dict(dict["DecodeParms"])}
+	case Array:
+		parms := d.Array(dict["DecodeParms"])
+		for i, item := range f {
+			filters = append(filters, d.Name(item))
+			if i < len(parms) {
+				params = append(params, d.Dict(parms[i]))
+			} else {
+				params = append(params, nil)
+			}
+		}
+	}
+
+	return filters, params
+}
+
+// inflate decompresses zlib data. Writers sometimes omit the zlib header or
+// cut the stream short, so raw deflate is tried as well and whatever was
+// decoded before an error is kept.
+func inflate(data []byte) ([]byte, error) {
+	var r io.ReadCloser
+	r, err := zlib.NewReader(bytes.NewReader(data))
+	if err != nil {
+		r = flate.NewReader(bytes.NewReader(data))
+	}
+	defer r.Close()
+
+	out, err := io.ReadAll(io.LimitReader(r, maxDecodedSize+1))
+	if len(out) > maxDecodedSize {
+		return nil, errors.New("decoded stream is too large")
+	}
+	if err != nil && len(out) == 0 {
+		return nil, err
+	}
+	return out, nil
+}
+
+// unpredict reverses the TIFF or PNG predictor named in params.
+func (d *Document) unpredict(data []byte, params Dict) ([]byte, error) {
+	predictor, _ := d.Int(params["Predictor"])
+	if predictor <= 1 {
+		return data, nil
+	}
+
+	colors, bpc, columns := int64(1), int64(8), int64(1)
+	if v, ok := d.Int(params["Colors"]); ok && v > 0 {
+		colors = v
+	}
+	if v, ok := d.Int(params["BitsPerComponent"]); ok && v > 0 {
+		bpc = v
+	}
+	if v, ok := d.Int(params["Columns"]); ok && v > 0 {
+		columns = v
+	}
+	if colors > 64 || bpc > 16 || columns > 1<<20 {
+		return nil, errors.New("invalid predictor parameters")
+	}
+
+	bpp := int((colors*bpc + 7) / 8)
+	rowSize := int((colors*bpc*columns + 7) / 8)
+
+	if predictor == 2 {
+		if bpc != 8 {
+			return data, nil
+		}
+		for row := 0; row+rowSize <= len(data); row += rowSize {
+			for i := bpp; i < rowSize; i++ {
+				data[row+i] += data[row+i-bpp]
+			}
+		}
+		return data, nil
+	}
+
+	out := make([]byte, 0, len(data))
+	prev := make([]byte, rowSize)
+	for pos := 0; pos < len(data); pos += rowSize + 1 {
+		end := pos + 1 + rowSize
+		if end > len(data) {
+			end = len(data)
+		}
+		kind := data[pos]
+		row := append([]byte(nil), data[pos+1:end]...)
+		row = append(row, make([]byte, rowSize-len(row))...)
+
+		for i := range row {
+			var left, up, upLeft byte
+			if i >= bpp {
+				left = row[i-bpp]
+				upLeft = prev[i-bpp]
+			}
+			up = prev[i]
+
+			switch kind {
+			case 1:
+				row[i] += left
+			case 2:
+				row[i] += up
+			case 3:
+				row[i] += byte((int(left) + int(up)) / 2)
+			case 4:
+				row[i] += paeth(left, up, upLeft)
+			}
+		}
+
+		out = append(out, row...)
+		prev = row
+	}
+	return out, nil
+}
+
+func paeth(a, b, c byte) byte {
+	p := int(a) + int(b) - int(c)
+	pa, pb, pc := abs(p-int(a)), abs(p-int(b)), abs(p-int(c))
+	switch {
+	case pa <= pb && pa <= pc:
+		return a
+	case pb <= pc:
+		return b
+	}
+	return c
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+func asciiHexDecode(data []byte) ([]byte, error) {
+	if end := bytes.IndexByte(data, '>'); end >= 0 {
+		data = data[:end]
+	}
+	l := newLexer(append(append([]byte(nil), data...), '>'), 0)
+	s, err := l.hexString()
+	return []byte(s), err
+}
+
+func ascii85Decode(data []byte) ([]byte, error) {
+	if end := bytes.Index(data, []byte("~>")); end >= 0 {
+		data = data[:end]
+	}
+	data = bytes.TrimPrefix(bytes.TrimSpace(data), []byte("<~"))
+
+	var out []byte
+	var group [5]byte
+	n := 0
+	for _, c := range data {
+		if isSpace(c) {
+			continue
+		}
+		if c == 'z' && n == 0 {
+			out = append(out, 0, 0, 0, 0)
+			continue
+		}
+		if c < '!' || c > 'u' {
+			return nil, fmt.Errorf("invalid character %q", c)
+		}
+		group[n] = c - '!'
+		n++
+		if n == 5 {
+			out = appendBase85(out, group, 4)
+			n = 0
+		}
+	}
+	if n > 0 {
+		for i := n; i < 5; i++ {
+			group[i] = 'u' - '!'
+		}
+		out = appendBase85(out, group, n-1)
+	}
+	return out, nil
+}
+
+func appendBase85(out []byte, group [5]byte, n int) []byte {
+	var v uint32
+	for _, g := range group {
+		v = v*85 + uint32(g)
+	}
+	word := []byte{byte(v >> 24), byte(v >> 16), byte(v >> 8), byte(v)}
+	return append(out, word[:n]...)
+}
+
+func runLengthDecode(data []byte) ([]byte, error) {
+	var out []byte
+	for i := 0; i < len(data); {
+		n := int(data[i])
+		i++
+		switch {
+		case n == 128:
+			return out, nil
+		case n < 128:
+			end := i + n + 1
+			if end > len(data) {
+				end = len(data)
+			}
+			out = append(out, data[i:end]...)
+			i = end
+		default:
+			if i >= len(data) {
+				return out, nil
+			}
+			out = append(out, bytes.Repeat(data[i:i+1], 257-n)...)
+			i++
+		}
+		if len(out) > maxDecodedSize {
+			return nil, errors.New("decoded stream is too large")
+		}
+	}
+	return out, nil
+}
+
+// lzwDecode implements the LZW variant used by PDF, which unlike
+// compress/lzw may switch code widths one code early.
+func lzwDecode(data []byte, earlyChange bool) ([]byte, error) {
+	const (
+		clearCode = 256
+		eodCode   = 257
+	)
+
+	var out []byte
+	table := make([][]byte, 258, 4096)
+	reset := func() {
+		table = table[:258]
+		for i := 0; i < 256; i++ {
+			table[i] = []byte{byte(i)}
+		}
+	}
+	reset()
+
+	width := 9
+	var bits uint32
+	nbits := 0
+	var prev []byte
+
+	early := 0
+	if earlyChange {
+		early = 1
+	}
+
+	for _, b := range data {
+		bits = bits<<8 | uint32(b)
+		nbits += 8
+
+		for nbits >= width {
+			code := int(bits>>(nbits-width)) & (1<<width - 1)
+			nbits -= width
+
+			switch {
+			case code == clearCode:
+				reset()
+				width = 9
+				prev = nil
+				continue
+			case code == eodCode:
+				return out, nil
+			}
+
+			var entry []byte
+			switch {
+			case code < len(table):
+				entry = table[code]
+			case code == len(table) && prev != nil:
+				entry = append(append([]byte(nil), prev...), prev[0])
+			default:
+				return nil, fmt.Errorf("invalid LZW code %d", code)
+			}
+
+			out = append(out, entry...)
+			if len(out) > maxDecodedSize {
+				return nil, errors.New("decoded stream is too large")
+			}
+
+			if prev != nil && len(table) < 4096 {
+				table = append(table, append(append([]byte(nil), prev...), entry[0]))
+			}
+			prev = entry
+
+			if len(table)+early >= 1<<width && width < 12 {
+				width++
+			}
+		}
+	}
+
+	return out, nil
+}
@@ -0,0 +1,222 @@
+package pdfdoc
+
+import "strings"
+
+// font decodes the strings shown with a font into text and glyph widths.
+type font struct {
+	composite bool
+	toUnicode *toUnicodeCMap
+	encoding  simpleEncoding
+	// differences overrides encoding for codes whose glyph names the font
+	// lists explicitly.
+	differences map[int]string
+
+	// Widths are in text space units, already scaled from glyph space.
+	firstChar    int
+	widths       []float64
+	cidWidths    map[uint32]float64
+	defaultWidth float64
+}
+
+// glyph is one character code shown by a text operator.
+type glyph struct {
+	text  string
+	code  String
+	width float64
+	// space marks the single-byte code 32, which word spacing applies to.
+	space bool
+}
+
+// loadFont reads the font dictionary fontObj. Fonts that can't be read still
+// decode as Latin-1 so their text is not silently lost.
+func (d *Document) loadFont(fontObj Object) *font {
+	dict := d.Dict(fontObj)
+	f := &font{encoding: winAnsiEncoding, defaultWidth: 0.5}
+	if dict == nil {
+		return f
+	}
+
+	if stream := d.Stream(dict["ToUnicode"]); stream != nil {
+		if data, err := d.StreamData(stream); err == nil {
+			f.toUnicode = parseToUnicode(data)
+		}
+	}
+
+	if d.Name(dict["Subtype"]) == "Type0" {
+		f.composite = true
+		d.loadCIDWidths(f, dict)
+		return f
+	}
+
+	d.loadEncoding(f, dict)
+	d.loadSimpleWidths(f, dict)
+	return f
+}
+
+func (d *Document) loadEncoding(f *font, dict Dict) {
+	switch enc := d.Resolve(dict["Encoding"]).(type) {
+	case Name:
+		if named, ok := namedEncoding(enc); ok {
+			f.encoding = named
+		}
+	case Dict:
+		if named, ok := namedEncoding(d.Name(enc["BaseEncoding"])); ok {
+			f.encoding = named
+		}
+
+		code := 0
+		for _, item := range d.Array(enc["Differences"]) {
+			switch v := d.Resolve(item).(type) {
+			case int64:
+				code = int(v)
+			case Name:
+				if code >= 0 && code < 256 {
+					if f.differences == nil {
+						f.differences = make(map[int]string)
+					}
+					f.differences[code] = glyphText(v)
+				}
+				code++
+			}
+		}
+	}
+}
+
+func (d *Document) loadSimpleWidths(f *font, dict Dict) {
+	scale := 0.001
+	if d.Name(dict["Subtype"]) == "Type3" {
+		// Type 3 glyph widths are in the font's own glyph space
+		if matrix := d.Array(dict["FontMatrix"]); len(matrix) == 6 {
+			if v, ok := d.Number(matrix[0]); ok {
+				scale = v
+			}
+		}
+	}
+
+	if first, ok := d.Int(dict["FirstChar"]); ok {
+		f.firstChar = int(first)
+	}
+	for _, item := range d.Array(dict["Widths"]) {
+		w, _ := d.Number(item)
+		f.widths = append(f.widths, w*scale)
+	}
+
+	descriptor := d.Dict(dict["FontDescriptor"])
+	if missing, ok := d.Number(descriptor["MissingWidth"]); ok && missing > 0 {
+		f.defaultWidth = missing * scale
+	}
+	if len(f.widths) == 0 && strings.HasPrefix(string(d.Name(dict["BaseFont"])), "Courier") {
+		// The standard monospaced font needs no widths to be spaced right
+		f.defaultWidth = 0.6
+	}
+}
+
+func (d *Document) loadCIDWidths(f *font, dict Dict) {
+	f.defaultWidth = 1
+	descendants := d.Array(dict["DescendantFonts"])
+	if len(descendants) == 0 {
+		return
+	}
+	cidFont := d.Dict(descendants[0])
+
+	if dw, ok := d.Number(cidFont["DW"]); ok {
+		f.defaultWidth = dw / 1000
+	}
+
+	// W holds "c [w1 w2 ...]" and "cFirst cLast w" entries
+	w := d.Array(cidFont["W"])
+	f.cidWidths = make(map[uint32]float64)
+	for i := 0; i < len(w); {
+		first, ok := d.Int(w[i])
+		if !ok || i+1 >= len(w) {
+			return
+		}
+
+		if list, isList := d.Resolve(w[i+1]).(Array); isList {
+			for j, item := range list {
+				if width, ok := d.Number(item); ok && j < maxCMapRange {
+					f.cidWidths[uint32(first)+uint32(j)] = width / 1000
+				}
+			}
+			i += 2
+			continue
+		}
+
+		if i+2 >= len(w) {
+			return
+		}
+		last, ok1 := d.Int(w[i+1])
+		width, ok2 := d.Number(w[i+2])
+		if !ok1 || !ok2 || last < first || last-first >= maxCMapRange {
+			return
+		}
+		for cid := first; cid <= last; cid++ {
+			f.cidWidths[uint32(cid)] = width / 1000
+		}
+		i += 3
+	}
+}
+
+// decode splits a shown string into glyphs.
+func (f *font) decode(s String) []glyph {
+	glyphs := make([]glyph, 0, len(s))
+
+	for i := 0; i < len(s); {
+		size := 1
+		if f.composite {
+			size = 2
+		}
+		if f.composite && f.toUnicode != nil {
+			size = f.toUnicode.codeLength(s[i:], size)
+		}
+		if i+size > len(s) {
+			size = len(s) - i
+		}
+
+		raw := s[i : i+size]
+		code := codeValue(raw)
+		i += size
+
+		glyphs = append(glyphs, glyph{
+			text:  f.text(code),
+			code:  raw,
+			width: f.width(code),
+			space: size == 1 && code == 32,
+		})
+	}
+	return glyphs
+}
+
+func (f *font) text(code uint32) string {
+	if f.toUnicode != nil {
+		if text, ok := f.toUnicode.lookup(code); ok {
+			return text
+		}
+	}
+	if f.composite {
+		// Without a ToUnicode map CIDs carry no meaning of their own
+		return ""
+	}
+
+	if text, ok := f.differences[int(code)]; ok {
+		return text
+	}
+	if code < 256 && f.encoding[code] != 0 {
+		return string(f.encoding[code])
+	}
+	return ""
+}
+
+func (f *font) width(code uint32) float64 {
+	if f.composite {
+		if w, ok := f.cidWidths[code]; ok {
+			return w
+		}
+		return f.defaultWidth
+	}
+
+	if i := int(code) - f.firstChar; i >= 0 && i < len(f.widths) && f.widths[i] > 0 {
+		return f.widths[i]
+	}
+	return f.defaultWidth
+}
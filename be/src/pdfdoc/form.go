@@ -0,0 +1,236 @@
+package pdfdoc
+
+import "strings"
+
+// Kinds of interactive form fields.
+const (
+	FieldText      = "text"
+	FieldCheckbox  = "checkbox"
+	FieldRadio     = "radio"
+	FieldChoice    = "choice"
+	FieldSignature = "signature"
+)
+
+// FormField is a terminal field of the document's interactive form.
+type FormField struct {
+	// Name is the fully qualified name, the partial names of the field and
+	// its ancestors joined with dots.
+	Name  string
+	Type  string
+	Value string
+	// Page is the 1-based page of the field's first widget, or 0 when it
+	// has none on a page of this document.
+	Page int
+}
+
+const (
+	// maxFormFields and maxFieldDepth stop walks of absurd or looping field
+	// trees.
+	maxFormFields = 5000
+	maxFieldDepth = 32
+
+	fieldFlagRadio      = 1 << 15
+	fieldFlagPushbutton = 1 << 16
+)
+
+// FormFields returns the fields of the document's AcroForm in tree order.
+// Push buttons are left out since they hold no value.
+func (d *Document) FormFields() []FormField {
+	return d.walkFields().fields
+}
+
+// walkFields walks the field tree of the document's AcroForm.
+func (d *Document) walkFields() *fieldWalker {
+	w := &fieldWalker{
+		doc:         d,
+		pageNumbers: make(map[Ref]int),
+		widgetPages: make(map[Ref]int),
+		visited:     make(map[Ref]bool),
+	}
+
+	form := d.Dict(d.Catalog()["AcroForm"])
+	if form == nil {
+		return w
+	}
+
+	if pages, err := d.Pages(); err == nil {
+		for _, page := range pages {
+			w.pageNumbers[page.Ref] = page.Number
+			for _, annot := range d.Array(page.Dict["Annots"]) {
+				if ref, ok := annot.(Ref); ok {
+					w.widgetPages[ref] = page.Number
+				}
+			}
+		}
+	}
+
+	for _, field := range d.Array(form["Fields"]) {
+		w.walk(field, fieldAttributes{}, 0)
+	}
+	return w
+}
+
+// fieldAttributes are the entries a field inherits from its ancestors.
+type fieldAttributes struct {
+	name  string
+	kind  Name
+	value Object
+	flags int64
+}
+
+type fieldWalker struct {
+	doc         *Document
+	pageNumbers map[Ref]int
+	widgetPages map[Ref]int
+	visited     map[Ref]bool
+	fields      []FormField
+	// signatures are the values of the signed signature fields.
+	signatures []signatureValue
+}
+
+type signatureValue struct {
+	field FormField
+	dict  Dict
+}
+
+func (w *fieldWalker) walk(obj Object, inherited fieldAttributes, depth int) {
+	if depth > maxFieldDepth || len(w.fields) >= maxFormFields {
+		return
+	}
+	if ref, ok := obj.(Ref); ok {
+		if w.visited[ref] {
+			return
+		}
+		w.visited[ref] = true
+	}
+
+	field := w.doc.Dict(obj)
+	if field == nil {
+		return
+	}
+
+	attrs := inherited
+	if partial := w.doc.Text(field["T"]); partial != "" {
+		if attrs.name != "" {
+			attrs.name += "."
+		}
+		attrs.name += partial
+	}
+	if kind := w.doc.Name(field["FT"]); kind != "" {
+		attrs.kind = kind
+	}
+	if value, ok := field["V"]; ok {
+		attrs.value = value
+	}
+	if flags, ok := w.doc.Int(field["Ff"]); ok {
+		attrs.flags = flags
+	}
+
+	// Kids with a partial name are fields of their own, the rest are the
+	// widgets that show this one
+	var widgets []Object
+	hasChildFields := false
+	for _, kid := range w.doc.Array(field["Kids"]) {
+		if _, named := w.doc.Dict(kid)["T"]; named {
+			hasChildFields = true
+			w.walk(kid, attrs, depth+1)
+		} else {
+			widgets = append(widgets, kid)
+		}
+	}
+	if hasChildFields {
+		return
+	}
+	if len(widgets) == 0 {
+		// A field merged with its only widget
+		widgets = []Object{obj}
+	}
+
+	kind := fieldKind(attrs.kind, attrs.flags)
+	if kind == "" {
+		return
+	}
+
+	formField := FormField{
+		Name:  attrs.name,
+		Type:  kind,
+		Value: w.fieldValue(kind, attrs.value),
+		Page:  w.widgetPage(widgets),
+	}
+	w.fields = append(w.fields, formField)
+
+	if kind == FieldSignature {
+		if value := w.doc.Dict(attrs.value); value != nil {
+			w.signatures = append(w.signatures, signatureValue{field: formField, dict: value})
+		}
+	}
+}
+
+func fieldKind(kind Name, flags int64) string {
+	switch kind {
+	case "Tx":
+		return FieldText
+	case "Ch":
+		return FieldChoice
+	case "Sig":
+		return FieldSignature
+	case "Btn":
+		if flags&fieldFlagPushbutton != 0 {
+			return ""
+		}
+		if flags&fieldFlagRadio != 0 {
+			return FieldRadio
+		}
+		return FieldCheckbox
+	}
+	return ""
+}
+
+// fieldValue renders a field's value as text. Buttons hold the name of the
+// selected state, which is Off when nothing is selected. Signatures are
+// reported as signed or not, their contents aren't text.
+func (w *fieldWalker) fieldValue(kind string, value Object) string {
+	value = w.doc.Resolve(value)
+	if value == nil {
+		return ""
+	}
+
+	switch kind {
+	case FieldCheckbox, FieldRadio:
+		return string(w.doc.Name(value))
+	case FieldSignature:
+		if w.doc.Dict(value) != nil {
+			return "signed"
+		}
+		return ""
+	}
+
+	if array, ok := value.(Array); ok {
+		values := make([]string, 0, len(array))
+		for _, item := range array {
+			if text := w.doc.Text(item); text != "" {
+				values = append(values, text)
+			}
+		}
+		return strings.Join(values, ", ")
+	}
+	return w.doc.Text(value)
+}
+
+// widgetPage finds the page of the first widget that is on one, from the
+// widget's /P entry or else from the page annotations.
+func (w *fieldWalker) widgetPage(widgets []Object) int {
+	for _, widget := range widgets {
+		if ref, ok := widget.(Ref); ok {
+			if page, ok := w.widgetPages[ref]; ok {
+				return page
+			}
+		}
+		if pageRef, ok := w.doc.Dict(widget)["P"].(Ref); ok {
+			if page, ok := w.pageNumbers[pageRef]; ok {
+				return page
+			}
+		}
+	}
+	return 0
+}
@@ -0,0 +1,414 @@
+package pdfdoc
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+var errUnexpectedEOF = errors.New("unexpected end of data")
+
+// maxNesting bounds how deep arrays and dictionaries may nest, so crafted
+// files can't exhaust the stack.
+const maxNesting = 256
+
+type lexer struct {
+	data  []byte
+	pos   int
+	depth int
+}
+
+func newLexer(data []byte, pos int) *lexer {
+	return &lexer{data: data, pos: pos}
+}
+
+func isSpace(c byte) bool {
+	switch c {
+	case 0, '\t', '\n', '\f', '\r', ' ':
+		return true
+	}
+	return false
+}
+
+func isDelimiter(c byte) bool {
+	switch c {
+	case '(', ')', '<', '>', '[', ']', '{', '}', '/', '%':
+		return true
+	}
+	return false
+}
+
+func isRegular(c byte) bool {
+	return !isSpace(c) && !isDelimiter(c)
+}
+
+// skipSpace moves past whitespace and comments.
+func (l *lexer) skipSpace() {
+	for l.pos < len(l.data) {
+		c := l.data[l.pos]
+		if isSpace(c) {
+			l.pos++
+			continue
+		}
+		if c == '%' {
+			for l.pos < len(l.data) && l.data[l.pos] != '\n' && l.data[l.pos] != '\r' {
+				l.pos++
+			}
+			continue
+		}
+		return
+	}
+}
+
+// token reads the next token. Numbers, strings and names come back as their
+// object types, everything else (including "[", "<<" and bare words) as a
+// Keyword.
+func (l *lexer) token() (Object, error) {
+	l.skipSpace()
+	if l.pos >= len(l.data) {
+		return nil, errUnexpectedEOF
+	}
+
+	c := l.data[l.pos]
+	switch {
+	case c == '(':
+		l.pos++
+		return l.literalString()
+	case c == '<':
+		if l.pos+1 < len(l.data) && l.data[l.pos+1] == '<' {
+			l.pos += 2
+			return Keyword("<<"), nil
+		}
+		l.pos++
+		return l.hexString()
+	case c == '>':
+		if l.pos+1 < len(l.data) && l.data[l.pos+1] == '>' {
+			l.pos += 2
+			return Keyword(">>"), nil
+		}
+		l.pos++
+		return nil, fmt.Errorf("unexpected '>' at offset %d", l.pos-1)
+	case c == '[' || c == ']' || c == '{' || c == '}':
+		l.pos++
+		return Keyword(c), nil
+	case c == '/':
+		l.pos++
+		return l.name(), nil
+	case c == ')':
+		l.pos++
+		return nil, fmt.Errorf("unexpected ')' at offset %d", l.pos-1)
+	}
+
+	start := l.pos
+	for l.pos < len(l.data) && isRegular(l.data[l.pos]) {
+		l.pos++
+	}
+	word := l.data[start:l.pos]
+
+	if number, ok := parseNumber(word); ok {
+		return number, nil
+	}
+	return Keyword(word), nil
+}
+
+func parseNumber(word []byte) (Object, bool) {
+	if len(word) == 0 {
+		return nil, false
+	}
+
+	real := false
+	for i, c := range word {
+		switch {
+		case c >= '0' && c <= '9':
+		case c == '.':
+			real = true
+		case (c == '+' || c == '-') && i == 0:
+		default:
+			return nil, false
+		}
+	}
+
+	if !real {
+		if n, err := strconv.ParseInt(string(word), 10, 64); err == nil {
+			return n, true
+		}
+	}
+
+	f, err := strconv.ParseFloat(string(word), 64)
+	if err != nil {
+		// Lone signs and dots show up in sloppy files and read as zero
+		return float64(0), word[len(word)-1] == '.' || word[len(word)-1] == '-' || word[len(word)-1] == '+'
+	}
+	return f, true
+}
+
+func (l *lexer) name() Name {
+	var buf []byte
+	for l.pos < len(l.data) && isRegular(l.data[l.pos]) {
+		c := l.data[l.pos]
+		if c == '#' && l.pos+2 < len(l.data) {
+			if v, ok := unhex(l.data[l.pos+1], l.data[l.pos+2]); ok {
+				buf = append(buf, v)
+				l.pos += 3
+				continue
+			}
+		}
+		buf = append(buf, c)
+		l.pos++
+	}
+	return Name(buf)
+}
+
+func (l *lexer) literalString() (String, error) {
+	var buf []byte
+	depth := 1
+
+	for l.pos < len(l.data) {
+		c := l.data[l.pos]
+		l.pos++
+
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return String(buf), nil
+			}
+		case '\r':
+			// EOL inside a string is always read as a single newline
+			if l.pos < len(l.data) && l.data[l.pos] == '\n' {
+				l.pos++
+			}
+			c = '\n'
+		case '\\':
+			if l.pos >= len(l.data) {
+				return nil, errUnexpectedEOF
+			}
+			c = l.data[l.pos]
+			l.pos++
+
+			switch c {
+			case 'n':
+				c = '\n'
+			case 'r':
+				c = '\r'
+			case 't':
+				c = '\t'
+			case 'b':
+				c = '\b'
+			case 'f':
+				c = '\f'
+			case '\r':
+				if l.pos < len(l.data) && l.data[l.pos] == '\n' {
+					l.pos++
+				}
+				continue
+			case '\n':
+				continue
+			default:
+				if c >= '0' && c <= '7' {
+					v := int(c - '0')
+					for i := 0; i < 2 && l.pos < len(l.data) && l.data[l.pos] >= '0' && l.data[l.pos] <= '7'; i++ {
+						v = v*8 + int(l.data[l.pos]-'0')
+						l.pos++
+					}
+					c = byte(v)
+				}
+			}
+		}
+		buf = append(buf, c)
+	}
+
+	return nil, errUnexpectedEOF
+}
+
+func (l *lexer) hexString() (String, error) {
+	var buf []byte
+	var high byte
+	odd := false
+
+	for l.pos < len(l.data) {
+		c := l.data[l.pos]
+		l.pos++
+
+		if c == '>' {
+			if odd {
+				buf = append(buf, high<<4)
+			}
+			return String(buf), nil
+		}
+		if isSpace(c) {
+			continue
+		}
+
+		v, ok := hexDigit(c)
+		if !ok {
+			return nil, fmt.Errorf("invalid character %q in hex string at offset %d", c, l.pos-1)
+		}
+		if odd {
+			buf = append(buf, high<<4|v)
+		} else {
+			high = v
+		}
+		odd = !odd
+	}
+
+	return nil, errUnexpectedEOF
+}
+
+func hexDigit(c byte) (byte, bool) {
+	switch {
+	case c >= '0' && c <= '9':
+		return c - '0', true
+	case c >= 'a' && c <= 'f':
+		return c - 'a' + 10, true
+	case c >= 'A' && c <= 'F':
+		return c - 'A' + 10, true
+	}
+	return 0, false
+}
+
+func unhex(a, b byte) (byte, bool) {
+	high, ok1 := hexDigit(a)
+	low, ok2 := hexDigit(b)
+	return high<<4 | low, ok1 && ok2
+}
+
+// object reads a complete object, turning "n g R" into a Ref. Keywords
+// other than true, false and null are returned as is for the caller to
+// interpret.
+func (l *lexer) object() (Object, error) {
+	tok, err := l.token()
+	if err != nil {
+		return nil, err
+	}
+
+	switch t := tok.(type) {
+	case Keyword:
+		switch t {
+		case "[":
+			return l.array()
+		case "<<":
+			return l.dict()
+		case "null":
+			return nil, nil
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		}
+		return t, nil
+	case int64:
+		if ref, ok := l.ref(t); ok {
+			return ref, nil
+		}
+	}
+
+	return tok, nil
+}
+
+// ref looks ahead for "gen R" after an integer, leaving the position
+// untouched when the integer isn't the start of a reference.
+func (l *lexer) ref(num int64) (Ref, bool) {
+	save := l.pos
+
+	gen, err := l.token()
+	if g, ok := gen.(int64); err == nil && ok && g >= 0 {
+		r, err := l.token()
+		if err == nil && r == Keyword("R") && num >= 0 {
+			return Ref{Num: int(num), Gen: int(g)}, true
+		}
+	}
+
+	l.pos = save
+	return Ref{}, false
+}
+
+func (l *lexer) enter() error {
+	l.depth++
+	if l.depth > maxNesting {
+		return fmt.Errorf("objects nested too deeply at offset %d", l.pos)
+	}
+	return nil
+}
+
+func (l *lexer) array() (Array, error) {
+	if err := l.enter(); err != nil {
+		return nil, err
+	}
+	defer func() { l.depth-- }()
+
+	array := Array{}
+	for {
+		obj, err := l.object()
+		if err != nil {
+			return nil, err
+		}
+		if obj == Keyword("]") {
+			return array, nil
+		}
+		if obj == Keyword(">>") || obj == Keyword("endobj") {
+			return nil, fmt.Errorf("unterminated array at offset %d", l.pos)
+		}
+		array = append(array, obj)
+	}
+}
+
+func (l *lexer) dict() (Dict, error) {
+	if err := l.enter(); err != nil {
+		return nil, err
+	}
+	defer func() { l.depth-- }()
+
+	dict := Dict{}
+	for {
+		key, err := l.object()
+		if err != nil {
+			return nil, err
+		}
+		if key == Keyword(">>") {
+			return dict, nil
+		}
+
+		name, ok := key.(Name)
+		if !ok {
+			return nil, fmt.Errorf("dictionary key is not a name at offset %d", l.pos)
+		}
+
+		value, err := l.object()
+		if err != nil {
+			return nil, err
+		}
+		if value == Keyword(">>") {
+			// A key without a value; some writers do this, treat it as null
+			return dict, nil
+		}
+		if _, isKeyword := value.(Keyword); isKeyword {
+			return nil, fmt.Errorf("invalid value for /%s at offset %d", name, l.pos)
+		}
+		if value != nil {
+			dict[name] = value
+		}
+	}
+}
+
+// skipEOL moves past a single end-of-line marker.
+func (l *lexer) skipEOL() {
+	if l.pos < len(l.data) && l.data[l.pos] == '\r' {
+		l.pos++
+	}
+	if l.pos < len(l.data) && l.data[l.pos] == '\n' {
+		l.pos++
+	}
+}
+
+// hasKeywordAt reports whether data at pos, after whitespace, starts with
+// keyword.
+func hasKeywordAt(data []byte, pos int, keyword string) bool {
+	for pos < len(data) && isSpace(data[pos]) {
+		pos++
+	}
+	return bytes.HasPrefix(data[pos:], []byte(keyword))
+}
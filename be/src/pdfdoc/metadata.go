@@ -0,0 +1,269 @@
+package pdfdoc
+
+import (
+	"bytes"
+	"encoding/xml"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+type Metadata struct {
+	Title        string
+	Author       string
+	Subject      string
+	Keywords     string
+	Creator      string
+	Producer     string
+	CreationDate *time.Time
+	ModDate      *time.Time
+}
+
+// Metadata reads the document information dictionary, filling in anything it
+// lacks from the catalog's XMP packet.
+func (d *Document) Metadata() Metadata {
+	info := d.Dict(d.Trailer["Info"])
+
+	meta := Metadata{
+		Title:    strings.TrimSpace(d.Text(info["Title"])),
+		Author:   strings.TrimSpace(d.Text(info["Author"])),
+		Subject:  strings.TrimSpace(d.Text(info["Subject"])),
+		Keywords: strings.TrimSpace(d.Text(info["Keywords"])),
+		Creator:  strings.TrimSpace(d.Text(info["Creator"])),
+		Producer: strings.TrimSpace(d.Text(info["Producer"])),
+	}
+	if date, ok := ParseDate(d.Text(info["CreationDate"])); ok {
+		meta.CreationDate = &date
+	}
+	if date, ok := ParseDate(d.Text(info["ModDate"])); ok {
+		meta.ModDate = &date
+	}
+
+	if stream := d.Stream(d.Catalog()["Metadata"]); stream != nil {
+		if data, err := d.StreamData(stream); err == nil {
+			meta.merge(parseXMP(data))
+		}
+	}
+
+	return meta
+}
+
+func (m *Metadata) merge(other Metadata) {
+	fill := func(dst *string, src string) {
+		if *dst == "" {
+			*dst = src
+		}
+	}
+	fill(&m.Title, other.Title)
+	fill(&m.Author, other.Author)
+	fill(&m.Subject, other.Subject)
+	fill(&m.Keywords, other.Keywords)
+	fill(&m.Creator, other.Creator)
+	fill(&m.Producer, other.Producer)
+	if m.CreationDate == nil {
+		m.CreationDate = other.CreationDate
+	}
+	if m.ModDate == nil {
+		m.ModDate = other.ModDate
+	}
+}
+
+const (
+	nsDC  = "http://purl.org/dc/elements/1.1/"
+	nsXMP = "http://ns.adobe.com/xap/1.0/"
+	nsPDF = "http://ns.adobe.com/pdf/1.3/"
+)
+
+// parseXMP pulls the fields we care about out of an XMP packet. Values may be
+// element text, rdf:li items or attributes on rdf:Description.
+func parseXMP(data []byte) Metadata {
+	var meta Metadata
+	values := make(map[xml.Name][]string)
+
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	decoder.Strict = false
+
+	var stack []xml.Name
+	var text strings.Builder
+	for {
+		tok, err := decoder.Token()
+		if err != nil {
+			break
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			for _, attr := range t.Attr {
+				values[attr.Name] = append(values[attr.Name], attr.Value)
+			}
+			stack = append(stack, t.Name)
+			text.Reset()
+		case xml.CharData:
+			text.Write(t)
+		case xml.EndElement:
+			if len(stack) == 0 {
+				continue
+			}
+			stack = stack[:len(stack)-1]
+
+			value := strings.TrimSpace(text.String())
+			text.Reset()
+			if value == "" {
+				continue
+			}
+
+			// rdf:li values belong to the property wrapping the container
+			name := t.Name
+			if name.Local == "li" && len(stack) >= 2 {
+				name = stack[len(stack)-2]
+			}
+			values[name] = append(values[name], value)
+		}
+	}
+
+	first := func(space, local string) string {
+		if v := values[xml.Name{Space: space, Local: local}]; len(v) > 0 {
+			return v[0]
+		}
+		return ""
+	}
+
+	meta.Title = first(nsDC, "title")
+	meta.Author = strings.Join(values[xml.Name{Space: nsDC, Local: "creator"}], ", ")
+	meta.Subject = first(nsDC, "description")
+	meta.Keywords = first(nsPDF, "Keywords")
+	meta.Creator = first(nsXMP, "CreatorTool")
+	meta.Producer = first(nsPDF, "Producer")
+	if date, ok := parseISODate(first(nsXMP, "CreateDate")); ok {
+		meta.CreationDate = &date
+	}
+	if date, ok := parseISODate(first(nsXMP, "ModifyDate")); ok {
+		meta.ModDate = &date
+	}
+
+	return meta
+}
+
+func parseISODate(s string) (time.Time, bool) {
+	for _, layout := range []string{time.RFC3339Nano, "2006-01-02T15:04:05", "2006-01-02T15:04Z07:00", "2006-01-02T15:04", "2006-01-02", "2006-01", "2006"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// ParseDate parses a PDF date string such as "D:20240131235959+07'00'".
+// Every field after the year is optional.
+func ParseDate(s string) (time.Time, bool) {
+	s = strings.TrimPrefix(strings.TrimSpace(s), "D:")
+
+	digits := 0
+	for digits < len(s) && s[digits] >= '0' && s[digits] <= '9' {
+		digits++
+	}
+	if digits < 4 {
+		return time.Time{}, false
+	}
+
+	// year, month, day, hour, minute, second
+	fields := []int{0, 1, 1, 0, 0, 0}
+	widths := []int{4, 2, 2, 2, 2, 2}
+	pos := 0
+	for i, width := range widths {
+		if pos+width > digits {
+			break
+		}
+		fields[i], _ = strconv.Atoi(s[pos : pos+width])
+		pos += width
+	}
+
+	loc := time.UTC
+	rest := s[digits:]
+	if len(rest) > 0 && (rest[0] == '+' || rest[0] == '-') {
+		offset := strings.NewReplacer("'", "", ":", "").Replace(rest[1:])
+		hours, minutes := 0, 0
+		if len(offset) >= 2 {
+			hours, _ = strconv.Atoi(offset[:2])
+		}
+		if len(offset) >= 4 {
+			minutes, _ = strconv.Atoi(offset[2:4])
+		}
+		seconds := hours*3600 + minutes*60
+		if rest[0] == '-' {
+			seconds = -seconds
+		}
+		loc = time.FixedZone("", seconds)
+	}
+
+	if fields[1] < 1 || fields[1] > 12 || fields[2] < 1 || fields[2] > 31 || fields[3] > 23 || fields[4] > 59 || fields[5] > 59 {
+		return time.Time{}, false
+	}
+
+	return time.Date(fields[0], time.Month(fields[1]), fields[2], fields[3], fields[4], fields[5], 0, loc), true
+}
+
+// pdfDocEncoding maps the bytes of PDFDocEncoding that differ from Latin-1.
+var pdfDocEncoding = map[byte]rune{
+	0x18: '˘', 0x19: 'ˇ', 0x1a: 'ˆ', 0x1b: '˙', 0x1c: '˝', 0x1d: '˛', 0x1e: '˚', 0x1f: '˜',
+	0x80: '•', 0x81: '†', 0x82: '‡', 0x83: '…', 0x84: '—', 0x85: '–', 0x86: 'ƒ', 0x87: '⁄',
+	0x88: '‹', 0x89: '›', 0x8a: '−', 0x8b: '‰', 0x8c: '„', 0x8d: '“', 0x8e: '”', 0x8f: '‘',
+	0x90: '’', 0x91: '‚', 0x92: '™', 0x93: 'ﬁ', 0x94: 'ﬂ', 0x95: 'Ł', 0x96: 'Œ', 0x97: 'Š',
+	0x98: 'Ÿ', 0x99: 'Ž', 0x9a: 'ı', 0x9b: 'ł', 0x9c: 'œ', 0x9d: 'š', 0x9e: 'ž', 0xa0: '€',
+}
+
+// DecodeText decodes a PDF text string, which is UTF-16BE or UTF-8 when it
+// starts with a byte order mark and PDFDocEncoding otherwise.
+func DecodeText(s String) string {
+	b := []byte(s)
+
+	if len(b) >= 2 && b[0] == 0xfe && b[1] == 0xff {
+		units := make([]uint16, 0, len(b)/2)
+		for i := 2; i+1 < len(b); i += 2 {
+			units = append(units, uint16(b[i])<<8|uint16(b[i+1]))
+		}
+		return stripControl(string(utf16.Decode(units)))
+	}
+
+	if len(b) >= 3 && b[0] == 0xef && b[1] == 0xbb && b[2] == 0xbf && utf8.Valid(b[3:]) {
+		return stripControl(string(b[3:]))
+	}
+
+	runes := make([]rune, 0, len(b))
+	for _, c := range b {
+		if r, ok := pdfDocEncoding[c]; ok {
+			runes = append(runes, r)
+		} else {
+			runes = append(runes, rune(c))
+		}
+	}
+	return stripControl(string(runes))
+}
+
+// stripControl drops control characters, including the language escape
+// sequences UTF-16 strings may contain.
+func stripControl(s string) string {
+	if i := strings.IndexRune(s, '\x1b'); i >= 0 {
+		var b strings.Builder
+		inEscape := false
+		for _, r := range s {
+			if r == '\x1b' {
+				inEscape = !inEscape
+				continue
+			}
+			if !inEscape {
+				b.WriteRune(r)
+			}
+		}
+		s = b.String()
+	}
+
+	return strings.Map(func(r rune) rune {
+		if r < 0x20 && r != '\n' && r != '\t' || r == 0x7f {
+			return -1
+		}
+		return r
+	}, s)
+}
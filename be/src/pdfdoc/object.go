@@ -0,0 +1,109 @@
+package pdfdoc
+
+// Object is a PDF object: nil, bool, int64, float64, String, Name, Array,
+// Dict, Ref or *Stream.
+type Object interface{}
+
+// Name is a PDF name without its leading slash, with #xx escapes decoded.
+type Name string
+
+// String holds the raw bytes of a literal or hexadecimal string. Use
+// DecodeText for strings meant to be read by people.
+type String []byte
+
+type Array []Object
+
+type Dict map[Name]Object
+
+// Ref is an indirect reference such as "12 0 R".
+type Ref struct {
+	Num int
+	Gen int
+}
+
+// Stream is a stream object. Raw holds the data as stored in the file, already
+// decrypted but still encoded.
+type Stream struct {
+	Dict Dict
+	Ref  Ref
+	Raw  []byte
+}
+
+// Keyword is a bare word that is not a number, boolean or null, such as
+// "obj", "R" or a content stream operator.
+type Keyword string
+
+// Resolve follows indirect references until it reaches a direct object.
+// References to missing objects resolve to nil.
+func (d *Document) Resolve(obj Object) Object {
+	for i := 0; i < 32; i++ {
+		ref, ok := obj.(Ref)
+		if !ok {
+			return obj
+		}
+		obj = d.object(ref)
+	}
+	return nil
+}
+
+// Dict resolves obj and returns it as a dictionary. A stream yields its
+// dictionary.
+func (d *Document) Dict(obj Object) Dict {
+	switch v := d.Resolve(obj).(type) {
+	case Dict:
+		return v
+	case *Stream:
+		return v.Dict
+	}
+	return nil
+}
+
+func (d *Document) Array(obj Object) Array {
+	array, _ := d.Resolve(obj).(Array)
+	return array
+}
+
+func (d *Document) Stream(obj Object) *Stream {
+	stream, _ := d.Resolve(obj).(*Stream)
+	return stream
+}
+
+func (d *Document) Name(obj Object) Name {
+	name, _ := d.Resolve(obj).(Name)
+	return name
+}
+
+func (d *Document) Int(obj Object) (int64, bool) {
+	switch v := d.Resolve(obj).(type) {
+	case int64:
+		return v, true
+	case float64:
+		return int64(v), true
+	}
+	return 0, false
+}
+
+func (d *Document) Number(obj Object) (float64, bool) {
+	switch v := d.Resolve(obj).(type) {
+	case int64:
+		return float64(v), true
+	case float64:
+		return v, true
+	}
+	return 0, false
+}
+
+func (d *Document) String(obj Object) (String, bool) {
+	s, ok := d.Resolve(obj).(String)
+	return s, ok
+}
+
+// Text resolves obj as a text string and decodes it, returning "" for
+// anything else.
+func (d *Document) Text(obj Object) string {
+	s, ok := d.String(obj)
+	if !ok {
+		return ""
+	}
+	return DecodeText(s)
+}
@@ -0,0 +1,177 @@
+package pdfdoc
+
+import "strings"
+
+// OutlineItem is one bookmark of the document outline.
+type OutlineItem struct {
+	Title string
+	// Level is 1 for top-level items and grows with each nesting step.
+	Level int
+	// Page is the 1-based page the item points to, or 0 when it points
+	// nowhere in this document.
+	Page int
+}
+
+const (
+	// maxOutlineItems and maxOutlineDepth stop outline walks on files with
+	// absurd or looping bookmark trees.
+	maxOutlineItems = 10000
+	maxOutlineDepth = 32
+	// maxNameTreeDepth bounds walks of name trees.
+	maxNameTreeDepth = 32
+)
+
+// Outline returns the document's bookmarks flattened in reading order, each
+// with its nesting level. Documents without an outline return none.
+func (d *Document) Outline() []OutlineItem {
+	root := d.Dict(d.Catalog()["Outlines"])
+	if root == nil {
+		return nil
+	}
+
+	pageNumbers := make(map[Ref]int)
+	if pages, err := d.Pages(); err == nil {
+		for _, page := range pages {
+			pageNumbers[page.Ref] = page.Number
+		}
+	}
+
+	w := &outlineWalker{doc: d, pageNumbers: pageNumbers, visited: make(map[Ref]bool)}
+	w.walk(root["First"], 1)
+	return w.items
+}
+
+type outlineWalker struct {
+	doc         *Document
+	pageNumbers map[Ref]int
+	visited     map[Ref]bool
+	items       []OutlineItem
+}
+
+func (w *outlineWalker) walk(first Object, level int) {
+	if level > maxOutlineDepth {
+		return
+	}
+
+	for next := first; next != nil; {
+		// Items are always indirect, so their refs catch loops
+		ref, ok := next.(Ref)
+		if !ok || w.visited[ref] || len(w.items) >= maxOutlineItems {
+			return
+		}
+		w.visited[ref] = true
+
+		item := w.doc.Dict(ref)
+		if item == nil {
+			return
+		}
+
+		w.items = append(w.items, OutlineItem{
+			Title: strings.Join(strings.Fields(w.doc.Text(item["Title"])), " "),
+			Level: level,
+			Page:  w.target(item),
+		})
+		w.walk(item["First"], level+1)
+
+		next = item["Next"]
+	}
+}
+
+// target resolves the page an outline item's destination or GoTo action
+// points to.
+func (w *outlineWalker) target(item Dict) int {
+	dest := item["Dest"]
+	if dest == nil {
+		action := w.doc.Dict(item["A"])
+		if action == nil || w.doc.Name(action["S"]) != "GoTo" {
+			return 0
+		}
+		dest = action["D"]
+	}
+	return w.destinationPage(dest)
+}
+
+// destinationPage reads an explicit destination array, or looks up a named
+// one first.
+func (w *outlineWalker) destinationPage(dest Object) int {
+	switch v := w.doc.Resolve(dest).(type) {
+	case Name:
+		dest = w.doc.namedDestination(string(v))
+	case String:
+		dest = w.doc.namedDestination(string(v))
+	}
+
+	// Named destinations may be wrapped in a dictionary
+	if dict := w.doc.Dict(dest); dict != nil {
+		dest = dict["D"]
+	}
+
+	array := w.doc.Array(dest)
+	if len(array) == 0 {
+		return 0
+	}
+
+	switch page := array[0].(type) {
+	case Ref:
+		return w.pageNumbers[page]
+	case int64:
+		// Only remote destinations should use page indexes, but some
+		// writers use them for local ones too
+		if page >= 0 && int(page) < len(w.pageNumbers) {
+			return int(page) + 1
+		}
+	}
+	return 0
+}
+
+// namedDestination looks name up in the catalog's Dests dictionary, which
+// older files use, and then in the Dests name tree.
+func (d *Document) namedDestination(name string) Object {
+	if dests := d.Dict(d.Catalog()["Dests"]); dests != nil {
+		if dest, ok := dests[Name(name)]; ok {
+			return dest
+		}
+	}
+
+	names := d.Dict(d.Catalog()["Names"])
+	if names == nil {
+		return nil
+	}
+	return d.lookupNameTree(names["Dests"], name, make(map[Ref]bool), 0)
+}
+
+func (d *Document) lookupNameTree(node Object, name string, visited map[Ref]bool, depth int) Object {
+	if ref, ok := node.(Ref); ok {
+		if visited[ref] {
+			return nil
+		}
+		visited[ref] = true
+	}
+
+	dict := d.Dict(node)
+	if dict == nil || depth > maxNameTreeDepth {
+		return nil
+	}
+
+	names := d.Array(dict["Names"])
+	for i := 0; i+1 < len(names); i += 2 {
+		if key, ok := d.String(names[i]); ok && string(key) == name {
+			return names[i+1]
+		}
+	}
+
+	for _, kid := range d.Array(dict["Kids"]) {
+		// Limits let most kids be skipped without loading their entries
+		if limits := d.Array(d.Dict(kid)["Limits"]); len(limits) == 2 {
+			low, _ := d.String(limits[0])
+			high, _ := d.String(limits[1])
+			if name < string(low) || name > string(high) {
+				continue
+			}
+		}
+		if dest := d.lookupNameTree(kid, name, visited, depth+1); dest != nil {
+			return dest
+		}
+	}
+	return nil
+}
@@ -0,0 +1,196 @@
+package pdfdoc
+
+import "fmt"
+
+// Page is a leaf of the page tree with its inheritable attributes already
+// resolved from its ancestors.
+type Page struct {
+	// Number is the 1-based position of the page in the document.
+	Number    int
+	Ref       Ref
+	Dict      Dict
+	Resources Dict
+	MediaBox  [4]float64
+	Rotate    int
+
+	doc *Document
+}
+
+// maxPages stops page tree walks on files that claim absurd page counts.
+const maxPages = 100000
+
+// Pages walks the page tree and returns every page in order.
+func (d *Document) Pages() ([]*Page, error) {
+	if d.pages != nil {
+		return d.pages, nil
+	}
+
+	root, ok := d.Catalog()["Pages"].(Ref)
+	if !ok {
+		return nil, fmt.Errorf("%w: page tree not found", ErrCorrupt)
+	}
+
+	pages := []*Page{}
+	visited := make(map[int]bool)
+	inherited := &Page{MediaBox: [4]float64{0, 0, 612, 792}}
+
+	if err := d.walkPages(root, inherited, visited, &pages); err != nil {
+		return nil, err
+	}
+
+	d.pages = pages
+	return pages, nil
+}
+
+// NumPages returns the number of pages, or 0 when the page tree is broken.
+func (d *Document) NumPages() int {
+	pages, err := d.Pages()
+	if err != nil {
+		return 0
+	}
+	return len(pages)
+}
+
+func (d *Document) walkPages(ref Ref, inherited *Page, visited map[int]bool, pages *[]*Page) error {
+	if visited[ref.Num] {
+		return fmt.Errorf("%w: page tree contains a loop", ErrCorrupt)
+	}
+	visited[ref.Num] = true
+
+	node := d.Dict(ref)
+	if node == nil {
+		return fmt.Errorf("%w: page tree node %d is missing", ErrCorrupt, ref.Num)
+	}
+
+	attrs := *inherited
+	if resources := d.Dict(node["Resources"]); resources != nil {
+		attrs.Resources = resources
+	}
+	if box, ok := d.rectangle(node["MediaBox"]); ok {
+		attrs.MediaBox = box
+	}
+	if rotate, ok := d.Int(node["Rotate"]); ok {
+		attrs.Rotate = int(rotate)
+	}
+
+	kids, isTree := d.Resolve(node["Kids"]).(Array)
+	if node["Type"] == Name("Page") || !isTree {
+		if len(*pages) >= maxPages {
+			return fmt.Errorf("%w: too many pages", ErrCorrupt)
+		}
+
+		page := attrs
+		page.Number = len(*pages) + 1
+		page.Ref = ref
+		page.Dict = node
+		page.doc = d
+		*pages = append(*pages, &page)
+		return nil
+	}
+
+	for _, kid := range kids {
+		kidRef, ok := kid.(Ref)
+		if !ok {
+			return fmt.Errorf("%w: page tree node %d has an invalid kid", ErrCorrupt, ref.Num)
+		}
+		if err := d.walkPages(kidRef, &attrs, visited, pages); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d *Document) rectangle(obj Object) ([4]float64, bool) {
+	var box [4]float64
+	array := d.Array(obj)
+	if len(array) != 4 {
+		return box, false
+	}
+	for i := range box {
+		v, ok := d.Number(array[i])
+		if !ok {
+			return box, false
+		}
+		box[i] = v
+	}
+	return box, true
+}
+
+// Contents returns the page's content streams decoded and joined in order.
+func (p *Page) Contents() ([]byte, error) {
+	streams, err := p.contentStreams()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(streams) == 1 {
+		return p.decodeContent(streams[0])
+	}
+
+	var data []byte
+	for _, stream := range streams {
+		decoded, err := p.decodeContent(stream)
+		if err != nil {
+			return nil, err
+		}
+		// Streams may split tokens across boundaries only at whitespace
+		data = append(data, decoded...)
+		data = append(data, '\n')
+	}
+	return data, nil
+}
+
+func (p *Page) contentStreams() ([]*Stream, error) {
+	var streams []*Stream
+	switch contents := p.doc.Resolve(p.Dict["Contents"]).(type) {
+	case nil:
+		if p.Dict["Contents"] != nil {
+			return nil, fmt.Errorf("%w: content stream of page %d is missing", ErrCorrupt, p.Number)
+		}
+	case *Stream:
+		streams = []*Stream{contents}
+	case Array:
+		for _, item := range contents {
+			stream := p.doc.Stream(item)
+			if stream == nil {
+				return nil, fmt.Errorf("%w: content stream of page %d is missing", ErrCorrupt, p.Number)
+			}
+			streams = append(streams, stream)
+		}
+	}
+	return streams, nil
+}
+
+func (p *Page) decodeContent(stream *Stream) ([]byte, error) {
+	decoded, err := p.doc.StreamData(stream)
+	if err != nil {
+		return nil, fmt.Errorf("%w: content stream of page %d can't be decoded: %v", ErrCorrupt, p.Number, err)
+	}
+	return decoded, nil
+}
+
+// Check loads every page and decodes its content streams, reporting the
+// first one that is missing or can't be decoded. Streams shared between
+// pages are decoded once.
+func (d *Document) Check() error {
+	pages, err := d.Pages()
+	if err != nil {
+		return err
+	}
+	if len(pages) == 0 {
+		return fmt.Errorf("%w: document has no pages", ErrCorrupt)
+	}
+
+	for _, page := range pages {
+		streams, err := page.contentStreams()
+		if err != nil {
+			return err
+		}
+		for _, stream := range streams {
+			if _, err := page.decodeContent(stream); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
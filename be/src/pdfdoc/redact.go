@@ -0,0 +1,394 @@
+package pdfdoc
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"math"
+	"strings"
+)
+
+// Rect is an area of a page in default user space, from the bottom-left
+// corner.
+type Rect struct {
+	X0, Y0, X1, Y1 float64
+}
+
+// normalize orders the corners so X0 <= X1 and Y0 <= Y1.
+func (r Rect) normalize() Rect {
+	return Rect{
+		X0: math.Min(r.X0, r.X1), Y0: math.Min(r.Y0, r.Y1),
+		X1: math.Max(r.X0, r.X1), Y1: math.Max(r.Y0, r.Y1),
+	}
+}
+
+// overlaps reports whether r and o share any area; touching edges don't
+// count.
+func (r Rect) overlaps(o Rect) bool {
+	return r.X0 < o.X1 && o.X0 < r.X1 && r.Y0 < o.Y1 && o.Y0 < r.Y1
+}
+
+func (r Rect) contains(x, y float64) bool {
+	return x >= r.X0 && x <= r.X1 && y >= r.Y0 && y <= r.Y1
+}
+
+func (r Rect) union(o Rect) Rect {
+	return Rect{
+		X0: math.Min(r.X0, o.X0), Y0: math.Min(r.Y0, o.Y0),
+		X1: math.Max(r.X1, o.X1), Y1: math.Max(r.Y1, o.Y1),
+	}
+}
+
+var unitSquare = Rect{X0: 0, Y0: 0, X1: 1, Y1: 1}
+
+// transformRect returns the bounding box of r after m.
+func transformRect(r Rect, m matrix) Rect {
+	out := Rect{X0: math.Inf(1), Y0: math.Inf(1), X1: math.Inf(-1), Y1: math.Inf(-1)}
+	for _, p := range [4][2]float64{{r.X0, r.Y0}, {r.X1, r.Y0}, {r.X0, r.Y1}, {r.X1, r.Y1}} {
+		x := p[0]*m[0] + p[1]*m[2] + m[4]
+		y := p[0]*m[1] + p[1]*m[3] + m[5]
+		out = out.union(Rect{X0: x, Y0: y, X1: x, Y1: y})
+	}
+	return out
+}
+
+// formBBox returns the bounding box of a form XObject in its own space. A
+// form without one is treated as covering everything.
+func formBBox(d *Document, form *Stream) Rect {
+	if values := numbers(d.Array(form.Dict["BBox"])); len(values) == 4 {
+		return Rect{X0: values[0], Y0: values[1], X1: values[2], Y1: values[3]}.normalize()
+	}
+	return Rect{X0: -math.MaxFloat64, Y0: -math.MaxFloat64, X1: math.MaxFloat64, Y1: math.MaxFloat64}
+}
+
+// Span is a byte range of a line of text, tagged with what was found there.
+type Span struct {
+	Start, End int
+	Tag        string
+}
+
+// Found is text located on a page.
+type Found struct {
+	Tag  string
+	Area Rect
+}
+
+// FindText calls find with the text of each line of the page, as Line.Text
+// gives it, and returns where on the page the spans it reports are. Unlike
+// Lines, it fails on content it can't read to the end, as text it misses
+// can't be found.
+func (p *Page) FindText(find func(line string) []Span) ([]Found, error) {
+	data, err := p.Contents()
+	if err != nil {
+		return nil, err
+	}
+
+	e := &textExtractor{doc: p.doc, fonts: make(map[Ref]*font), forms: make(map[Ref]bool)}
+	if err := e.run(data, p.Resources, identity); err != nil {
+		return nil, err
+	}
+
+	var found []Found
+	for _, row := range glyphRows(e.glyphs) {
+		words, wordGlyphs := buildWords(row.glyphs)
+
+		// Where each glyph's text starts and ends in the line
+		var text strings.Builder
+		var glyphs []int
+		var starts, ends []int
+		for i := range words {
+			if i > 0 {
+				text.WriteByte(' ')
+			}
+			for _, g := range wordGlyphs[i] {
+				glyphs = append(glyphs, g)
+				starts = append(starts, text.Len())
+				text.WriteString(row.glyphs[g].text)
+				ends = append(ends, text.Len())
+			}
+		}
+
+		for _, span := range find(text.String()) {
+			var area *Rect
+			for i, g := range glyphs {
+				if starts[i] >= span.End || ends[i] <= span.Start {
+					continue
+				}
+				box := row.glyphs[g].box
+				if area == nil {
+					area = &box
+				} else {
+					*area = area.union(box)
+				}
+			}
+			if area != nil {
+				found = append(found, Found{Tag: span.Tag, Area: *area})
+			}
+		}
+	}
+	return found, nil
+}
+
+// RedactionResult counts what a redaction removed.
+type RedactionResult struct {
+	Glyphs int
+	// Images counts images, and forms that couldn't be read, that were
+	// dropped whole for overlapping a redacted area.
+	Images      int
+	Annotations int
+}
+
+// Redact returns a copy of the page with everything drawn in the given
+// areas removed from its content and the areas painted black. Text goes
+// glyph by glyph, so the rest of a line stays in place; images in the way
+// go whole. Annotations, which carry their own text, are all removed, as
+// by WithoutAnnotations. The original page is left untouched.
+func (p *Page) Redact(areas []Rect) (*Page, RedactionResult, error) {
+	data, err := p.Contents()
+	if err != nil {
+		return nil, RedactionResult{}, err
+	}
+
+	r := &redaction{level: &redactionLevel{}}
+	for _, area := range areas {
+		r.areas = append(r.areas, area.normalize())
+	}
+
+	e := &textExtractor{doc: p.doc, fonts: make(map[Ref]*font), forms: make(map[Ref]bool), redaction: r}
+	if err := e.run(data, p.Resources, identity); err != nil {
+		return nil, RedactionResult{}, err
+	}
+
+	// The original content runs in its own graphics state so the boxes are
+	// drawn in default user space whatever it leaves behind
+	content := []byte("q\n")
+	content = append(content, r.level.out...)
+	content = append(content, "Q\nq 0 g\n"...)
+	for _, area := range r.areas {
+		content = fmt.Appendf(content, "%s %s %s %s re f\n",
+			formatNumber(area.X0), formatNumber(area.Y0), formatNumber(area.X1-area.X0), formatNumber(area.Y1-area.Y0))
+	}
+	content = append(content, "Q\n"...)
+
+	redacted, annotations := p.WithoutAnnotations()
+	redacted.Dict["Contents"] = flateStream(Dict{}, content)
+	redacted.Resources = withXObjects(p.doc, p.Resources, r.level.xobjects)
+	r.result.Annotations = annotations
+	return redacted, r.result, nil
+}
+
+// WithoutAnnotations returns a copy of the page without its annotations and
+// the other page-level extras that may hold text, along with how many
+// annotations it had. Filled form fields and comments keep their text in
+// annotations, which FindText doesn't search, so a redacted document needs
+// them gone from every page, not only from the pages redacted. The original
+// page is left untouched.
+func (p *Page) WithoutAnnotations() (*Page, int) {
+	dict := make(Dict, len(p.Dict))
+	for key, value := range p.Dict {
+		switch key {
+		case "Annots", "Thumb", "PieceInfo", "Metadata", "B":
+			continue
+		}
+		dict[key] = value
+	}
+
+	stripped := &Page{
+		Number:    p.Number,
+		Ref:       p.Ref,
+		Dict:      dict,
+		Resources: p.Resources,
+		MediaBox:  p.MediaBox,
+		Rotate:    p.Rotate,
+		doc:       p.doc,
+	}
+	return stripped, len(p.doc.Array(p.Dict["Annots"]))
+}
+
+// redaction collects the rewritten content of a page being redacted.
+type redaction struct {
+	areas  []Rect
+	level  *redactionLevel
+	result RedactionResult
+}
+
+// redactionLevel is the content of the page or of one of its forms.
+type redactionLevel struct {
+	out []byte
+	// xobjects holds rewritten forms to add to the level's resources.
+	xobjects Dict
+}
+
+// covers reports whether a glyph lies in a redacted area, going by its
+// centre so neighbouring glyphs that merely touch the area stay.
+func (r *redaction) covers(box Rect) bool {
+	x, y := (box.X0+box.X1)/2, (box.Y0+box.Y1)/2
+	for _, area := range r.areas {
+		if area.contains(x, y) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *redaction) overlaps(box Rect) bool {
+	for _, area := range r.areas {
+		if area.overlaps(box) {
+			return true
+		}
+	}
+	return false
+}
+
+// emit writes an operator with its operands to the current level.
+func (r *redaction) emit(op Keyword, args []Object) {
+	out := r.level.out
+	if op == "BI" {
+		// The image's own bytes run through to its EI
+		out = append(out, "BI"...)
+		if len(args) == 1 {
+			if image, ok := args[0].(inlineImage); ok {
+				out = append(out, image...)
+			}
+		}
+		r.level.out = append(out, '\n')
+		return
+	}
+
+	for _, arg := range args {
+		out = appendObject(out, arg)
+		out = append(out, ' ')
+	}
+	out = append(out, op...)
+	r.level.out = append(out, '\n')
+}
+
+// redactForm redacts what a form draws. Forms that lose nothing are drawn
+// as before; others are replaced by a rewritten copy under a new name, so
+// other pages drawing the same form are unaffected.
+func (e *textExtractor) redactForm(resources Dict, name Name, form *Stream, data []byte, formResources Dict, ctm matrix) error {
+	r := e.redaction
+	parent, before := r.level, r.result
+
+	r.level = &redactionLevel{}
+	err := e.run(data, formResources, ctm)
+	level := r.level
+	r.level = parent
+	if err != nil {
+		return err
+	}
+
+	if r.result == before {
+		r.emit("Do", []Object{name})
+		return nil
+	}
+
+	dict := make(Dict, len(form.Dict))
+	for key, value := range form.Dict {
+		switch key {
+		case "Length", "Filter", "DecodeParms":
+			continue
+		}
+		dict[key] = value
+	}
+	dict["Resources"] = withXObjects(e.doc, formResources, level.xobjects)
+
+	// Named so it can't shadow anything the resources already hold
+	existing := e.doc.Dict(resources["XObject"])
+	var newName Name
+	for i := 1; ; i++ {
+		newName = Name(fmt.Sprintf("Redacted%d", i))
+		if _, taken := existing[newName]; taken {
+			continue
+		}
+		if _, taken := parent.xobjects[newName]; !taken {
+			break
+		}
+	}
+	if parent.xobjects == nil {
+		parent.xobjects = make(Dict)
+	}
+	parent.xobjects[newName] = flateStream(dict, level.out)
+
+	r.emit("Do", []Object{newName})
+	return nil
+}
+
+// withXObjects returns resources with the given XObjects added, leaving the
+// original dictionaries as they are.
+func withXObjects(d *Document, resources Dict, xobjects Dict) Dict {
+	if len(xobjects) == 0 {
+		return resources
+	}
+
+	copied := make(Dict, len(resources)+1)
+	for key, value := range resources {
+		copied[key] = value
+	}
+	merged := make(Dict)
+	for key, value := range d.Dict(resources["XObject"]) {
+		merged[key] = value
+	}
+	for key, value := range xobjects {
+		merged[key] = value
+	}
+	copied["XObject"] = merged
+	return copied
+}
+
+// appendCode adds a glyph's code to the string at the end of a TJ array.
+func appendCode(shown Array, code String) Array {
+	if n := len(shown); n > 0 {
+		if s, ok := shown[n-1].(String); ok {
+			shown[n-1] = append(s, code...)
+			return shown
+		}
+	}
+	return append(shown, append(String(nil), code...))
+}
+
+// appendAdjustment adds a TJ position adjustment, merging it with one just
+// before it.
+func appendAdjustment(shown Array, n float64) Array {
+	if count := len(shown); count > 0 {
+		if prev, ok := shown[count-1].(float64); ok {
+			shown[count-1] = prev + n
+			return shown
+		}
+	}
+	return append(shown, n)
+}
+
+// withoutAlternateText drops the replacement, alternate and expanded text
+// of an inline marked-content property list, which may repeat redacted
+// text. Named property lists are left as they are.
+func withoutAlternateText(properties Object) Object {
+	dict, ok := properties.(Dict)
+	if !ok {
+		return properties
+	}
+
+	copied := make(Dict, len(dict))
+	for key, value := range dict {
+		switch key {
+		case "ActualText", "Alt", "E":
+			continue
+		}
+		copied[key] = value
+	}
+	return copied
+}
+
+func flateStream(dict Dict, data []byte) *Stream {
+	var buf bytes.Buffer
+	zw := zlib.NewWriter(&buf)
+	zw.Write(data)
+	zw.Close()
+
+	dict["Filter"] = Name("FlateDecode")
+	return &Stream{Dict: dict, Raw: buf.Bytes()}
+}
+
+func formatNumber(n float64) string {
+	return string(appendObject(nil, n))
+}
@@ -0,0 +1,116 @@
+package pdfdoc
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"time"
+)
+
+var ErrInvalidByteRange = errors.New("invalid signature byte range")
+
+// Signature is the value of a signed signature field.
+type Signature struct {
+	FieldName string
+	// Page is the page of the field's widget, or 0 for invisible
+	// signatures without one on a page.
+	Page int
+	// SubFilter names the signature encoding, such as adbe.pkcs7.detached
+	// or ETSI.CAdES.detached.
+	SubFilter   string
+	Name        string
+	Reason      string
+	Location    string
+	ContactInfo string
+	// SigningTime is the time the signing software recorded, which isn't
+	// part of what is signed.
+	SigningTime *time.Time
+	// ByteRange holds offset and length pairs of the signed parts of the
+	// file. The gap between them holds the signature itself.
+	ByteRange []int64
+	// Contents is the signature, read from the gap in the file rather than
+	// the parsed object so that it is never decrypted.
+	Contents []byte
+	// CoversDocument reports whether the signed bytes run to the end of the
+	// file, so nothing was appended to it after signing.
+	CoversDocument bool
+	// Err tells why the byte range can't be checked, if it can't.
+	Err error
+}
+
+// Signatures returns the signatures of the document's signed signature
+// fields in field order.
+func (d *Document) Signatures() []Signature {
+	var signatures []Signature
+	for _, value := range d.walkFields().signatures {
+		sig := Signature{
+			FieldName:   value.field.Name,
+			Page:        value.field.Page,
+			SubFilter:   string(d.Name(value.dict["SubFilter"])),
+			Name:        d.Text(value.dict["Name"]),
+			Reason:      d.Text(value.dict["Reason"]),
+			Location:    d.Text(value.dict["Location"]),
+			ContactInfo: d.Text(value.dict["ContactInfo"]),
+		}
+		if date, ok := d.String(value.dict["M"]); ok {
+			if t, ok := ParseDate(string(date)); ok {
+				sig.SigningTime = &t
+			}
+		}
+		for _, n := range d.Array(value.dict["ByteRange"]) {
+			if n, ok := d.Int(n); ok {
+				sig.ByteRange = append(sig.ByteRange, n)
+			}
+		}
+
+		sig.Contents, sig.CoversDocument, sig.Err = d.signatureContents(sig.ByteRange)
+		signatures = append(signatures, sig)
+	}
+	return signatures
+}
+
+// signatureContents checks that a byte range signs the whole file apart
+// from one gap holding a hex string, and decodes that string.
+func (d *Document) signatureContents(byteRange []int64) ([]byte, bool, error) {
+	if len(byteRange) != 4 {
+		return nil, false, ErrInvalidByteRange
+	}
+	start1, len1, start2, len2 := byteRange[0], byteRange[1], byteRange[2], byteRange[3]
+	size := int64(len(d.data))
+	if start1 != 0 || len1 < 0 || len2 < 0 || start2 < len1+2 || start2 > size || len2 > size-start2 {
+		return nil, false, ErrInvalidByteRange
+	}
+
+	gap := d.data[len1:start2]
+	if gap[0] != '<' || gap[len(gap)-1] != '>' {
+		return nil, false, ErrInvalidByteRange
+	}
+	digits := bytes.Map(func(r rune) rune {
+		if r < 0x80 && isSpace(byte(r)) {
+			return -1
+		}
+		return r
+	}, gap[1:len(gap)-1])
+	contents := make([]byte, hex.DecodedLen(len(digits)))
+	if _, err := hex.Decode(contents, digits); err != nil {
+		return nil, false, ErrInvalidByteRange
+	}
+
+	// Writers commonly end the file with a newline the range leaves out
+	rest := bytes.TrimRight(d.data[start2+len2:], "\r\n\x00 \t\f")
+	return contents, len(rest) == 0, nil
+}
+
+// SignedContent returns the bytes a signature signs, the file without the
+// gap holding the signature.
+func (d *Document) SignedContent(sig Signature) ([]byte, error) {
+	if sig.Err != nil || len(sig.ByteRange) != 4 {
+		return nil, ErrInvalidByteRange
+	}
+
+	len1, start2, len2 := sig.ByteRange[1], sig.ByteRange[2], sig.ByteRange[3]
+	content := make([]byte, 0, len1+len2)
+	content = append(content, d.data[:len1]...)
+	content = append(content, d.data[start2:start2+len2]...)
+	return content, nil
+}
@@ -0,0 +1,137 @@
+package pdfdoc
+
+import (
+	"math"
+	"sort"
+)
+
+// Table is a grid of cells found in the positioned text of a page. Every row
+// has one entry per column, empty where the row has no cell.
+type Table struct {
+	Rows [][]string
+	// Top is the baseline of the first row and Bottom that of the last.
+	Top, Bottom float64
+}
+
+const (
+	// cellGap is the gap between words, in font sizes, that separates the
+	// cells of a row. Spaces between words are far narrower.
+	cellGap = 1.0
+	// rowGap is the largest distance between baselines, in font sizes, of
+	// consecutive rows of one table.
+	rowGap = 2.5
+	// maxCellWords tells tables from columns of running text, whose cells
+	// average more words than this.
+	maxCellWords = 6
+
+	minTableRows    = 2
+	minTableColumns = 2
+)
+
+// Tables detects the tables of the page from the positions of its words. It
+// returns what was found up to a broken content stream along with the error.
+func (p *Page) Tables() ([]Table, error) {
+	lines, err := p.Lines()
+	return FindTables(lines), err
+}
+
+// FindTables looks for runs of consecutive lines that split into cells at
+// wide gaps, and lines their cells up into columns. Lines must be ordered
+// top to bottom, as Page.Lines returns them.
+func FindTables(lines []Line) []Table {
+	var tables []Table
+	var block []Line
+	var rows [][]cell
+
+	flush := func() {
+		if table, ok := buildTable(block, rows); ok {
+			tables = append(tables, table)
+		}
+		block, rows = nil, nil
+	}
+
+	for _, line := range lines {
+		cells := splitCells(line)
+		if len(cells) < minTableColumns {
+			flush()
+			continue
+		}
+
+		if n := len(block); n > 0 && block[n-1].Y-line.Y > rowGap*math.Max(lineSize(block[n-1]), 1) {
+			flush()
+		}
+		block = append(block, line)
+		rows = append(rows, cells)
+	}
+	flush()
+
+	return tables
+}
+
+type cell struct {
+	text   string
+	x0, x1 float64
+	words  int
+}
+
+func splitCells(line Line) []cell {
+	var cells []cell
+	for _, word := range line.Words {
+		if n := len(cells); n > 0 && word.X0-cells[n-1].x1 <= cellGap*math.Max(word.Size, 1) {
+			last := &cells[n-1]
+			last.text += " " + word.Text
+			last.x1 = math.Max(last.x1, word.X1)
+			last.words++
+			continue
+		}
+		cells = append(cells, cell{text: word.Text, x0: word.X0, x1: word.X1, words: 1})
+	}
+	return cells
+}
+
+// buildTable derives the columns of a block from the horizontal extents of
+// its cells: cells that overlap share a column.
+func buildTable(lines []Line, rows [][]cell) (Table, bool) {
+	if len(rows) < minTableRows {
+		return Table{}, false
+	}
+
+	var spans [][2]float64
+	words := 0
+	for _, row := range rows {
+		for _, c := range row {
+			spans = append(spans, [2]float64{c.x0, c.x1})
+			words += c.words
+		}
+	}
+	if words > maxCellWords*len(spans) {
+		return Table{}, false
+	}
+
+	sort.Slice(spans, func(i, j int) bool { return spans[i][0] < spans[j][0] })
+	var columns [][2]float64
+	for _, span := range spans {
+		if n := len(columns); n > 0 && span[0] <= columns[n-1][1] {
+			columns[n-1][1] = math.Max(columns[n-1][1], span[1])
+			continue
+		}
+		columns = append(columns, span)
+	}
+	if len(columns) < minTableColumns {
+		return Table{}, false
+	}
+
+	table := Table{Top: lines[0].Y, Bottom: lines[len(lines)-1].Y}
+	for _, row := range rows {
+		values := make([]string, len(columns))
+		for _, c := range row {
+			i := sort.Search(len(columns), func(i int) bool { return columns[i][1] >= c.x0 })
+			if values[i] != "" {
+				values[i] += " "
+			}
+			values[i] += c.text
+		}
+		table.Rows = append(table.Rows, values)
+	}
+	return table, true
+}
@@ -0,0 +1,547 @@
+package pdfdoc
+
+import (
+	"errors"
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Word is a run of text on one baseline with no gap wide enough to be a
+// space. Coordinates are in default user space, from the bottom-left corner
+// of the page.
+type Word struct {
+	Text string
+	// X0 and X1 are the left and right edges, Y is the baseline.
+	X0, X1, Y float64
+	Size      float64
+}
+
+// Line is a row of words sharing a baseline, ordered left to right.
+type Line struct {
+	Words []Word
+	Y     float64
+}
+
+// Text joins the words of the line with single spaces.
+func (l Line) Text() string {
+	parts := make([]string, len(l.Words))
+	for i, word := range l.Words {
+		parts[i] = word.Text
+	}
+	return strings.Join(parts, " ")
+}
+
+const (
+	// maxGlyphs bounds how many glyphs are collected from a single page.
+	maxGlyphs = 1 << 20
+	// maxFormDepth bounds how deeply form XObjects may draw each other.
+	maxFormDepth = 8
+	// maxStateDepth bounds the q/Q graphics state stack.
+	maxStateDepth = 64
+)
+
+var errTooManyGlyphs = errors.New("too many glyphs")
+
+// Lines extracts the text of the page as lines ordered top to bottom. A
+// content stream that breaks partway yields the text read up to that point
+// along with the error.
+func (p *Page) Lines() ([]Line, error) {
+	data, err := p.Contents()
+	if err != nil {
+		return nil, err
+	}
+
+	e := &textExtractor{doc: p.doc, fonts: make(map[Ref]*font), forms: make(map[Ref]bool)}
+	err = e.run(data, p.Resources, identity)
+	if errors.Is(err, errTooManyGlyphs) {
+		err = nil
+	}
+	return buildLines(e.glyphs), err
+}
+
+// Text extracts the text of the page, one line of text per line of output.
+func (p *Page) Text() (string, error) {
+	lines, err := p.Lines()
+
+	var b strings.Builder
+	for i, line := range lines {
+		if i > 0 {
+			b.WriteByte('\n')
+			// Leave a blank line where a paragraph gap is
+			if prev := lines[i-1]; prev.Y-line.Y > 2*lineSize(prev) {
+				b.WriteByte('\n')
+			}
+		}
+		b.WriteString(line.Text())
+	}
+	return b.String(), err
+}
+
+func lineSize(line Line) float64 {
+	size := 0.0
+	for _, word := range line.Words {
+		size = math.Max(size, word.Size)
+	}
+	return size
+}
+
+// matrix is a PDF transformation matrix [a b c d e f].
+type matrix [6]float64
+
+var identity = matrix{1, 0, 0, 1, 0, 0}
+
+// multiply returns m × n, applying m first.
+func (m matrix) multiply(n matrix) matrix {
+	return matrix{
+		m[0]*n[0] + m[1]*n[2],
+		m[0]*n[1] + m[1]*n[3],
+		m[2]*n[0] + m[3]*n[2],
+		m[2]*n[1] + m[3]*n[3],
+		m[4]*n[0] + m[5]*n[2] + n[4],
+		m[4]*n[1] + m[5]*n[3] + n[5],
+	}
+}
+
+func translate(x, y float64) matrix {
+	return matrix{1, 0, 0, 1, x, y}
+}
+
+type textState struct {
+	font      *font
+	size      float64
+	charSpace float64
+	wordSpace float64
+	scale     float64
+	leading   float64
+	rise      float64
+}
+
+type graphicsState struct {
+	ctm  matrix
+	text textState
+}
+
+// positionedGlyph is a glyph placed on the page.
+type positionedGlyph struct {
+	text        string
+	x, y, width float64
+	size        float64
+	box         Rect
+}
+
+type textExtractor struct {
+	doc    *Document
+	fonts  map[Ref]*font
+	forms  map[Ref]bool
+	glyphs []positionedGlyph
+	depth  int
+	// redaction, when set, has run write a copy of the content without
+	// what lies in the redacted areas instead of collecting glyphs.
+	redaction *redaction
+}
+
+func (e *textExtractor) run(data []byte, resources Dict, ctm matrix) error {
+	state := graphicsState{ctm: ctm, text: textState{scale: 1}}
+	var stack []graphicsState
+	tm, tlm := identity, identity
+
+	nextLine := func(tx, ty float64) {
+		tlm = translate(tx, ty).multiply(tlm)
+		tm = tlm
+	}
+
+	return parseContent(data, func(op Keyword, args []Object) error {
+		nums := numbers(args)
+
+		switch op {
+		case "q":
+			if len(stack) < maxStateDepth {
+				stack = append(stack, state)
+			}
+		case "Q":
+			if len(stack) > 0 {
+				state = stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+			}
+		case "cm":
+			if len(nums) == 6 {
+				state.ctm = matrix(nums).multiply(state.ctm)
+			}
+		case "BT":
+			tm, tlm = identity, identity
+		case "Tf":
+			if len(args) == 2 {
+				if name, ok := args[0].(Name); ok {
+					state.text.font = e.font(resources, name)
+				}
+				if size, ok := number(args[1]); ok {
+					state.text.size = size
+				}
+			}
+		case "Tc", "Tw", "Tz", "TL", "Ts":
+			if len(nums) != 1 {
+				break
+			}
+			switch op {
+			case "Tc":
+				state.text.charSpace = nums[0]
+			case "Tw":
+				state.text.wordSpace = nums[0]
+			case "Tz":
+				state.text.scale = nums[0] / 100
+			case "TL":
+				state.text.leading = nums[0]
+			case "Ts":
+				state.text.rise = nums[0]
+			}
+		case "Td", "TD":
+			if len(nums) == 2 {
+				if op == "TD" {
+					state.text.leading = -nums[1]
+				}
+				nextLine(nums[0], nums[1])
+			}
+		case "Tm":
+			if len(nums) == 6 {
+				tlm = matrix(nums)
+				tm = tlm
+			}
+		case "T*":
+			nextLine(0, -state.text.leading)
+		case "Tj", "'", "\"":
+			if op == "\"" && len(args) == 3 {
+				state.text.wordSpace, _ = number(args[0])
+				state.text.charSpace, _ = number(args[1])
+			}
+			if op != "Tj" {
+				nextLine(0, -state.text.leading)
+			}
+
+			var shown Array
+			if len(args) > 0 {
+				if s, ok := args[len(args)-1].(String); ok {
+					if err := e.show(s, &state, &tm, &shown); err != nil {
+						return err
+					}
+				}
+			}
+			if r := e.redaction; r != nil {
+				// Rewritten as the operators they stand for, so only TJ
+				// has to carry the removed glyphs' gaps
+				if op == "\"" && len(args) == 3 {
+					r.emit("Tw", args[:1])
+					r.emit("Tc", args[1:2])
+				}
+				if op != "Tj" {
+					r.emit("T*", nil)
+				}
+				r.emit("TJ", []Object{shown})
+				return nil
+			}
+		case "TJ":
+			if len(args) != 1 {
+				break
+			}
+			var shown Array
+			items, _ := args[0].(Array)
+			for _, item := range items {
+				if s, ok := item.(String); ok {
+					if err := e.show(s, &state, &tm, &shown); err != nil {
+						return err
+					}
+				} else if n, ok := number(item); ok {
+					tx := -n / 1000 * state.text.size * state.text.scale
+					tm = translate(tx, 0).multiply(tm)
+					shown = appendAdjustment(shown, n)
+				}
+			}
+			if r := e.redaction; r != nil {
+				r.emit("TJ", []Object{shown})
+				return nil
+			}
+		case "Do":
+			if len(args) == 1 {
+				if name, ok := args[0].(Name); ok {
+					return e.drawXObject(resources, name, state.ctm)
+				}
+			}
+		case "BI":
+			if r := e.redaction; r != nil && r.overlaps(transformRect(unitSquare, state.ctm)) {
+				r.result.Images++
+				return nil
+			}
+		case "BDC", "DP":
+			if r := e.redaction; r != nil && len(args) == 2 {
+				r.emit(op, []Object{args[0], withoutAlternateText(args[1])})
+				return nil
+			}
+		}
+
+		if r := e.redaction; r != nil {
+			r.emit(op, args)
+		}
+		return nil
+	})
+}
+
+// show places the glyphs of s. When redacting, the glyphs that are kept
+// and the gaps of those that aren't are added to shown, as TJ operands.
+func (e *textExtractor) show(s String, state *graphicsState, tm *matrix, shown *Array) error {
+	ts := &state.text
+	f := ts.font
+	if f == nil {
+		f = e.doc.loadFont(nil)
+		ts.font = f
+	}
+
+	for _, g := range f.decode(s) {
+		trm := matrix{ts.size * ts.scale, 0, 0, ts.size, 0, ts.rise}.multiply(*tm).multiply(state.ctm)
+
+		tx := g.width*ts.size + ts.charSpace
+		if g.space {
+			tx += ts.wordSpace
+		}
+		*tm = translate(tx*ts.scale, 0).multiply(*tm)
+		end := tm.multiply(state.ctm)
+		box := glyphBox(trm, g.width)
+
+		if r := e.redaction; r != nil {
+			if !r.covers(box) {
+				*shown = appendCode(*shown, g.code)
+				continue
+			}
+			r.result.Glyphs++
+			// The gap keeps the text after the glyph where it was
+			if ts.size != 0 {
+				*shown = appendAdjustment(*shown, -tx*1000/ts.size)
+			}
+			continue
+		}
+
+		if g.text == "" {
+			continue
+		}
+		if len(e.glyphs) >= maxGlyphs {
+			return errTooManyGlyphs
+		}
+		e.glyphs = append(e.glyphs, positionedGlyph{
+			text:  g.text,
+			x:     trm[4],
+			y:     trm[5],
+			width: math.Hypot(end[4]-trm[4], end[5]-trm[5]),
+			size:  math.Hypot(trm[2], trm[3]),
+			box:   box,
+		})
+	}
+	return nil
+}
+
+// glyphBox returns the area a glyph of the given width covers, taking
+// ascent and descent as typical fractions of the font size.
+func glyphBox(trm matrix, width float64) Rect {
+	return transformRect(Rect{X0: 0, Y0: -0.2, X1: width, Y1: 0.9}, trm)
+}
+
+func (e *textExtractor) font(resources Dict, name Name) *font {
+	fontObj := e.doc.Dict(resources["Font"])[name]
+	ref, isRef := fontObj.(Ref)
+	if isRef {
+		if f, ok := e.fonts[ref]; ok {
+			return f
+		}
+	}
+
+	f := e.doc.loadFont(fontObj)
+	if isRef {
+		e.fonts[ref] = f
+	}
+	return f
+}
+
+// drawXObject extracts the text of a form XObject. Images have no text,
+// but when redacting those in the way are left out.
+func (e *textExtractor) drawXObject(resources Dict, name Name, ctm matrix) error {
+	r := e.redaction
+	obj := e.doc.Dict(resources["XObject"])[name]
+	ref, _ := obj.(Ref)
+	stream := e.doc.Stream(obj)
+	if stream == nil || e.doc.Name(stream.Dict["Subtype"]) != "Form" {
+		if r != nil {
+			if stream != nil && e.doc.Name(stream.Dict["Subtype"]) == "Image" && r.overlaps(transformRect(unitSquare, ctm)) {
+				r.result.Images++
+				return nil
+			}
+			r.emit("Do", []Object{name})
+		}
+		return nil
+	}
+
+	m := identity
+	if values := numbers(e.doc.Array(stream.Dict["Matrix"])); len(values) == 6 {
+		m = matrix(values)
+	}
+	ctm = m.multiply(ctm)
+
+	var data []byte
+	readable := e.depth < maxFormDepth && !e.forms[ref]
+	if readable {
+		var err error
+		data, err = e.doc.StreamData(stream)
+		readable = err == nil
+	}
+	if !readable {
+		if r != nil {
+			// What the form draws can't be checked, so it goes whole when
+			// it's in the way
+			if r.overlaps(transformRect(formBBox(e.doc, stream), ctm)) {
+				r.result.Images++
+				return nil
+			}
+			r.emit("Do", []Object{name})
+		}
+		return nil
+	}
+
+	formResources := e.doc.Dict(stream.Dict["Resources"])
+	if formResources == nil {
+		formResources = resources
+	}
+
+	e.depth++
+	e.forms[ref] = true
+	defer func() {
+		e.depth--
+		delete(e.forms, ref)
+	}()
+
+	if r != nil {
+		return e.redactForm(resources, name, stream, data, formResources, ctm)
+	}
+
+	err := e.run(data, formResources, ctm)
+	if errors.Is(err, errTooManyGlyphs) {
+		return err
+	}
+	// A broken form shouldn't hide the text of the rest of the page
+	return nil
+}
+
+func number(obj Object) (float64, bool) {
+	switch v := obj.(type) {
+	case int64:
+		return float64(v), true
+	case float64:
+		return v, true
+	}
+	return 0, false
+}
+
+// numbers returns args as numbers, or nil when any of them is not one.
+func numbers(args []Object) []float64 {
+	nums := make([]float64, len(args))
+	for i, arg := range args {
+		n, ok := number(arg)
+		if !ok {
+			return nil
+		}
+		nums[i] = n
+	}
+	return nums
+}
+
+// buildLines groups glyphs into lines by baseline and lines into words by
+// the gaps between glyphs.
+func buildLines(glyphs []positionedGlyph) []Line {
+	var lines []Line
+	for _, row := range glyphRows(glyphs) {
+		if words, _ := buildWords(row.glyphs); len(words) > 0 {
+			lines = append(lines, Line{Words: words, Y: row.y})
+		}
+	}
+	return lines
+}
+
+type glyphRow struct {
+	y      float64
+	glyphs []positionedGlyph
+}
+
+// glyphRows sorts glyphs into rows sharing a baseline, top to bottom, each
+// ordered left to right.
+func glyphRows(glyphs []positionedGlyph) []glyphRow {
+	sorted := make([]positionedGlyph, len(glyphs))
+	copy(sorted, glyphs)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].y > sorted[j].y })
+
+	var rows []glyphRow
+	for start := 0; start < len(sorted); {
+		baseline := sorted[start].y
+		tolerance := math.Max(sorted[start].size, 1) / 2
+
+		end := start + 1
+		for end < len(sorted) && baseline-sorted[end].y <= tolerance {
+			end++
+		}
+
+		row := sorted[start:end]
+		sort.SliceStable(row, func(i, j int) bool { return row[i].x < row[j].x })
+		rows = append(rows, glyphRow{y: baseline, glyphs: row})
+		start = end
+	}
+	return rows
+}
+
+// buildWords splits a row into words, also returning the indexes of the
+// glyphs that make up each word.
+func buildWords(row []positionedGlyph) ([]Word, [][]int) {
+	var words []Word
+	var wordGlyphs [][]int
+	var current *Word
+	var indexes []int
+	var text strings.Builder
+	var prev *positionedGlyph
+
+	flush := func() {
+		if current != nil && text.Len() > 0 {
+			current.Text = text.String()
+			words = append(words, *current)
+			wordGlyphs = append(wordGlyphs, indexes)
+		}
+		current = nil
+		indexes = nil
+		text.Reset()
+	}
+
+	for i := range row {
+		g := &row[i]
+
+		// Fake bold draws the same glyph again slightly offset
+		if prev != nil && g.text == prev.text && math.Abs(g.x-prev.x) < g.size*0.1 && math.Abs(g.y-prev.y) < g.size*0.1 {
+			continue
+		}
+
+		if strings.TrimFunc(g.text, unicode.IsSpace) == "" {
+			flush()
+			prev = g
+			continue
+		}
+
+		if current != nil && prev != nil && g.x-(prev.x+prev.width) > math.Max(g.size, prev.size)*0.2 {
+			flush()
+		}
+
+		if current == nil {
+			current = &Word{X0: g.x, Y: g.y}
+		}
+		text.WriteString(g.text)
+		indexes = append(indexes, i)
+		current.X1 = math.Max(current.X1, g.x+g.width)
+		current.Size = math.Max(current.Size, g.size)
+		prev = g
+	}
+	flush()
+
+	return words, wordGlyphs
+}
@@ -0,0 +1,307 @@
+package pdfdoc
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+)
+
+// ErrNoPages is returned when asked to write a document without pages.
+var ErrNoPages = errors.New("pdfdoc: no pages to write")
+
+// WritePages writes a new, unencrypted document made of the given pages in
+// order. Pages may come from several documents; everything they reference is
+// copied along, except for links to pages that aren't part of the new file.
+// Document-level parts such as the outline, forms and metadata are left out.
+func WritePages(out io.Writer, pages []*Page) error {
+	if len(pages) == 0 {
+		return ErrNoPages
+	}
+
+	w := &pageWriter{
+		out:  bufio.NewWriter(out),
+		refs: make(map[*Document]map[Ref]Ref),
+	}
+
+	// Catalog and page tree come first so page dictionaries can point at
+	// their parent before it's written
+	catalog := w.allocate()
+	tree := w.allocate()
+
+	// Every page gets its number up front, so links between selected pages
+	// survive and links to dropped ones can be told apart
+	kids := make(Array, len(pages))
+	for i, page := range pages {
+		ref := w.allocate()
+		if page.doc != nil {
+			w.mapped(page.doc)[page.Ref] = ref
+		}
+		kids[i] = ref
+	}
+
+	w.header()
+	w.writeObject(catalog, Dict{"Type": Name("Catalog"), "Pages": tree})
+	w.writeObject(tree, Dict{"Type": Name("Pages"), "Kids": kids, "Count": int64(len(pages))})
+	for i, page := range pages {
+		w.writeObject(kids[i].(Ref), w.pageDict(page, tree))
+	}
+
+	// Objects reached from the pages are written as they're discovered
+	for len(w.queue) > 0 && w.err == nil {
+		next := w.queue[0]
+		w.queue = w.queue[1:]
+
+		obj := next.obj
+		if stream, ok := obj.(*Stream); ok {
+			obj = &Stream{Dict: w.copy(next.doc, stream.Dict).(Dict), Raw: stream.Raw}
+		} else {
+			obj = w.copy(next.doc, obj)
+		}
+		w.writeObject(next.dst, obj)
+	}
+
+	w.trailer(catalog)
+	if w.err != nil {
+		return w.err
+	}
+	return w.out.Flush()
+}
+
+type pendingObject struct {
+	doc *Document
+	obj Object
+	dst Ref
+}
+
+type pageWriter struct {
+	out     *bufio.Writer
+	written int64
+	offsets []int64
+	// refs maps each source document's object numbers to the new ones.
+	refs  map[*Document]map[Ref]Ref
+	queue []pendingObject
+	// err is the first write error; nothing is written after it.
+	err error
+}
+
+func (w *pageWriter) allocate() Ref {
+	w.offsets = append(w.offsets, 0)
+	return Ref{Num: len(w.offsets)}
+}
+
+func (w *pageWriter) mapped(doc *Document) map[Ref]Ref {
+	refs, ok := w.refs[doc]
+	if !ok {
+		refs = make(map[Ref]Ref)
+		w.refs[doc] = refs
+	}
+	return refs
+}
+
+// pageDict builds a standalone page dictionary, with the attributes the
+// page inherited from the source tree set on the page itself.
+func (w *pageWriter) pageDict(page *Page, parent Ref) Dict {
+	dict := make(Dict, len(page.Dict)+4)
+	for key, value := range page.Dict {
+		dict[key] = value
+	}
+	dict["Resources"] = page.Resources
+	if page.Resources == nil {
+		dict["Resources"] = Dict{}
+	}
+	dict["MediaBox"] = Array{page.MediaBox[0], page.MediaBox[1], page.MediaBox[2], page.MediaBox[3]}
+	delete(dict, "Rotate")
+	if page.Rotate != 0 {
+		dict["Rotate"] = int64(page.Rotate)
+	}
+
+	copied := w.copy(page.doc, dict).(Dict)
+	copied["Type"] = Name("Page")
+	copied["Parent"] = parent
+	return copied
+}
+
+// copy returns obj with its references renumbered for the new file, queuing
+// the objects they point to.
+func (w *pageWriter) copy(doc *Document, obj Object) Object {
+	switch v := obj.(type) {
+	case Ref:
+		return w.copyRef(doc, v)
+	case Array:
+		copied := make(Array, len(v))
+		for i, item := range v {
+			copied[i] = w.copy(doc, item)
+		}
+		return copied
+	case Dict:
+		copied := make(Dict, len(v))
+		for key, value := range v {
+			// Parents lead back into the source page tree
+			if key == "Parent" {
+				continue
+			}
+			copied[key] = w.copy(doc, value)
+		}
+		return copied
+	case *Stream:
+		// Streams made in memory, such as rewritten page contents, become
+		// objects of their own as every stream must be indirect
+		dst := w.allocate()
+		w.queue = append(w.queue, pendingObject{doc: doc, obj: v, dst: dst})
+		return dst
+	}
+	return obj
+}
+
+func (w *pageWriter) copyRef(doc *Document, ref Ref) Object {
+	if doc == nil {
+		return nil
+	}
+	refs := w.mapped(doc)
+	if dst, ok := refs[ref]; ok {
+		return dst
+	}
+
+	// Pages that weren't picked and the source's own page tree and catalog
+	// stay behind
+	if dict := doc.Dict(ref); dict != nil {
+		switch doc.Name(dict["Type"]) {
+		case "Page", "Pages", "Catalog":
+			return nil
+		}
+	}
+	obj := doc.object(ref)
+	if obj == nil {
+		return nil
+	}
+
+	dst := w.allocate()
+	refs[ref] = dst
+	w.queue = append(w.queue, pendingObject{doc: doc, obj: obj, dst: dst})
+	return dst
+}
+
+func (w *pageWriter) write(s string) {
+	if w.err != nil {
+		return
+	}
+	n, err := w.out.WriteString(s)
+	w.written += int64(n)
+	w.err = err
+}
+
+func (w *pageWriter) writeBytes(b []byte) {
+	if w.err != nil {
+		return
+	}
+	n, err := w.out.Write(b)
+	w.written += int64(n)
+	w.err = err
+}
+
+func (w *pageWriter) header() {
+	// The binary comment tells transfer tools the file isn't plain text
+	w.write("%PDF-1.7\n%\xe2\xe3\xcf\xd3\n")
+}
+
+func (w *pageWriter) writeObject(ref Ref, obj Object) {
+	w.offsets[ref.Num-1] = w.written
+	w.write(fmt.Sprintf("%d 0 obj\n", ref.Num))
+
+	if stream, ok := obj.(*Stream); ok {
+		dict := make(Dict, len(stream.Dict))
+		for key, value := range stream.Dict {
+			dict[key] = value
+		}
+		dict["Length"] = int64(len(stream.Raw))
+		w.writeBytes(appendObject(nil, dict))
+		w.write("\nstream\n")
+		w.writeBytes(stream.Raw)
+		w.write("\nendstream")
+	} else {
+		w.writeBytes(appendObject(nil, obj))
+	}
+
+	w.write("\nendobj\n")
+}
+
+// appendObject appends the PDF syntax of obj to b.
+func appendObject(b []byte, obj Object) []byte {
+	switch v := obj.(type) {
+	case nil:
+		return append(b, "null"...)
+	case bool:
+		return strconv.AppendBool(b, v)
+	case int64:
+		return strconv.AppendInt(b, v, 10)
+	case float64:
+		return strconv.AppendFloat(b, v, 'f', -1, 64)
+	case String:
+		// Hex keeps binary strings safe without any escaping rules
+		return fmt.Appendf(b, "<%x>", []byte(v))
+	case Name:
+		return appendName(b, v)
+	case Keyword:
+		return append(b, v...)
+	case Ref:
+		return fmt.Appendf(b, "%d %d R", v.Num, v.Gen)
+	case Array:
+		b = append(b, '[')
+		for i, item := range v {
+			if i > 0 {
+				b = append(b, ' ')
+			}
+			b = appendObject(b, item)
+		}
+		return append(b, ']')
+	case Dict:
+		// Sorted so the same input always gives the same file
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, string(key))
+		}
+		sort.Strings(keys)
+
+		b = append(b, "<<"...)
+		for _, key := range keys {
+			b = appendName(b, Name(key))
+			b = append(b, ' ')
+			b = appendObject(b, v[Name(key)])
+		}
+		return append(b, ">>"...)
+	}
+	// Streams must be indirect, so one nested in another object can only
+	// come from a broken file and its data is dropped
+	return append(b, "null"...)
+}
+
+// appendName appends a name with its slash, escaping bytes that can't
+// appear in it literally.
+func appendName(b []byte, name Name) []byte {
+	b = append(b, '/')
+	for i := 0; i < len(name); i++ {
+		c := name[i]
+		if c < '!' || c > '~' || c == '#' || isDelimiter(c) {
+			b = fmt.Appendf(b, "#%02X", c)
+			continue
+		}
+		b = append(b, c)
+	}
+	return b
+}
+
+func (w *pageWriter) trailer(catalog Ref) {
+	start := w.written
+	w.write(fmt.Sprintf("xref\n0 %d\n", len(w.offsets)+1))
+	w.write("0000000000 65535 f \n")
+	for _, offset := range w.offsets {
+		w.write(fmt.Sprintf("%010d 00000 n \n", offset))
+	}
+
+	w.write("trailer\n")
+	w.writeBytes(appendObject(nil, Dict{"Size": int64(len(w.offsets) + 1), "Root": catalog}))
+	w.write(fmt.Sprintf("\nstartxref\n%d\n%%%%EOF\n", start))
+}
@@ -0,0 +1,319 @@
+package pdfdoc
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+)
+
+type xrefEntry struct {
+	offset int64
+	gen    int
+
+	// For objects stored in an object stream
+	compressed bool
+	stream     int
+	index      int
+}
+
+// loadXref reads the cross-reference sections starting at startxref and
+// following /Prev, newest first, so later updates win.
+func (d *Document) loadXref() error {
+	offset, err := d.startxref()
+	if err != nil {
+		return err
+	}
+
+	visited := make(map[int64]bool)
+	for offset >= 0 {
+		if visited[offset] {
+			return fmt.Errorf("%w: cross-reference sections form a loop", ErrCorrupt)
+		}
+		visited[offset] = true
+
+		if offset >= int64(len(d.data)) {
+			return fmt.Errorf("%w: cross-reference offset %d is past the end of the file", ErrCorrupt, offset)
+		}
+
+		var trailer Dict
+		if hasKeywordAt(d.data, int(offset), "xref") {
+			trailer, err = d.readXrefTable(int(offset))
+		} else {
+			trailer, err = d.readXrefStream(int(offset))
+		}
+		if err != nil {
+			return err
+		}
+
+		// Hybrid files keep newer entries in a stream referenced from the table
+		if stm, ok := trailer["XRefStm"].(int64); ok && !visited[stm] {
+			visited[stm] = true
+			if _, err := d.readXrefStream(int(stm)); err != nil {
+				return err
+			}
+		}
+
+		if d.Trailer == nil {
+			d.Trailer = trailer
+		} else {
+			for key, value := range trailer {
+				if _, ok := d.Trailer[key]; !ok {
+					d.Trailer[key] = value
+				}
+			}
+		}
+
+		prev, ok := trailer["Prev"].(int64)
+		if !ok {
+			break
+		}
+		offset = prev
+	}
+
+	return nil
+}
+
+func (d *Document) startxref() (int64, error) {
+	tail := d.data
+	if len(tail) > 4096 {
+		tail = tail[len(tail)-4096:]
+	}
+
+	i := bytes.LastIndex(tail, []byte("startxref"))
+	if i < 0 {
+		return 0, fmt.Errorf("%w: startxref not found", ErrCorrupt)
+	}
+
+	l := newLexer(tail, i+len("startxref"))
+	tok, err := l.token()
+	offset, ok := tok.(int64)
+	if err != nil || !ok || offset < 0 {
+		return 0, fmt.Errorf("%w: startxref does not point to a valid offset", ErrCorrupt)
+	}
+	return offset, nil
+}
+
+func (d *Document) setEntry(num int, entry xrefEntry) {
+	if _, ok := d.xref[num]; ok {
+		return
+	}
+	d.xref[num] = entry
+}
+
+func (d *Document) readXrefTable(offset int) (Dict, error) {
+	l := newLexer(d.data, offset)
+	l.token() // xref
+
+	for {
+		tok, err := l.token()
+		if err != nil {
+			return nil, fmt.Errorf("%w: cross-reference table at offset %d is incomplete", ErrCorrupt, offset)
+		}
+		if tok == Keyword("trailer") {
+			break
+		}
+
+		start, ok1 := tok.(int64)
+		countTok, _ := l.token()
+		count, ok2 := countTok.(int64)
+		if !ok1 || !ok2 || start < 0 || count < 0 || count > int64(len(d.data)/18) {
+			return nil, fmt.Errorf("%w: cross-reference table at offset %d is malformed", ErrCorrupt, offset)
+		}
+
+		for i := int64(0); i < count; i++ {
+			offTok, _ := l.token()
+			genTok, _ := l.token()
+			kind, _ := l.token()
+
+			objOffset, ok1 := offTok.(int64)
+			gen, ok2 := genTok.(int64)
+			if !ok1 || !ok2 || (kind != Keyword("n") && kind != Keyword("f")) {
+				return nil, fmt.Errorf("%w: cross-reference entry %d at offset %d is malformed", ErrCorrupt, start+i, offset)
+			}
+
+			num := int(start + i)
+			if kind == Keyword("f") {
+				d.setEntry(num, xrefEntry{offset: -1})
+				continue
+			}
+			d.setEntry(num, xrefEntry{offset: objOffset, gen: int(gen)})
+		}
+	}
+
+	trailer, err := l.object()
+	dict, ok := trailer.(Dict)
+	if err != nil || !ok {
+		return nil, fmt.Errorf("%w: trailer at offset %d is malformed", ErrCorrupt, offset)
+	}
+	return dict, nil
+}
+
+func (d *Document) readXrefStream(offset int) (Dict, error) {
+	_, obj, err := d.readIndirect(offset)
+	if err != nil {
+		return nil, fmt.Errorf("%w: cross-reference stream at offset %d: %v", ErrCorrupt, offset, err)
+	}
+
+	stream, ok := obj.(*Stream)
+	if !ok || stream.Dict["Type"] != Name("XRef") {
+		return nil, fmt.Errorf("%w: no cross-reference data at offset %d", ErrCorrupt, offset)
+	}
+
+	data, err := d.decode(stream)
+	if err != nil {
+		return nil, fmt.Errorf("%w: cross-reference stream at offset %d: %v", ErrCorrupt, offset, err)
+	}
+
+	var widths [3]int
+	w, _ := stream.Dict["W"].(Array)
+	if len(w) != 3 {
+		return nil, fmt.Errorf("%w: cross-reference stream at offset %d has invalid /W", ErrCorrupt, offset)
+	}
+	for i := range widths {
+		n, ok := w[i].(int64)
+		if !ok || n < 0 || n > 8 {
+			return nil, fmt.Errorf("%w: cross-reference stream at offset %d has invalid /W", ErrCorrupt, offset)
+		}
+		widths[i] = int(n)
+	}
+	rowSize := widths[0] + widths[1] + widths[2]
+	if rowSize == 0 {
+		return nil, fmt.Errorf("%w: cross-reference stream at offset %d has invalid /W", ErrCorrupt, offset)
+	}
+
+	index, _ := stream.Dict["Index"].(Array)
+	if index == nil {
+		size, _ := stream.Dict["Size"].(int64)
+		index = Array{int64(0), size}
+	}
+
+	pos := 0
+	for i := 0; i+1 < len(index); i += 2 {
+		start, ok1 := index[i].(int64)
+		count, ok2 := index[i+1].(int64)
+		if !ok1 || !ok2 || start < 0 || count < 0 {
+			return nil, fmt.Errorf("%w: cross-reference stream at offset %d has invalid /Index", ErrCorrupt, offset)
+		}
+
+		for j := int64(0); j < count; j++ {
+			if pos+rowSize > len(data) {
+				return nil, fmt.Errorf("%w: cross-reference stream at offset %d is truncated", ErrCorrupt, offset)
+			}
+			row := data[pos : pos+rowSize]
+			pos += rowSize
+
+			kind := int64(1)
+			if widths[0] > 0 {
+				kind = readField(row[:widths[0]])
+			}
+			f2 := readField(row[widths[0] : widths[0]+widths[1]])
+			f3 := readField(row[widths[0]+widths[1]:])
+
+			num := int(start + j)
+			switch kind {
+			case 0:
+				d.setEntry(num, xrefEntry{offset: -1})
+			case 1:
+				d.setEntry(num, xrefEntry{offset: f2, gen: int(f3)})
+			case 2:
+				d.setEntry(num, xrefEntry{compressed: true, stream: int(f2), index: int(f3)})
+			}
+		}
+	}
+
+	return stream.Dict, nil
+}
+
+func readField(b []byte) int64 {
+	var v int64
+	for _, c := range b {
+		v = v<<8 | int64(c)
+	}
+	return v
+}
+
+var objHeader = regexp.MustCompile(`(?m)(?:^|[\s%])(\d{1,10})[\x00\t\f ]+(\d{1,5})[\x00\t\n\f\r ]+obj\b`)
+
+// rebuildXref recovers the object table by scanning the whole file, which is
+// what viewers do when the cross-reference data is damaged.
+func (d *Document) rebuildXref() error {
+	d.xref = make(map[int]xrefEntry)
+	d.cache = make(map[int]Object)
+	d.Trailer = nil
+
+	found := make(map[int]xrefEntry)
+	for _, m := range objHeader.FindAllSubmatchIndex(d.data, -1) {
+		num, _ := strconv.Atoi(string(d.data[m[2]:m[3]]))
+		gen, _ := strconv.Atoi(string(d.data[m[4]:m[5]]))
+		found[num] = xrefEntry{offset: int64(m[2]), gen: gen}
+	}
+	if len(found) == 0 {
+		return fmt.Errorf("%w: no objects found", ErrCorrupt)
+	}
+	d.xref = found
+
+	// Objects inside object streams only have their stream in the scan
+	for num, entry := range found {
+		_, obj, err := d.readIndirect(int(entry.offset))
+		stream, ok := obj.(*Stream)
+		if err != nil || !ok {
+			continue
+		}
+
+		switch stream.Dict["Type"] {
+		case Name("ObjStm"):
+			objStm, err := d.objectStream(num)
+			if err != nil {
+				continue
+			}
+			for i, n := range objStm.nums {
+				if _, ok := d.xref[n]; !ok {
+					d.xref[n] = xrefEntry{compressed: true, stream: num, index: i}
+				}
+			}
+		case Name("XRef"):
+			if d.Trailer == nil {
+				d.Trailer = Dict{}
+			}
+			for key, value := range stream.Dict {
+				d.Trailer[key] = value
+			}
+		}
+	}
+
+	if i := bytes.LastIndex(d.data, []byte("trailer")); i >= 0 {
+		l := newLexer(d.data, i+len("trailer"))
+		if trailer, ok := mustObject(l).(Dict); ok {
+			if d.Trailer == nil {
+				d.Trailer = Dict{}
+			}
+			for key, value := range trailer {
+				d.Trailer[key] = value
+			}
+		}
+	}
+
+	if d.Trailer == nil || d.Dict(d.Trailer["Root"]) == nil {
+		if d.Trailer == nil {
+			d.Trailer = Dict{}
+		}
+		for num := range d.xref {
+			ref := Ref{Num: num, Gen: d.xref[num].gen}
+			if d.Dict(ref)["Type"] == Name("Catalog") {
+				d.Trailer["Root"] = ref
+				break
+			}
+		}
+	}
+
+	return nil
+}
+
+func mustObject(l *lexer) Object {
+	obj, err := l.object()
+	if err != nil {
+		return nil
+	}
+	return obj
+}
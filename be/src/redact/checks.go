@@ -0,0 +1,91 @@
+package redact
+
+import "strings"
+
+// validNIK checks the layout of an Indonesian NIK: province, regency and
+// district codes, birth date with 40 added to the day for women, and a
+// serial number.
+func validNIK(match string) bool {
+	n := number(match[:2])
+	if n < 11 || n > 94 || number(match[2:4]) == 0 || number(match[4:6]) == 0 {
+		return false
+	}
+
+	day, month := number(match[6:8]), number(match[8:10])
+	if day > 40 {
+		day -= 40
+	}
+	return day >= 1 && day <= 31 && month >= 1 && month <= 12 && number(match[12:16]) != 0
+}
+
+// validMyNumber checks the check digit of a Japanese individual number.
+func validMyNumber(match string) bool {
+	d := digits(match)
+	if len(d) != 12 {
+		return false
+	}
+
+	sum := 0
+	for n := 1; n <= 11; n++ {
+		weight := n + 1
+		if n >= 7 {
+			weight = n - 5
+		}
+		sum += int(d[11-n]-'0') * weight
+	}
+
+	check := 0
+	if r := sum % 11; r > 1 {
+		check = 11 - r
+	}
+	return int(d[11]-'0') == check
+}
+
+// validIBAN checks an IBAN's length and its mod 97 check digits.
+func validIBAN(match string) bool {
+	iban := strings.ReplaceAll(match, " ", "")
+	if len(iban) < 15 || len(iban) > 34 {
+		return false
+	}
+
+	remainder := 0
+	for _, c := range iban[4:] + iban[:4] {
+		switch {
+		case c >= '0' && c <= '9':
+			remainder = (remainder*10 + int(c-'0')) % 97
+		case c >= 'A' && c <= 'Z':
+			remainder = (remainder*100 + int(c-'A') + 10) % 97
+		default:
+			return false
+		}
+	}
+	return remainder == 1
+}
+
+// luhn checks the check digit card numbers carry.
+func luhn(d string) bool {
+	if len(d) < 13 || len(d) > 19 {
+		return false
+	}
+
+	sum := 0
+	for i := 0; i < len(d); i++ {
+		n := int(d[len(d)-1-i] - '0')
+		if i%2 == 1 {
+			n *= 2
+			if n > 9 {
+				n -= 9
+			}
+		}
+		sum += n
+	}
+	return sum%10 == 0
+}
+
+func number(s string) int {
+	n := 0
+	for i := 0; i < len(s); i++ {
+		n = n*10 + int(s[i]-'0')
+	}
+	return n
+}
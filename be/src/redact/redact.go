@@ -0,0 +1,270 @@
+package redact
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Built-in detectors, by the names used to enable them.
+const (
+	Email      = "email"
+	Phone      = "phone"
+	NIK        = "nik"
+	MyNumber   = "my_number"
+	IBAN       = "iban"
+	CreditCard = "credit_card"
+)
+
+// Detector finds one kind of personal data. Label names the kind in
+// placeholders, so it should say what was removed without revealing it.
+type Detector struct {
+	Label   string
+	Pattern *regexp.Regexp
+	// Valid, when set, rejects matches that have the right shape but fail
+	// a checksum or range check.
+	Valid func(match string) bool
+}
+
+var builtins = map[string]Detector{
+	Email: {
+		Label:   "EMAIL",
+		Pattern: regexp.MustCompile(`(?i)\b[a-z0-9][a-z0-9._%+\-]*@[a-z0-9](?:[a-z0-9\-]*[a-z0-9])?(?:\.[a-z0-9](?:[a-z0-9\-]*[a-z0-9])?)*\.[a-z]{2,}\b`),
+	},
+	Phone: {
+		// International numbers, or national ones with their leading 0,
+		// such as 0812-3456-7890 or 03 1234 5678. Requiring one of the two
+		// keeps dates and plain amounts out.
+		Label:   "PHONE",
+		Pattern: regexp.MustCompile(`(?:\+\d{1,3}[ .\-]?|\b0)(?:\(\d{1,4}\)[ .\-]?)?\d{1,4}(?:[ .\-]?\d{2,4}){1,4}\b`),
+		Valid:   func(match string) bool { n := len(digits(match)); return n >= 9 && n <= 15 },
+	},
+	NIK: {
+		Label:   "NIK",
+		Pattern: regexp.MustCompile(`\b\d{16}\b`),
+		Valid:   validNIK,
+	},
+	MyNumber: {
+		Label:   "MY_NUMBER",
+		Pattern: regexp.MustCompile(`\b\d{4}[ \-]?\d{4}[ \-]?\d{4}\b`),
+		Valid:   validMyNumber,
+	},
+	IBAN: {
+		Label:   "IBAN",
+		Pattern: regexp.MustCompile(`\b[A-Z]{2}\d{2}(?: ?[A-Z0-9]{4}){2,7}(?: ?[A-Z0-9]{1,3})?\b`),
+		Valid:   validIBAN,
+	},
+	CreditCard: {
+		Label:   "CARD",
+		Pattern: regexp.MustCompile(`\b\d(?:[ \-]?\d){12,18}\b`),
+		Valid:   func(match string) bool { return luhn(digits(match)) },
+	},
+}
+
+// precedence decides between detectors that match exactly the same text.
+// Checksummed formats go first, as a passing checksum is the stronger hint.
+var precedence = []string{Email, IBAN, CreditCard, MyNumber, NIK, Phone}
+
+// Names lists the built-in detectors.
+func Names() []string {
+	return append([]string(nil), precedence...)
+}
+
+// Builtin returns the named built-in detector.
+func Builtin(name string) (Detector, error) {
+	detector, ok := builtins[name]
+	if !ok {
+		return Detector{}, fmt.Errorf("unknown detector %q", name)
+	}
+	return detector, nil
+}
+
+var labelPattern = regexp.MustCompile(`^[A-Z][A-Z0-9_]*$`)
+
+// Custom builds a detector from a regular expression.
+func Custom(label, pattern string) (Detector, error) {
+	if !labelPattern.MatchString(label) {
+		return Detector{}, fmt.Errorf("invalid label %q, use upper case letters, digits and underscores", label)
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return Detector{}, fmt.Errorf("invalid pattern for %s: %w", label, err)
+	}
+	return Detector{Label: label, Pattern: re}, nil
+}
+
+// Redactor replaces personal data in text with placeholders.
+type Redactor struct {
+	detectors []Detector
+}
+
+// New returns a Redactor running the given detectors. Where matches overlap
+// the one that starts first wins, then the longest, then the earlier
+// detector.
+func New(detectors ...Detector) *Redactor {
+	return &Redactor{detectors: detectors}
+}
+
+// Match is personal data found in a text, as a byte range of it.
+type Match struct {
+	Start, End int
+	Label      string
+}
+
+type match struct {
+	start, end int
+	detector   int
+}
+
+// Find returns where the detectors find personal data in text, in order and
+// without overlaps.
+func (r *Redactor) Find(text string) []Match {
+	var matches []match
+	for i, detector := range r.detectors {
+		for _, loc := range detector.Pattern.FindAllStringIndex(text, -1) {
+			if loc[0] == loc[1] {
+				continue
+			}
+			if detector.Valid != nil && !detector.Valid(text[loc[0]:loc[1]]) {
+				continue
+			}
+			matches = append(matches, match{start: loc[0], end: loc[1], detector: i})
+		}
+	}
+
+	sort.Slice(matches, func(i, j int) bool {
+		a, b := matches[i], matches[j]
+		if a.start != b.start {
+			return a.start < b.start
+		}
+		if a.end != b.end {
+			return a.end > b.end
+		}
+		return a.detector < b.detector
+	})
+
+	var found []Match
+	last := 0
+	for _, m := range matches {
+		if m.start < last {
+			continue
+		}
+		found = append(found, Match{Start: m.start, End: m.end, Label: r.detectors[m.detector].Label})
+		last = m.end
+	}
+	return found
+}
+
+// Redact replaces everything the detectors find with placeholders such as
+// [EMAIL_1], recording each in found. A value seen before, in this text or
+// an earlier one redacted into the same found, gets the same placeholder.
+func (r *Redactor) Redact(text string, found *Redactions) string {
+	matches := r.Find(text)
+	if len(matches) == 0 {
+		return text
+	}
+
+	var b strings.Builder
+	last := 0
+	for _, m := range matches {
+		b.WriteString(text[last:m.Start])
+		b.WriteString(found.placeholder(m.Label, text[m.Start:m.End]))
+		last = m.End
+	}
+	b.WriteString(text[last:])
+	return b.String()
+}
+
+// Redactions remembers which value each placeholder stands for, so they can
+// be put back later. The zero value is ready to use.
+type Redactions struct {
+	byValue map[string]string
+	values  map[string]string
+	counts  map[string]int
+}
+
+func (f *Redactions) placeholder(label, value string) string {
+	key := label + "\x00" + value
+	if placeholder, ok := f.byValue[key]; ok {
+		return placeholder
+	}
+
+	if f.byValue == nil {
+		f.byValue = make(map[string]string)
+		f.values = make(map[string]string)
+		f.counts = make(map[string]int)
+	}
+	f.counts[label]++
+	placeholder := fmt.Sprintf("[%s_%d]", label, f.counts[label])
+	f.byValue[key] = placeholder
+	f.values[placeholder] = value
+	return placeholder
+}
+
+// Len returns how many distinct values were redacted.
+func (f *Redactions) Len() int {
+	return len(f.values)
+}
+
+// Counts returns how many distinct values were redacted per label.
+func (f *Redactions) Counts() map[string]int {
+	counts := make(map[string]int, len(f.counts))
+	for label, n := range f.counts {
+		counts[label] = n
+	}
+	return counts
+}
+
+// Restore puts the original values back in place of their placeholders.
+// Placeholders the text doesn't contain are simply not used.
+func (f *Redactions) Restore(text string) string {
+	if len(f.values) == 0 {
+		return text
+	}
+
+	pairs := make([]string, 0, 2*len(f.values))
+	for placeholder, value := range f.values {
+		pairs = append(pairs, placeholder, value)
+	}
+	return strings.NewReplacer(pairs...).Replace(text)
+}
+
+func digits(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] >= '0' && s[i] <= '9' {
+			b.WriteByte(s[i])
+		}
+	}
+	return b.String()
+}
+
+// LoadPatterns reads custom detectors from a file with one LABEL=regex per
+// line. Blank lines and lines starting with # are skipped.
+func LoadPatterns(path string) ([]Detector, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var detectors []Detector
+	for i, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		label, pattern, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("%s:%d: expected LABEL=regex", path, i+1)
+		}
+		detector, err := Custom(strings.TrimSpace(label), strings.TrimSpace(pattern))
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, i+1, err)
+		}
+		detectors = append(detectors, detector)
+	}
+	return detectors, nil
+}
@@ -0,0 +1,94 @@
+package render
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Pdftoppm renders pages with poppler's pdftoppm. WebP images are converted
+// from its PNG output with cwebp. Both must be on the PATH unless the
+// commands are set explicitly.
+type Pdftoppm struct {
+	Command     string
+	WebPCommand string
+	// WebPQuality is cwebp's quality factor, from 0 to 100.
+	WebPQuality int
+}
+
+func NewPdftoppm() *Pdftoppm {
+	return &Pdftoppm{
+		Command:     "pdftoppm",
+		WebPCommand: "cwebp",
+		WebPQuality: 80,
+	}
+}
+
+func (p *Pdftoppm) Render(ctx context.Context, path, dest string, opts Options) error {
+	if opts.Format != FormatPNG && opts.Format != FormatWebP {
+		return fmt.Errorf("%w: %q", ErrUnsupportedFormat, opts.Format)
+	}
+
+	dir, err := os.MkdirTemp("", "render-*")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	image := filepath.Join(dir, "page")
+	args := []string{
+		"-f", strconv.Itoa(opts.Page), "-l", strconv.Itoa(opts.Page),
+		"-scale-to-x", strconv.Itoa(opts.Width), "-scale-to-y", "-1",
+		"-png", "-singlefile",
+		path, image,
+	}
+
+	if err := run(ctx, p.Command, args); err != nil {
+		return fmt.Errorf("failed to render page %d: %w", opts.Page, err)
+	}
+	image += ".png"
+
+	if opts.Format == FormatWebP {
+		webp := filepath.Join(dir, "page.webp")
+		args := []string{"-quiet", "-q", strconv.Itoa(p.WebPQuality), image, "-o", webp}
+		if err := run(ctx, p.WebPCommand, args); err != nil {
+			return fmt.Errorf("failed to convert page %d to WebP: %w", opts.Page, err)
+		}
+		image = webp
+	}
+
+	return moveFile(image, dest)
+}
+
+// moveFile renames src to dest, copying when they sit on different devices,
+// as the temporary directory often does.
+func moveFile(src, dest string) error {
+	if err := os.Rename(src, dest); err == nil {
+		return nil
+	}
+
+	data, err := os.ReadFile(src)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(dest, data, 0o644)
+}
+
+func run(ctx context.Context, command string, args []string) error {
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, command, args...)
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return fmt.Errorf("%s: %w: %s", command, err, msg)
+		}
+		return fmt.Errorf("%s: %w", command, err)
+	}
+	return nil
+}
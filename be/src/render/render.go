@@ -0,0 +1,39 @@
+package render
+
+import (
+	"context"
+	"errors"
+)
+
+// Image formats a Renderer can produce.
+const (
+	FormatPNG  = "png"
+	FormatWebP = "webp"
+)
+
+var ErrUnsupportedFormat = errors.New("unsupported image format")
+
+// Options describes one rendered page.
+type Options struct {
+	// Page is 1-based.
+	Page int
+	// Width in pixels, the height follows the page's aspect ratio.
+	Width  int
+	Format string
+}
+
+// Renderer draws PDF pages as images.
+type Renderer interface {
+	// Render writes page opts.Page of the PDF at path to dest as an image.
+	// Protected files are passed as a decrypted copy, so that no password
+	// ever ends up on a command line where other users can read it.
+	Render(ctx context.Context, path, dest string, opts Options) error
+}
+
+// ContentType returns the media type of an image format.
+func ContentType(format string) string {
+	if format == FormatWebP {
+		return "image/webp"
+	}
+	return "image/png"
+}
@@ -0,0 +1,30 @@
+package response
+
+import (
+	"app/src/model"
+
+	"github.com/google/uuid"
+)
+
+type AccountExportResponse struct {
+	Code    int                 `json:"code"`
+	Status  string              `json:"status"`
+	Message string              `json:"message"`
+	Data    model.AccountExport `json:"data"`
+}
+
+type ArchiveImportResult struct {
+	SourceID uuid.UUID  `json:"source_id"`
+	Filename string     `json:"filename"`
+	Status   string     `json:"status"`
+	PDFID    *uuid.UUID `json:"pdf_id,omitempty"`
+	Reason   string     `json:"reason,omitempty"`
+}
+
+type ArchiveImportResponse struct {
+	Data     []ArchiveImportResult `json:"data"`
+	Imported int                   `json:"imported"`
+	Skipped  int                   `json:"skipped"`
+	Failed   int                   `json:"failed"`
+	Message  string                `json:"message"`
+}
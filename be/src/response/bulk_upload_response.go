@@ -0,0 +1,20 @@
+package response
+
+import "github.com/google/uuid"
+
+type BulkUploadResult struct {
+	Filename      string     `json:"filename"`
+	Status        string     `json:"status"`
+	PDFID         *uuid.UUID `json:"pdf_id,omitempty"`
+	Reason        string     `json:"reason,omitempty"`
+	SummaryQueued bool       `json:"summary_queued"`
+	SummaryError  string     `json:"summary_error,omitempty"`
+}
+
+type BulkUploadResponse struct {
+	Data       []BulkUploadResult `json:"data"`
+	Created    int                `json:"created"`
+	Duplicates int                `json:"duplicates"`
+	Rejected   int                `json:"rejected"`
+	Message    string             `json:"message"`
+}
@@ -0,0 +1,37 @@
+package response
+
+import "app/src/model"
+
+type EmailPreview struct {
+	Template string `json:"template"`
+	Locale   string `json:"locale"`
+	Subject  string `json:"subject"`
+	Text     string `json:"text"`
+	HTML     string `json:"html"`
+}
+
+type EmailPreviewResponse struct {
+	Code    int          `json:"code"`
+	Status  string       `json:"status"`
+	Message string       `json:"message"`
+	Data    EmailPreview `json:"data"`
+}
+
+type EmailTemplates struct {
+	Templates []string `json:"templates"`
+	Locales   []string `json:"locales"`
+}
+
+type EmailTemplatesResponse struct {
+	Code    int            `json:"code"`
+	Status  string         `json:"status"`
+	Message string         `json:"message"`
+	Data    EmailTemplates `json:"data"`
+}
+
+type EmailOutboxResponse struct {
+	Code    int               `json:"code"`
+	Status  string            `json:"status"`
+	Message string            `json:"message"`
+	Data    model.EmailOutbox `json:"data"`
+}
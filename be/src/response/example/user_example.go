@@ -8,6 +8,7 @@ type User struct {
 	Email         string    `json:"email" example:"fake@example.com"`
 	Role          string    `json:"role" example:"user"`
 	VerifiedEmail bool      `json:"verified_email" example:"false"`
+	Locale        string    `json:"locale" example:"en"`
 }
 
 type GoogleUser struct {
@@ -16,4 +17,5 @@ type GoogleUser struct {
 	Email         string    `json:"email" example:"fake@example.com"`
 	Role          string    `json:"role" example:"user"`
 	VerifiedEmail bool      `json:"verified_email" example:"true"`
+	Locale        string    `json:"locale" example:"en"`
 }
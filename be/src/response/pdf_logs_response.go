@@ -9,6 +9,7 @@ import (
 type PDFLogResponse struct {
 	ID         uuid.UUID `json:"id"`
 	PDFID      uuid.UUID `json:"pdf_id"`
+	Version    int       `json:"version"`
 	Summary    string    `json:"summary"`
 	Language   string    `json:"language"`
 	OutputType string    `json:"output_type"`
@@ -1,21 +1,42 @@
 package response
 
 import (
+	"app/src/model"
 	"time"
 
 	"github.com/google/uuid"
 )
 
 type PDFResponse struct {
-	ID               uuid.UUID `json:"id"`
-	OriginalFilename string    `json:"original_filename"`
-	FileSize         int64     `json:"file_size"`
-	Summary          *string   `json:"summary,omitempty"`
-	Language         string    `json:"language"`
-	OutputType       string    `json:"output_type"`
-	SummaryStatus    string    `json:"summary_status"`
-	SummaryError     *string   `json:"summary_error,omitempty"`
-	UploadDate       time.Time `json:"upload_date"`
+	ID                uuid.UUID              `json:"id"`
+	OriginalFilename  string                 `json:"original_filename"`
+	FileSize          int64                  `json:"file_size"`
+	Version           int                    `json:"version"`
+	SourceURL         *string                `json:"source_url,omitempty"`
+	ParentID          *uuid.UUID             `json:"parent_id,omitempty"`
+	SpecVersion       string                 `json:"spec_version"`
+	PageCount         int                    `json:"page_count"`
+	TableCount        int                    `json:"table_count"`
+	TableStatus       string                 `json:"table_status"`
+	Encrypted         bool                   `json:"encrypted"`
+	PasswordProtected bool                   `json:"password_protected"`
+	OCRUsed           bool                   `json:"ocr_used"`
+	ScanStatus        string                 `json:"scan_status"`
+	ScanSignature     *string                `json:"scan_signature,omitempty"`
+	Title             *string                `json:"title,omitempty"`
+	Author            *string                `json:"author,omitempty"`
+	Subject           *string                `json:"subject,omitempty"`
+	Producer          *string                `json:"producer,omitempty"`
+	CreationDate      *time.Time             `json:"creation_date,omitempty"`
+	Summary           *string                `json:"summary,omitempty"`
+	Language          string                 `json:"language"`
+	OutputType        string                 `json:"output_type"`
+	SummaryStatus     string                 `json:"summary_status"`
+	SummaryError      *string                `json:"summary_error,omitempty"`
+	ChapterSummaries  []model.ChapterSummary `json:"chapter_summaries,omitempty"`
+	RedactionReport   *model.RedactionReport `json:"redaction_report,omitempty"`
+	ThumbnailURL      string                 `json:"thumbnail_url"`
+	UploadDate        time.Time              `json:"upload_date"`
 }
 
 type PDFListResponse struct {
@@ -27,19 +48,123 @@ type PDFListResponse struct {
 }
 
 type SummaryResponse struct {
+	PDFID            uuid.UUID              `json:"pdf_id"`
+	OriginalFilename string                 `json:"original_filename"`
+	SummaryText      string                 `json:"summary_text"`
+	Chapters         []model.ChapterSummary `json:"chapters,omitempty"`
+	Redacted         map[string]int         `json:"redacted,omitempty"`
+	Language         string                 `json:"language"`
+	OutputType       string                 `json:"output_type"`
+	ProcessingTimeMs int                    `json:"processing_time_ms"`
+	GeneratedAt      time.Time              `json:"generated_at"`
+}
+
+type UploadPDFResponse struct {
+	ID               uuid.UUID  `json:"id"`
+	OriginalFilename string     `json:"original_filename"`
+	FileSize         int64      `json:"file_size"`
+	PageCount        int        `json:"page_count"`
+	ParentID         *uuid.UUID `json:"parent_id,omitempty"`
+	ScanStatus       string     `json:"scan_status"`
+	UploadDate       time.Time  `json:"upload_date"`
+	Message          string     `json:"message"`
+}
+
+type RedactPDFResponse struct {
+	UploadPDFResponse
+	RedactionReport *model.RedactionReport `json:"redaction_report"`
+}
+
+type SplitPDFResponse struct {
+	Data    []UploadPDFResponse `json:"data"`
+	Message string              `json:"message"`
+}
+
+type UnlockPDFResponse struct {
+	UnlockToken string    `json:"unlock_token"`
+	ExpiresAt   time.Time `json:"expires_at"`
+	Message     string    `json:"message"`
+}
+
+type PDFVersionResponse struct {
+	ID               uuid.UUID `json:"id"`
 	PDFID            uuid.UUID `json:"pdf_id"`
+	Version          int       `json:"version"`
 	OriginalFilename string    `json:"original_filename"`
-	SummaryText      string    `json:"summary_text"`
-	Language         string    `json:"language"`
-	OutputType       string    `json:"output_type"`
-	ProcessingTimeMs int       `json:"processing_time_ms"`
-	GeneratedAt      time.Time `json:"generated_at"`
+	FileSize         int64     `json:"file_size"`
+	ScanStatus       string    `json:"scan_status"`
+	CreatedAt        time.Time `json:"created_at"`
 }
 
-type UploadPDFResponse struct {
+type PDFVersionListResponse struct {
+	Data []PDFVersionResponse `json:"data"`
+}
+
+type UploadPDFVersionResponse struct {
 	ID               uuid.UUID `json:"id"`
+	Version          int       `json:"version"`
 	OriginalFilename string    `json:"original_filename"`
 	FileSize         int64     `json:"file_size"`
+	ScanStatus       string    `json:"scan_status"`
 	UploadDate       time.Time `json:"upload_date"`
 	Message          string    `json:"message"`
 }
+
+type OutlineItemResponse struct {
+	Title string `json:"title"`
+	Level int    `json:"level"`
+	Page  int    `json:"page"`
+}
+
+type OutlineResponse struct {
+	Data []OutlineItemResponse `json:"data"`
+}
+
+type TableResponse struct {
+	Index       int        `json:"index"`
+	PageNumber  int        `json:"page_number"`
+	RowCount    int        `json:"row_count"`
+	ColumnCount int        `json:"column_count"`
+	Rows        [][]string `json:"rows"`
+	CSVURL      string     `json:"csv_url"`
+}
+
+type TableListResponse struct {
+	Data []TableResponse `json:"data"`
+}
+
+type FormFieldResponse struct {
+	Name  string `json:"name"`
+	Type  string `json:"type"`
+	Value string `json:"value"`
+	Page  int    `json:"page"`
+}
+
+type FormFieldListResponse struct {
+	Data []FormFieldResponse `json:"data"`
+}
+
+type SignatureResponse struct {
+	FieldName      string     `json:"field_name"`
+	Page           int        `json:"page"`
+	SubFilter      string     `json:"sub_filter"`
+	SignerName     string     `json:"signer_name"`
+	Subject        string     `json:"subject,omitempty"`
+	Issuer         string     `json:"issuer,omitempty"`
+	Serial         string     `json:"serial,omitempty"`
+	NotBefore      *time.Time `json:"not_before,omitempty"`
+	NotAfter       *time.Time `json:"not_after,omitempty"`
+	SigningTime    *time.Time `json:"signing_time,omitempty"`
+	Reason         string     `json:"reason,omitempty"`
+	Location       string     `json:"location,omitempty"`
+	ContactInfo    string     `json:"contact_info,omitempty"`
+	Status         string     `json:"status"`
+	Intact         bool       `json:"intact"`
+	Trusted        bool       `json:"trusted"`
+	CoversDocument bool       `json:"covers_document"`
+	Problems       []string   `json:"problems"`
+}
+
+type SignatureListResponse struct {
+	Data []SignatureResponse `json:"data"`
+}
@@ -0,0 +1,50 @@
+package response
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type ShareResponse struct {
+	ID                uuid.UUID  `json:"id"`
+	PDFID             uuid.UUID  `json:"pdf_id"`
+	Token             string     `json:"token"`
+	URL               string     `json:"url"`
+	PasswordProtected bool       `json:"password_protected"`
+	ExpiresAt         time.Time  `json:"expires_at"`
+	MaxViews          *int       `json:"max_views,omitempty"`
+	ViewCount         int        `json:"view_count"`
+	RevokedAt         *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt         time.Time  `json:"created_at"`
+}
+
+type ShareListResponse struct {
+	Data []ShareResponse `json:"data"`
+}
+
+type ShareAccessResponse struct {
+	ID         uuid.UUID `json:"id"`
+	Resource   string    `json:"resource"`
+	Outcome    string    `json:"outcome"`
+	IPAddress  string    `json:"ip_address"`
+	UserAgent  string    `json:"user_agent"`
+	AccessedAt time.Time `json:"accessed_at"`
+}
+
+// SharedPDFResponse is the read-only view of a PDF behind a share link.
+type SharedPDFResponse struct {
+	OriginalFilename string    `json:"original_filename"`
+	FileSize         int64     `json:"file_size"`
+	PageCount        int       `json:"page_count"`
+	Title            *string   `json:"title,omitempty"`
+	Author           *string   `json:"author,omitempty"`
+	Summary          *string   `json:"summary,omitempty"`
+	Language         string    `json:"language"`
+	OutputType       string    `json:"output_type"`
+	SummaryStatus    string    `json:"summary_status"`
+	FileURL          string    `json:"file_url"`
+	ExpiresAt        time.Time `json:"expires_at"`
+	ViewsRemaining   *int      `json:"views_remaining,omitempty"`
+	UpdatedAt        time.Time `json:"updated_at"`
+}
@@ -0,0 +1,10 @@
+package response
+
+import "app/src/model"
+
+type SummaryEmailResponse struct {
+	Code    int                `json:"code"`
+	Status  string             `json:"status"`
+	Message string             `json:"message"`
+	Data    model.SummaryEmail `json:"data"`
+}
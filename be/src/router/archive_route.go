@@ -0,0 +1,21 @@
+package router
+
+import (
+	"app/src/controller"
+	m "app/src/middleware"
+	"app/src/service"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func ArchiveRoutes(v1 fiber.Router, p service.PDFService, u service.UserService) {
+	archiveController := controller.NewArchiveController(p)
+
+	export := v1.Group("/exports")
+
+	export.Post("/", m.Auth(u, "manageArchives"), archiveController.CreateExport)
+	export.Get("/:exportId", m.Auth(u, "manageArchives"), archiveController.GetExport)
+	export.Get("/:exportId/download", m.Auth(u, "manageArchives"), archiveController.DownloadExport)
+
+	v1.Post("/imports", m.Auth(u, "manageArchives"), archiveController.ImportArchive)
+}
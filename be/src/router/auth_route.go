@@ -9,11 +9,8 @@ import (
 	"github.com/gofiber/fiber/v2"
 )
 
-func AuthRoutes(
-	v1 fiber.Router, a service.AuthService, u service.UserService,
-	t service.TokenService, e service.EmailService,
-) {
-	authController := controller.NewAuthController(a, u, t, e)
+func AuthRoutes(v1 fiber.Router, a service.AuthService, u service.UserService, t service.TokenService) {
+	authController := controller.NewAuthController(a, u, t)
 	config.GoogleConfig()
 
 	auth := v1.Group("/auth")
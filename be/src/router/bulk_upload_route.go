@@ -0,0 +1,16 @@
+package router
+
+import (
+	"app/src/controller"
+	"app/src/service"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func BulkUploadRoutes(v1 fiber.Router, b service.BulkUploadService) {
+	bulkUploadController := controller.NewBulkUploadController(b)
+
+	pdf := v1.Group("/pdfs")
+
+	pdf.Post("/bulk", bulkUploadController.BulkUpload)
+}
@@ -0,0 +1,21 @@
+package router
+
+import (
+	"app/src/controller"
+	m "app/src/middleware"
+	"app/src/service"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func EmailRoutes(v1 fiber.Router, e service.EmailService, u service.UserService) {
+	emailController := controller.NewEmailController(e)
+
+	email := v1.Group("/emails")
+
+	email.Get("/templates", m.Auth(u, "manageEmails"), emailController.GetTemplates)
+	email.Get("/templates/:name/preview", m.Auth(u, "manageEmails"), emailController.PreviewTemplate)
+	email.Get("/outbox", m.Auth(u, "manageEmails"), emailController.GetOutbox)
+	email.Get("/outbox/:emailId", m.Auth(u, "manageEmails"), emailController.GetOutboxEmail)
+	email.Post("/outbox/:emailId/retry", m.Auth(u, "manageEmails"), emailController.RetryOutboxEmail)
+}
@@ -3,22 +3,39 @@ package router
 import (
 	"app/src/controller"
 	"app/src/handler"
+	m "app/src/middleware"
 	"app/src/service"
 
 	"github.com/gofiber/fiber/v2"
 )
 
-func PDFRoutes(v1 fiber.Router, p service.PDFService) {
+func PDFRoutes(v1 fiber.Router, p service.PDFService, r service.RateLimitService) {
 	pdfController := controller.NewPDFController(p)
 	pdfHandler := handler.NewPDFHandler(p)
 
 	pdf := v1.Group("/pdfs")
 
 	pdf.Post("/", pdfController.UploadPDF)
+	pdf.Post("/import", pdfController.ImportPDF)
+	pdf.Post("/merge", pdfController.MergePDFs)
 	pdf.Get("/", pdfController.GetPDFs)
+	pdf.Get("/summaries/export", pdfController.ExportSummaries)
 	pdf.Get("/:pdfId", pdfController.GetPDFByID)
 	pdf.Get("/:id/view", pdfHandler.ViewPDF)
+	pdf.Get("/:id/download", pdfHandler.DownloadPDF)
+	pdf.Get("/:pdfId/pages/:n/thumbnail", pdfHandler.Thumbnail)
+	pdf.Post("/:pdfId/versions", pdfController.UploadVersion)
+	pdf.Get("/:pdfId/versions", pdfController.GetVersions)
+	pdf.Get("/:pdfId/outline", pdfController.GetOutline)
+	pdf.Get("/:pdfId/form-fields", pdfController.GetFormFields)
+	pdf.Get("/:pdfId/signatures", pdfController.GetSignatures)
+	pdf.Get("/:pdfId/tables", pdfController.GetTables)
+	pdf.Get("/:pdfId/tables/:n", pdfController.GetTable)
 	pdf.Delete("/:pdfId", pdfController.DeletePDF)
+	pdf.Post("/:pdfId/unlock", append(m.UnlockLimiter(r), pdfController.UnlockPDF)...)
+	pdf.Post("/:pdfId/split", pdfController.SplitPDF)
+	pdf.Post("/:pdfId/redact", pdfController.RedactPDF)
 	pdf.Post("/:pdfId/summarize", pdfController.SummarizePDF)
+	pdf.Get("/:pdfId/summary/export", pdfController.ExportSummary)
 	pdf.Post("/:pdfId/cancel", pdfController.CancelSummarization)
 }
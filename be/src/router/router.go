@@ -13,20 +13,31 @@ func Routes(app *fiber.App, db *gorm.DB) {
 	validate := validation.Validator()
 
 	healthCheckService := service.NewHealthCheckService(db)
-	emailService := service.NewEmailService()
+	rateLimitService := service.NewRateLimitService(db)
+	emailService := service.NewEmailService(db, validate)
 	userService := service.NewUserService(db, validate)
 	tokenService := service.NewTokenService(db, validate, userService)
-	authService := service.NewAuthService(db, validate, userService, tokenService)
+	authService := service.NewAuthService(db, validate, userService, tokenService, emailService)
 	pdfService := service.NewPDFService(db, validate, config.SummaryServiceURL)
 	pdfLogService := service.NewPDFLogService(db, validate)
+	uploadService := service.NewUploadService(db, validate, pdfService)
+	bulkUploadService := service.NewBulkUploadService(validate, pdfService)
+	shareService := service.NewShareService(db, validate, pdfService)
+	summaryEmailService := service.NewSummaryEmailService(db, validate, pdfService, emailService)
 
 	v1 := app.Group("/v1")
 
 	HealthCheckRoutes(v1, healthCheckService)
-	AuthRoutes(v1, authService, userService, tokenService, emailService)
+	AuthRoutes(v1, authService, userService, tokenService)
 	UserRoutes(v1, userService, tokenService)
-	PDFRoutes(v1, pdfService)
+	PDFRoutes(v1, pdfService, rateLimitService)
 	PDFLogRoutes(v1, pdfLogService)
+	UploadRoutes(v1, uploadService)
+	BulkUploadRoutes(v1, bulkUploadService)
+	ShareRoutes(v1, shareService)
+	ArchiveRoutes(v1, pdfService, userService)
+	SummaryEmailRoutes(v1, summaryEmailService, userService)
+	EmailRoutes(v1, emailService, userService)
 	// TODO: add another routes here...
 
 	if !config.IsProd {
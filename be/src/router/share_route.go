@@ -0,0 +1,24 @@
+package router
+
+import (
+	"app/src/controller"
+	"app/src/service"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func ShareRoutes(v1 fiber.Router, s service.ShareService) {
+	shareController := controller.NewShareController(s)
+
+	pdf := v1.Group("/pdfs")
+
+	pdf.Post("/:pdfId/shares", shareController.CreateShare)
+	pdf.Get("/:pdfId/shares", shareController.GetShares)
+	pdf.Delete("/:pdfId/shares/:shareId", shareController.RevokeShare)
+	pdf.Get("/:pdfId/shares/:shareId/accesses", shareController.GetShareAccesses)
+
+	shared := v1.Group("/shared")
+
+	shared.Get("/:token", shareController.GetSharedPDF)
+	shared.Get("/:token/file", shareController.GetSharedFile)
+}
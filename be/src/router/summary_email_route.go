@@ -0,0 +1,18 @@
+package router
+
+import (
+	"app/src/controller"
+	m "app/src/middleware"
+	"app/src/service"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func SummaryEmailRoutes(v1 fiber.Router, s service.SummaryEmailService, u service.UserService) {
+	summaryEmailController := controller.NewSummaryEmailController(s)
+
+	pdf := v1.Group("/pdfs")
+
+	pdf.Post("/:pdfId/summary/email", m.Auth(u), summaryEmailController.EmailSummary)
+	pdf.Get("/:pdfId/summary/emails", m.Auth(u), summaryEmailController.GetSummaryEmails)
+}
@@ -0,0 +1,20 @@
+package router
+
+import (
+	"app/src/controller"
+	"app/src/service"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func UploadRoutes(v1 fiber.Router, u service.UploadService) {
+	uploadController := controller.NewUploadController(u)
+
+	upload := v1.Group("/uploads", uploadController.TusResumable)
+
+	upload.Options("/", uploadController.Options)
+	upload.Post("/", uploadController.CreateUpload)
+	upload.Head("/:uploadId", uploadController.GetUpload)
+	upload.Patch("/:uploadId", uploadController.PatchUpload)
+	upload.Delete("/:uploadId", uploadController.TerminateUpload)
+}
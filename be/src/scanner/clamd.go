@@ -0,0 +1,134 @@
+package scanner
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"time"
+)
+
+// Clamd scans files with a ClamAV daemon over its INSTREAM command, so the
+// daemon needs no access to the files themselves.
+type Clamd struct {
+	Network string
+	Address string
+	Timeout time.Duration
+	// ChunkSize is the most data sent per INSTREAM chunk.
+	ChunkSize int
+}
+
+// NewClamd parses address as "tcp://host:port", "unix:///path/clamd.sock"
+// or a bare "host:port".
+func NewClamd(address string, timeout time.Duration) (*Clamd, error) {
+	network, addr := "tcp", address
+	if scheme, rest, ok := strings.Cut(address, "://"); ok {
+		network, addr = scheme, rest
+	}
+	if network != "tcp" && network != "unix" {
+		return nil, fmt.Errorf("unsupported clamd network %q", network)
+	}
+	if addr == "" {
+		return nil, fmt.Errorf("clamd address is empty")
+	}
+
+	return &Clamd{Network: network, Address: addr, Timeout: timeout, ChunkSize: 64 * 1024}, nil
+}
+
+func (c *Clamd) Scan(ctx context.Context, r io.Reader) (*Result, error) {
+	if c.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.Timeout)
+		defer cancel()
+	}
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, c.Network, c.Address)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrScanFailed, err)
+	}
+	defer conn.Close()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	stop := context.AfterFunc(ctx, func() { conn.SetDeadline(time.Now()) })
+	defer stop()
+
+	if err := c.stream(conn, r); err != nil {
+		// The daemon hangs up early when the stream is over its size
+		// limit, and says so in its reply
+		if reply, replyErr := readReply(conn); replyErr == nil && reply != "" {
+			return parseReply(reply)
+		}
+		return nil, fmt.Errorf("%w: %v", ErrScanFailed, err)
+	}
+
+	reply, err := readReply(conn)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrScanFailed, err)
+	}
+	return parseReply(reply)
+}
+
+// stream sends the INSTREAM command followed by length-prefixed chunks and
+// the zero-length chunk that ends the stream.
+func (c *Clamd) stream(conn net.Conn, r io.Reader) error {
+	w := bufio.NewWriter(conn)
+	if _, err := w.WriteString("zINSTREAM\x00"); err != nil {
+		return err
+	}
+
+	chunk := make([]byte, c.ChunkSize)
+	var size [4]byte
+	for {
+		n, err := io.ReadFull(r, chunk)
+		if n > 0 {
+			binary.BigEndian.PutUint32(size[:], uint32(n))
+			if _, err := w.Write(size[:]); err != nil {
+				return err
+			}
+			if _, err := w.Write(chunk[:n]); err != nil {
+				return err
+			}
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	binary.BigEndian.PutUint32(size[:], 0)
+	if _, err := w.Write(size[:]); err != nil {
+		return err
+	}
+	return w.Flush()
+}
+
+// readReply reads the NUL-terminated reply to a "z" command.
+func readReply(conn net.Conn) (string, error) {
+	reply, err := bufio.NewReader(io.LimitReader(conn, 4096)).ReadString(0)
+	if err != nil && (err != io.EOF || reply == "") {
+		return "", err
+	}
+	return strings.TrimSpace(strings.TrimRight(reply, "\x00")), nil
+}
+
+// parseReply reads replies such as "stream: OK" and
+// "stream: Win.Test.EICAR_HDB-1 FOUND".
+func parseReply(reply string) (*Result, error) {
+	verdict := strings.TrimPrefix(reply, "stream: ")
+
+	switch {
+	case verdict == "OK":
+		return &Result{}, nil
+	case strings.HasSuffix(verdict, " FOUND"):
+		return &Result{Infected: true, Signature: strings.TrimSuffix(verdict, " FOUND")}, nil
+	}
+	return nil, fmt.Errorf("%w: %s", ErrScanFailed, reply)
+}
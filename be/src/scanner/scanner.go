@@ -0,0 +1,21 @@
+package scanner
+
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+var ErrScanFailed = errors.New("malware scan failed")
+
+// Result is the verdict on a scanned file.
+type Result struct {
+	Infected bool
+	// Signature names the malware found, empty when the file is clean.
+	Signature string
+}
+
+// Scanner checks file contents for malware.
+type Scanner interface {
+	Scan(ctx context.Context, r io.Reader) (*Result, error)
+}
@@ -19,7 +19,9 @@ type AuthService interface {
 	Login(c *fiber.Ctx, req *validation.Login) (*model.User, error)
 	Logout(c *fiber.Ctx, req *validation.Logout) error
 	RefreshAuth(c *fiber.Ctx, req *validation.RefreshToken) (*response.Tokens, error)
+	ForgotPassword(c *fiber.Ctx, req *validation.ForgotPassword) error
 	ResetPassword(c *fiber.Ctx, query *validation.Token, req *validation.UpdatePassOrVerify) error
+	SendVerificationEmail(c *fiber.Ctx, user *model.User) error
 	VerifyEmail(c *fiber.Ctx, query *validation.Token) error
 }
 
@@ -29,10 +31,12 @@ type authService struct {
 	Validate     *validator.Validate
 	UserService  UserService
 	TokenService TokenService
+	EmailService EmailService
 }
 
 func NewAuthService(
-	db *gorm.DB, validate *validator.Validate, userService UserService, tokenService TokenService,
+	db *gorm.DB, validate *validator.Validate, userService UserService,
+	tokenService TokenService, emailService EmailService,
 ) AuthService {
 	return &authService{
 		Log:          utils.Log,
@@ -40,6 +44,7 @@ func NewAuthService(
 		Validate:     validate,
 		UserService:  userService,
 		TokenService: tokenService,
+		EmailService: emailService,
 	}
 }
 
@@ -58,6 +63,7 @@ func (s *authService) Register(c *fiber.Ctx, req *validation.Register) (*model.U
 		Name:     req.Name,
 		Email:    req.Email,
 		Password: hashedPassword,
+		Locale:   req.Locale,
 	}
 
 	result := s.DB.WithContext(c.Context()).Create(user)
@@ -127,6 +133,28 @@ func (s *authService) RefreshAuth(c *fiber.Ctx, req *validation.RefreshToken) (*
 	return newTokens, err
 }
 
+// ForgotPassword saves a reset password token and queues the email carrying
+// it in one transaction, so neither is left behind without the other.
+func (s *authService) ForgotPassword(c *fiber.Ctx, req *validation.ForgotPassword) error {
+	if err := s.Validate.Struct(req); err != nil {
+		return err
+	}
+
+	user, err := s.UserService.GetUserByEmail(c, req.Email)
+	if err != nil {
+		return err
+	}
+
+	return s.DB.WithContext(c.Context()).Transaction(func(tx *gorm.DB) error {
+		resetPasswordToken, err := s.TokenService.GenerateResetPasswordToken(tx, user)
+		if err != nil {
+			return err
+		}
+
+		return s.EmailService.SendResetPasswordEmail(tx, user, resetPasswordToken)
+	})
+}
+
 func (s *authService) ResetPassword(c *fiber.Ctx, query *validation.Token, req *validation.UpdatePassOrVerify) error {
 	if err := s.Validate.Struct(query); err != nil {
 		return err
@@ -153,6 +181,17 @@ func (s *authService) ResetPassword(c *fiber.Ctx, query *validation.Token, req *
 	return nil
 }
 
+func (s *authService) SendVerificationEmail(c *fiber.Ctx, user *model.User) error {
+	return s.DB.WithContext(c.Context()).Transaction(func(tx *gorm.DB) error {
+		verifyEmailToken, err := s.TokenService.GenerateVerifyEmailToken(tx, user)
+		if err != nil {
+			return err
+		}
+
+		return s.EmailService.SendVerificationEmail(tx, user, *verifyEmailToken)
+	})
+}
+
 func (s *authService) VerifyEmail(c *fiber.Ctx, query *validation.Token) error {
 	if err := s.Validate.Struct(query); err != nil {
 		return err
@@ -0,0 +1,330 @@
+package service
+
+import (
+	"app/src/config"
+	"app/src/model"
+	"app/src/response"
+	"app/src/utils"
+	"app/src/validation"
+	"archive/zip"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	BulkStatusCreated   = "created"
+	BulkStatusDuplicate = "duplicate"
+	BulkStatusRejected  = "rejected"
+)
+
+type BulkUploadService interface {
+	BulkUpload(c *fiber.Ctx) ([]response.BulkUploadResult, error)
+}
+
+type bulkUploadService struct {
+	Log        *logrus.Logger
+	Validate   *validator.Validate
+	PDFService PDFService
+}
+
+func NewBulkUploadService(validate *validator.Validate, pdfService PDFService) BulkUploadService {
+	return &bulkUploadService{
+		Log:        utils.Log,
+		Validate:   validate,
+		PDFService: pdfService,
+	}
+}
+
+// bulkBatch tracks the files of a single bulk request so duplicates within
+// the request are caught before they reach the database.
+type bulkBatch struct {
+	results []response.BulkUploadResult
+	hashes  map[string]uuid.UUID
+	// optionsError is why summarization options sent after the first file
+	// were refused. The files are still stored and reported.
+	optionsError string
+}
+
+func (b *bulkBatch) full() bool {
+	return len(b.results) >= config.BulkMaxFiles
+}
+
+// refuseOptions fails the request over invalid summarization options while
+// no file has been handled yet. After that, failing would lose the results
+// of files already stored, so the error is reported with them instead.
+func (b *bulkBatch) refuseOptions(message string) error {
+	if len(b.results) == 0 {
+		return fiber.NewError(fiber.StatusBadRequest, message)
+	}
+	if b.optionsError == "" {
+		b.optionsError = message
+	}
+	return nil
+}
+
+func (b *bulkBatch) add(filename, status string, id *uuid.UUID, reason string) {
+	b.results = append(b.results, response.BulkUploadResult{
+		Filename: filename,
+		Status:   status,
+		PDFID:    id,
+		Reason:   reason,
+	})
+}
+
+// BulkUpload streams every "files" (or "file") part of a multipart request
+// into storage. ZIP archives are unpacked and each entry is handled like a
+// separately uploaded file. Form fields may come in any order, so they are
+// checked as they arrive and summarization is only queued once the whole
+// body has been read.
+func (s *bulkUploadService) BulkUpload(c *fiber.Ctx) ([]response.BulkUploadResult, error) {
+	reader, body, err := utils.MultipartStream(c)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "Request must be multipart/form-data")
+	}
+	defer utils.DrainBody(c, body)
+
+	req := new(validation.BulkUpload)
+	batch := &bulkBatch{hashes: make(map[string]uuid.UUID)}
+
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			s.Log.Errorf("Failed to read multipart body: %+v", err)
+			return nil, fiber.NewError(fiber.StatusBadRequest, "Malformed multipart body")
+		}
+
+		err = s.receivePart(c, batch, req, part)
+		part.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if len(batch.results) == 0 {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "At least one file is required")
+	}
+
+	if batch.optionsError != "" {
+		for i := range batch.results {
+			if batch.results[i].Status == BulkStatusCreated {
+				batch.results[i].SummaryError = batch.optionsError
+			}
+		}
+	} else if req.Summarize {
+		s.enqueueSummaries(c, batch, req)
+	}
+
+	return batch.results, nil
+}
+
+func (s *bulkUploadService) receivePart(c *fiber.Ctx, batch *bulkBatch, req *validation.BulkUpload, part *multipart.Part) error {
+	if part.FileName() == "" {
+		value, err := io.ReadAll(io.LimitReader(part, 256))
+		if err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, "Malformed multipart body")
+		}
+
+		switch part.FormName() {
+		case "summarize":
+			summarize, err := strconv.ParseBool(string(value))
+			if err != nil {
+				return batch.refuseOptions("summarize must be a boolean")
+			}
+			req.Summarize = summarize
+		case "language":
+			req.Language = string(value)
+		case "output_type":
+			req.OutputType = string(value)
+		}
+
+		if err := s.Validate.Struct(req); err != nil {
+			return batch.refuseOptions("Invalid summarization options")
+		}
+		return nil
+	}
+
+	if part.FormName() != "files" && part.FormName() != "file" {
+		return nil
+	}
+
+	if strings.EqualFold(filepath.Ext(part.FileName()), ".zip") {
+		s.receiveArchive(c, batch, part.FileName(), part)
+		return nil
+	}
+
+	s.receivePDF(c, batch, part.FileName(), filepath.Base(part.FileName()), part)
+	return nil
+}
+
+// receivePDF stores a single file and records the outcome. name is what the
+// client will recognise in the results, originalFilename what is kept on the
+// PDF.
+func (s *bulkUploadService) receivePDF(c *fiber.Ctx, batch *bulkBatch, name, originalFilename string, r io.Reader) {
+	if batch.full() {
+		batch.add(name, BulkStatusRejected, nil, fmt.Sprintf("Only %d files can be uploaded at once", config.BulkMaxFiles))
+		return
+	}
+
+	pdf, err := s.PDFService.StorePDF(originalFilename, r)
+	if err != nil {
+		batch.add(name, BulkStatusRejected, nil, rejectionReason(err))
+		return
+	}
+
+	if id, ok := batch.hashes[pdf.ContentHash]; ok {
+		s.removeStored(pdf)
+		batch.add(name, BulkStatusDuplicate, &id, "Same file as an earlier file in this upload")
+		return
+	}
+
+	existing, err := s.PDFService.FindPDFByContentHash(c, pdf.ContentHash)
+	if err != nil {
+		s.removeStored(pdf)
+		batch.add(name, BulkStatusRejected, nil, "Failed to check for duplicates")
+		return
+	}
+	if existing != nil {
+		s.removeStored(pdf)
+		batch.hashes[pdf.ContentHash] = existing.ID
+		batch.add(name, BulkStatusDuplicate, &existing.ID, "File has already been uploaded")
+		return
+	}
+
+	if err := s.PDFService.CreatePDF(c, pdf); err != nil {
+		s.removeStored(pdf)
+		batch.add(name, BulkStatusRejected, nil, rejectionReason(err))
+		return
+	}
+
+	batch.hashes[pdf.ContentHash] = pdf.ID
+	batch.add(name, BulkStatusCreated, &pdf.ID, "")
+}
+
+// receiveArchive spools a ZIP to disk, since entries can only be read with
+// random access, then handles each PDF in it.
+func (s *bulkUploadService) receiveArchive(c *fiber.Ctx, batch *bulkBatch, name string, r io.Reader) {
+	uploadDir := "./storage/uploads"
+	if err := os.MkdirAll(uploadDir, os.ModePerm); err != nil {
+		s.Log.Errorf("Failed to create upload directory: %+v", err)
+		batch.add(name, BulkStatusRejected, nil, "Failed to process archive")
+		return
+	}
+
+	archivePath := filepath.Join(uploadDir, uuid.New().String()+".zip")
+	stored, err := utils.StoreStream(r, archivePath, config.BulkMaxArchiveSize, "application/zip")
+	if errors.Is(err, utils.ErrInvalidFileType) {
+		batch.add(name, BulkStatusRejected, nil, "Invalid file type, only ZIP archives are allowed")
+		return
+	}
+	if errors.Is(err, utils.ErrFileTooLarge) {
+		batch.add(name, BulkStatusRejected, nil, fmt.Sprintf("Archive exceeds the maximum limit of %d MB", config.BulkMaxArchiveSize>>20))
+		return
+	}
+	if err != nil {
+		s.Log.Errorf("Failed to save archive: %+v", err)
+		batch.add(name, BulkStatusRejected, nil, "Failed to process archive")
+		return
+	}
+	defer os.Remove(stored.Path)
+
+	archive, err := zip.OpenReader(stored.Path)
+	if err != nil {
+		batch.add(name, BulkStatusRejected, nil, "Archive is corrupted or not a ZIP file")
+		return
+	}
+	defer archive.Close()
+
+	if len(archive.File) > config.BulkMaxArchiveEntries {
+		batch.add(name, BulkStatusRejected, nil, fmt.Sprintf("Archive contains more than %d entries", config.BulkMaxArchiveEntries))
+		return
+	}
+
+	for _, f := range archive.File {
+		if f.FileInfo().IsDir() || utils.IsArchiveMetadata(f.Name) {
+			continue
+		}
+		s.receiveArchiveEntry(c, batch, name+"/"+f.Name, f)
+	}
+}
+
+func (s *bulkUploadService) receiveArchiveEntry(c *fiber.Ctx, batch *bulkBatch, name string, f *zip.File) {
+	originalFilename, err := utils.ArchiveEntryName(f.Name)
+	if err != nil {
+		s.Log.Warnf("Rejected archive entry %q: %+v", f.Name, err)
+		batch.add(name, BulkStatusRejected, nil, "Invalid path in archive")
+		return
+	}
+
+	entry, err := utils.OpenArchiveEntry(f, config.PDFMaxSize, config.BulkMaxCompressionRatio)
+	if errors.Is(err, utils.ErrFileTooLarge) {
+		batch.add(name, BulkStatusRejected, nil, "File size exceeds the maximum limit of 10 MB")
+		return
+	}
+	if errors.Is(err, utils.ErrSuspiciousArchive) {
+		batch.add(name, BulkStatusRejected, nil, "Compression ratio is too high")
+		return
+	}
+	if err != nil {
+		batch.add(name, BulkStatusRejected, nil, "Failed to read file from archive")
+		return
+	}
+	defer entry.Close()
+
+	s.receivePDF(c, batch, name, originalFilename, entry)
+}
+
+func (s *bulkUploadService) enqueueSummaries(c *fiber.Ctx, batch *bulkBatch, req *validation.BulkUpload) {
+	summarizeReq := &validation.SummarizeRequest{
+		Language:   req.Language,
+		OutputType: req.OutputType,
+	}
+	if summarizeReq.Language == "" {
+		summarizeReq.Language = "auto"
+	}
+	if summarizeReq.OutputType == "" {
+		summarizeReq.OutputType = "paragraph"
+	}
+
+	for i := range batch.results {
+		result := &batch.results[i]
+		if result.Status != BulkStatusCreated {
+			continue
+		}
+
+		if err := s.PDFService.EnqueueSummary(c, result.PDFID.String(), summarizeReq); err != nil {
+			s.Log.Warnf("Failed to queue summarization for PDF %s: %+v", result.PDFID, err)
+			result.SummaryError = rejectionReason(err)
+			continue
+		}
+		result.SummaryQueued = true
+	}
+}
+
+func (s *bulkUploadService) removeStored(pdf *model.PDF) {
+	if err := os.Remove(pdf.FilePath); err != nil {
+		s.Log.Errorf("Failed to delete stored file: %+v", err)
+	}
+}
+
+// rejectionReason turns a service error into a message safe to show the client.
+func rejectionReason(err error) string {
+	var fiberErr *fiber.Error
+	if errors.As(err, &fiberErr) {
+		return fiberErr.Message
+	}
+	return "Failed to process file"
+}
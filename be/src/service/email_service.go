@@ -2,73 +2,317 @@ package service
 
 import (
 	"app/src/config"
+	"app/src/mail"
+	"app/src/model"
 	"app/src/utils"
-	"fmt"
+	"app/src/validation"
+	"context"
+	"errors"
+	"net/url"
+	"time"
 
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
 	"github.com/sirupsen/logrus"
-	"gopkg.in/gomail.v2"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// EmailService writes emails to the outbox, from where a background sender
+// delivers them. Queueing takes the transaction of the change the email is
+// about, so the email is sent if and only if that change is committed.
 type EmailService interface {
-	SendEmail(to, subject, body string) error
-	SendResetPasswordEmail(to, token string) error
-	SendVerificationEmail(to, token string) error
+	Queue(tx *gorm.DB, email *mail.Email) (*model.EmailOutbox, error)
+	SendResetPasswordEmail(tx *gorm.DB, user *model.User, token string) error
+	SendVerificationEmail(tx *gorm.DB, user *model.User, token string) error
+	Preview(name, locale string) (*mail.Message, error)
+	GetOutbox(c *fiber.Ctx, params *validation.QueryEmailOutbox) ([]model.EmailOutbox, int64, error)
+	GetOutboxEmail(c *fiber.Ctx, id string) (*model.EmailOutbox, error)
+	RetryOutboxEmail(c *fiber.Ctx, id string) (*model.EmailOutbox, error)
 }
 
 type emailService struct {
-	Log    *logrus.Logger
-	Dialer *gomail.Dialer
+	Log      *logrus.Logger
+	DB       *gorm.DB
+	Validate *validator.Validate
+	Sender   mail.Sender
 }
 
-func NewEmailService() EmailService {
-	return &emailService{
-		Log: utils.Log,
-		Dialer: gomail.NewDialer(
+func NewEmailService(db *gorm.DB, validate *validator.Validate) EmailService {
+	s := &emailService{
+		Log:      utils.Log,
+		DB:       db,
+		Validate: validate,
+		Sender: mail.NewSMTPSender(
 			config.SMTPHost,
 			config.SMTPPort,
 			config.SMTPUsername,
 			config.SMTPPassword,
+			config.EmailFrom,
 		),
 	}
+
+	go s.runSender()
+
+	return s
 }
 
-func (s *emailService) SendEmail(to, subject, body string) error {
-	mailer := gomail.NewMessage()
-	mailer.SetHeader("From", config.EmailFrom)
-	mailer.SetHeader("To", to)
-	mailer.SetHeader("Subject", subject)
-	mailer.SetBody("text/plain", body)
+func (s *emailService) Queue(tx *gorm.DB, email *mail.Email) (*model.EmailOutbox, error) {
+	record := &model.EmailOutbox{
+		Recipients: email.To,
+		ReplyTo:    email.ReplyTo,
+		Subject:    email.Subject,
+		TextBody:   email.Text,
+		HTMLBody:   email.HTML,
+		Status:     model.OutboxStatusPending,
+	}
+	for _, attachment := range email.Attachments {
+		record.Attachments = append(record.Attachments, model.EmailAttachment{
+			Path:        attachment.Path,
+			Filename:    attachment.Filename,
+			ContentType: attachment.ContentType,
+		})
+	}
+
+	if err := tx.Create(record).Error; err != nil {
+		s.Log.Errorf("Failed to queue email: %+v", err)
+		return nil, err
+	}
+
+	return record, nil
+}
 
-	if err := s.Dialer.DialAndSend(mailer); err != nil {
-		s.Log.Errorf("Failed to send email: %v", err)
+func (s *emailService) SendResetPasswordEmail(tx *gorm.DB, user *model.User, token string) error {
+	return s.queueTemplate(tx, user, mail.TemplateResetPassword, token)
+}
+
+func (s *emailService) SendVerificationEmail(tx *gorm.DB, user *model.User, token string) error {
+	return s.queueTemplate(tx, user, mail.TemplateVerifyEmail, token)
+}
+
+// Preview renders a template for a made-up user, as it would be sent.
+func (s *emailService) Preview(name, locale string) (*mail.Message, error) {
+	return mail.Render(name, locale, templateData(name, "Budi Santoso", "preview-token"))
+}
+
+func (s *emailService) queueTemplate(tx *gorm.DB, user *model.User, name, token string) error {
+	message, err := mail.Render(name, user.Locale, templateData(name, user.Name, token))
+	if err != nil {
+		s.Log.Errorf("Failed to render %s email: %+v", name, err)
 		return err
 	}
 
-	return nil
+	_, err = s.Queue(tx, &mail.Email{
+		To:      []string{user.Email},
+		Subject: message.Subject,
+		Text:    message.Text,
+		HTML:    message.HTML,
+	})
+	return err
+}
+
+// templateData fills in a template with a link to the frontend page that
+// takes the token.
+func templateData(name, userName, token string) mail.Data {
+	data := mail.Data{Name: userName}
+	switch name {
+	case mail.TemplateResetPassword:
+		data.URL = config.FrontendURL + "/reset-password?token=" + url.QueryEscape(token)
+		data.ExpiresIn = config.JWTResetPasswordExp
+	case mail.TemplateVerifyEmail:
+		data.URL = config.FrontendURL + "/verify-email?token=" + url.QueryEscape(token)
+		data.ExpiresIn = config.JWTVerifyEmailExp
+	}
+	return data
+}
+
+func (s *emailService) GetOutbox(c *fiber.Ctx, params *validation.QueryEmailOutbox) ([]model.EmailOutbox, int64, error) {
+	if err := s.Validate.Struct(params); err != nil {
+		return nil, 0, fiber.NewError(fiber.StatusBadRequest, "Invalid query parameters")
+	}
+
+	var emails []model.EmailOutbox
+	var totalResults int64
+
+	query := s.DB.WithContext(c.Context()).Model(&model.EmailOutbox{})
+	if params.Status != "" {
+		query = query.Where("status = ?", params.Status)
+	}
+
+	if err := query.Count(&totalResults).Error; err != nil {
+		s.Log.Errorf("Failed to count outbox emails: %+v", err)
+		return nil, 0, fiber.NewError(fiber.StatusInternalServerError, "Failed to count outbox emails")
+	}
+
+	offset := (params.Page - 1) * params.Limit
+	if err := query.Order("created_at desc").Limit(params.Limit).Offset(offset).Find(&emails).Error; err != nil {
+		s.Log.Errorf("Failed to get outbox emails: %+v", err)
+		return nil, 0, fiber.NewError(fiber.StatusInternalServerError, "Failed to get outbox emails")
+	}
+
+	return emails, totalResults, nil
+}
+
+func (s *emailService) GetOutboxEmail(c *fiber.Ctx, id string) (*model.EmailOutbox, error) {
+	email := new(model.EmailOutbox)
+
+	result := s.DB.WithContext(c.Context()).First(email, "id = ?", id)
+
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return nil, fiber.NewError(fiber.StatusNotFound, "Email not found")
+	}
+
+	if result.Error != nil {
+		s.Log.Errorf("Failed to get outbox email: %+v", result.Error)
+		return nil, result.Error
+	}
+
+	return email, nil
+}
+
+// RetryOutboxEmail gives a dead email a fresh set of attempts.
+func (s *emailService) RetryOutboxEmail(c *fiber.Ctx, id string) (*model.EmailOutbox, error) {
+	result := s.DB.WithContext(c.Context()).Model(&model.EmailOutbox{}).
+		Where("id = ? AND status = ?", id, model.OutboxStatusDead).
+		Updates(map[string]interface{}{
+			"status":          model.OutboxStatusPending,
+			"attempts":        0,
+			"next_attempt_at": time.Now(),
+		})
+	if result.Error != nil {
+		s.Log.Errorf("Failed to retry outbox email: %+v", result.Error)
+		return nil, fiber.NewError(fiber.StatusInternalServerError, "Failed to retry email")
+	}
+
+	email, err := s.GetOutboxEmail(c, id)
+	if err != nil {
+		return nil, err
+	}
+	if result.RowsAffected == 0 {
+		return nil, fiber.NewError(fiber.StatusConflict, "Only dead emails can be retried")
+	}
+
+	return email, nil
+}
+
+// runSender delivers due emails, checking for new ones every poll interval
+// and straight away while full batches keep coming.
+func (s *emailService) runSender() {
+	ctx := context.Background()
+	ticker := time.NewTicker(config.EmailOutboxPollInterval)
+	defer ticker.Stop()
+
+	var pruned time.Time
+	for range ticker.C {
+		for s.sendDue(ctx) == config.EmailOutboxBatchSize {
+		}
+
+		if time.Since(pruned) > time.Hour {
+			s.pruneSent(ctx)
+			pruned = time.Now()
+		}
+	}
+}
+
+// sendDue claims a batch of due emails and sends them, returning how many
+// it claimed.
+func (s *emailService) sendDue(ctx context.Context) int {
+	emails, err := s.claimDue(ctx)
+	if err != nil {
+		s.Log.Errorf("Failed to claim outbox emails: %+v", err)
+		return 0
+	}
+
+	for i := range emails {
+		s.deliver(ctx, &emails[i])
+	}
+	return len(emails)
 }
 
-func (s *emailService) SendResetPasswordEmail(to, token string) error {
-	subject := "Reset password"
+// claimDue takes due emails, including sending ones whose sender stopped
+// before finishing. Locked rows are skipped, so several instances can send
+// from the same outbox. Emails are sent at least once: one taken over after
+// a crash mid-send may arrive twice.
+func (s *emailService) claimDue(ctx context.Context) ([]model.EmailOutbox, error) {
+	var emails []model.EmailOutbox
 
-	// TODO: replace this url with the link to the reset password page of your front-end app
-	resetPasswordURL := fmt.Sprintf("http://link-to-app/reset-password?token=%s", token)
-	body := fmt.Sprintf(`Dear user,
+	err := s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status IN ? AND next_attempt_at <= ?", []string{model.OutboxStatusPending, model.OutboxStatusSending}, now).
+			Order("next_attempt_at").
+			Limit(config.EmailOutboxBatchSize).
+			Find(&emails).Error
+		if err != nil || len(emails) == 0 {
+			return err
+		}
 
-To reset your password, click on this link: %s
+		ids := make([]uuid.UUID, len(emails))
+		for i := range emails {
+			ids[i] = emails[i].ID
+		}
+		return tx.Model(&model.EmailOutbox{}).Where("id IN ?", ids).Updates(map[string]interface{}{
+			"status":          model.OutboxStatusSending,
+			"next_attempt_at": now.Add(config.EmailSendLease),
+		}).Error
+	})
 
-If you did not request any password resets, then ignore this email.`, resetPasswordURL)
-	return s.SendEmail(to, subject, body)
+	return emails, err
 }
 
-func (s *emailService) SendVerificationEmail(to, token string) error {
-	subject := "Email Verification"
+func (s *emailService) deliver(ctx context.Context, record *model.EmailOutbox) {
+	email := &mail.Email{
+		To:      record.Recipients,
+		ReplyTo: record.ReplyTo,
+		Subject: record.Subject,
+		Text:    record.TextBody,
+		HTML:    record.HTMLBody,
+	}
+	for _, attachment := range record.Attachments {
+		email.Attachments = append(email.Attachments, mail.Attachment{
+			Path:        attachment.Path,
+			Filename:    attachment.Filename,
+			ContentType: attachment.ContentType,
+		})
+	}
 
-	// TODO: replace this url with the link to the email verification page of your front-end app
-	verificationEmailURL := fmt.Sprintf("http://link-to-app/verify-email?token=%s", token)
-	body := fmt.Sprintf(`Dear user,
+	attempts := record.Attempts + 1
+	values := map[string]interface{}{"attempts": attempts}
 
-To verify your email, click on this link: %s
+	err := s.Sender.Send(email)
+	switch {
+	case err == nil:
+		values["status"] = model.OutboxStatusSent
+		values["sent_at"] = time.Now()
+		values["last_error"] = nil
+	case mail.IsPermanent(err) || attempts >= config.EmailOutboxMaxAttempts:
+		s.Log.Errorf("Gave up sending email %s after %d attempts: %+v", record.ID, attempts, err)
+		values["status"] = model.OutboxStatusDead
+		values["last_error"] = err.Error()
+	default:
+		delay := utils.Backoff(attempts, config.EmailRetryBaseDelay, config.EmailRetryMaxDelay)
+		s.Log.Warnf("Failed to send email %s, retrying in %s: %+v", record.ID, delay, err)
+		values["status"] = model.OutboxStatusPending
+		values["next_attempt_at"] = time.Now().Add(delay)
+		values["last_error"] = err.Error()
+	}
 
-If you did not create an account, then ignore this email.`, verificationEmailURL)
-	return s.SendEmail(to, subject, body)
+	err = s.DB.WithContext(ctx).Model(&model.EmailOutbox{}).
+		Where("id = ? AND status = ?", record.ID, model.OutboxStatusSending).
+		Updates(values).Error
+	if err != nil {
+		s.Log.Errorf("Failed to update outbox email %s: %+v", record.ID, err)
+	}
+}
+
+// pruneSent deletes sent emails past the retention period. Summary emails
+// keep their record, without the delivery details.
+func (s *emailService) pruneSent(ctx context.Context) {
+	err := s.DB.WithContext(ctx).
+		Where("status = ? AND sent_at < ?", model.OutboxStatusSent, time.Now().Add(-config.EmailOutboxRetention)).
+		Delete(&model.EmailOutbox{}).Error
+	if err != nil {
+		s.Log.Errorf("Failed to prune sent emails: %+v", err)
+	}
 }
@@ -0,0 +1,673 @@
+package service
+
+import (
+	"app/src/archive"
+	"app/src/config"
+	"app/src/model"
+	"app/src/response"
+	"app/src/utils"
+	"app/src/validation"
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Outcomes of restoring one document from an archive.
+const (
+	ImportStatusImported = "imported"
+	ImportStatusSkipped  = "skipped"
+	ImportStatusFailed   = "failed"
+)
+
+const (
+	exportQueueSize = 20
+	exportDir       = "./storage/exports"
+)
+
+// CreateExport queues an archive of the whole library for the user to
+// download once it is built. PDFs have no owner, so the route is kept for
+// admins.
+func (s *pdfService) CreateExport(c *fiber.Ctx, user *model.User) (*model.AccountExport, error) {
+	s.removeExpiredExports(c.Context())
+
+	export := &model.AccountExport{
+		UserID:    user.ID,
+		Status:    model.ExportStatusQueued,
+		ExpiresAt: time.Now().Add(config.AccountExportExpiration),
+	}
+	if err := s.DB.WithContext(c.Context()).Create(export).Error; err != nil {
+		s.Log.Errorf("Failed to create export: %+v", err)
+		return nil, fiber.NewError(fiber.StatusInternalServerError, "Failed to create export")
+	}
+
+	s.enqueueExport(export.ID)
+	return export, nil
+}
+
+func (s *pdfService) GetExport(c *fiber.Ctx, userID uuid.UUID, id string) (*model.AccountExport, error) {
+	export := new(model.AccountExport)
+
+	result := s.DB.WithContext(c.Context()).First(export, "id = ? AND user_id = ?", id, userID)
+
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return nil, fiber.NewError(fiber.StatusNotFound, "Export not found")
+	}
+
+	if result.Error != nil {
+		s.Log.Errorf("Failed to get export: %+v", result.Error)
+		return nil, result.Error
+	}
+
+	return export, nil
+}
+
+func (s *pdfService) SendExport(c *fiber.Ctx, userID uuid.UUID, id string) error {
+	export, err := s.GetExport(c, userID, id)
+	if err != nil {
+		return err
+	}
+
+	if time.Now().After(export.ExpiresAt) {
+		return fiber.NewError(fiber.StatusGone, "Export has expired, create a new one")
+	}
+	if export.Status != model.ExportStatusCompleted {
+		return fiber.NewError(fiber.StatusConflict, "Export is not ready yet")
+	}
+
+	file := utils.FileResponse{
+		Path:        export.FilePath,
+		Filename:    fmt.Sprintf("export-%s.zip", export.CreatedAt.Format("2006-01-02")),
+		ContentType: "application/zip",
+		Attachment:  true,
+	}
+	if err := utils.SendFile(c, file); err != nil {
+		var fiberErr *fiber.Error
+		if errors.As(err, &fiberErr) && fiberErr.Code == fiber.StatusNotFound {
+			return fiber.NewError(fiber.StatusNotFound, "Export file not found")
+		}
+		s.Log.Errorf("Failed to send export: %+v", err)
+		return fiber.NewError(fiber.StatusInternalServerError, "Failed to read export")
+	}
+	return nil
+}
+
+// enqueueExport hands an export to the worker. Like scans, a full queue
+// never drops one.
+func (s *pdfService) enqueueExport(id uuid.UUID) {
+	select {
+	case s.exportJobs <- id:
+	default:
+		go func() { s.exportJobs <- id }()
+	}
+}
+
+// resumeExports requeues exports interrupted by a restart.
+func (s *pdfService) resumeExports() {
+	ctx := context.Background()
+	s.removeExpiredExports(ctx)
+
+	var ids []uuid.UUID
+	err := s.DB.WithContext(ctx).Model(&model.AccountExport{}).
+		Where("status IN ?", []string{model.ExportStatusQueued, model.ExportStatusProcessing}).
+		Pluck("id", &ids).Error
+	if err != nil {
+		s.Log.Errorf("Failed to load unfinished exports: %+v", err)
+		return
+	}
+
+	for _, id := range ids {
+		s.exportJobs <- id
+	}
+}
+
+// removeExpiredExports deletes exports past their download window along
+// with their files.
+func (s *pdfService) removeExpiredExports(ctx context.Context) {
+	var exports []model.AccountExport
+	if err := s.DB.WithContext(ctx).Where("expires_at < ?", time.Now()).Find(&exports).Error; err != nil {
+		s.Log.Errorf("Failed to load expired exports: %+v", err)
+		return
+	}
+
+	for _, export := range exports {
+		if export.FilePath != "" {
+			if err := os.Remove(export.FilePath); err != nil && !os.IsNotExist(err) {
+				s.Log.Errorf("Failed to delete export file: %+v", err)
+				continue
+			}
+		}
+		if err := s.DB.WithContext(ctx).Delete(&model.AccountExport{}, "id = ?", export.ID).Error; err != nil {
+			s.Log.Errorf("Failed to delete expired export: %+v", err)
+		}
+	}
+}
+
+func (s *pdfService) runExportWorker() {
+	for id := range s.exportJobs {
+		s.buildExport(context.Background(), id)
+	}
+}
+
+func (s *pdfService) buildExport(ctx context.Context, id uuid.UUID) {
+	export := new(model.AccountExport)
+	if err := s.DB.WithContext(ctx).First(export, "id = ?", id).Error; err != nil {
+		s.Log.Errorf("Failed to load export %s: %+v", id, err)
+		return
+	}
+	s.updateExport(ctx, export.ID, map[string]interface{}{"status": model.ExportStatusProcessing})
+
+	if err := os.MkdirAll(exportDir, os.ModePerm); err != nil {
+		s.failExport(ctx, export, err)
+		return
+	}
+	filePath := filepath.Join(exportDir, export.ID.String()+".zip")
+
+	count, err := s.writeExport(ctx, export, filePath)
+	if err != nil {
+		os.Remove(filePath)
+		s.failExport(ctx, export, err)
+		return
+	}
+
+	info, err := os.Stat(filePath)
+	if err != nil {
+		s.failExport(ctx, export, err)
+		return
+	}
+
+	now := time.Now()
+	s.updateExport(ctx, export.ID, map[string]interface{}{
+		"status":       model.ExportStatusCompleted,
+		"file_path":    filePath,
+		"file_size":    info.Size(),
+		"pdf_count":    count,
+		"completed_at": now,
+		"expires_at":   now.Add(config.AccountExportExpiration),
+	})
+	s.Log.Infof("Export %s finished with %d PDFs (%d bytes)", export.ID, count, info.Size())
+}
+
+func (s *pdfService) failExport(ctx context.Context, export *model.AccountExport, err error) {
+	s.Log.Errorf("Failed to build export %s: %+v", export.ID, err)
+	s.updateExport(ctx, export.ID, map[string]interface{}{
+		"status": model.ExportStatusFailed,
+		"error":  "Failed to build export",
+	})
+}
+
+func (s *pdfService) updateExport(ctx context.Context, id uuid.UUID, values map[string]interface{}) {
+	if err := s.DB.WithContext(ctx).Model(&model.AccountExport{}).Where("id = ?", id).Updates(values).Error; err != nil {
+		s.Log.Errorf("Failed to update export %s: %+v", id, err)
+	}
+}
+
+// writeExport writes every PDF with its versions and summary history into
+// a ZIP at filePath, followed by the manifest describing them.
+func (s *pdfService) writeExport(ctx context.Context, export *model.AccountExport, filePath string) (int, error) {
+	db := s.DB.WithContext(ctx)
+
+	user := new(model.User)
+	if err := db.First(user, "id = ?", export.UserID).Error; err != nil {
+		return 0, err
+	}
+
+	var pdfs []model.PDF
+	if err := db.Order("created_at").Find(&pdfs).Error; err != nil {
+		return 0, err
+	}
+
+	file, err := os.Create(filePath)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+	zw := zip.NewWriter(file)
+
+	manifest := archive.Manifest{
+		Format:     archive.Format,
+		Version:    archive.Version,
+		ExportedAt: time.Now().UTC(),
+		Account:    archive.Account{ID: user.ID, Name: user.Name, Email: user.Email},
+		PDFs:       make([]archive.PDF, 0, len(pdfs)),
+	}
+
+	for i := range pdfs {
+		entry, err := s.exportPDF(ctx, zw, &pdfs[i])
+		if err != nil {
+			return 0, err
+		}
+		manifest.PDFs = append(manifest.PDFs, *entry)
+	}
+
+	w, err := zw.Create(archive.ManifestName)
+	if err != nil {
+		return 0, err
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(manifest); err != nil {
+		return 0, err
+	}
+
+	if err := zw.Close(); err != nil {
+		return 0, err
+	}
+	return len(pdfs), file.Close()
+}
+
+func (s *pdfService) exportPDF(ctx context.Context, zw *zip.Writer, pdf *model.PDF) (*archive.PDF, error) {
+	db := s.DB.WithContext(ctx)
+
+	var versions []model.PDFVersion
+	if err := db.Where("pdf_id = ?", pdf.ID).Order("version").Find(&versions).Error; err != nil {
+		return nil, err
+	}
+	var logs []model.PDFLog
+	if err := db.Where("pdf_id = ?", pdf.ID).Order("created_at").Find(&logs).Error; err != nil {
+		return nil, err
+	}
+
+	entry := &archive.PDF{
+		ID:               pdf.ID,
+		ParentID:         pdf.ParentID,
+		OriginalFilename: pdf.OriginalFilename,
+		SourceURL:        pdf.SourceURL,
+		Version:          pdf.Version,
+		Summary:          pdf.Summary,
+		Language:         pdf.Language,
+		OutputType:       pdf.OutputType,
+		SummaryStatus:    pdf.SummaryStatus,
+		ChapterSummaries: pdf.ChapterSummaries,
+		RedactionReport:  pdf.RedactionReport,
+		UploadDate:       pdf.UploadDate,
+		CreatedAt:        pdf.CreatedAt,
+	}
+
+	hasCurrent := false
+	for _, v := range versions {
+		if v.Version == pdf.Version {
+			// The document holds the latest scan result of its file
+			v.ScanStatus = pdf.ScanStatus
+			hasCurrent = true
+		}
+		fileVersion, err := exportFile(zw, pdf.ID, v)
+		if err != nil {
+			return nil, err
+		}
+		entry.Versions = append(entry.Versions, fileVersion)
+	}
+	if !hasCurrent {
+		fileVersion, err := exportFile(zw, pdf.ID, model.PDFVersion{
+			Version:          pdf.Version,
+			OriginalFilename: pdf.OriginalFilename,
+			FilePath:         pdf.FilePath,
+			FileSize:         pdf.FileSize,
+			ContentHash:      pdf.ContentHash,
+			ScanStatus:       pdf.ScanStatus,
+			CreatedAt:        pdf.CreatedAt,
+		})
+		if err != nil {
+			return nil, err
+		}
+		entry.Versions = append(entry.Versions, fileVersion)
+	}
+
+	for _, log := range logs {
+		entry.Logs = append(entry.Logs, archive.Log{
+			Version:    log.Version,
+			Summary:    log.Summary,
+			Language:   log.Language,
+			OutputType: log.OutputType,
+			CreatedAt:  log.CreatedAt,
+		})
+	}
+
+	return entry, nil
+}
+
+// exportFile copies one version of a document into the archive. Quarantined
+// and missing files are listed without an entry.
+func exportFile(zw *zip.Writer, pdfID uuid.UUID, v model.PDFVersion) (archive.FileVersion, error) {
+	fileVersion := archive.FileVersion{
+		Version:          v.Version,
+		OriginalFilename: v.OriginalFilename,
+		FileSize:         v.FileSize,
+		ContentHash:      v.ContentHash,
+		CreatedAt:        v.CreatedAt,
+	}
+	if v.ScanStatus == model.ScanStatusInfected {
+		return fileVersion, nil
+	}
+
+	src, err := os.Open(v.FilePath)
+	if os.IsNotExist(err) {
+		return fileVersion, nil
+	}
+	if err != nil {
+		return fileVersion, err
+	}
+	defer src.Close()
+
+	name := archive.FileEntry(pdfID, v.Version)
+	w, err := zw.Create(name)
+	if err != nil {
+		return fileVersion, err
+	}
+	if _, err := io.Copy(w, src); err != nil {
+		return fileVersion, err
+	}
+
+	fileVersion.File = name
+	return fileVersion, nil
+}
+
+// ImportArchive restores an archive made by an export, on this or another
+// instance. Every document gets a new id; documents whose current file is
+// already in the library are skipped unless onConflict is "duplicate".
+func (s *pdfService) ImportArchive(c *fiber.Ctx, query *validation.QueryImportArchive) ([]response.ArchiveImportResult, error) {
+	if err := s.Validate.Struct(query); err != nil {
+		return nil, err
+	}
+
+	part, err := utils.FormFileStream(c, "file")
+	if err != nil {
+		s.Log.Errorf("Failed to get file from form: %+v", err)
+		return nil, fiber.NewError(fiber.StatusBadRequest, "File is required")
+	}
+	defer part.Close()
+
+	uploadDir := "./storage/uploads"
+	if err := os.MkdirAll(uploadDir, os.ModePerm); err != nil {
+		s.Log.Errorf("Failed to create upload directory: %+v", err)
+		return nil, fiber.NewError(fiber.StatusInternalServerError, "Failed to process archive")
+	}
+
+	archivePath := filepath.Join(uploadDir, uuid.New().String()+".zip")
+	stored, err := utils.StoreStream(part, archivePath, config.ArchiveImportMaxSize, "application/zip")
+	if errors.Is(err, utils.ErrInvalidFileType) {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "Invalid file type, only ZIP archives are allowed")
+	}
+	if errors.Is(err, utils.ErrFileTooLarge) {
+		return nil, fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("Archive exceeds the maximum limit of %d MB", config.ArchiveImportMaxSize>>20))
+	}
+	if err != nil {
+		s.Log.Errorf("Failed to save archive: %+v", err)
+		return nil, fiber.NewError(fiber.StatusInternalServerError, "Failed to process archive")
+	}
+	defer os.Remove(stored.Path)
+
+	zr, err := zip.OpenReader(stored.Path)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "Archive is corrupted or not a ZIP file")
+	}
+	defer zr.Close()
+
+	if len(zr.File) > config.ArchiveImportMaxEntries {
+		return nil, fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("Archive contains more than %d entries", config.ArchiveImportMaxEntries))
+	}
+
+	files := make(map[string]*zip.File, len(zr.File))
+	for _, f := range zr.File {
+		files[f.Name] = f
+	}
+
+	manifest, err := readManifest(files[archive.ManifestName])
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "Invalid archive: "+err.Error())
+	}
+
+	results := make([]response.ArchiveImportResult, 0, len(manifest.PDFs))
+	ids := make(map[uuid.UUID]uuid.UUID, len(manifest.PDFs))
+	var imported []*model.PDF
+	var parents []*uuid.UUID
+
+	for i := range manifest.PDFs {
+		entry := &manifest.PDFs[i]
+		result := response.ArchiveImportResult{SourceID: entry.ID, Filename: entry.OriginalFilename}
+
+		pdf, existing, err := s.importPDF(c, files, entry, query.OnConflict)
+		switch {
+		case err != nil:
+			result.Status = ImportStatusFailed
+			result.Reason = rejectionReason(err)
+		case existing != nil:
+			ids[entry.ID] = existing.ID
+			result.Status = ImportStatusSkipped
+			result.PDFID = &existing.ID
+			result.Reason = "File is already in the library"
+		default:
+			ids[entry.ID] = pdf.ID
+			imported = append(imported, pdf)
+			parents = append(parents, entry.ParentID)
+			result.Status = ImportStatusImported
+			result.PDFID = &pdf.ID
+		}
+		results = append(results, result)
+	}
+
+	// Parents can come after their children, so links are restored once
+	// every document has its new id.
+	for i, pdf := range imported {
+		if parents[i] == nil {
+			continue
+		}
+		parentID, ok := ids[*parents[i]]
+		if !ok {
+			continue
+		}
+		err := s.DB.WithContext(c.Context()).Model(&model.PDF{}).Where("id = ?", pdf.ID).Update("parent_id", parentID).Error
+		if err != nil {
+			s.Log.Errorf("Failed to link imported PDF %s to its parent: %+v", pdf.ID, err)
+		}
+	}
+
+	for _, pdf := range imported {
+		s.enqueueScan(scanJob{PDFID: pdf.ID, Version: pdf.Version, FilePath: pdf.FilePath})
+		if !pdf.PasswordProtected {
+			s.enqueueTables(tableJob{PDFID: pdf.ID, Version: pdf.Version, FilePath: pdf.FilePath})
+		}
+		s.warmThumbnail(pdf.ID)
+	}
+
+	return results, nil
+}
+
+func readManifest(f *zip.File) (*archive.Manifest, error) {
+	if f == nil {
+		return nil, errors.New(archive.ManifestName + " is missing")
+	}
+
+	r, err := utils.OpenArchiveEntry(f, config.ArchiveManifestMaxSize, config.ArchiveManifestMaxRatio)
+	if err != nil {
+		return nil, errors.New(archive.ManifestName + " is too large")
+	}
+	defer r.Close()
+
+	manifest := new(archive.Manifest)
+	if err := json.NewDecoder(r).Decode(manifest); err != nil {
+		return nil, errors.New(archive.ManifestName + " is not valid JSON")
+	}
+	if err := manifest.Check(); err != nil {
+		return nil, err
+	}
+	return manifest, nil
+}
+
+// importPDF stores the files of one document and saves it with its
+// history. It returns the document already holding the same current file
+// instead when conflicts are skipped.
+func (s *pdfService) importPDF(c *fiber.Ctx, files map[string]*zip.File, entry *archive.PDF, onConflict string) (*model.PDF, *model.PDF, error) {
+	current := entry.Current()
+	if current == nil || current.File == "" {
+		return nil, nil, fiber.NewError(fiber.StatusBadRequest, "File is not in the archive")
+	}
+
+	if onConflict != "duplicate" && current.ContentHash != "" {
+		existing, err := s.FindPDFByContentHash(c, current.ContentHash)
+		if err != nil {
+			return nil, nil, fiber.NewError(fiber.StatusInternalServerError, "Failed to check for duplicates")
+		}
+		if existing != nil {
+			return nil, existing, nil
+		}
+	}
+
+	pdf, err := s.importFile(files, current)
+	if err != nil {
+		return nil, nil, err
+	}
+	stored := []string{pdf.FilePath}
+	defer func() {
+		if err != nil {
+			for _, filePath := range stored {
+				os.Remove(filePath)
+			}
+		}
+	}()
+
+	versions := make([]*model.PDFVersion, 0, len(entry.Versions))
+	for i := range entry.Versions {
+		v := &entry.Versions[i]
+		if v.Version == entry.Version {
+			continue
+		}
+		if v.File == "" {
+			// Quarantined when exported, only its place in history is lost
+			continue
+		}
+
+		var file *model.PDF
+		file, err = s.importFile(files, v)
+		if err != nil {
+			return nil, nil, err
+		}
+		stored = append(stored, file.FilePath)
+		versions = append(versions, &model.PDFVersion{
+			ID:               uuid.New(),
+			Version:          v.Version,
+			Filename:         file.Filename,
+			OriginalFilename: path.Base(v.OriginalFilename),
+			FilePath:         file.FilePath,
+			FileSize:         file.FileSize,
+			ContentHash:      file.ContentHash,
+			ScanStatus:       file.ScanStatus,
+			CreatedAt:        v.CreatedAt,
+		})
+	}
+
+	now := time.Now()
+	pdf.ID = uuid.New()
+	pdf.OriginalFilename = path.Base(entry.OriginalFilename)
+	pdf.SourceURL = entry.SourceURL
+	pdf.Version = entry.Version
+	pdf.Summary = entry.Summary
+	pdf.Language = entry.Language
+	pdf.OutputType = entry.OutputType
+	pdf.SummaryStatus = entry.SummaryStatus
+	pdf.ChapterSummaries = entry.ChapterSummaries
+	pdf.RedactionReport = entry.RedactionReport
+	pdf.UploadDate = entry.UploadDate
+	pdf.CreatedAt = entry.CreatedAt
+	pdf.UpdatedAt = now
+	if pdf.Summary == nil {
+		// Summaries in progress when exported have to be asked for again
+		pdf.SummaryStatus = "pending"
+	}
+
+	versions = append(versions, &model.PDFVersion{
+		ID:               uuid.New(),
+		Version:          pdf.Version,
+		Filename:         pdf.Filename,
+		OriginalFilename: pdf.OriginalFilename,
+		FilePath:         pdf.FilePath,
+		FileSize:         pdf.FileSize,
+		ContentHash:      pdf.ContentHash,
+		ScanStatus:       pdf.ScanStatus,
+		CreatedAt:        current.CreatedAt,
+	})
+
+	logs := make([]model.PDFLog, 0, len(entry.Logs))
+	for _, log := range entry.Logs {
+		logs = append(logs, model.PDFLog{
+			ID:         uuid.New(),
+			PDFID:      pdf.ID,
+			Version:    log.Version,
+			Summary:    log.Summary,
+			Language:   log.Language,
+			OutputType: log.OutputType,
+			CreatedAt:  log.CreatedAt,
+		})
+	}
+
+	// Hooks are skipped so the original ids given above are replaced but
+	// the original dates are kept.
+	err = s.DB.WithContext(c.Context()).Session(&gorm.Session{SkipHooks: true}).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(pdf).Error; err != nil {
+			return err
+		}
+		for _, version := range versions {
+			version.PDFID = pdf.ID
+			if err := tx.Create(version).Error; err != nil {
+				return err
+			}
+		}
+		if len(logs) > 0 {
+			return tx.Create(&logs).Error
+		}
+		return nil
+	})
+	if err != nil {
+		s.Log.Errorf("Failed to save imported PDF: %+v", err)
+		return nil, nil, fiber.NewError(fiber.StatusInternalServerError, "Failed to save PDF metadata")
+	}
+
+	for _, version := range versions {
+		if version.Version != pdf.Version {
+			s.enqueueScan(scanJob{PDFID: pdf.ID, Version: version.Version, FilePath: version.FilePath})
+		}
+	}
+
+	return pdf, nil, nil
+}
+
+// importFile stores one file of an archive like an upload, checking it is
+// the file the manifest describes.
+func (s *pdfService) importFile(files map[string]*zip.File, v *archive.FileVersion) (*model.PDF, error) {
+	f, ok := files[v.File]
+	if !ok {
+		return nil, fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("File of version %d is not in the archive", v.Version))
+	}
+
+	r, err := utils.OpenArchiveEntry(f, config.PDFMaxSize, config.BulkMaxCompressionRatio)
+	if errors.Is(err, utils.ErrFileTooLarge) {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "File size exceeds the maximum limit of 10 MB")
+	}
+	if errors.Is(err, utils.ErrSuspiciousArchive) {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "Compression ratio is too high")
+	}
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "Failed to read file from archive")
+	}
+	defer r.Close()
+
+	pdf, err := s.StorePDF(path.Base(v.File), r)
+	if err != nil {
+		return nil, err
+	}
+	if v.ContentHash != "" && pdf.ContentHash != v.ContentHash {
+		os.Remove(pdf.FilePath)
+		return nil, fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("File of version %d doesn't match the manifest", v.Version))
+	}
+	return pdf, nil
+}
@@ -0,0 +1,80 @@
+package service
+
+import (
+	"app/src/export"
+	"app/src/model"
+	"app/src/utils"
+	"app/src/validation"
+	"bytes"
+	"path/filepath"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// ExportSummaries sends the summaries of one or more PDFs, in the given
+// order, as a single document download.
+func (s *pdfService) ExportSummaries(c *fiber.Ctx, query *validation.QueryExportSummaries) error {
+	if err := s.Validate.Struct(query); err != nil {
+		return err
+	}
+
+	summaries := make([]export.Summary, 0, len(query.PDFIDs))
+	var name string
+	for _, id := range query.PDFIDs {
+		pdf, err := s.GetPDFByID(c, id)
+		if err != nil {
+			return err
+		}
+		if pdf.Summary == nil || pdf.SummaryStatus != "completed" {
+			return fiber.NewError(fiber.StatusNotFound, "PDF "+id+" has no summary yet")
+		}
+
+		summaries = append(summaries, exportSummary(pdf))
+		name = strings.TrimSuffix(pdf.OriginalFilename, filepath.Ext(pdf.OriginalFilename)) + "-summary"
+	}
+	if len(summaries) > 1 {
+		name = "summaries"
+	}
+
+	var buf bytes.Buffer
+	if err := export.Write(&buf, query.Format, summaries); err != nil {
+		s.Log.Errorf("Failed to export summaries: %+v", err)
+		return fiber.NewError(fiber.StatusInternalServerError, "Failed to export summary")
+	}
+
+	c.Set(fiber.HeaderContentType, export.ContentType(query.Format))
+	c.Set(fiber.HeaderContentDisposition, utils.ContentDisposition("attachment", name+"."+query.Format))
+	return c.Status(fiber.StatusOK).Send(buf.Bytes())
+}
+
+func exportSummary(pdf *model.PDF) export.Summary {
+	summary := export.Summary{
+		Title:       strings.TrimSuffix(pdf.OriginalFilename, filepath.Ext(pdf.OriginalFilename)),
+		Filename:    pdf.OriginalFilename,
+		PageCount:   pdf.PageCount,
+		Version:     pdf.Version,
+		Text:        *pdf.Summary,
+		Language:    pdf.Language,
+		OutputType:  pdf.OutputType,
+		GeneratedAt: pdf.UpdatedAt,
+	}
+	if pdf.Title != nil && strings.TrimSpace(*pdf.Title) != "" {
+		summary.Title = *pdf.Title
+	}
+	if pdf.Author != nil {
+		summary.Author = *pdf.Author
+	}
+	if pdf.SourceURL != nil {
+		summary.SourceURL = *pdf.SourceURL
+	}
+	for _, chapter := range pdf.ChapterSummaries {
+		summary.Chapters = append(summary.Chapters, export.Chapter{
+			Title:     chapter.Title,
+			StartPage: chapter.StartPage,
+			EndPage:   chapter.EndPage,
+			Text:      chapter.Summary,
+		})
+	}
+	return summary
+}
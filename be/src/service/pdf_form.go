@@ -0,0 +1,59 @@
+package service
+
+import (
+	"app/src/model"
+	"app/src/pdfdoc"
+	"app/src/validation"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func (s *pdfService) GetFormFields(c *fiber.Ctx, id string, query *validation.QueryUnlockToken) (model.FormFields, error) {
+	pdf, err := s.unlockedPDF(c, id, query)
+	if err != nil {
+		return nil, err
+	}
+
+	if pdf.FormFields == nil {
+		return model.FormFields{}, nil
+	}
+	return pdf.FormFields, nil
+}
+
+func documentFormFields(doc *pdfdoc.Document) model.FormFields {
+	docFields := doc.FormFields()
+	if len(docFields) == 0 {
+		return nil
+	}
+
+	fields := make(model.FormFields, len(docFields))
+	for i, field := range docFields {
+		fields[i] = model.FormField{
+			// Postgres JSONB can't hold NUL
+			Name:  strings.ReplaceAll(field.Name, "\x00", ""),
+			Type:  field.Type,
+			Value: strings.ReplaceAll(field.Value, "\x00", ""),
+			Page:  field.Page,
+		}
+	}
+	return fields
+}
+
+// formFieldContext lists the filled-in fields of a form as "name: value"
+// lines for the summarizer. Unticked boxes and signatures say nothing about
+// the content, so they are left out.
+func formFieldContext(fields model.FormFields) string {
+	var lines []string
+	for _, field := range fields {
+		value := strings.Join(strings.Fields(field.Value), " ")
+		if value == "" || field.Type == pdfdoc.FieldSignature {
+			continue
+		}
+		if (field.Type == pdfdoc.FieldCheckbox || field.Type == pdfdoc.FieldRadio) && value == "Off" {
+			continue
+		}
+		lines = append(lines, field.Name+": "+value)
+	}
+	return strings.Join(lines, "\n")
+}
@@ -74,6 +74,9 @@ func (s *pdfLogService) GetLogsByPDFID(c *fiber.Ctx, pdfID string, params *valid
 	if params.OutputType != "" {
 		query = query.Where("output_type = ?", params.OutputType)
 	}
+	if params.Version != 0 {
+		query = query.Where("version = ?", params.Version)
+	}
 	if params.Search != "" {
 		query = query.Where("summary LIKE ?", "%"+params.Search+"%")
 	}
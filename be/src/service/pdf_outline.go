@@ -0,0 +1,123 @@
+package service
+
+import (
+	"app/src/model"
+	"app/src/pdfdoc"
+	"app/src/validation"
+	"context"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Summary modes of a summarize request. Chapters mode adds a summary of
+// every top-level outline item to the one of the whole document.
+const (
+	SummaryModeDocument = "document"
+	SummaryModeChapters = "chapters"
+)
+
+// maxSummaryChapters bounds the summarizer calls a single request can make.
+const maxSummaryChapters = 50
+
+func (s *pdfService) GetOutline(c *fiber.Ctx, id string, query *validation.QueryUnlockToken) (model.Outline, error) {
+	pdf, err := s.unlockedPDF(c, id, query)
+	if err != nil {
+		return nil, err
+	}
+
+	if pdf.Outline == nil {
+		return model.Outline{}, nil
+	}
+	return pdf.Outline, nil
+}
+
+func documentOutline(doc *pdfdoc.Document) model.Outline {
+	items := doc.Outline()
+	if len(items) == 0 {
+		return nil
+	}
+
+	outline := make(model.Outline, len(items))
+	for i, item := range items {
+		outline[i] = model.OutlineItem{Title: item.Title, Level: item.Level, Page: item.Page}
+	}
+	return outline
+}
+
+// outlineChapters splits the document at its top-level outline items. Each
+// chapter runs up to the page before the next one starts; items that point
+// nowhere or back to an earlier page are skipped.
+func outlineChapters(outline model.Outline, pageCount int) model.ChapterSummaries {
+	var chapters model.ChapterSummaries
+	for _, item := range outline {
+		if item.Level != 1 || item.Page < 1 || item.Page > pageCount {
+			continue
+		}
+
+		if n := len(chapters); n > 0 {
+			if item.Page <= chapters[n-1].StartPage {
+				continue
+			}
+			chapters[n-1].EndPage = item.Page - 1
+		}
+		chapters = append(chapters, model.ChapterSummary{
+			Title:     item.Title,
+			StartPage: item.Page,
+			EndPage:   pageCount,
+		})
+	}
+	return chapters
+}
+
+// checkSummaryMode rejects chapter summaries of PDFs they can't be made for.
+func checkSummaryMode(pdf *model.PDF, req *validation.SummarizeRequest) error {
+	if req.Mode != SummaryModeChapters {
+		return nil
+	}
+
+	chapters := outlineChapters(pdf.Outline, pdf.PageCount)
+	if len(chapters) == 0 {
+		return fiber.NewError(fiber.StatusBadRequest, "PDF has no outline to summarize by chapter")
+	}
+	if len(chapters) > maxSummaryChapters {
+		return fiber.NewError(fiber.StatusBadRequest, "PDF outline has too many chapters to summarize separately")
+	}
+	return nil
+}
+
+// summarizeChapters summarizes the text of every chapter on its own.
+// Chapters without any text are left without a summary.
+func (s *pdfService) summarizeChapters(
+	ctx, parent context.Context, pdf *model.PDF, req *validation.SummarizeRequest, opts *summarizeOptions, pages []model.PDFPage,
+) (model.ChapterSummaries, error) {
+	chapters := outlineChapters(pdf.Outline, pdf.PageCount)
+
+	for i := range chapters {
+		chapter := &chapters[i]
+
+		var chapterPages []model.PDFPage
+		for _, page := range pages {
+			if page.PageNumber >= chapter.StartPage && page.PageNumber <= chapter.EndPage {
+				chapterPages = append(chapterPages, page)
+			}
+		}
+
+		text := joinPageTexts(chapterPages)
+		if strings.TrimSpace(text) == "" {
+			continue
+		}
+
+		s.Log.Infof("Summarizing chapter %d of %d for PDF %s", i+1, len(chapters), pdf.ID)
+		// Form values belong to the whole document, not a chapter
+		chapterOpts := *opts
+		chapterOpts.Text = s.redactText(text, opts)
+		chapterOpts.FormFields = ""
+		resp, err := s.requestSummary(ctx, parent, pdf, req, &chapterOpts)
+		if err != nil {
+			return nil, err
+		}
+		chapter.Summary = restoreRedactions(resp.SummaryText, opts)
+	}
+	return chapters, nil
+}
@@ -0,0 +1,259 @@
+package service
+
+import (
+	"app/src/config"
+	"app/src/model"
+	"app/src/pdfdoc"
+	"app/src/redact"
+	"app/src/validation"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// newRedactor builds the redaction stage from the configured detectors,
+// custom patterns first so they win ties with the built-in ones. It returns
+// nil when nothing is to be redacted.
+func newRedactor() (*redact.Redactor, error) {
+	var detectors []redact.Detector
+	if config.RedactPatternsFile != "" {
+		custom, err := redact.LoadPatterns(config.RedactPatternsFile)
+		if err != nil {
+			return nil, err
+		}
+		detectors = append(detectors, custom...)
+	}
+
+	enabled := make(map[string]bool)
+	for _, name := range config.RedactDetectors {
+		if _, err := redact.Builtin(name); err != nil {
+			return nil, err
+		}
+		enabled[name] = true
+	}
+	for _, name := range redact.Names() {
+		if enabled[name] {
+			detector, _ := redact.Builtin(name)
+			detectors = append(detectors, detector)
+		}
+	}
+
+	if len(detectors) == 0 {
+		return nil, nil
+	}
+	return redact.New(detectors...), nil
+}
+
+// redactOptions replaces personal data in everything opts would send to the
+// summarizer. The summarizer reads the file itself when it gets no text, so
+// with redaction on the text extracted here is always sent, and a document
+// without any is refused rather than sent unredacted.
+func (s *pdfService) redactOptions(opts *summarizeOptions, pages []model.PDFPage) error {
+	if s.redactorErr != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "PII redaction is misconfigured, summaries are disabled")
+	}
+	if s.Redactor == nil {
+		return nil
+	}
+	if pages == nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "Failed to read the PDF's text for redaction")
+	}
+
+	opts.Redactions = new(redact.Redactions)
+	opts.Text = s.Redactor.Redact(joinPageTexts(pages), opts.Redactions)
+	opts.FormFields = s.Redactor.Redact(opts.FormFields, opts.Redactions)
+
+	// Forms without text stand in for it, as the summarizer would do
+	if strings.TrimSpace(opts.Text) == "" {
+		opts.Text, opts.FormFields = opts.FormFields, ""
+	}
+	if strings.TrimSpace(opts.Text) == "" {
+		return fiber.NewError(fiber.StatusBadRequest, "Could not extract text from PDF")
+	}
+	return nil
+}
+
+// redactText redacts text for a summarizer call made with opts.
+func (s *pdfService) redactText(text string, opts *summarizeOptions) string {
+	if opts.Redactions == nil {
+		return text
+	}
+	return s.Redactor.Redact(text, opts.Redactions)
+}
+
+// restoreRedactions puts redacted values back into a summary when
+// configured to.
+func restoreRedactions(summary string, opts *summarizeOptions) string {
+	if opts.Redactions == nil || !config.RedactRestore {
+		return summary
+	}
+	return opts.Redactions.Restore(summary)
+}
+
+// redactedCounts reports how many values of each kind were redacted.
+func redactedCounts(opts *summarizeOptions) map[string]int {
+	if opts.Redactions == nil || opts.Redactions.Len() == 0 {
+		return nil
+	}
+	return opts.Redactions.Counts()
+}
+
+// RedactPDF stores a copy of a PDF with the requested data removed from its
+// content, not merely covered, and a report of what was removed. Pages with
+// nothing to redact are copied without their annotations, whose text isn't
+// searched; document-wide parts such as the metadata and interactive form
+// are never copied.
+func (s *pdfService) RedactPDF(c *fiber.Ctx, id string, req *validation.RedactPDF) (*model.PDF, error) {
+	if err := s.Validate.Struct(req); err != nil {
+		return nil, err
+	}
+
+	if len(req.Detectors) == 0 && len(req.Texts) == 0 && len(req.Regions) == 0 {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "Give detectors, texts or regions to redact")
+	}
+
+	pdf, doc, err := s.openSource(c, id, req.UnlockToken)
+	if err != nil {
+		return nil, err
+	}
+
+	pages, err := doc.Pages()
+	if err != nil {
+		s.Log.Errorf("Failed to read pages of PDF %s: %+v", pdf.ID, err)
+		return nil, fiber.NewError(fiber.StatusInternalServerError, "Failed to read PDF")
+	}
+
+	for _, region := range req.Regions {
+		if region.Page > len(pages) {
+			return nil, fiber.NewError(fiber.StatusBadRequest,
+				fmt.Sprintf("Region on page %d is outside the document's %d pages", region.Page, len(pages)))
+		}
+	}
+
+	finder := requestRedactor(req)
+	report := &model.RedactionReport{
+		SourceVersion: pdf.Version,
+		Detectors:     req.Detectors,
+		Areas:         []model.RedactedArea{},
+		CreatedAt:     time.Now(),
+	}
+
+	redacted := make([]*pdfdoc.Page, len(pages))
+	for i, page := range pages {
+		areas, err := redactionAreas(page, finder, req.Regions)
+		if err != nil {
+			// Text that can't be read can't be found, so the copy would
+			// look redacted without being so
+			s.Log.Errorf("Failed to find text on page %d of PDF %s: %+v", page.Number, pdf.ID, err)
+			return nil, fiber.NewError(fiber.StatusUnprocessableEntity,
+				fmt.Sprintf("Page %d could not be read, so it can't be redacted safely", page.Number))
+		}
+		if len(areas) == 0 {
+			var annotations int
+			redacted[i], annotations = page.WithoutAnnotations()
+			report.AnnotationsRemoved += annotations
+			continue
+		}
+
+		rects := make([]pdfdoc.Rect, len(areas))
+		for j, area := range areas {
+			rects[j] = pdfdoc.Rect{X0: area.X0, Y0: area.Y0, X1: area.X1, Y1: area.Y1}
+		}
+		redactedPage, result, err := page.Redact(rects)
+		if err != nil {
+			s.Log.Errorf("Failed to redact page %d of PDF %s: %+v", page.Number, pdf.ID, err)
+			return nil, fiber.NewError(fiber.StatusUnprocessableEntity,
+				fmt.Sprintf("Page %d could not be read, so it can't be redacted safely", page.Number))
+		}
+
+		redacted[i] = redactedPage
+		report.Areas = append(report.Areas, areas...)
+		report.GlyphsRemoved += result.Glyphs
+		report.ImagesRemoved += result.Images
+		report.AnnotationsRemoved += result.Annotations
+	}
+
+	name := strings.TrimSuffix(pdf.OriginalFilename, filepath.Ext(pdf.OriginalFilename))
+	copied, err := s.storePages(name+"-redacted.pdf", redacted)
+	if err != nil {
+		return nil, err
+	}
+	copied.ParentID = &pdf.ID
+	copied.RedactionReport = report
+
+	if err := s.CreatePDF(c, copied); err != nil {
+		os.Remove(copied.FilePath)
+		return nil, err
+	}
+	return copied, nil
+}
+
+// requestRedactor finds the texts and personal data a redaction request
+// asks for, or returns nil when it only gives regions.
+func requestRedactor(req *validation.RedactPDF) *redact.Redactor {
+	var detectors []redact.Detector
+	for _, text := range req.Texts {
+		// Lines are searched with words one space apart
+		words := strings.Fields(text)
+		if len(words) == 0 {
+			continue
+		}
+		for i, word := range words {
+			words[i] = regexp.QuoteMeta(word)
+		}
+		detector, _ := redact.Custom("TEXT", "(?i)"+strings.Join(words, `\s+`))
+		detectors = append(detectors, detector)
+	}
+
+	for _, name := range req.Detectors {
+		detector, err := redact.Builtin(name)
+		if err == nil {
+			detectors = append(detectors, detector)
+		}
+	}
+
+	if len(detectors) == 0 {
+		return nil
+	}
+	return redact.New(detectors...)
+}
+
+// redactionAreas returns the areas of a page to redact: where finder finds
+// something in its text, and the regions requested for it.
+func redactionAreas(page *pdfdoc.Page, finder *redact.Redactor, regions []validation.RedactRegion) ([]model.RedactedArea, error) {
+	var areas []model.RedactedArea
+	if finder != nil {
+		found, err := page.FindText(func(line string) []pdfdoc.Span {
+			var spans []pdfdoc.Span
+			for _, m := range finder.Find(line) {
+				spans = append(spans, pdfdoc.Span{Start: m.Start, End: m.End, Tag: m.Label})
+			}
+			return spans
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, f := range found {
+			areas = append(areas, model.RedactedArea{
+				Page: page.Number, Kind: f.Tag,
+				X0: f.Area.X0, Y0: f.Area.Y0, X1: f.Area.X1, Y1: f.Area.Y1,
+			})
+		}
+	}
+
+	for _, region := range regions {
+		if region.Page == page.Number {
+			areas = append(areas, model.RedactedArea{
+				Page: page.Number, Kind: "REGION",
+				X0: region.X0, Y0: region.Y0, X1: region.X1, Y1: region.Y1,
+			})
+		}
+	}
+	return areas, nil
+}
@@ -0,0 +1,296 @@
+package service
+
+import (
+	"app/src/config"
+	"app/src/model"
+	"app/src/scanner"
+	"app/src/utils"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// scanJob is one stored file waiting for a malware scan.
+type scanJob struct {
+	PDFID    uuid.UUID
+	Version  int
+	FilePath string
+	// Failures counts the earlier scans of this file that failed.
+	Failures int
+}
+
+const (
+	scanWorkers   = 2
+	scanQueueSize = 100
+	scanAttempts  = 3
+	// scanPollInterval is how often queued summaries check on a scan.
+	scanPollInterval = 2 * time.Second
+	quarantineDir    = "./storage/quarantine"
+)
+
+// newMalwareScanner returns nil when no scanner is configured, in which case
+// uploads are marked as skipped instead of pending.
+func newMalwareScanner() scanner.Scanner {
+	if config.ClamdAddress == "" {
+		return nil
+	}
+
+	clamd, err := scanner.NewClamd(config.ClamdAddress, config.ScanTimeout)
+	if err != nil {
+		utils.Log.Errorf("Invalid CLAMD_ADDRESS, uploads will be marked as not scanned: %+v", err)
+		return nil
+	}
+	return clamd
+}
+
+func (s *pdfService) initialScanStatus() string {
+	if s.Scanner == nil {
+		return model.ScanStatusSkipped
+	}
+	return model.ScanStatusPending
+}
+
+// enqueueScan hands a newly stored file to the scan workers. A full queue
+// never drops a scan, the send just waits in the background.
+func (s *pdfService) enqueueScan(job scanJob) {
+	if s.Scanner == nil {
+		return
+	}
+
+	select {
+	case s.scanJobs <- job:
+	default:
+		go func() { s.scanJobs <- job }()
+	}
+}
+
+// resumeScans requeues files whose scan or scan retry was interrupted by a
+// restart, earlier versions included. Failed scans still waiting for their
+// retry are left to retryScans.
+func (s *pdfService) resumeScans() {
+	var versions []model.PDFVersion
+	err := s.DB.Where("scan_status = ?", model.ScanStatusPending).
+		Or("scan_status = ? AND next_scan_at IS NULL", model.ScanStatusError).
+		Find(&versions).Error
+	if err != nil {
+		s.Log.Errorf("Failed to load unscanned PDF versions: %+v", err)
+		return
+	}
+
+	for _, version := range versions {
+		s.scanJobs <- versionScanJob(version)
+	}
+}
+
+// retryScans requeues failed scans once their backoff has passed, so files
+// become available again soon after the scanner recovers.
+func (s *pdfService) retryScans() {
+	ctx := context.Background()
+	ticker := time.NewTicker(config.ScanRetryPollInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		jobs, err := s.claimScanRetries(ctx)
+		if err != nil {
+			s.Log.Errorf("Failed to load failed scans: %+v", err)
+			continue
+		}
+		for _, job := range jobs {
+			s.enqueueScan(job)
+		}
+	}
+}
+
+// claimScanRetries takes the failed scans that are due, of any version.
+// Clearing their next_scan_at keeps them from being taken again while
+// queued; the next failure sets it again. Locked rows are skipped, so
+// several instances can retry from the same table.
+func (s *pdfService) claimScanRetries(ctx context.Context) ([]scanJob, error) {
+	var versions []model.PDFVersion
+
+	err := s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("scan_status = ? AND next_scan_at <= ?", model.ScanStatusError, time.Now()).
+			Order("next_scan_at").
+			Limit(scanQueueSize).
+			Find(&versions).Error
+		if err != nil || len(versions) == 0 {
+			return err
+		}
+
+		ids := make([]uuid.UUID, len(versions))
+		for i := range versions {
+			ids[i] = versions[i].ID
+		}
+		return tx.Model(&model.PDFVersion{}).Where("id IN ?", ids).Update("next_scan_at", nil).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	jobs := make([]scanJob, len(versions))
+	for i, version := range versions {
+		jobs[i] = versionScanJob(version)
+	}
+	return jobs, nil
+}
+
+func versionScanJob(version model.PDFVersion) scanJob {
+	return scanJob{
+		PDFID:    version.PDFID,
+		Version:  version.Version,
+		FilePath: version.FilePath,
+		Failures: version.ScanFailures,
+	}
+}
+
+func (s *pdfService) runScanWorker() {
+	for job := range s.scanJobs {
+		s.scanFile(context.Background(), job)
+	}
+}
+
+func (s *pdfService) scanFile(ctx context.Context, job scanJob) {
+	var result *scanner.Result
+	var err error
+
+	for attempt := 1; attempt <= scanAttempts; attempt++ {
+		result, err = s.scanPath(ctx, job.FilePath)
+		if err == nil || errors.Is(err, os.ErrNotExist) {
+			break
+		}
+		s.Log.Warnf("Malware scan attempt %d for PDF %s failed: %+v", attempt, job.PDFID, err)
+		if attempt < scanAttempts {
+			time.Sleep(time.Duration(attempt*5) * time.Second)
+		}
+	}
+
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		// Deleted or replaced before its turn came
+		return
+	case err != nil:
+		s.Log.Errorf("Malware scan failed for PDF %s: %+v", job.PDFID, err)
+		s.setScanStatus(ctx, job, model.ScanStatusError, nil, "")
+	case result.Infected:
+		s.Log.Warnf("Malware %q found in PDF %s version %d", result.Signature, job.PDFID, job.Version)
+		s.quarantine(ctx, job, result.Signature)
+	default:
+		s.setScanStatus(ctx, job, model.ScanStatusClean, nil, "")
+		s.warmThumbnail(job.PDFID)
+	}
+}
+
+func (s *pdfService) scanPath(ctx context.Context, path string) (*scanner.Result, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return s.Scanner.Scan(ctx, file)
+}
+
+// quarantine moves an infected file out of storage, where nothing serves it,
+// and keeps it for inspection until the PDF is deleted.
+func (s *pdfService) quarantine(ctx context.Context, job scanJob, signature string) {
+	filePath := job.FilePath
+
+	if err := os.MkdirAll(quarantineDir, 0o700); err != nil {
+		s.Log.Errorf("Failed to create quarantine directory: %+v", err)
+	} else {
+		dest := filepath.Join(quarantineDir, filepath.Base(job.FilePath))
+		if err := os.Rename(job.FilePath, dest); err != nil {
+			s.Log.Errorf("Failed to quarantine PDF %s: %+v", job.PDFID, err)
+		} else {
+			os.Chmod(dest, 0o600)
+			filePath = dest
+		}
+	}
+
+	s.setScanStatus(ctx, job, model.ScanStatusInfected, &signature, filePath)
+}
+
+// setScanStatus records a verdict on the scanned version, and on the PDF too
+// when that version is still the current one. A non-empty filePath records
+// where the file was moved to. Failed scans are scheduled for a retry.
+func (s *pdfService) setScanStatus(ctx context.Context, job scanJob, status string, signature *string, filePath string) {
+	versionUpdates := map[string]interface{}{"scan_status": status}
+	pdfUpdates := map[string]interface{}{"scan_status": status, "scan_signature": signature}
+	if status == model.ScanStatusError {
+		failures := job.Failures + 1
+		delay := utils.Backoff(failures, config.ScanRetryBaseDelay, config.ScanRetryMaxDelay)
+		s.Log.Warnf("Retrying malware scan of PDF %s version %d in %s", job.PDFID, job.Version, delay)
+		versionUpdates["scan_failures"] = failures
+		versionUpdates["next_scan_at"] = time.Now().Add(delay)
+	} else {
+		versionUpdates["scan_failures"] = 0
+		versionUpdates["next_scan_at"] = nil
+	}
+	if filePath != "" {
+		versionUpdates["file_path"] = filePath
+		pdfUpdates["file_path"] = filePath
+	}
+
+	err := s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&model.PDFVersion{}).
+			Where("pdf_id = ? AND version = ?", job.PDFID, job.Version).
+			Updates(versionUpdates).Error; err != nil {
+			return err
+		}
+		return tx.Model(&model.PDF{}).
+			Where("id = ? AND version = ?", job.PDFID, job.Version).
+			Updates(pdfUpdates).Error
+	})
+	if err != nil {
+		s.Log.Errorf("Failed to save malware scan result for PDF %s: %+v", job.PDFID, err)
+	}
+}
+
+// waitForScan polls until the PDF's scan has finished and returns the PDF
+// as it is then.
+func (s *pdfService) waitForScan(ctx context.Context, id uuid.UUID) (*model.PDF, error) {
+	ctx, cancel := context.WithTimeout(ctx, scanAttempts*(config.ScanTimeout+15*time.Second))
+	defer cancel()
+
+	ticker := time.NewTicker(scanPollInterval)
+	defer ticker.Stop()
+
+	for {
+		pdf := new(model.PDF)
+		if err := s.DB.WithContext(ctx).First(pdf, "id = ?", id).Error; err != nil {
+			return nil, err
+		}
+		if pdf.ScanStatus != model.ScanStatusPending {
+			return pdf, nil
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return nil, fmt.Errorf("scan still pending: %w", ctx.Err())
+		}
+	}
+}
+
+// scanAccessError refuses access to files that are not known to be clean.
+// Files stored while scanning was disabled stay accessible.
+func scanAccessError(status string) error {
+	switch status {
+	case model.ScanStatusClean, model.ScanStatusSkipped:
+		return nil
+	case model.ScanStatusPending:
+		return fiber.NewError(fiber.StatusConflict, "PDF is still being scanned for malware, try again shortly")
+	case model.ScanStatusInfected:
+		return fiber.NewError(fiber.StatusForbidden, "PDF has been quarantined because malware was detected")
+	}
+	return fiber.NewError(fiber.StatusServiceUnavailable, "PDF could not be scanned for malware, try again later")
+}
@@ -1,20 +1,29 @@
 package service
 
 import (
+	"app/src/config"
 	"app/src/dto"
 	"app/src/model"
+	"app/src/ocr"
+	"app/src/pdfdoc"
+	"app/src/redact"
+	"app/src/render"
 	"app/src/response"
+	"app/src/scanner"
 	"app/src/utils"
 	"app/src/validation"
-	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"mime"
 	"mime/multipart"
+	"net"
 	"net/http"
+	"net/url"
 	"os"
+	"path"
 	"path/filepath"
 	"strings"
 	"time"
@@ -24,16 +33,43 @@ import (
 	"github.com/google/uuid"
 	"github.com/sirupsen/logrus"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type PDFService interface {
 	UploadPDF(c *fiber.Ctx) (*model.PDF, error)
+	ImportFile(c *fiber.Ctx, srcPath, originalFilename string) (*model.PDF, error)
+	ImportURL(c *fiber.Ctx, req *validation.ImportPDF) (*model.PDF, error)
+	StorePDF(originalFilename string, r io.Reader) (*model.PDF, error)
+	CreatePDF(c *fiber.Ctx, pdf *model.PDF) error
+	FindPDFByContentHash(c *fiber.Ctx, hash string) (*model.PDF, error)
+	UploadVersion(c *fiber.Ctx, id string) (*model.PDFVersion, error)
+	GetVersions(c *fiber.Ctx, id string) ([]model.PDFVersion, error)
+	GetVersion(c *fiber.Ctx, id string, number int) (*model.PDFVersion, error)
 	GetPDFs(c *fiber.Ctx, params *validation.QueryPDF) ([]model.PDF, int64, error)
 	GetPDFByID(c *fiber.Ctx, id string) (*model.PDF, error)
+	GetOutline(c *fiber.Ctx, id string, query *validation.QueryUnlockToken) (model.Outline, error)
+	GetFormFields(c *fiber.Ctx, id string, query *validation.QueryUnlockToken) (model.FormFields, error)
+	GetSignatures(c *fiber.Ctx, id string, query *validation.QuerySignatures) ([]model.PDFSignature, error)
+	GetTables(c *fiber.Ctx, id string, query *validation.QueryUnlockToken) ([]model.PDFTable, error)
+	GetTable(c *fiber.Ctx, id string, index int, query *validation.QueryUnlockToken) (*model.PDFTable, error)
+	SendTableCSV(c *fiber.Ctx, id string, index int, query *validation.QueryUnlockToken) error
 	DeletePDF(c *fiber.Ctx, id string) error
 	SummarizePDF(c *fiber.Ctx, id string, req *validation.SummarizeRequest) (*response.SummaryResponse, error)
+	EnqueueSummary(c *fiber.Ctx, id string, req *validation.SummarizeRequest) error
 	CancelSummarization(c *fiber.Ctx, id string) error
-	ViewPDF(c *fiber.Ctx, id string) error
+	ViewPDF(c *fiber.Ctx, id string, version int) error
+	DownloadPDF(c *fiber.Ctx, id string, version int) error
+	UnlockPDF(c *fiber.Ctx, id string, req *validation.UnlockPDF) (string, time.Time, error)
+	Thumbnail(c *fiber.Ctx, id string, page int, query *validation.QueryThumbnail) error
+	SplitPDF(c *fiber.Ctx, id string, req *validation.SplitPDF) ([]model.PDF, error)
+	MergePDFs(c *fiber.Ctx, req *validation.MergePDFs) (*model.PDF, error)
+	RedactPDF(c *fiber.Ctx, id string, req *validation.RedactPDF) (*model.PDF, error)
+	ExportSummaries(c *fiber.Ctx, query *validation.QueryExportSummaries) error
+	CreateExport(c *fiber.Ctx, user *model.User) (*model.AccountExport, error)
+	GetExport(c *fiber.Ctx, userID uuid.UUID, id string) (*model.AccountExport, error)
+	SendExport(c *fiber.Ctx, userID uuid.UUID, id string) error
+	ImportArchive(c *fiber.Ctx, query *validation.QueryImportArchive) ([]response.ArchiveImportResult, error)
 }
 
 type pdfService struct {
@@ -41,58 +77,396 @@ type pdfService struct {
 	DB                *gorm.DB
 	Validate          *validator.Validate
 	SummaryServiceURL string
+	SummaryClient     *http.Client
+	ImportClient      *http.Client
+	OCR               ocr.Provider
+	Scanner           scanner.Scanner
+	Renderer          render.Renderer
+	Redactor          *redact.Redactor
+	redactorErr       error
+	summaryJobs       chan summaryJob
+	scanJobs          chan scanJob
+	exportJobs        chan uuid.UUID
+	tableJobs         chan tableJob
+	renderSlots       chan struct{}
 }
 
+type summaryJob struct {
+	PDFID   string
+	Request validation.SummarizeRequest
+}
+
+const (
+	summaryWorkers   = 2
+	summaryQueueSize = 100
+)
+
 func NewPDFService(db *gorm.DB, validate *validator.Validate, summaryServiceURL string) PDFService {
-	return &pdfService{
+	s := &pdfService{
 		Log:               utils.Log,
 		DB:                db,
 		Validate:          validate,
 		SummaryServiceURL: summaryServiceURL,
+		SummaryClient:     newSummaryClient(),
+		ImportClient:      utils.NewPublicHTTPClient(config.ImportTimeout, config.ImportMaxRedirects),
+		OCR:               newOCRProvider(),
+		Scanner:           newMalwareScanner(),
+		Renderer:          render.NewPdftoppm(),
+		summaryJobs:       make(chan summaryJob, summaryQueueSize),
+		scanJobs:          make(chan scanJob, scanQueueSize),
+		exportJobs:        make(chan uuid.UUID, exportQueueSize),
+		tableJobs:         make(chan tableJob, tableQueueSize),
+		renderSlots:       make(chan struct{}, config.ThumbnailRenderers),
+	}
+
+	s.Redactor, s.redactorErr = newRedactor()
+	if s.redactorErr != nil {
+		s.Log.Errorf("Invalid PII redaction settings, summaries fail until they are fixed: %+v", s.redactorErr)
+	}
+
+	for i := 0; i < summaryWorkers; i++ {
+		go s.runSummaryWorker()
+	}
+	if s.Scanner != nil {
+		for i := 0; i < scanWorkers; i++ {
+			go s.runScanWorker()
+		}
+	}
+	for i := 0; i < config.TableDetectionWorkers; i++ {
+		go s.runTableWorker()
+	}
+	go s.runExportWorker()
+
+	// Under prefork every child process builds its own service. Only the
+	// parent picks up work left from a previous run, so it runs just once.
+	if !fiber.IsChild() {
+		go s.resumeSummaries()
+		if s.Scanner != nil {
+			go s.resumeScans()
+		}
+		go s.resumeTables()
+		go s.resumeExports()
+	}
+	if s.Scanner != nil {
+		go s.retryScans()
+	}
+
+	return s
+}
+
+// summaryResponseLimit bounds how much of a summarizer response is read.
+const summaryResponseLimit = 4 * 1024 * 1024
+
+// newSummaryClient builds the client shared by every summarization so
+// connections to the summarizer are pooled instead of redialled per attempt.
+func newSummaryClient() *http.Client {
+	transport := &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: (&net.Dialer{
+			Timeout:   10 * time.Second,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		MaxIdleConns:          100,
+		MaxIdleConnsPerHost:   32,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: 1 * time.Second,
+		WriteBufferSize:       32 * 1024,
+		ReadBufferSize:        32 * 1024,
+	}
+
+	return &http.Client{
+		Transport: transport,
+		Timeout:   120 * time.Second,
 	}
 }
 
 func (s *pdfService) UploadPDF(c *fiber.Ctx) (*model.PDF, error) {
-	file, err := c.FormFile("file")
+	pdf, err := s.receivePDF(c)
 	if err != nil {
-		s.Log.Errorf("Failed to get file from form: %+v", err)
-		return nil, fiber.NewError(fiber.StatusBadRequest, "File is required")
+		return nil, err
 	}
 
-	ext := filepath.Ext(file.Filename)
-	if ext != ".pdf" {
-		return nil, fiber.NewError(fiber.StatusBadRequest, "Only PDF files are allowed")
+	if err := s.CreatePDF(c, pdf); err != nil {
+		os.Remove(pdf.FilePath)
+		return nil, err
+	}
+	return pdf, nil
+}
+
+// importContentTypes are the Content-Type values accepted from a remote
+// server. Many hosts serve PDFs as generic binaries, so the body is sniffed
+// again by StorePDF either way.
+var importContentTypes = map[string]bool{
+	"application/pdf":          true,
+	"application/x-pdf":        true,
+	"application/octet-stream": true,
+	"binary/octet-stream":      true,
+}
+
+func (s *pdfService) ImportURL(c *fiber.Ctx, req *validation.ImportPDF) (*model.PDF, error) {
+	if err := s.Validate.Struct(req); err != nil {
+		return nil, err
+	}
+
+	sourceURL, err := url.Parse(req.URL)
+	if err != nil || (sourceURL.Scheme != "http" && sourceURL.Scheme != "https") || sourceURL.Host == "" {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "Only http and https URLs can be imported")
+	}
+
+	httpReq, err := http.NewRequestWithContext(c.Context(), http.MethodGet, sourceURL.String(), nil)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "Invalid URL")
+	}
+	httpReq.Header.Set("Accept", "application/pdf")
+
+	resp, err := s.ImportClient.Do(httpReq)
+	if errors.Is(err, utils.ErrBlockedAddress) {
+		s.Log.Warnf("Blocked import from %s: %+v", sourceURL.Redacted(), err)
+		return nil, fiber.NewError(fiber.StatusBadRequest, "URL points to an address that is not allowed")
+	}
+	if err != nil {
+		s.Log.Errorf("Failed to download %s: %+v", sourceURL.Redacted(), err)
+		return nil, fiber.NewError(fiber.StatusBadGateway, "Failed to download the file")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fiber.NewError(fiber.StatusBadGateway, fmt.Sprintf("Remote server responded with status %d", resp.StatusCode))
+	}
+
+	contentType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if !importContentTypes[contentType] {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "Invalid file type, only PDF files are allowed")
+	}
+
+	if resp.ContentLength > config.PDFMaxSize {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "File size exceeds the maximum limit of 10 MB. Please choose a smaller file.")
+	}
+
+	pdf, err := s.StorePDF(importFilename(resp), resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	pdf.SourceURL = &req.URL
+
+	if err := s.CreatePDF(c, pdf); err != nil {
+		os.Remove(pdf.FilePath)
+		return nil, err
+	}
+	return pdf, nil
+}
+
+// importFilename picks a name for a downloaded file from Content-Disposition
+// or the final URL path, making sure it ends in .pdf since the content itself
+// is checked on storage.
+func importFilename(resp *http.Response) string {
+	name := ""
+	if _, params, err := mime.ParseMediaType(resp.Header.Get("Content-Disposition")); err == nil {
+		name = params["filename"]
+	}
+	if name == "" {
+		name = path.Base(resp.Request.URL.Path)
+	}
+
+	name = strings.TrimSpace(filepath.Base(strings.ReplaceAll(name, `\`, "/")))
+	if name == "" || name == "." || name == "/" {
+		name = "document"
+	}
+	if !strings.EqualFold(filepath.Ext(name), ".pdf") {
+		name += ".pdf"
+	}
+	return strings.TrimSuffix(name, filepath.Ext(name)) + ".pdf"
+}
+
+func (s *pdfService) UploadVersion(c *fiber.Ctx, id string) (*model.PDFVersion, error) {
+	if _, err := s.GetPDFByID(c, id); err != nil {
+		return nil, err
+	}
+
+	file, err := s.receivePDF(c)
+	if err != nil {
+		return nil, err
+	}
+
+	version := &model.PDFVersion{
+		Filename:         file.Filename,
+		OriginalFilename: file.OriginalFilename,
+		FilePath:         file.FilePath,
+		FileSize:         file.FileSize,
+		ContentHash:      file.ContentHash,
+		ScanStatus:       file.ScanStatus,
+	}
+
+	err = s.DB.WithContext(c.Context()).Transaction(func(tx *gorm.DB) error {
+		pdf := new(model.PDF)
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(pdf, "id = ?", id).Error; err != nil {
+			return err
+		}
+
+		version.PDFID = pdf.ID
+		version.Version = pdf.Version + 1
+		if err := tx.Create(version).Error; err != nil {
+			return err
+		}
+
+		// Tables of the new file are detected in the background
+		if err := replaceTables(tx, pdf.ID, nil); err != nil {
+			return err
+		}
+
+		// Clearing the summary makes the trigger archive it in pdf_logs
+		// under the version it was generated from.
+		return tx.Model(&model.PDF{}).Where("id = ?", id).Updates(map[string]interface{}{
+			"filename":           version.Filename,
+			"original_filename":  version.OriginalFilename,
+			"file_path":          version.FilePath,
+			"file_size":          version.FileSize,
+			"content_hash":       version.ContentHash,
+			"spec_version":       file.SpecVersion,
+			"encrypted":          file.Encrypted,
+			"password_protected": file.PasswordProtected,
+			"ocr_used":           false,
+			"scan_status":        version.ScanStatus,
+			"scan_signature":     nil,
+			"page_count":         file.PageCount,
+			"table_count":        0,
+			"table_status":       model.TableStatusPending,
+			"title":              file.Title,
+			"author":             file.Author,
+			"subject":            file.Subject,
+			"producer":           file.Producer,
+			"creation_date":      file.CreationDate,
+			"outline":            file.Outline,
+			"form_fields":        file.FormFields,
+			"version":            version.Version,
+			"summary":            nil,
+			"chapter_summaries":  nil,
+			"summary_status":     "pending",
+			"summary_error":      nil,
+			"summary_request":    nil,
+			"upload_date":        version.CreatedAt,
+		}).Error
+	})
+	if err != nil {
+		os.Remove(version.FilePath)
+		s.Log.Errorf("Failed to create PDF version: %+v", err)
+		return nil, fiber.NewError(fiber.StatusInternalServerError, "Failed to save PDF version")
+	}
+
+	s.enqueueScan(scanJob{PDFID: version.PDFID, Version: version.Version, FilePath: version.FilePath})
+	if !file.PasswordProtected {
+		s.enqueueTables(tableJob{PDFID: version.PDFID, Version: version.Version, FilePath: version.FilePath})
+	}
+	s.warmThumbnail(version.PDFID)
+	return version, nil
+}
+
+func (s *pdfService) GetVersions(c *fiber.Ctx, id string) ([]model.PDFVersion, error) {
+	if _, err := s.GetPDFByID(c, id); err != nil {
+		return nil, err
+	}
+
+	var versions []model.PDFVersion
+	result := s.DB.WithContext(c.Context()).Where("pdf_id = ?", id).Order("version desc").Find(&versions)
+	if result.Error != nil {
+		s.Log.Errorf("Failed to get PDF versions: %+v", result.Error)
+		return nil, fiber.NewError(fiber.StatusInternalServerError, "Failed to get PDF versions")
+	}
+
+	return versions, nil
+}
+
+func (s *pdfService) GetVersion(c *fiber.Ctx, id string, number int) (*model.PDFVersion, error) {
+	version := new(model.PDFVersion)
+
+	result := s.DB.WithContext(c.Context()).First(version, "pdf_id = ? AND version = ?", id, number)
+
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return nil, fiber.NewError(fiber.StatusNotFound, "PDF version not found")
 	}
 
-	// Validate MIME type
-	fileReader, err := file.Open()
+	if result.Error != nil {
+		s.Log.Errorf("Failed to get PDF version: %+v", result.Error)
+		return nil, result.Error
+	}
+
+	return version, nil
+}
+
+func (s *pdfService) ImportFile(c *fiber.Ctx, srcPath, originalFilename string) (*model.PDF, error) {
+	file, err := os.Open(srcPath)
 	if err != nil {
 		s.Log.Errorf("Failed to open file: %+v", err)
 		return nil, fiber.NewError(fiber.StatusInternalServerError, "Failed to process file")
 	}
-	defer fileReader.Close()
+	pdf, err := s.StorePDF(originalFilename, file)
+	file.Close()
+	if err != nil {
+		return nil, err
+	}
 
-	buffer := make([]byte, 512)
-	n, err := fileReader.Read(buffer)
-	if err != nil && err != io.EOF {
-		s.Log.Errorf("Failed to read file: %+v", err)
-		return nil, fiber.NewError(fiber.StatusInternalServerError, "Failed to process file")
+	if err := s.CreatePDF(c, pdf); err != nil {
+		os.Remove(pdf.FilePath)
+		return nil, err
 	}
 
-	mimeType := http.DetectContentType(buffer[:n])
-	s.Log.Infof("Detected MIME type: %s for file: %s", mimeType, file.Filename)
-	if mimeType != "application/pdf" {
-		return nil, fiber.NewError(fiber.StatusBadRequest, "Invalid file type, only PDF files are allowed")
+	if err := os.Remove(srcPath); err != nil {
+		s.Log.Warnf("Failed to remove imported file %s: %+v", srcPath, err)
+	}
+	return pdf, nil
+}
+
+func (s *pdfService) CreatePDF(c *fiber.Ctx, pdf *model.PDF) error {
+	err := s.DB.WithContext(c.Context()).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(pdf).Error; err != nil {
+			return err
+		}
+
+		return tx.Create(&model.PDFVersion{
+			PDFID:            pdf.ID,
+			Version:          pdf.Version,
+			Filename:         pdf.Filename,
+			OriginalFilename: pdf.OriginalFilename,
+			FilePath:         pdf.FilePath,
+			FileSize:         pdf.FileSize,
+			ContentHash:      pdf.ContentHash,
+			ScanStatus:       pdf.ScanStatus,
+		}).Error
+	})
+	if err != nil {
+		s.Log.Errorf("Failed to create PDF record: %+v", err)
+		return fiber.NewError(fiber.StatusInternalServerError, "Failed to save PDF metadata")
+	}
+
+	s.enqueueScan(scanJob{PDFID: pdf.ID, Version: pdf.Version, FilePath: pdf.FilePath})
+	if !pdf.PasswordProtected {
+		s.enqueueTables(tableJob{PDFID: pdf.ID, Version: pdf.Version, FilePath: pdf.FilePath})
 	}
+	s.warmThumbnail(pdf.ID)
+	return nil
+}
 
-	maxSize := int64(10 * 1024 * 1024)
-	if file.Size > maxSize {
-		fileSizeMB := float64(file.Size) / (1024 * 1024)
-		return nil, fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("File size (%.2f MB) exceeds the maximum limit of 10 MB. Please choose a smaller file.", fileSizeMB))
+// receivePDF streams the "file" part of a multipart request into storage
+// without buffering the request body.
+func (s *pdfService) receivePDF(c *fiber.Ctx) (*model.PDF, error) {
+	part, err := utils.FormFileStream(c, "file")
+	if err != nil {
+		s.Log.Errorf("Failed to get file from form: %+v", err)
+		return nil, fiber.NewError(fiber.StatusBadRequest, "File is required")
 	}
+	defer part.Close()
 
-	uniqueID := uuid.New().String()
-	filename := fmt.Sprintf("%s%s", uniqueID, ext)
+	return s.StorePDF(part.FileName(), part)
+}
+
+// StorePDF validates and writes a PDF to storage in a single pass, returning
+// an unsaved record describing it.
+func (s *pdfService) StorePDF(originalFilename string, r io.Reader) (*model.PDF, error) {
+	ext := filepath.Ext(originalFilename)
+	if ext != ".pdf" {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "Only PDF files are allowed")
+	}
 
 	storageDir := "./storage/pdf"
 	if err := os.MkdirAll(storageDir, os.ModePerm); err != nil {
@@ -100,29 +474,99 @@ func (s *pdfService) UploadPDF(c *fiber.Ctx) (*model.PDF, error) {
 		return nil, fiber.NewError(fiber.StatusInternalServerError, "Failed to create storage directory")
 	}
 
+	filename := fmt.Sprintf("%s%s", uuid.New().String(), ext)
 	filePath := filepath.Join(storageDir, filename)
 
-	if err := c.SaveFile(file, filePath); err != nil {
+	stored, err := utils.StoreStream(r, filePath, config.PDFMaxSize, "application/pdf")
+	if errors.Is(err, utils.ErrInvalidFileType) {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "Invalid file type, only PDF files are allowed")
+	}
+	if errors.Is(err, utils.ErrFileTooLarge) {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "File size exceeds the maximum limit of 10 MB. Please choose a smaller file.")
+	}
+	if err != nil {
 		s.Log.Errorf("Failed to save file: %+v", err)
 		return nil, fiber.NewError(fiber.StatusInternalServerError, "Failed to save file")
 	}
 
+	s.Log.Infof("Stored %s (%d bytes, sha256 %s)", originalFilename, stored.Size, stored.Hash)
+
 	pdf := &model.PDF{
 		Filename:         filename,
-		OriginalFilename: file.Filename,
-		FilePath:         filePath,
-		FileSize:         file.Size,
+		OriginalFilename: originalFilename,
+		FilePath:         stored.Path,
+		FileSize:         stored.Size,
+		ContentHash:      stored.Hash,
+		Version:          1,
+		ScanStatus:       s.initialScanStatus(),
+		TableStatus:      model.TableStatusPending,
 	}
 
-	result := s.DB.WithContext(c.Context()).Create(pdf)
-	if result.Error != nil {
-		os.Remove(filePath)
-		s.Log.Errorf("Failed to create PDF record: %+v", result.Error)
-		return nil, fiber.NewError(fiber.StatusInternalServerError, "Failed to save PDF metadata")
+	if err := s.inspectPDF(pdf); err != nil {
+		os.Remove(pdf.FilePath)
+		return nil, err
 	}
+
 	return pdf, nil
 }
 
+// inspectPDF parses a stored file beyond its magic bytes, rejecting anything
+// the summarizer would choke on, and copies its page count and document
+// metadata onto the record.
+func (s *pdfService) inspectPDF(pdf *model.PDF) error {
+	doc, err := pdfdoc.OpenFile(pdf.FilePath)
+	if errors.Is(err, pdfdoc.ErrPasswordRequired) {
+		// Pages and metadata can only be read once the password is supplied
+		pdf.Encrypted = true
+		pdf.PasswordProtected = true
+		return nil
+	}
+	if err == nil {
+		err = doc.Check()
+	}
+	if err != nil {
+		s.Log.Warnf("Rejected %s: %+v", pdf.OriginalFilename, err)
+		return fiber.NewError(fiber.StatusBadRequest, invalidPDFMessage(err))
+	}
+
+	pdf.Encrypted = doc.Encrypted()
+	applyDocumentInfo(pdf, doc)
+
+	return nil
+}
+
+func applyDocumentInfo(pdf *model.PDF, doc *pdfdoc.Document) {
+	meta := doc.Metadata()
+	pdf.SpecVersion = doc.Version
+	pdf.PageCount = doc.NumPages()
+	pdf.Title = optionalString(meta.Title)
+	pdf.Author = optionalString(meta.Author)
+	pdf.Subject = optionalString(meta.Subject)
+	pdf.Producer = optionalString(meta.Producer)
+	pdf.CreationDate = meta.CreationDate
+	pdf.Outline = documentOutline(doc)
+	pdf.FormFields = documentFormFields(doc)
+}
+
+func invalidPDFMessage(err error) string {
+	switch {
+	case errors.Is(err, pdfdoc.ErrUnsupportedEncryption):
+		return "PDFs encrypted with certificates or custom security handlers are not supported"
+	case errors.Is(err, pdfdoc.ErrNotPDF),
+		errors.Is(err, pdfdoc.ErrTruncated),
+		errors.Is(err, pdfdoc.ErrCorrupt):
+		return fmt.Sprintf("Invalid PDF file: %v", err)
+	}
+	return "Invalid PDF file: the file could not be read"
+}
+
+func optionalString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
 func (s *pdfService) GetPDFs(c *fiber.Ctx, params *validation.QueryPDF) ([]model.PDF, int64, error) {
 	var pdfs []model.PDF
 	var totalResults int64
@@ -170,16 +614,53 @@ func (s *pdfService) GetPDFByID(c *fiber.Ctx, id string) (*model.PDF, error) {
 	return pdf, nil
 }
 
+// FindPDFByContentHash returns the PDF whose current version has the given
+// content hash, or nil when there is none.
+func (s *pdfService) FindPDFByContentHash(c *fiber.Ctx, hash string) (*model.PDF, error) {
+	pdf := new(model.PDF)
+
+	result := s.DB.WithContext(c.Context()).Where("content_hash = ?", hash).Limit(1).Find(pdf)
+	if result.Error != nil {
+		s.Log.Errorf("Failed to get PDF by content hash: %+v", result.Error)
+		return nil, result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return nil, nil
+	}
+
+	return pdf, nil
+}
+
 func (s *pdfService) DeletePDF(c *fiber.Ctx, id string) error {
 	pdf, err := s.GetPDFByID(c, id)
 	if err != nil {
 		return err
 	}
 
-	if err := os.Remove(pdf.FilePath); err != nil {
-		if !os.IsNotExist(err) {
-			s.Log.Errorf("Failed to delete file: %+v", err)
-			return fiber.NewError(fiber.StatusInternalServerError, "Failed to delete file")
+	var versions []model.PDFVersion
+	if err := s.DB.WithContext(c.Context()).Where("pdf_id = ?", id).Find(&versions).Error; err != nil {
+		s.Log.Errorf("Failed to get PDF versions: %+v", err)
+		return fiber.NewError(fiber.StatusInternalServerError, "Failed to delete file")
+	}
+
+	filePaths := []string{pdf.FilePath}
+	thumbnailKeys := []string{thumbnailKey(pdf)}
+	for _, version := range versions {
+		if version.FilePath != pdf.FilePath {
+			filePaths = append(filePaths, version.FilePath)
+		}
+		if version.ContentHash != "" && version.ContentHash != pdf.ContentHash {
+			thumbnailKeys = append(thumbnailKeys, version.ContentHash)
+		}
+	}
+
+	for _, filePath := range filePaths {
+		if err := os.Remove(filePath); err != nil {
+			if !os.IsNotExist(err) {
+				s.Log.Errorf("Failed to delete file: %+v", err)
+				return fiber.NewError(fiber.StatusInternalServerError, "Failed to delete file")
+			}
 		}
 	}
 
@@ -188,16 +669,14 @@ func (s *pdfService) DeletePDF(c *fiber.Ctx, id string) error {
 		s.Log.Errorf("Failed to delete PDF record: %+v", result.Error)
 		return fiber.NewError(fiber.StatusInternalServerError, "Failed to delete PDF record")
 	}
+
+	s.removeThumbnails(thumbnailKeys...)
 	return nil
 }
 
+// SummarizePDF summarizes a PDF while the request waits. PDFs with pages
+// that need OCR are queued instead, and no summary is returned for them.
 func (s *pdfService) SummarizePDF(c *fiber.Ctx, id string, req *validation.SummarizeRequest) (*response.SummaryResponse, error) {
-	startTime := time.Now()
-
-	// Create cancellable context
-	ctx, cancel := context.WithCancel(c.Context())
-	defer cancel()
-
 	// 1. Validate request
 	if err := s.Validate.Struct(req); err != nil {
 		return nil, fiber.NewError(fiber.StatusBadRequest, "Invalid request data")
@@ -209,8 +688,179 @@ func (s *pdfService) SummarizePDF(c *fiber.Ctx, id string, req *validation.Summa
 		return nil, err
 	}
 
-	// 3. Set status to processing
+	if err := scanAccessError(pdf.ScanStatus); err != nil {
+		return nil, err
+	}
+
+	password, err := s.documentPassword(pdf, req.UnlockToken)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkSummaryMode(pdf, req); err != nil {
+		return nil, err
+	}
+
+	// OCR of scanned pages takes too long to hold the request open, so
+	// those PDFs are summarized in the background instead. Text found
+	// here is stored and not extracted again by summarize.
+	if _, err := s.pageTexts(c.Context(), pdf, password, false); errors.Is(err, errOCRNeeded) {
+		return nil, s.EnqueueSummary(c, id, req)
+	}
+
+	return s.summarize(c.Context(), pdf, req)
+}
+
+func (s *pdfService) EnqueueSummary(c *fiber.Ctx, id string, req *validation.SummarizeRequest) error {
+	if err := s.Validate.Struct(req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid request data")
+	}
+
+	pdf, err := s.GetPDFByID(c, id)
+	if err != nil {
+		return err
+	}
+
+	// Workers wait for scans still running, see runSummaryWorker
+	if pdf.ScanStatus != model.ScanStatusPending {
+		if err := scanAccessError(pdf.ScanStatus); err != nil {
+			return err
+		}
+	}
+
+	if _, err := s.documentPassword(pdf, req.UnlockToken); err != nil {
+		return err
+	}
+
+	if err := checkSummaryMode(pdf, req); err != nil {
+		return err
+	}
+
+	// Mark as queued before handing over so a fast worker can't be overwritten.
+	// The request is kept to queue the job again after a restart; the unlock
+	// token only travels with the job.
 	if err := s.DB.WithContext(c.Context()).Model(&model.PDF{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"summary_status": "queued",
+		"summary_error":  nil,
+		"summary_request": &model.SummaryRequest{
+			Language:   req.Language,
+			OutputType: req.OutputType,
+			Mode:       req.Mode,
+		},
+	}).Error; err != nil {
+		s.Log.Errorf("Failed to set queued status: %+v", err)
+		return fiber.NewError(fiber.StatusInternalServerError, "Failed to queue summarization")
+	}
+
+	select {
+	case s.summaryJobs <- summaryJob{PDFID: id, Request: *req}:
+		return nil
+	default:
+		s.DB.WithContext(c.Context()).Model(&model.PDF{}).Where("id = ?", id).Updates(map[string]interface{}{
+			"summary_status":  "pending",
+			"summary_request": nil,
+		})
+		return fiber.NewError(fiber.StatusServiceUnavailable, "Summarization queue is full")
+	}
+}
+
+// resumeSummaries requeues summaries that were queued or running when the
+// service stopped. Those started by a request that is gone with it, which
+// left no request behind, are marked as failed, and so are those of
+// protected PDFs, whose password went with the job.
+func (s *pdfService) resumeSummaries() {
+	ctx := context.Background()
+
+	var pdfs []model.PDF
+	if err := s.DB.WithContext(ctx).Where("summary_status IN ?", []string{"queued", "processing"}).Find(&pdfs).Error; err != nil {
+		s.Log.Errorf("Failed to load unfinished summaries: %+v", err)
+		return
+	}
+
+	for _, pdf := range pdfs {
+		id := pdf.ID.String()
+		if pdf.SummaryRequest == nil {
+			s.setFailedStatus(ctx, id, "Interrupted by a restart")
+			continue
+		}
+		if pdf.PasswordProtected {
+			s.setFailedStatus(ctx, id, "Interrupted by a restart, unlock the PDF again to summarize it")
+			continue
+		}
+
+		if err := s.DB.WithContext(ctx).Model(&model.PDF{}).Where("id = ?", id).Update("summary_status", "queued").Error; err != nil {
+			s.Log.Errorf("Failed to requeue summarization for PDF %s: %+v", id, err)
+			continue
+		}
+		s.summaryJobs <- summaryJob{PDFID: id, Request: validation.SummarizeRequest{
+			Language:   pdf.SummaryRequest.Language,
+			OutputType: pdf.SummaryRequest.OutputType,
+			Mode:       pdf.SummaryRequest.Mode,
+		}}
+	}
+}
+
+// runSummaryWorker processes queued summarizations outside of any request.
+// Jobs whose PDF was cancelled or deleted while waiting are skipped.
+func (s *pdfService) runSummaryWorker() {
+	for job := range s.summaryJobs {
+		ctx := context.Background()
+
+		pdf := new(model.PDF)
+		if err := s.DB.WithContext(ctx).First(pdf, "id = ?", job.PDFID).Error; err != nil {
+			s.Log.Warnf("Skipping queued summarization for PDF %s: %+v", job.PDFID, err)
+			continue
+		}
+
+		if pdf.SummaryStatus != "queued" {
+			continue
+		}
+
+		if pdf.ScanStatus == model.ScanStatusPending {
+			scanned, err := s.waitForScan(ctx, pdf.ID)
+			if err != nil {
+				s.Log.Errorf("Failed to wait for malware scan of PDF %s: %+v", job.PDFID, err)
+				s.setFailedStatus(ctx, job.PDFID, "Malware scan did not finish")
+				continue
+			}
+			pdf = scanned
+		}
+
+		if _, err := s.summarize(ctx, pdf, &job.Request); err != nil {
+			s.Log.Errorf("Queued summarization failed for PDF %s: %+v", job.PDFID, err)
+		}
+	}
+}
+
+func (s *pdfService) summarize(parent context.Context, pdf *model.PDF, req *validation.SummarizeRequest) (*response.SummaryResponse, error) {
+	startTime := time.Now()
+	id := pdf.ID.String()
+
+	// Create cancellable context
+	ctx, cancel := context.WithCancel(parent)
+	defer cancel()
+
+	if err := scanAccessError(pdf.ScanStatus); err != nil {
+		var fiberErr *fiber.Error
+		if errors.As(err, &fiberErr) {
+			s.setFailedStatus(parent, id, fiberErr.Message)
+		}
+		return nil, err
+	}
+
+	// The unlock token may have expired while the job was queued
+	password, err := s.documentPassword(pdf, req.UnlockToken)
+	if err != nil {
+		var fiberErr *fiber.Error
+		if errors.As(err, &fiberErr) {
+			s.setFailedStatus(parent, id, fiberErr.Message)
+		}
+		return nil, err
+	}
+	opts := &summarizeOptions{Password: password, FormFields: formFieldContext(pdf.FormFields)}
+
+	// 3. Set status to processing
+	if err := s.DB.WithContext(parent).Model(&model.PDF{}).Where("id = ?", id).Updates(map[string]interface{}{
 		"summary_status": "processing",
 		"summary_error":  nil,
 	}).Error; err != nil {
@@ -225,109 +875,185 @@ func (s *pdfService) SummarizePDF(c *fiber.Ctx, id string, req *validation.Summa
 		"upload_date": time.Now(),
 	}
 
-	if err := s.DB.WithContext(c.Context()).Model(&model.PDF{}).Where("id = ?", id).Updates(updates).Error; err != nil {
+	if err := s.DB.WithContext(parent).Model(&model.PDF{}).Where("id = ?", id).Updates(updates).Error; err != nil {
 		s.Log.Errorf("Failed to update PDF config: %+v", err)
 		return nil, fiber.NewError(fiber.StatusInternalServerError, "Failed to update configuration")
 	}
 
-	// 5. Read PDF file
-	fileContent, err := os.ReadFile(pdf.FilePath)
-	if err != nil {
-		s.Log.Errorf("Failed to read file: %+v", err)
-		s.setFailedStatus(c, id, "PDF file not found")
+	// 5. Check PDF file, it is streamed to the summarizer on each attempt
+	if _, err := os.Stat(pdf.FilePath); err != nil {
+		s.Log.Errorf("Failed to stat file: %+v", err)
+		s.setFailedStatus(parent, id, "PDF file not found")
 		return nil, fiber.NewError(fiber.StatusNotFound, "PDF file not found")
 	}
 
-	// 6. Retry logic
+	// Scanned pages have no text layer for the summarizer to read
+	pages, err := s.pageTexts(ctx, pdf, password, true)
+	if ctx.Err() != nil {
+		s.setFailedStatus(parent, id, "Cancelled by user")
+		return nil, fiber.NewError(fiber.StatusRequestTimeout, "Summarization cancelled")
+	}
+	if err != nil {
+		s.Log.Warnf("Failed to extract page text for PDF %s: %+v", id, err)
+	} else if pdf.OCRUsed {
+		opts.Text = joinPageTexts(pages)
+	}
+
+	if err := s.redactOptions(opts, pages); err != nil {
+		var fiberErr *fiber.Error
+		if errors.As(err, &fiberErr) {
+			s.setFailedStatus(parent, id, fiberErr.Message)
+		}
+		return nil, err
+	}
+	if opts.Redactions != nil && opts.Redactions.Len() > 0 {
+		s.Log.Infof("Redacted %d values from PDF %s before summarizing", opts.Redactions.Len(), id)
+	}
+
+	var chapters model.ChapterSummaries
+	if req.Mode == SummaryModeChapters {
+		if pages == nil {
+			s.setFailedStatus(parent, id, "Failed to read the PDF's pages")
+			return nil, fiber.NewError(fiber.StatusInternalServerError, "Failed to read the PDF's pages")
+		}
+		chapters, err = s.summarizeChapters(ctx, parent, pdf, req, opts, pages)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	pythonResp, err := s.requestSummary(ctx, parent, pdf, req, opts)
+	if err != nil {
+		return nil, err
+	}
+	summaryText := restoreRedactions(pythonResp.SummaryText, opts)
+
+	if err := s.DB.WithContext(parent).Model(&model.PDF{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"summary":           summaryText,
+		"chapter_summaries": chapters,
+		"summary_status":    "completed",
+		"summary_error":     nil,
+		"summary_request":   nil,
+	}).Error; err != nil {
+		s.Log.Errorf("Failed to save summary: %+v", err)
+		return nil, fiber.NewError(fiber.StatusInternalServerError, "Failed to save summary")
+	}
+
+	return &response.SummaryResponse{
+		PDFID:            pdf.ID,
+		OriginalFilename: pdf.OriginalFilename,
+		SummaryText:      summaryText,
+		Chapters:         chapters,
+		Redacted:         redactedCounts(opts),
+		Language:         req.Language,
+		OutputType:       req.OutputType,
+		ProcessingTimeMs: int(time.Since(startTime).Milliseconds()),
+		GeneratedAt:      time.Now(),
+	}, nil
+}
+
+// requestSummary calls the summarizer, retrying failures that may pass.
+// When it gives up the PDF is marked as failed.
+func (s *pdfService) requestSummary(
+	ctx, parent context.Context, pdf *model.PDF, req *validation.SummarizeRequest, opts *summarizeOptions,
+) (*dto.PythonSummarizeResponse, error) {
+	id := pdf.ID.String()
+
 	maxRetries := 3
 	var lastError error
 	for attempt := 1; attempt <= maxRetries; attempt++ {
 		s.Log.Infof("Summarization attempt %d for PDF %s", attempt, id)
 
-		pythonResp, err := s.callPythonService(ctx, pdf, fileContent, req)
-		if err != nil {
-			lastError = err
-			if s.isPermanentError(err) {
-				s.Log.Errorf("Permanent error on attempt %d: %+v", attempt, err)
-				s.setFailedStatus(c, id, err.Error())
-				return nil, err
-			}
-			if attempt < maxRetries {
-				waitTime := time.Duration(attempt*5) * time.Second
-				s.Log.Infof("Retrying in %v...", waitTime)
-				select {
-				case <-time.After(waitTime):
-				case <-ctx.Done():
-					s.Log.Info("Summarization cancelled")
-					s.setFailedStatus(c, id, "Cancelled by user")
-					return nil, fiber.NewError(fiber.StatusRequestTimeout, "Summarization cancelled")
-				}
-				continue
-			}
-		} else {
-			if err := s.DB.WithContext(c.Context()).Model(&model.PDF{}).Where("id = ?", id).Updates(map[string]interface{}{
-				"summary":        pythonResp.SummaryText,
-				"summary_status": "completed",
-				"summary_error":  nil,
-			}).Error; err != nil {
-				s.Log.Errorf("Failed to save summary: %+v", err)
-				return nil, fiber.NewError(fiber.StatusInternalServerError, "Failed to save summary")
-			}
+		pythonResp, err := s.callPythonService(ctx, pdf, req, opts)
+		if err == nil {
+			return pythonResp, nil
+		}
 
-			return &response.SummaryResponse{
-				PDFID:            pdf.ID,
-				OriginalFilename: pdf.OriginalFilename,
-				SummaryText:      pythonResp.SummaryText,
-				Language:         req.Language,
-				OutputType:       req.OutputType,
-				ProcessingTimeMs: int(time.Since(startTime).Milliseconds()),
-				GeneratedAt:      time.Now(),
-			}, nil
+		lastError = err
+		if s.isPermanentError(err) {
+			s.Log.Errorf("Permanent error on attempt %d: %+v", attempt, err)
+			s.setFailedStatus(parent, id, err.Error())
+			return nil, err
+		}
+		if attempt < maxRetries {
+			waitTime := time.Duration(attempt*5) * time.Second
+			s.Log.Infof("Retrying in %v...", waitTime)
+			select {
+			case <-time.After(waitTime):
+			case <-ctx.Done():
+				s.Log.Info("Summarization cancelled")
+				s.setFailedStatus(parent, id, "Cancelled by user")
+				return nil, fiber.NewError(fiber.StatusRequestTimeout, "Summarization cancelled")
+			}
 		}
 	}
 
 	// All retries failed
 	errorMsg := fmt.Sprintf("Failed after %d attempts: %v", maxRetries, lastError)
-	s.Log.Errorf(errorMsg)
-	s.setFailedStatus(c, id, errorMsg)
+	s.Log.Error(errorMsg)
+	s.setFailedStatus(parent, id, errorMsg)
 	return nil, fiber.NewError(fiber.StatusServiceUnavailable, "Summarization failed after retries")
 }
 
-func (s *pdfService) callPythonService(ctx context.Context, pdf *model.PDF, fileContent []byte, req *validation.SummarizeRequest) (*dto.PythonSummarizeResponse, error) {
-	// Prepare multipart form
-	body := &bytes.Buffer{}
-	writer := multipart.NewWriter(body)
+// summarizeOptions carries per-job inputs for the summarizer that are never
+// stored, such as the password of a protected PDF.
+type summarizeOptions struct {
+	Password string
+	// Text replaces the summarizer's own extraction when set.
+	Text string
+	// FormFields lists the values filled into the PDF's form, if any.
+	FormFields string
+	// Redactions holds what was redacted from Text and FormFields, or nil
+	// when redaction is off.
+	Redactions *redact.Redactions
+}
 
-	// Kirim file
-	part, err := writer.CreateFormFile("file", pdf.OriginalFilename)
-	if err != nil {
-		return nil, fiber.NewError(fiber.StatusInternalServerError, "Failed to create form")
+func (s *pdfService) callPythonService(ctx context.Context, pdf *model.PDF, req *validation.SummarizeRequest, opts *summarizeOptions) (*dto.PythonSummarizeResponse, error) {
+	// Reopened on every attempt, a retry must not reuse a half-read file.
+	// With redaction on, only the redacted text may leave, never the file.
+	var file io.ReadCloser
+	if opts.Redactions == nil {
+		f, err := os.Open(pdf.FilePath)
+		if err != nil {
+			s.Log.Errorf("Failed to open file: %+v", err)
+			return nil, fiber.NewError(fiber.StatusNotFound, "PDF file not found")
+		}
+		file = f
 	}
-	part.Write(fileContent)
 
-	writer.WriteField("pdf_id", pdf.ID.String())
-	writer.WriteField("original_filename", pdf.OriginalFilename)
-	writer.WriteField("file_size", fmt.Sprintf("%d", pdf.FileSize))
-	writer.WriteField("language", req.Language)
-	writer.WriteField("output_type", req.OutputType)
+	// Stream the multipart form through a pipe so the file is never held in memory
+	body, bodyWriter := io.Pipe()
+	writer := multipart.NewWriter(bodyWriter)
 
-	writer.Close()
+	go func() {
+		if file != nil {
+			defer file.Close()
+		}
+		bodyWriter.CloseWithError(writeSummarizeForm(writer, file, pdf, req, opts))
+	}()
 
 	httpReq, err := http.NewRequestWithContext(ctx, "POST", s.SummaryServiceURL+"/summarize", body)
 	if err != nil {
+		body.Close()
 		return nil, fiber.NewError(fiber.StatusInternalServerError, "Failed to create request")
 	}
 
 	httpReq.Header.Set("Content-Type", writer.FormDataContentType())
 
-	client := &http.Client{Timeout: 120 * time.Second}
-	httpResp, err := client.Do(httpReq)
+	httpResp, err := s.SummaryClient.Do(httpReq)
 	if err != nil {
+		s.Log.Errorf("Failed to call summarization service: %+v", err)
 		return nil, fiber.NewError(fiber.StatusServiceUnavailable, "Summarization service unavailable")
 	}
 	defer httpResp.Body.Close()
 
-	respBody, _ := io.ReadAll(httpResp.Body)
+	respBody, err := io.ReadAll(io.LimitReader(httpResp.Body, summaryResponseLimit+1))
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusServiceUnavailable, "Failed to read response")
+	}
+	if len(respBody) > summaryResponseLimit {
+		return nil, fiber.NewError(fiber.StatusInternalServerError, "Summarization response is too large")
+	}
 
 	var pythonResp dto.PythonSummarizeResponse
 	if err := json.Unmarshal(respBody, &pythonResp); err != nil {
@@ -344,34 +1070,227 @@ func (s *pdfService) callPythonService(ctx context.Context, pdf *model.PDF, file
 	return &pythonResp, nil
 }
 
+// writeSummarizeForm writes the summarizer's form. file is nil when only
+// text is sent, in which case the password isn't needed either.
+func writeSummarizeForm(writer *multipart.Writer, file io.Reader, pdf *model.PDF, req *validation.SummarizeRequest, opts *summarizeOptions) error {
+	fields := [][2]string{
+		{"pdf_id", pdf.ID.String()},
+		{"original_filename", pdf.OriginalFilename},
+		{"file_size", fmt.Sprintf("%d", pdf.FileSize)},
+		{"language", req.Language},
+		{"output_type", req.OutputType},
+	}
+	if opts.Password != "" && file != nil {
+		fields = append(fields, [2]string{"password", opts.Password})
+	}
+	if opts.Text != "" {
+		fields = append(fields, [2]string{"text", opts.Text})
+	}
+	if opts.FormFields != "" {
+		fields = append(fields, [2]string{"form_fields", opts.FormFields})
+	}
+	for _, field := range fields {
+		if err := writer.WriteField(field[0], field[1]); err != nil {
+			return err
+		}
+	}
+
+	// Kirim file
+	if file != nil {
+		part, err := writer.CreateFormFile("file", pdf.OriginalFilename)
+		if err != nil {
+			return err
+		}
+		if _, err := io.Copy(part, file); err != nil {
+			return err
+		}
+	}
+
+	return writer.Close()
+}
+
 func (s *pdfService) isPermanentError(err error) bool {
 	if e, ok := err.(*fiber.Error); ok {
-		return e.Code == fiber.StatusBadRequest || e.Code == fiber.StatusTooManyRequests
+		return e.Code == fiber.StatusBadRequest || e.Code == fiber.StatusNotFound ||
+			e.Code == fiber.StatusTooManyRequests
 	}
 	return false
 }
 
-func (s *pdfService) setFailedStatus(c *fiber.Ctx, id string, errorMsg string) {
-	s.DB.WithContext(c.Context()).Model(&model.PDF{}).Where("id = ?", id).Updates(map[string]interface{}{
-		"summary_status": "failed",
-		"summary_error":  errorMsg,
+func (s *pdfService) setFailedStatus(ctx context.Context, id string, errorMsg string) {
+	s.DB.WithContext(ctx).Model(&model.PDF{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"summary_status":  "failed",
+		"summary_error":   errorMsg,
+		"summary_request": nil,
 	})
 }
 
-func (s *pdfService) ViewPDF(c *fiber.Ctx, id string) error {
+func (s *pdfService) ViewPDF(c *fiber.Ctx, id string, version int) error {
+	return s.sendPDF(c, id, version, false)
+}
+
+func (s *pdfService) DownloadPDF(c *fiber.Ctx, id string, version int) error {
+	return s.sendPDF(c, id, version, true)
+}
+
+// sendPDF serves the current file of a PDF, or an earlier version of it, with
+// range and conditional request support. Version 0 means the current one.
+func (s *pdfService) sendPDF(c *fiber.Ctx, id string, version int, attachment bool) error {
 	pdf, err := s.GetPDFByID(c, id)
 	if err != nil {
 		return err
 	}
 
-	if _, err := os.Stat(pdf.FilePath); os.IsNotExist(err) {
-		return fiber.NewError(fiber.StatusNotFound, "PDF file not found")
+	file := utils.FileResponse{
+		Path:        pdf.FilePath,
+		Filename:    pdf.OriginalFilename,
+		ContentType: "application/pdf",
+		ETag:        pdf.ContentHash,
+		Attachment:  attachment,
+	}
+	scanStatus := pdf.ScanStatus
+
+	if version > 0 && version != pdf.Version {
+		v, err := s.GetVersion(c, id, version)
+		if err != nil {
+			return err
+		}
+		file.Path, file.Filename, file.ETag = v.FilePath, v.OriginalFilename, v.ContentHash
+		scanStatus = v.ScanStatus
+	}
+
+	if err := scanAccessError(scanStatus); err != nil {
+		return err
+	}
+
+	if err := utils.SendFile(c, file); err != nil {
+		var fiberErr *fiber.Error
+		if errors.As(err, &fiberErr) && fiberErr.Code == fiber.StatusNotFound {
+			return fiber.NewError(fiber.StatusNotFound, "PDF file not found")
+		}
+		s.Log.Errorf("Failed to send PDF file: %+v", err)
+		return fiber.NewError(fiber.StatusInternalServerError, "Failed to read PDF file")
+	}
+	return nil
+}
+
+// unlockPurpose scopes sealed unlock tokens so no other sealed token can be
+// used in their place.
+const unlockPurpose = "pdf-unlock"
+
+// unlockClaims is sealed into the token returned by UnlockPDF. The password
+// only ever exists encrypted inside the token held by the client, and the
+// content hash ties it to the file version it was checked against.
+type unlockClaims struct {
+	PDFID       string    `json:"pdf_id"`
+	ContentHash string    `json:"content_hash"`
+	Password    string    `json:"password"`
+	ExpiresAt   time.Time `json:"expires_at"`
+}
+
+func (s *pdfService) UnlockPDF(c *fiber.Ctx, id string, req *validation.UnlockPDF) (string, time.Time, error) {
+	if err := s.Validate.Struct(req); err != nil {
+		return "", time.Time{}, err
+	}
+
+	pdf, err := s.GetPDFByID(c, id)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	if !pdf.PasswordProtected {
+		return "", time.Time{}, fiber.NewError(fiber.StatusBadRequest, "PDF is not password protected")
+	}
+
+	doc, err := pdfdoc.OpenFileWithPassword(pdf.FilePath, req.Password)
+	if errors.Is(err, pdfdoc.ErrIncorrectPassword) {
+		return "", time.Time{}, fiber.NewError(fiber.StatusBadRequest, "Incorrect PDF password")
+	}
+	if err != nil {
+		s.Log.Errorf("Failed to open protected PDF: %+v", err)
+		return "", time.Time{}, fiber.NewError(fiber.StatusBadRequest, invalidPDFMessage(err))
+	}
+
+	// The page count and metadata could not be read at upload
+	if pdf.PageCount == 0 {
+		applyDocumentInfo(pdf, doc)
+		if err := s.DB.WithContext(c.Context()).Model(&model.PDF{}).Where("id = ?", id).Updates(map[string]interface{}{
+			"spec_version":  pdf.SpecVersion,
+			"page_count":    pdf.PageCount,
+			"title":         pdf.Title,
+			"author":        pdf.Author,
+			"subject":       pdf.Subject,
+			"producer":      pdf.Producer,
+			"creation_date": pdf.CreationDate,
+			"outline":       pdf.Outline,
+			"form_fields":   pdf.FormFields,
+		}).Error; err != nil {
+			s.Log.Errorf("Failed to save PDF metadata: %+v", err)
+		}
+	}
+	if pdf.TableStatus == model.TableStatusPending {
+		s.enqueueTables(tableJob{PDFID: pdf.ID, Version: pdf.Version, FilePath: pdf.FilePath, Password: req.Password})
 	}
 
-	c.Set("Content-Type", "application/pdf")
-	c.Set("Content-Disposition", "inline; filename=\""+pdf.OriginalFilename+"\"")
+	expiresAt := time.Now().Add(config.PDFUnlockExpiration)
+	token, err := utils.SealToken(config.JWTSecret, unlockPurpose, unlockClaims{
+		PDFID:       pdf.ID.String(),
+		ContentHash: pdf.ContentHash,
+		Password:    req.Password,
+		ExpiresAt:   expiresAt,
+	})
+	if err != nil {
+		s.Log.Errorf("Failed to seal unlock token: %+v", err)
+		return "", time.Time{}, fiber.NewError(fiber.StatusInternalServerError, "Failed to unlock PDF")
+	}
 
-	return c.SendFile(pdf.FilePath)
+	return token, expiresAt, nil
+}
+
+// documentPassword returns the password to open pdf with, taken from an
+// unlock token. Unprotected PDFs need no token.
+func (s *pdfService) documentPassword(pdf *model.PDF, token string) (string, error) {
+	if !pdf.PasswordProtected {
+		return "", nil
+	}
+
+	if token == "" {
+		return "", fiber.NewError(fiber.StatusBadRequest, "PDF is password protected, unlock it first")
+	}
+
+	var claims unlockClaims
+	if err := utils.OpenToken(config.JWTSecret, unlockPurpose, token, &claims); err != nil {
+		return "", fiber.NewError(fiber.StatusBadRequest, "Invalid unlock token")
+	}
+
+	if claims.PDFID != pdf.ID.String() || claims.ContentHash != pdf.ContentHash {
+		return "", fiber.NewError(fiber.StatusBadRequest, "Unlock token does not match this PDF")
+	}
+
+	if time.Now().After(claims.ExpiresAt) {
+		return "", fiber.NewError(fiber.StatusBadRequest, "Unlock token has expired, unlock the PDF again")
+	}
+
+	return claims.Password, nil
+}
+
+// unlockedPDF returns a PDF whose extracted contents, such as its outline,
+// form values and tables, may be shown: one that isn't password protected,
+// or a protected one with a valid unlock token.
+func (s *pdfService) unlockedPDF(c *fiber.Ctx, id string, query *validation.QueryUnlockToken) (*model.PDF, error) {
+	if err := s.Validate.Struct(query); err != nil {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "Invalid query parameters")
+	}
+
+	pdf, err := s.GetPDFByID(c, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := s.documentPassword(pdf, query.UnlockToken); err != nil {
+		return nil, err
+	}
+	return pdf, nil
 }
 
 func (s *pdfService) CancelSummarization(c *fiber.Ctx, id string) error {
@@ -381,8 +1300,9 @@ func (s *pdfService) CancelSummarization(c *fiber.Ctx, id string) error {
 	}
 
 	if err := s.DB.WithContext(c.Context()).Model(&model.PDF{}).Where("id = ?", id).Updates(map[string]interface{}{
-		"summary_status": "pending",
-		"summary_error":  nil,
+		"summary_status":  "pending",
+		"summary_error":   nil,
+		"summary_request": nil,
 	}).Error; err != nil {
 		s.Log.Errorf("Failed to cancel summarization: %+v", err)
 		return fiber.NewError(fiber.StatusInternalServerError, "Failed to cancel summarization")
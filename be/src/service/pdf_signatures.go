@@ -0,0 +1,181 @@
+package service
+
+import (
+	"app/src/cms"
+	"app/src/config"
+	"app/src/model"
+	"app/src/pdfdoc"
+	"app/src/validation"
+	"crypto/x509"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// GetSignatures checks every signature of a PDF: that the signed bytes are
+// unchanged, that the signer's key made the signature and that the signer's
+// certificate chains to the configured trust store.
+func (s *pdfService) GetSignatures(c *fiber.Ctx, id string, query *validation.QuerySignatures) ([]model.PDFSignature, error) {
+	if err := s.Validate.Struct(query); err != nil {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "Invalid query parameters")
+	}
+
+	pdf, err := s.GetPDFByID(c, id)
+	if err != nil {
+		return nil, err
+	}
+
+	password, err := s.documentPassword(pdf, query.UnlockToken)
+	if err != nil {
+		return nil, err
+	}
+
+	doc, err := pdfdoc.OpenFileWithPassword(pdf.FilePath, password)
+	if err != nil {
+		s.Log.Errorf("Failed to open PDF %s: %+v", pdf.ID, err)
+		return nil, fiber.NewError(fiber.StatusInternalServerError, "Failed to read PDF")
+	}
+
+	docSignatures := doc.Signatures()
+	if len(docSignatures) == 0 {
+		return []model.PDFSignature{}, nil
+	}
+
+	roots, err := loadTrustStore(config.SignatureTrustStore)
+	if err != nil {
+		// Signatures still get checked, they just can't be trusted
+		s.Log.Errorf("Failed to load signature trust store: %+v", err)
+		roots = x509.NewCertPool()
+	}
+
+	signatures := make([]model.PDFSignature, len(docSignatures))
+	for i, sig := range docSignatures {
+		signatures[i] = checkSignature(doc, sig, roots)
+	}
+	return signatures, nil
+}
+
+func checkSignature(doc *pdfdoc.Document, sig pdfdoc.Signature, roots *x509.CertPool) model.PDFSignature {
+	result := model.PDFSignature{
+		FieldName:      sig.FieldName,
+		Page:           sig.Page,
+		SubFilter:      sig.SubFilter,
+		SignerName:     sig.Name,
+		SigningTime:    sig.SigningTime,
+		Reason:         sig.Reason,
+		Location:       sig.Location,
+		ContactInfo:    sig.ContactInfo,
+		Status:         model.SignatureStatusInvalid,
+		CoversDocument: sig.CoversDocument,
+		Problems:       []string{},
+	}
+
+	if sig.Err != nil {
+		result.Problems = append(result.Problems, "The signature's byte range is invalid")
+		return result
+	}
+
+	signed, err := cms.Parse(sig.Contents)
+	if err != nil {
+		result.Problems = append(result.Problems, "The signature can't be read: "+err.Error())
+		return result
+	}
+
+	cert := signed.Signer
+	if cert.Subject.CommonName != "" {
+		result.SignerName = cert.Subject.CommonName
+	}
+	result.Subject = cert.Subject.String()
+	result.Issuer = cert.Issuer.String()
+	result.Serial = strings.ToUpper(cert.SerialNumber.Text(16))
+	result.NotBefore = &cert.NotBefore
+	result.NotAfter = &cert.NotAfter
+	// The time in the signed attributes is covered by the signature, the
+	// one in the signature dictionary isn't
+	if signed.SigningTime != nil {
+		result.SigningTime = signed.SigningTime
+	}
+
+	content, err := doc.SignedContent(sig)
+	if err == nil {
+		err = signed.Verify(content)
+	}
+	switch {
+	case errors.Is(err, cms.ErrDigestMismatch):
+		result.Problems = append(result.Problems, "The document was changed after it was signed")
+		return result
+	case errors.Is(err, cms.ErrBadSignature):
+		result.Problems = append(result.Problems, "The signature does not match the signer's certificate")
+		return result
+	case err != nil:
+		result.Problems = append(result.Problems, "The signature can't be checked: "+err.Error())
+		return result
+	}
+	result.Intact = true
+
+	// Without a trusted timestamp the signing time is only the signer's
+	// word, so the certificate has to be valid now
+	now := time.Now()
+	if _, err := signed.VerifyChain(roots, now); err != nil {
+		result.Status = model.SignatureStatusUntrusted
+		if now.After(cert.NotAfter) {
+			result.Problems = append(result.Problems, "The signer's certificate expired on "+cert.NotAfter.Format(time.DateOnly))
+		} else {
+			result.Problems = append(result.Problems, "The signer's certificate is not trusted: "+err.Error())
+		}
+	} else {
+		result.Trusted = true
+		result.Status = model.SignatureStatusValid
+	}
+
+	if !sig.CoversDocument {
+		result.Status = model.SignatureStatusModified
+		result.Problems = append(result.Problems, "The document was updated after this signature, the updates aren't covered by it")
+	}
+	return result
+}
+
+// loadTrustStore reads every certificate in dir, PEM or DER encoded. An
+// empty dir trusts nothing.
+func loadTrustStore(dir string) (*x509.CertPool, error) {
+	pool := x509.NewCertPool()
+	if dir == "" {
+		return pool, nil
+	}
+
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return pool, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for _, entry := range entries {
+		switch strings.ToLower(filepath.Ext(entry.Name())) {
+		case ".pem", ".crt", ".cer", ".der":
+		default:
+			continue
+		}
+
+		data, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		if strings.Contains(string(data), "-----BEGIN") {
+			pool.AppendCertsFromPEM(data)
+			continue
+		}
+		cert, err := x509.ParseCertificate(data)
+		if err != nil {
+			return nil, err
+		}
+		pool.AddCert(cert)
+	}
+	return pool, nil
+}
@@ -0,0 +1,160 @@
+package service
+
+import (
+	"app/src/model"
+	"app/src/pdfdoc"
+	"app/src/validation"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// SplitPDF copies each page range of a PDF into a new document linked to it.
+// Copies are stored like uploads, so they go through the same checks and
+// scans. Copies of protected files are written without a password, since
+// making them took the password anyway.
+func (s *pdfService) SplitPDF(c *fiber.Ctx, id string, req *validation.SplitPDF) ([]model.PDF, error) {
+	if err := s.Validate.Struct(req); err != nil {
+		return nil, err
+	}
+
+	pdf, doc, err := s.openSource(c, id, req.UnlockToken)
+	if err != nil {
+		return nil, err
+	}
+
+	pages, err := doc.Pages()
+	if err != nil {
+		s.Log.Errorf("Failed to read pages of PDF %s: %+v", pdf.ID, err)
+		return nil, fiber.NewError(fiber.StatusInternalServerError, "Failed to read PDF")
+	}
+
+	for _, r := range req.Ranges {
+		if r.End > len(pages) {
+			return nil, fiber.NewError(fiber.StatusBadRequest,
+				fmt.Sprintf("Page range %d-%d is outside the document's %d pages", r.Start, r.End, len(pages)))
+		}
+	}
+
+	// Every file is written before any record is saved, so a bad range or
+	// a full disk leaves nothing half done
+	name := strings.TrimSuffix(pdf.OriginalFilename, filepath.Ext(pdf.OriginalFilename))
+	parts := make([]model.PDF, 0, len(req.Ranges))
+	for _, r := range req.Ranges {
+		part, err := s.storePages(fmt.Sprintf("%s-p%d-%d.pdf", name, r.Start, r.End), pages[r.Start-1:r.End])
+		if err != nil {
+			removeStoredFiles(parts)
+			return nil, err
+		}
+		part.ParentID = &pdf.ID
+		parts = append(parts, *part)
+	}
+
+	for i := range parts {
+		if err := s.CreatePDF(c, &parts[i]); err != nil {
+			removeStoredFiles(parts[i:])
+			return nil, err
+		}
+	}
+	return parts, nil
+}
+
+// MergePDFs stores the pages of several PDFs, in the given order, as one new
+// document.
+func (s *pdfService) MergePDFs(c *fiber.Ctx, req *validation.MergePDFs) (*model.PDF, error) {
+	if err := s.Validate.Struct(req); err != nil {
+		return nil, err
+	}
+
+	var pages []*pdfdoc.Page
+	for _, id := range req.PDFIDs {
+		pdf, doc, err := s.openSource(c, id, req.UnlockTokens[id])
+		if err != nil {
+			return nil, err
+		}
+
+		docPages, err := doc.Pages()
+		if err != nil {
+			s.Log.Errorf("Failed to read pages of PDF %s: %+v", pdf.ID, err)
+			return nil, fiber.NewError(fiber.StatusInternalServerError, "Failed to read PDF")
+		}
+		pages = append(pages, docPages...)
+	}
+
+	merged, err := s.storePages(mergedFilename(req.Filename), pages)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.CreatePDF(c, merged); err != nil {
+		os.Remove(merged.FilePath)
+		return nil, err
+	}
+	return merged, nil
+}
+
+func mergedFilename(name string) string {
+	name = strings.TrimSpace(filepath.Base(name))
+	if name == "" || name == "." || name == string(filepath.Separator) {
+		return "merged.pdf"
+	}
+	if filepath.Ext(name) != ".pdf" {
+		name += ".pdf"
+	}
+	return name
+}
+
+// openSource opens a stored PDF to copy pages out of. Only files known to be
+// clean can be copied.
+func (s *pdfService) openSource(c *fiber.Ctx, id, unlockToken string) (*model.PDF, *pdfdoc.Document, error) {
+	pdf, err := s.GetPDFByID(c, id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := scanAccessError(pdf.ScanStatus); err != nil {
+		return nil, nil, err
+	}
+
+	password, err := s.documentPassword(pdf, unlockToken)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	doc, err := pdfdoc.OpenFileWithPassword(pdf.FilePath, password)
+	if err != nil {
+		s.Log.Errorf("Failed to open PDF %s: %+v", pdf.ID, err)
+		return nil, nil, fiber.NewError(fiber.StatusInternalServerError, "Failed to read PDF")
+	}
+	return pdf, doc, nil
+}
+
+// storePages writes pages as a new document straight into StorePDF, which
+// checks and stores it like an upload.
+func (s *pdfService) storePages(originalFilename string, pages []*pdfdoc.Page) (*model.PDF, error) {
+	reader, writer := io.Pipe()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		writer.CloseWithError(pdfdoc.WritePages(writer, pages))
+	}()
+
+	pdf, err := s.StorePDF(originalFilename, reader)
+
+	// Unblocks the writer when storage gave up early, and waits for it so
+	// the source documents are never read by two goroutines
+	reader.Close()
+	<-done
+
+	return pdf, err
+}
+
+func removeStoredFiles(pdfs []model.PDF) {
+	for _, pdf := range pdfs {
+		os.Remove(pdf.FilePath)
+	}
+}
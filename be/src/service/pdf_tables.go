@@ -0,0 +1,244 @@
+package service
+
+import (
+	"app/src/config"
+	"app/src/model"
+	"app/src/pdfdoc"
+	"app/src/utils"
+	"app/src/validation"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// maxDocumentTables bounds how many tables are kept of a single file.
+const maxDocumentTables = 500
+
+const tableQueueSize = 100
+
+// tableJob is a stored file waiting for table detection. Password is only
+// set for protected files, which wait until someone unlocks them.
+type tableJob struct {
+	PDFID    uuid.UUID
+	Version  int
+	FilePath string
+	Password string
+}
+
+func (s *pdfService) GetTables(c *fiber.Ctx, id string, query *validation.QueryUnlockToken) ([]model.PDFTable, error) {
+	if _, err := s.unlockedPDF(c, id, query); err != nil {
+		return nil, err
+	}
+
+	var tables []model.PDFTable
+	if err := s.DB.WithContext(c.Context()).Where("pdf_id = ?", id).Order("table_index").Find(&tables).Error; err != nil {
+		s.Log.Errorf("Failed to get PDF tables: %+v", err)
+		return nil, fiber.NewError(fiber.StatusInternalServerError, "Failed to get PDF tables")
+	}
+
+	return tables, nil
+}
+
+func (s *pdfService) GetTable(c *fiber.Ctx, id string, index int, query *validation.QueryUnlockToken) (*model.PDFTable, error) {
+	if _, err := s.unlockedPDF(c, id, query); err != nil {
+		return nil, err
+	}
+
+	table := new(model.PDFTable)
+	result := s.DB.WithContext(c.Context()).First(table, "pdf_id = ? AND table_index = ?", id, index)
+
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return nil, fiber.NewError(fiber.StatusNotFound, "Table not found")
+	}
+
+	if result.Error != nil {
+		s.Log.Errorf("Failed to get PDF table: %+v", result.Error)
+		return nil, fiber.NewError(fiber.StatusInternalServerError, "Failed to get PDF table")
+	}
+
+	return table, nil
+}
+
+// SendTableCSV sends one table of a PDF as a CSV download.
+func (s *pdfService) SendTableCSV(c *fiber.Ctx, id string, index int, query *validation.QueryUnlockToken) error {
+	pdf, err := s.unlockedPDF(c, id, query)
+	if err != nil {
+		return err
+	}
+
+	table, err := s.GetTable(c, id, index, query)
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+	if err := writer.WriteAll(table.Rows); err != nil {
+		s.Log.Errorf("Failed to write table CSV: %+v", err)
+		return fiber.NewError(fiber.StatusInternalServerError, "Failed to export table")
+	}
+
+	name := strings.TrimSuffix(pdf.OriginalFilename, filepath.Ext(pdf.OriginalFilename))
+	c.Set(fiber.HeaderContentType, "text/csv; charset=utf-8")
+	c.Set(fiber.HeaderContentDisposition, utils.ContentDisposition("attachment", fmt.Sprintf("%s-table-%d.csv", name, index)))
+	return c.Status(fiber.StatusOK).Send(buf.Bytes())
+}
+
+// enqueueTables hands a stored file to the table workers. Like scans, a
+// full queue never drops a job.
+func (s *pdfService) enqueueTables(job tableJob) {
+	select {
+	case s.tableJobs <- job:
+	default:
+		go func() { s.tableJobs <- job }()
+	}
+}
+
+// resumeTables requeues files whose table detection was interrupted by a
+// restart. Protected files are left for the next unlock.
+func (s *pdfService) resumeTables() {
+	var pdfs []model.PDF
+	err := s.DB.Where("table_status = ? AND NOT password_protected", model.TableStatusPending).Find(&pdfs).Error
+	if err != nil {
+		s.Log.Errorf("Failed to load PDFs waiting for table detection: %+v", err)
+		return
+	}
+
+	for _, pdf := range pdfs {
+		s.tableJobs <- tableJob{PDFID: pdf.ID, Version: pdf.Version, FilePath: pdf.FilePath}
+	}
+}
+
+func (s *pdfService) runTableWorker() {
+	for job := range s.tableJobs {
+		s.detectTables(context.Background(), job)
+	}
+}
+
+// detectTables stores the tables of a file, unless the PDF has moved on to
+// another version or had them detected already.
+func (s *pdfService) detectTables(ctx context.Context, job tableJob) {
+	pdf := new(model.PDF)
+	if err := s.DB.WithContext(ctx).First(pdf, "id = ?", job.PDFID).Error; err != nil {
+		return
+	}
+	if pdf.Version != job.Version || pdf.TableStatus != model.TableStatusPending {
+		return
+	}
+
+	doc, err := pdfdoc.OpenFileWithPassword(job.FilePath, job.Password)
+	if err != nil {
+		s.Log.Warnf("Failed to open PDF %s for table detection: %+v", job.PDFID, err)
+		return
+	}
+
+	detectCtx, cancel := context.WithTimeout(ctx, config.TableDetectionTimeout)
+	tables, complete := documentTables(detectCtx, doc)
+	cancel()
+
+	status := model.TableStatusCompleted
+	if !complete {
+		s.Log.Warnf("Stopped table detection of PDF %s at its limits after %d tables", job.PDFID, len(tables))
+		status = model.TableStatusPartial
+	}
+
+	err = s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		current := new(model.PDF)
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(current, "id = ?", job.PDFID).Error; err != nil {
+			return err
+		}
+		if current.Version != job.Version || current.TableStatus != model.TableStatusPending {
+			return nil
+		}
+
+		if err := replaceTables(tx, job.PDFID, tables); err != nil {
+			return err
+		}
+		return tx.Model(&model.PDF{}).Where("id = ?", job.PDFID).Updates(map[string]interface{}{
+			"table_count":  len(tables),
+			"table_status": status,
+		}).Error
+	})
+	if err != nil {
+		s.Log.Errorf("Failed to save tables of PDF %s: %+v", job.PDFID, err)
+	}
+}
+
+// documentTables detects the tables of every page. Pages whose text can't
+// be read contribute what was found before the error. It stops early, and
+// reports the result incomplete, once ctx is done or the pages read so far
+// hold more content than config.TableDetectionMaxContent.
+func documentTables(ctx context.Context, doc *pdfdoc.Document) ([]model.PDFTable, bool) {
+	pages, err := doc.Pages()
+	if err != nil {
+		return nil, true
+	}
+
+	var tables []model.PDFTable
+	content := 0
+	for _, page := range pages {
+		if ctx.Err() != nil {
+			return tables, false
+		}
+
+		// Decoded once and cached, so Tables reads the same data again
+		data, err := page.Contents()
+		if err != nil {
+			continue
+		}
+		content += len(data)
+		if content > config.TableDetectionMaxContent {
+			return tables, false
+		}
+
+		found, _ := page.Tables()
+		for _, table := range found {
+			if len(tables) >= maxDocumentTables {
+				return tables, true
+			}
+
+			rows := make(model.TableRows, len(table.Rows))
+			for i, row := range table.Rows {
+				rows[i] = make([]string, len(row))
+				for j, value := range row {
+					// Postgres JSONB can't hold NUL either
+					rows[i][j] = strings.ReplaceAll(value, "\x00", "")
+				}
+			}
+
+			tables = append(tables, model.PDFTable{
+				TableIndex:  len(tables) + 1,
+				PageNumber:  page.Number,
+				RowCount:    len(rows),
+				ColumnCount: len(rows[0]),
+				Rows:        rows,
+			})
+		}
+	}
+	return tables, true
+}
+
+// replaceTables swaps the stored tables of a PDF for those of its current
+// file.
+func replaceTables(tx *gorm.DB, pdfID uuid.UUID, tables []model.PDFTable) error {
+	if err := tx.Where("pdf_id = ?", pdfID).Delete(&model.PDFTable{}).Error; err != nil {
+		return err
+	}
+	if len(tables) == 0 {
+		return nil
+	}
+
+	for i := range tables {
+		tables[i].PDFID = pdfID
+	}
+	return tx.CreateInBatches(tables, 100).Error
+}
@@ -0,0 +1,199 @@
+package service
+
+import (
+	"app/src/config"
+	"app/src/model"
+	"app/src/ocr"
+	"app/src/pdfdoc"
+	"context"
+	"errors"
+	"os"
+	"strings"
+	"unicode"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// errOCRNeeded is returned when pages need OCR the caller can't wait for.
+var errOCRNeeded = errors.New("pages need OCR")
+
+func newOCRProvider() ocr.Provider {
+	if config.OCRProvider == "tesseract" {
+		return ocr.NewTesseract(config.OCRLanguages)
+	}
+	return ocr.Noop{}
+}
+
+// pageTexts returns the text of every page of the PDF's current file. It is
+// extracted on first use and stored, with pages that have no text layer read
+// through OCR instead. Without allowOCR, it returns errOCRNeeded rather than
+// run OCR, and stores nothing.
+func (s *pdfService) pageTexts(ctx context.Context, pdf *model.PDF, password string, allowOCR bool) ([]model.PDFPage, error) {
+	var pages []model.PDFPage
+	if err := s.DB.WithContext(ctx).
+		Where("pdf_id = ? AND content_hash = ?", pdf.ID, pdf.ContentHash).
+		Order("page_number").
+		Find(&pages).Error; err != nil {
+		return nil, err
+	}
+	if len(pages) > 0 {
+		return pages, nil
+	}
+
+	pages, err := s.extractPages(ctx, pdf, password, allowOCR)
+	if err != nil {
+		return nil, err
+	}
+
+	ocrUsed := false
+	for _, page := range pages {
+		if page.Source == model.PageSourceOCR {
+			ocrUsed = true
+		}
+	}
+
+	// Pages of earlier versions are replaced
+	err = s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("pdf_id = ? AND content_hash <> ?", pdf.ID, pdf.ContentHash).Delete(&model.PDFPage{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).CreateInBatches(&pages, 100).Error; err != nil {
+			return err
+		}
+		return tx.Model(&model.PDF{}).Where("id = ?", pdf.ID).Update("ocr_used", ocrUsed).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	pdf.OCRUsed = ocrUsed
+	return pages, nil
+}
+
+func (s *pdfService) extractPages(ctx context.Context, pdf *model.PDF, password string, allowOCR bool) ([]model.PDFPage, error) {
+	doc, err := pdfdoc.OpenFileWithPassword(pdf.FilePath, password)
+	if err != nil {
+		return nil, err
+	}
+
+	docPages, err := doc.Pages()
+	if err != nil {
+		return nil, err
+	}
+
+	// Protected files are read through OCR from a decrypted copy, made
+	// when the first page needs it
+	source := pdf.FilePath
+	if password != "" {
+		source = ""
+		defer func() {
+			if source != "" {
+				os.Remove(source)
+			}
+		}()
+	}
+
+	pages := make([]model.PDFPage, 0, len(docPages))
+	for _, docPage := range docPages {
+		text, err := docPage.Text()
+		if err != nil {
+			s.Log.Warnf("Failed to extract text of page %d of PDF %s: %+v", docPage.Number, pdf.ID, err)
+		}
+
+		page := model.PDFPage{
+			PDFID:       pdf.ID,
+			ContentHash: pdf.ContentHash,
+			PageNumber:  docPage.Number,
+			Text:        text,
+			Source:      model.PageSourceText,
+		}
+
+		if textLength(text) < config.OCRMinPageText {
+			if !allowOCR && s.ocrEnabled() {
+				return nil, errOCRNeeded
+			}
+			if source == "" {
+				if source, err = writeDecryptedCopy(docPages); err != nil {
+					return nil, err
+				}
+			}
+			if err := s.recognizePage(ctx, source, &page); err != nil {
+				if ctx.Err() != nil {
+					return nil, ctx.Err()
+				}
+				s.Log.Warnf("Failed to OCR page %d of PDF %s: %+v", docPage.Number, pdf.ID, err)
+			}
+		}
+
+		// Postgres text columns can't hold NUL
+		page.Text = strings.ReplaceAll(page.Text, "\x00", "")
+		pages = append(pages, page)
+	}
+	return pages, nil
+}
+
+// recognizePage reads the page of the file at path through OCR, keeping the
+// OCR text only when it found more than the text layer had.
+func (s *pdfService) recognizePage(ctx context.Context, path string, page *model.PDFPage) error {
+	ctx, cancel := context.WithTimeout(ctx, config.OCRPageTimeout)
+	defer cancel()
+
+	result, err := s.OCR.Recognize(ctx, path, page.PageNumber)
+	if err != nil {
+		return err
+	}
+
+	if textLength(result.Text) > textLength(page.Text) {
+		confidence := result.Confidence
+		page.Text = result.Text
+		page.Source = model.PageSourceOCR
+		page.Confidence = &confidence
+	}
+	return nil
+}
+
+func (s *pdfService) ocrEnabled() bool {
+	_, noop := s.OCR.(ocr.Noop)
+	return !noop
+}
+
+// writeDecryptedCopy writes pages to a temporary, unencrypted file that only
+// this process's user can read. External tools are given the copy instead of
+// a password on their command line, where any user of the machine could see
+// it. The caller removes the file.
+func writeDecryptedCopy(pages []*pdfdoc.Page) (string, error) {
+	file, err := os.CreateTemp("", "decrypted-*.pdf")
+	if err != nil {
+		return "", err
+	}
+
+	err = pdfdoc.WritePages(file, pages)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(file.Name())
+		return "", err
+	}
+	return file.Name(), nil
+}
+
+// textLength counts the characters of text that aren't whitespace.
+func textLength(text string) int {
+	n := 0
+	for _, r := range text {
+		if !unicode.IsSpace(r) {
+			n++
+		}
+	}
+	return n
+}
+
+func joinPageTexts(pages []model.PDFPage) string {
+	texts := make([]string, len(pages))
+	for i, page := range pages {
+		texts[i] = page.Text
+	}
+	return strings.Join(texts, "\n\n")
+}
@@ -0,0 +1,214 @@
+package service
+
+import (
+	"app/src/config"
+	"app/src/model"
+	"app/src/pdfdoc"
+	"app/src/render"
+	"app/src/utils"
+	"app/src/validation"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+const thumbnailDir = "./storage/thumbnails"
+
+// Thumbnail sends page of a PDF's current file as an image, rendering it on
+// first request and serving it from the cache after that.
+func (s *pdfService) Thumbnail(c *fiber.Ctx, id string, page int, query *validation.QueryThumbnail) error {
+	if err := s.Validate.Struct(query); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid query parameters")
+	}
+
+	pdf, err := s.GetPDFByID(c, id)
+	if err != nil {
+		return err
+	}
+
+	if err := scanAccessError(pdf.ScanStatus); err != nil {
+		return err
+	}
+
+	if page < 1 || (pdf.PageCount > 0 && page > pdf.PageCount) {
+		return fiber.NewError(fiber.StatusNotFound, "Page not found")
+	}
+
+	// Cached thumbnails of protected files need the password as much as
+	// the file itself
+	password, err := s.documentPassword(pdf, query.UnlockToken)
+	if err != nil {
+		return err
+	}
+
+	opts := render.Options{
+		Page:   page,
+		Width:  thumbnailWidth(query.Width),
+		Format: query.Format,
+	}
+	if opts.Format == "" {
+		opts.Format = render.FormatPNG
+	}
+
+	path, err := s.thumbnail(c.Context(), pdf, password, opts)
+	if err != nil {
+		s.Log.Errorf("Failed to render thumbnail of PDF %s page %d: %+v", pdf.ID, page, err)
+		return fiber.NewError(fiber.StatusInternalServerError, "Failed to render thumbnail")
+	}
+
+	name := strings.TrimSuffix(pdf.OriginalFilename, filepath.Ext(pdf.OriginalFilename))
+	return utils.SendFile(c, utils.FileResponse{
+		Path:         path,
+		Filename:     fmt.Sprintf("%s-page-%d.%s", name, page, opts.Format),
+		ContentType:  render.ContentType(opts.Format),
+		ETag:         strings.TrimSuffix(filepath.Base(path), "."+opts.Format),
+		CacheControl: fmt.Sprintf("private, max-age=%d", int(config.ThumbnailMaxAge.Seconds())),
+	})
+}
+
+// thumbnailWidth rounds a requested width up to the next step.
+func thumbnailWidth(width int) int {
+	if width == 0 {
+		return config.ThumbnailDefaultWidth
+	}
+	step := config.ThumbnailWidthStep
+	return (width + step - 1) / step * step
+}
+
+// thumbnailKey names the cached images of a file. The content hash changes
+// with every new version, so stale thumbnails are never served.
+func thumbnailKey(pdf *model.PDF) string {
+	if pdf.ContentHash != "" {
+		return pdf.ContentHash
+	}
+	return fmt.Sprintf("%s-v%d", pdf.ID, pdf.Version)
+}
+
+func thumbnailPath(key string, opts render.Options) string {
+	name := fmt.Sprintf("%s-p%d-w%d.%s", key, opts.Page, opts.Width, opts.Format)
+	return filepath.Join(thumbnailDir, key[:2], name)
+}
+
+// thumbnail returns the path of a cached image, rendering it first when
+// needed. At most config.ThumbnailRenderers renders run at once.
+func (s *pdfService) thumbnail(ctx context.Context, pdf *model.PDF, password string, opts render.Options) (string, error) {
+	path := thumbnailPath(thumbnailKey(pdf), opts)
+	if _, err := os.Stat(path); err == nil {
+		return path, nil
+	}
+
+	select {
+	case s.renderSlots <- struct{}{}:
+		defer func() { <-s.renderSlots }()
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+
+	// Another request may have rendered it while this one waited
+	if _, err := os.Stat(path); err == nil {
+		return path, nil
+	}
+
+	if password == "" {
+		return path, s.renderThumbnail(ctx, pdf.FilePath, path, opts)
+	}
+
+	// The renderer gets a decrypted copy of the page rather than the password
+	doc, err := pdfdoc.OpenFileWithPassword(pdf.FilePath, password)
+	if err != nil {
+		return "", err
+	}
+	pages, err := doc.Pages()
+	if err != nil {
+		return "", err
+	}
+	if opts.Page > len(pages) {
+		return "", fmt.Errorf("page %d of %d", opts.Page, len(pages))
+	}
+
+	copyPath, err := writeDecryptedCopy(pages[opts.Page-1 : opts.Page])
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(copyPath)
+
+	pageOpts := opts
+	pageOpts.Page = 1
+	return path, s.renderThumbnail(ctx, copyPath, path, pageOpts)
+}
+
+func (s *pdfService) renderThumbnail(ctx context.Context, filePath, path string, opts render.Options) error {
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, config.ThumbnailRenderTimeout)
+	defer cancel()
+
+	// Rendered under a temporary name so no request sees a partial image
+	tmp := fmt.Sprintf("%s.%s.tmp", path, uuid.NewString())
+	if err := s.Renderer.Render(ctx, filePath, tmp, opts); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return nil
+}
+
+// warmThumbnail renders the first page of a newly stored file in the
+// background, so document lists have a thumbnail to show straight away.
+// Files that aren't known to be clean or need a password are left for
+// on-demand rendering, as is everything while the renderers are busy.
+func (s *pdfService) warmThumbnail(id uuid.UUID) {
+	select {
+	case s.renderSlots <- struct{}{}:
+	default:
+		return
+	}
+
+	go func() {
+		defer func() { <-s.renderSlots }()
+
+		ctx := context.Background()
+		pdf := new(model.PDF)
+		if err := s.DB.WithContext(ctx).First(pdf, "id = ?", id).Error; err != nil {
+			return
+		}
+		if scanAccessError(pdf.ScanStatus) != nil || pdf.PasswordProtected {
+			return
+		}
+
+		opts := render.Options{Page: 1, Width: config.ThumbnailDefaultWidth, Format: render.FormatPNG}
+		path := thumbnailPath(thumbnailKey(pdf), opts)
+		if _, err := os.Stat(path); err == nil {
+			return
+		}
+		if err := s.renderThumbnail(ctx, pdf.FilePath, path, opts); err != nil {
+			s.Log.Warnf("Failed to render first page thumbnail of PDF %s: %+v", pdf.ID, err)
+		}
+	}()
+}
+
+// removeThumbnails deletes every cached image of the given keys.
+func (s *pdfService) removeThumbnails(keys ...string) {
+	for _, key := range keys {
+		paths, err := filepath.Glob(filepath.Join(thumbnailDir, key[:2], key+"-p*"))
+		if err != nil {
+			continue
+		}
+		for _, path := range paths {
+			if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+				s.Log.Warnf("Failed to remove thumbnail %s: %+v", path, err)
+			}
+		}
+	}
+}
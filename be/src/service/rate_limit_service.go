@@ -0,0 +1,81 @@
+package service
+
+import (
+	"app/src/model"
+	"app/src/utils"
+	"context"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// RateLimitService keeps rate limit counters in the database, so they are
+// shared by every prefork process and instance rather than kept per process.
+type RateLimitService interface {
+	Hit(c *fiber.Ctx, key string, window time.Duration) (int, error)
+	Forgive(c *fiber.Ctx, key string) error
+}
+
+type rateLimitService struct {
+	Log *logrus.Logger
+	DB  *gorm.DB
+}
+
+func NewRateLimitService(db *gorm.DB) RateLimitService {
+	s := &rateLimitService{
+		Log: utils.Log,
+		DB:  db,
+	}
+
+	go s.runPruner()
+
+	return s
+}
+
+// Hit counts a hit on key and returns the hits in its current window. The
+// window starts with the first hit after the previous one expired.
+func (s *rateLimitService) Hit(c *fiber.Ctx, key string, window time.Duration) (int, error) {
+	now := time.Now()
+
+	var hits int
+	err := s.DB.WithContext(c.Context()).Raw(`
+		INSERT INTO rate_limits (key, hits, expires_at) VALUES (?, 1, ?)
+		ON CONFLICT (key) DO UPDATE SET
+			hits = CASE WHEN rate_limits.expires_at <= ? THEN 1 ELSE rate_limits.hits + 1 END,
+			expires_at = CASE WHEN rate_limits.expires_at <= ? THEN EXCLUDED.expires_at ELSE rate_limits.expires_at END
+		RETURNING hits`, key, now.Add(window), now, now).Scan(&hits).Error
+	if err != nil {
+		s.Log.Errorf("Failed to count rate limit hit: %+v", err)
+		return 0, fiber.NewError(fiber.StatusInternalServerError, "Failed to check rate limit")
+	}
+
+	return hits, nil
+}
+
+// Forgive takes back a hit, for requests that only count when they fail.
+func (s *rateLimitService) Forgive(c *fiber.Ctx, key string) error {
+	err := s.DB.WithContext(c.Context()).Model(&model.RateLimit{}).
+		Where("key = ? AND hits > 0", key).
+		Update("hits", gorm.Expr("hits - 1")).Error
+	if err != nil {
+		s.Log.Errorf("Failed to update rate limit: %+v", err)
+	}
+	return err
+}
+
+// runPruner deletes expired counters every hour.
+func (s *rateLimitService) runPruner() {
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		err := s.DB.WithContext(context.Background()).
+			Where("expires_at < ?", time.Now()).
+			Delete(&model.RateLimit{}).Error
+		if err != nil {
+			s.Log.Errorf("Failed to prune rate limits: %+v", err)
+		}
+	}
+}
@@ -0,0 +1,286 @@
+package service
+
+import (
+	"app/src/config"
+	"app/src/model"
+	"app/src/utils"
+	"app/src/validation"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// sharePurpose scopes share link signatures so no other signed token can be
+// used in their place.
+const sharePurpose = "pdf-share"
+
+type ShareService interface {
+	CreateShare(c *fiber.Ctx, pdfID string, req *validation.CreateShare) (*model.PDFShare, error)
+	GetShares(c *fiber.Ctx, pdfID string) ([]model.PDFShare, error)
+	RevokeShare(c *fiber.Ctx, pdfID, shareID string) error
+	GetShareAccesses(c *fiber.Ctx, pdfID, shareID string, params *validation.QueryShareAccess) ([]model.PDFShareAccess, int64, error)
+	ShareToken(share *model.PDFShare) (string, error)
+	GetSharedPDF(c *fiber.Ctx, token string) (*model.PDF, *model.PDFShare, error)
+	SendSharedFile(c *fiber.Ctx, token string, download bool) error
+}
+
+type shareService struct {
+	Log        *logrus.Logger
+	DB         *gorm.DB
+	Validate   *validator.Validate
+	PDFService PDFService
+}
+
+func NewShareService(db *gorm.DB, validate *validator.Validate, pdfService PDFService) ShareService {
+	return &shareService{
+		Log:        utils.Log,
+		DB:         db,
+		Validate:   validate,
+		PDFService: pdfService,
+	}
+}
+
+func (s *shareService) CreateShare(c *fiber.Ctx, pdfID string, req *validation.CreateShare) (*model.PDFShare, error) {
+	if err := s.Validate.Struct(req); err != nil {
+		return nil, err
+	}
+
+	pdf, err := s.PDFService.GetPDFByID(c, pdfID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Pending files may still be shared, the link serves them once clean
+	if pdf.ScanStatus == model.ScanStatusInfected {
+		return nil, scanAccessError(pdf.ScanStatus)
+	}
+
+	expiresIn := config.ShareDefaultExpiration
+	if req.ExpiresInHours > 0 {
+		expiresIn = time.Duration(req.ExpiresInHours) * time.Hour
+	}
+
+	share := &model.PDFShare{
+		PDFID:     pdf.ID,
+		ExpiresAt: time.Now().Add(expiresIn),
+		MaxViews:  req.MaxViews,
+	}
+
+	if req.Password != "" {
+		hash, err := utils.HashPassword(req.Password)
+		if err != nil {
+			s.Log.Errorf("Failed to hash share password: %+v", err)
+			return nil, fiber.NewError(fiber.StatusInternalServerError, "Failed to create share link")
+		}
+		share.PasswordHash = &hash
+	}
+
+	if err := s.DB.WithContext(c.Context()).Create(share).Error; err != nil {
+		s.Log.Errorf("Failed to create share link: %+v", err)
+		return nil, fiber.NewError(fiber.StatusInternalServerError, "Failed to create share link")
+	}
+
+	return share, nil
+}
+
+func (s *shareService) GetShares(c *fiber.Ctx, pdfID string) ([]model.PDFShare, error) {
+	if _, err := s.PDFService.GetPDFByID(c, pdfID); err != nil {
+		return nil, err
+	}
+
+	var shares []model.PDFShare
+	if err := s.DB.WithContext(c.Context()).Where("pdf_id = ?", pdfID).Order("created_at desc").Find(&shares).Error; err != nil {
+		s.Log.Errorf("Failed to get share links: %+v", err)
+		return nil, fiber.NewError(fiber.StatusInternalServerError, "Failed to get share links")
+	}
+
+	return shares, nil
+}
+
+func (s *shareService) getShare(c *fiber.Ctx, pdfID, shareID string) (*model.PDFShare, error) {
+	share := new(model.PDFShare)
+
+	result := s.DB.WithContext(c.Context()).First(share, "id = ? AND pdf_id = ?", shareID, pdfID)
+
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return nil, fiber.NewError(fiber.StatusNotFound, "Share link not found")
+	}
+
+	if result.Error != nil {
+		s.Log.Errorf("Failed to get share link: %+v", result.Error)
+		return nil, result.Error
+	}
+
+	return share, nil
+}
+
+// RevokeShare disables a share link for good. Revoking twice is harmless.
+func (s *shareService) RevokeShare(c *fiber.Ctx, pdfID, shareID string) error {
+	share, err := s.getShare(c, pdfID, shareID)
+	if err != nil {
+		return err
+	}
+
+	if share.RevokedAt != nil {
+		return nil
+	}
+
+	if err := s.DB.WithContext(c.Context()).Model(&model.PDFShare{}).Where("id = ?", share.ID).
+		Update("revoked_at", time.Now()).Error; err != nil {
+		s.Log.Errorf("Failed to revoke share link: %+v", err)
+		return fiber.NewError(fiber.StatusInternalServerError, "Failed to revoke share link")
+	}
+	return nil
+}
+
+func (s *shareService) GetShareAccesses(
+	c *fiber.Ctx, pdfID, shareID string, params *validation.QueryShareAccess,
+) ([]model.PDFShareAccess, int64, error) {
+	if err := s.Validate.Struct(params); err != nil {
+		return nil, 0, fiber.NewError(fiber.StatusBadRequest, "Invalid query parameters")
+	}
+
+	if _, err := s.getShare(c, pdfID, shareID); err != nil {
+		return nil, 0, err
+	}
+
+	var accesses []model.PDFShareAccess
+	var totalResults int64
+
+	query := s.DB.WithContext(c.Context()).Model(&model.PDFShareAccess{}).Where("share_id = ?", shareID)
+
+	if err := query.Count(&totalResults).Error; err != nil {
+		s.Log.Errorf("Failed to count share accesses: %+v", err)
+		return nil, 0, fiber.NewError(fiber.StatusInternalServerError, "Failed to count share accesses")
+	}
+
+	offset := (params.Page - 1) * params.Limit
+	if err := query.Order("accessed_at desc").Limit(params.Limit).Offset(offset).Find(&accesses).Error; err != nil {
+		s.Log.Errorf("Failed to get share accesses: %+v", err)
+		return nil, 0, fiber.NewError(fiber.StatusInternalServerError, "Failed to get share accesses")
+	}
+
+	return accesses, totalResults, nil
+}
+
+// ShareToken returns the public token of a share link. It's signed rather
+// than stored, so the same link can be shown again at any time.
+func (s *shareService) ShareToken(share *model.PDFShare) (string, error) {
+	return utils.SignToken(config.JWTSecret, sharePurpose, share.ID[:])
+}
+
+func (s *shareService) GetSharedPDF(c *fiber.Ctx, token string) (*model.PDF, *model.PDFShare, error) {
+	share, err := s.openShare(c, token, model.ShareResourceSummary)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	pdf, err := s.PDFService.GetPDFByID(c, share.PDFID.String())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return pdf, share, nil
+}
+
+// SendSharedFile serves the current file behind a share link. Every
+// response counts as a view, range requests included, since ranges can
+// add up to the whole file.
+func (s *shareService) SendSharedFile(c *fiber.Ctx, token string, download bool) error {
+	share, err := s.openShare(c, token, model.ShareResourceFile)
+	if err != nil {
+		return err
+	}
+
+	if download {
+		return s.PDFService.DownloadPDF(c, share.PDFID.String(), 0)
+	}
+	return s.PDFService.ViewPDF(c, share.PDFID.String(), 0)
+}
+
+// openShare checks a share token, its expiry, revocation, password and view
+// limit, counts the view and records the attempt in the share's access log.
+func (s *shareService) openShare(c *fiber.Ctx, token, resource string) (*model.PDFShare, error) {
+	value, err := utils.VerifySignedToken(config.JWTSecret, sharePurpose, token)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusNotFound, "Share link not found")
+	}
+	shareID, err := uuid.FromBytes(value)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusNotFound, "Share link not found")
+	}
+
+	share := new(model.PDFShare)
+	result := s.DB.WithContext(c.Context()).First(share, "id = ?", shareID)
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return nil, fiber.NewError(fiber.StatusNotFound, "Share link not found")
+	}
+	if result.Error != nil {
+		s.Log.Errorf("Failed to get share link: %+v", result.Error)
+		return nil, fiber.NewError(fiber.StatusInternalServerError, "Failed to open share link")
+	}
+
+	if share.RevokedAt != nil {
+		s.logAccess(c, share, resource, model.ShareAccessRevoked)
+		return nil, fiber.NewError(fiber.StatusGone, "Share link has been revoked")
+	}
+
+	if time.Now().After(share.ExpiresAt) {
+		s.logAccess(c, share, resource, model.ShareAccessExpired)
+		return nil, fiber.NewError(fiber.StatusGone, "Share link has expired")
+	}
+
+	if share.PasswordHash != nil {
+		password := c.Get(config.SharePasswordHeader)
+		if password == "" {
+			s.logAccess(c, share, resource, model.ShareAccessPasswordRequired)
+			return nil, fiber.NewError(fiber.StatusUnauthorized, "Share link is password protected")
+		}
+		if !utils.CheckPasswordHash(password, *share.PasswordHash) {
+			s.logAccess(c, share, resource, model.ShareAccessWrongPassword)
+			return nil, fiber.NewError(fiber.StatusUnauthorized, "Incorrect share link password")
+		}
+	}
+
+	// Checked and counted in one statement so concurrent views can't
+	// overshoot the limit
+	result = s.DB.WithContext(c.Context()).Model(&model.PDFShare{}).
+		Where("id = ? AND (max_views IS NULL OR view_count < max_views)", share.ID).
+		Update("view_count", gorm.Expr("view_count + 1"))
+	if result.Error != nil {
+		s.Log.Errorf("Failed to count share link view: %+v", result.Error)
+		return nil, fiber.NewError(fiber.StatusInternalServerError, "Failed to open share link")
+	}
+	if result.RowsAffected == 0 {
+		s.logAccess(c, share, resource, model.ShareAccessViewLimit)
+		return nil, fiber.NewError(fiber.StatusGone, "Share link has reached its view limit")
+	}
+	share.ViewCount++
+
+	s.logAccess(c, share, resource, model.ShareAccessGranted)
+	return share, nil
+}
+
+func (s *shareService) logAccess(c *fiber.Ctx, share *model.PDFShare, resource, outcome string) {
+	userAgent := c.Get(fiber.HeaderUserAgent)
+	if len(userAgent) > 512 {
+		userAgent = userAgent[:512]
+	}
+
+	access := &model.PDFShareAccess{
+		ShareID:   share.ID,
+		Resource:  resource,
+		Outcome:   outcome,
+		IPAddress: c.IP(),
+		UserAgent: strings.ToValidUTF8(userAgent, ""),
+	}
+	if err := s.DB.WithContext(c.Context()).Create(access).Error; err != nil {
+		s.Log.Errorf("Failed to log share link access: %+v", err)
+	}
+}
@@ -0,0 +1,219 @@
+package service
+
+import (
+	"app/src/config"
+	"app/src/export"
+	"app/src/mail"
+	"app/src/model"
+	"app/src/utils"
+	"app/src/validation"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type SummaryEmailService interface {
+	EmailSummary(c *fiber.Ctx, user *model.User, pdfID string, req *validation.EmailSummary) (*model.SummaryEmail, error)
+	GetSummaryEmails(c *fiber.Ctx, user *model.User, pdfID string, params *validation.QuerySummaryEmails) ([]model.SummaryEmail, int64, error)
+}
+
+type summaryEmailService struct {
+	Log          *logrus.Logger
+	DB           *gorm.DB
+	Validate     *validator.Validate
+	PDFService   PDFService
+	EmailService EmailService
+}
+
+func NewSummaryEmailService(
+	db *gorm.DB, validate *validator.Validate, pdfService PDFService, emailService EmailService,
+) SummaryEmailService {
+	return &summaryEmailService{
+		Log:          utils.Log,
+		DB:           db,
+		Validate:     validate,
+		PDFService:   pdfService,
+		EmailService: emailService,
+	}
+}
+
+// EmailSummary queues the summary of a PDF for the given recipients. The
+// record and the outbox email are written together; delivery shows on the
+// record once the background sender gets to it.
+func (s *summaryEmailService) EmailSummary(
+	c *fiber.Ctx, user *model.User, pdfID string, req *validation.EmailSummary,
+) (*model.SummaryEmail, error) {
+	if err := s.Validate.Struct(req); err != nil {
+		return nil, err
+	}
+
+	pdf, err := s.PDFService.GetPDFByID(c, pdfID)
+	if err != nil {
+		return nil, err
+	}
+	if pdf.Summary == nil || pdf.SummaryStatus != "completed" {
+		return nil, fiber.NewError(fiber.StatusNotFound, "PDF has no summary yet")
+	}
+
+	recipients := uniqueRecipients(req.Recipients)
+
+	summary := exportSummary(pdf)
+	email := &mail.Email{
+		To:      recipients,
+		ReplyTo: user.Email,
+		Subject: "Summary: " + summary.Title,
+	}
+
+	if req.AttachPDF {
+		if err := scanAccessError(pdf.ScanStatus); err != nil {
+			return nil, err
+		}
+		if pdf.FileSize > config.SummaryEmailAttachmentMaxSize {
+			return nil, fiber.NewError(fiber.StatusBadRequest,
+				fmt.Sprintf("PDF is too large to attach, the limit is %d MB", config.SummaryEmailAttachmentMaxSize>>20))
+		}
+		email.Attachments = []mail.Attachment{{
+			Path:        pdf.FilePath,
+			Filename:    pdf.OriginalFilename,
+			ContentType: "application/pdf",
+		}}
+	}
+
+	intro := []string{fmt.Sprintf("%s (%s) shared a summary of %s with you.", user.Name, user.Email, pdf.OriginalFilename)}
+	if note := strings.TrimSpace(req.Note); note != "" {
+		intro = append(intro, note)
+	}
+
+	var text, html strings.Builder
+	if err := export.WriteEmail(&text, &html, intro, summary); err != nil {
+		s.Log.Errorf("Failed to format summary email: %+v", err)
+		return nil, fiber.NewError(fiber.StatusInternalServerError, "Failed to send email")
+	}
+	email.Text, email.HTML = text.String(), html.String()
+
+	record := &model.SummaryEmail{
+		PDFID:          pdf.ID,
+		UserID:         user.ID,
+		Recipients:     recipients,
+		RecipientCount: len(recipients),
+		Subject:        email.Subject,
+		PDFVersion:     pdf.Version,
+		Attached:       req.AttachPDF,
+	}
+
+	err = s.DB.WithContext(c.Context()).Transaction(func(tx *gorm.DB) error {
+		// Locking the sender makes concurrent sends wait for each other, so
+		// every one of them counts the emails queued before it
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(new(model.User), "id = ?", user.ID).Error; err != nil {
+			return err
+		}
+		if err := s.checkSendingLimits(tx, user, len(recipients)); err != nil {
+			return err
+		}
+
+		delivery, err := s.EmailService.Queue(tx, email)
+		if err != nil {
+			return err
+		}
+		record.OutboxID = &delivery.ID
+		record.Delivery = delivery
+		return tx.Omit("Delivery").Create(record).Error
+	})
+	if err != nil {
+		var fiberErr *fiber.Error
+		if errors.As(err, &fiberErr) {
+			return nil, err
+		}
+		s.Log.Errorf("Failed to queue summary email: %+v", err)
+		return nil, fiber.NewError(fiber.StatusInternalServerError, "Failed to send email")
+	}
+
+	return record, nil
+}
+
+// checkSendingLimits refuses a send that would take the user past the
+// hourly email limit or the daily recipient limit. It runs in the
+// transaction that queues the email, with the user's row locked.
+func (s *summaryEmailService) checkSendingLimits(tx *gorm.DB, user *model.User, recipients int) error {
+	now := time.Now()
+	db := tx.Model(&model.SummaryEmail{}).Where("user_id = ?", user.ID)
+
+	var sent int64
+	if err := db.Where("created_at > ?", now.Add(-time.Hour)).Count(&sent).Error; err != nil {
+		s.Log.Errorf("Failed to count summary emails: %+v", err)
+		return fiber.NewError(fiber.StatusInternalServerError, "Failed to send email")
+	}
+	if sent >= config.SummaryEmailHourlyLimit {
+		return fiber.NewError(fiber.StatusTooManyRequests,
+			fmt.Sprintf("Only %d summary emails can be sent per hour, try again later", config.SummaryEmailHourlyLimit))
+	}
+
+	var reached int64
+	db = tx.Model(&model.SummaryEmail{}).Where("user_id = ?", user.ID)
+	err := db.Where("created_at > ?", now.Add(-24*time.Hour)).Select("COALESCE(SUM(recipient_count), 0)").Scan(&reached).Error
+	if err != nil {
+		s.Log.Errorf("Failed to count summary email recipients: %+v", err)
+		return fiber.NewError(fiber.StatusInternalServerError, "Failed to send email")
+	}
+	if reached+int64(recipients) > config.SummaryEmailDailyRecipientLimit {
+		return fiber.NewError(fiber.StatusTooManyRequests,
+			fmt.Sprintf("Summaries can be sent to at most %d recipients a day, try again later", config.SummaryEmailDailyRecipientLimit))
+	}
+
+	return nil
+}
+
+func (s *summaryEmailService) GetSummaryEmails(
+	c *fiber.Ctx, user *model.User, pdfID string, params *validation.QuerySummaryEmails,
+) ([]model.SummaryEmail, int64, error) {
+	if err := s.Validate.Struct(params); err != nil {
+		return nil, 0, fiber.NewError(fiber.StatusBadRequest, "Invalid query parameters")
+	}
+
+	if _, err := s.PDFService.GetPDFByID(c, pdfID); err != nil {
+		return nil, 0, err
+	}
+
+	var emails []model.SummaryEmail
+	var totalResults int64
+
+	query := s.DB.WithContext(c.Context()).Model(&model.SummaryEmail{}).
+		Where("pdf_id = ? AND user_id = ?", pdfID, user.ID)
+
+	if err := query.Count(&totalResults).Error; err != nil {
+		s.Log.Errorf("Failed to count summary emails: %+v", err)
+		return nil, 0, fiber.NewError(fiber.StatusInternalServerError, "Failed to count summary emails")
+	}
+
+	offset := (params.Page - 1) * params.Limit
+	err := query.Preload("Delivery").Order("created_at desc").Limit(params.Limit).Offset(offset).Find(&emails).Error
+	if err != nil {
+		s.Log.Errorf("Failed to get summary emails: %+v", err)
+		return nil, 0, fiber.NewError(fiber.StatusInternalServerError, "Failed to get summary emails")
+	}
+
+	return emails, totalResults, nil
+}
+
+// uniqueRecipients drops repeated addresses, comparing them without case.
+func uniqueRecipients(addresses []string) []string {
+	seen := make(map[string]bool, len(addresses))
+	var unique []string
+	for _, address := range addresses {
+		address = strings.TrimSpace(address)
+		key := strings.ToLower(address)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		unique = append(unique, address)
+	}
+	return unique
+}
@@ -5,7 +5,6 @@ import (
 	"app/src/model"
 	res "app/src/response"
 	"app/src/utils"
-	"app/src/validation"
 	"time"
 
 	"github.com/go-playground/validator/v10"
@@ -23,8 +22,8 @@ type TokenService interface {
 	DeleteAllToken(c *fiber.Ctx, userID string) error
 	GetTokenByUserID(c *fiber.Ctx, tokenStr string) (*model.Token, error)
 	GenerateAuthTokens(c *fiber.Ctx, user *model.User) (*res.Tokens, error)
-	GenerateResetPasswordToken(c *fiber.Ctx, req *validation.ForgotPassword) (string, error)
-	GenerateVerifyEmailToken(c *fiber.Ctx, user *model.User) (*string, error)
+	GenerateResetPasswordToken(tx *gorm.DB, user *model.User) (string, error)
+	GenerateVerifyEmailToken(tx *gorm.DB, user *model.User) (*string, error)
 }
 
 type tokenService struct {
@@ -56,7 +55,15 @@ func (s *tokenService) GenerateToken(userID string, expires time.Time, tokenType
 }
 
 func (s *tokenService) SaveToken(c *fiber.Ctx, token, userID, tokenType string, expires time.Time) error {
-	if err := s.DeleteToken(c, tokenType, userID); err != nil {
+	return s.saveToken(s.DB.WithContext(c.Context()), token, userID, tokenType, expires)
+}
+
+// saveToken replaces the user's token of the given type within db, which may
+// be a transaction.
+func (s *tokenService) saveToken(db *gorm.DB, token, userID, tokenType string, expires time.Time) error {
+	err := db.Where("type = ? AND user_id = ?", tokenType, userID).Delete(new(model.Token)).Error
+	if err != nil {
+		s.Log.Errorf("Failed to delete token: %+v", err)
 		return err
 	}
 
@@ -67,7 +74,7 @@ func (s *tokenService) SaveToken(c *fiber.Ctx, token, userID, tokenType string,
 		Expires: expires,
 	}
 
-	result := s.DB.WithContext(c.Context()).Create(tokenDoc)
+	result := db.Create(tokenDoc)
 
 	if result.Error != nil {
 		s.Log.Errorf("Failed save token: %+v", result.Error)
@@ -153,16 +160,7 @@ func (s *tokenService) GenerateAuthTokens(c *fiber.Ctx, user *model.User) (*res.
 	}, nil
 }
 
-func (s *tokenService) GenerateResetPasswordToken(c *fiber.Ctx, req *validation.ForgotPassword) (string, error) {
-	if err := s.Validate.Struct(req); err != nil {
-		return "", err
-	}
-
-	user, err := s.UserService.GetUserByEmail(c, req.Email)
-	if err != nil {
-		return "", err
-	}
-
+func (s *tokenService) GenerateResetPasswordToken(tx *gorm.DB, user *model.User) (string, error) {
 	expires := time.Now().UTC().Add(time.Minute * time.Duration(config.JWTResetPasswordExp))
 	resetPasswordToken, err := s.GenerateToken(user.ID.String(), expires, config.TokenTypeResetPassword)
 	if err != nil {
@@ -170,14 +168,14 @@ func (s *tokenService) GenerateResetPasswordToken(c *fiber.Ctx, req *validation.
 		return "", err
 	}
 
-	if err = s.SaveToken(c, resetPasswordToken, user.ID.String(), config.TokenTypeResetPassword, expires); err != nil {
+	if err = s.saveToken(tx, resetPasswordToken, user.ID.String(), config.TokenTypeResetPassword, expires); err != nil {
 		return "", err
 	}
 
 	return resetPasswordToken, nil
 }
 
-func (s *tokenService) GenerateVerifyEmailToken(c *fiber.Ctx, user *model.User) (*string, error) {
+func (s *tokenService) GenerateVerifyEmailToken(tx *gorm.DB, user *model.User) (*string, error) {
 	expires := time.Now().UTC().Add(time.Minute * time.Duration(config.JWTVerifyEmailExp))
 	verifyEmailToken, err := s.GenerateToken(user.ID.String(), expires, config.TokenTypeVerifyEmail)
 	if err != nil {
@@ -185,7 +183,7 @@ func (s *tokenService) GenerateVerifyEmailToken(c *fiber.Ctx, user *model.User)
 		return nil, err
 	}
 
-	if err = s.SaveToken(c, verifyEmailToken, user.ID.String(), config.TokenTypeVerifyEmail, expires); err != nil {
+	if err = s.saveToken(tx, verifyEmailToken, user.ID.String(), config.TokenTypeVerifyEmail, expires); err != nil {
 		return nil, err
 	}
 
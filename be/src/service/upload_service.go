@@ -0,0 +1,289 @@
+package service
+
+import (
+	"app/src/config"
+	"app/src/model"
+	"app/src/utils"
+	"app/src/validation"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type UploadService interface {
+	CreateUpload(c *fiber.Ctx, req *validation.CreateUpload, chunk io.Reader) (*model.Upload, error)
+	GetUpload(c *fiber.Ctx, id string) (*model.Upload, error)
+	WriteChunk(c *fiber.Ctx, id string, offset int64, chunk io.Reader) (*model.Upload, error)
+	TerminateUpload(c *fiber.Ctx, id string) error
+}
+
+type uploadService struct {
+	Log        *logrus.Logger
+	DB         *gorm.DB
+	Validate   *validator.Validate
+	PDFService PDFService
+}
+
+func NewUploadService(db *gorm.DB, validate *validator.Validate, pdfService PDFService) UploadService {
+	return &uploadService{
+		Log:        utils.Log,
+		DB:         db,
+		Validate:   validate,
+		PDFService: pdfService,
+	}
+}
+
+func (s *uploadService) CreateUpload(c *fiber.Ctx, req *validation.CreateUpload, chunk io.Reader) (*model.Upload, error) {
+	if err := s.Validate.Struct(req); err != nil {
+		return nil, err
+	}
+
+	if filepath.Ext(req.Filename) != ".pdf" {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "Only PDF files are allowed")
+	}
+
+	if req.Length > config.PDFMaxSize {
+		return nil, fiber.NewError(fiber.StatusRequestEntityTooLarge, "Upload-Length exceeds the maximum limit of 10 MB")
+	}
+
+	s.removeExpiredUploads(c)
+
+	uploadDir := "./storage/uploads"
+	if err := os.MkdirAll(uploadDir, os.ModePerm); err != nil {
+		s.Log.Errorf("Failed to create upload directory: %+v", err)
+		return nil, fiber.NewError(fiber.StatusInternalServerError, "Failed to create upload directory")
+	}
+
+	upload := &model.Upload{
+		OriginalFilename: req.Filename,
+		Length:           req.Length,
+		Metadata:         req.Metadata,
+		ExpiresAt:        time.Now().Add(config.UploadExpiration),
+	}
+
+	file, err := os.CreateTemp(uploadDir, "*.part")
+	if err != nil {
+		s.Log.Errorf("Failed to create upload file: %+v", err)
+		return nil, fiber.NewError(fiber.StatusInternalServerError, "Failed to create upload")
+	}
+	file.Close()
+	upload.FilePath = file.Name()
+
+	if err := s.DB.WithContext(c.Context()).Create(upload).Error; err != nil {
+		os.Remove(upload.FilePath)
+		s.Log.Errorf("Failed to create upload record: %+v", err)
+		return nil, fiber.NewError(fiber.StatusInternalServerError, "Failed to create upload")
+	}
+
+	// creation-with-upload: the POST body may already carry the first chunk
+	if chunk != nil {
+		return s.WriteChunk(c, upload.ID.String(), 0, chunk)
+	}
+
+	return upload, nil
+}
+
+func (s *uploadService) GetUpload(c *fiber.Ctx, id string) (*model.Upload, error) {
+	upload := new(model.Upload)
+
+	result := s.DB.WithContext(c.Context()).First(upload, "id = ?", id)
+
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return nil, fiber.NewError(fiber.StatusNotFound, "Upload not found")
+	}
+
+	if result.Error != nil {
+		s.Log.Errorf("Failed to get upload by ID: %+v", result.Error)
+		return nil, result.Error
+	}
+
+	if upload.IsExpired() {
+		return nil, fiber.NewError(fiber.StatusGone, "Upload has expired")
+	}
+
+	return upload, nil
+}
+
+func (s *uploadService) WriteChunk(c *fiber.Ctx, id string, offset int64, chunk io.Reader) (*model.Upload, error) {
+	upload := new(model.Upload)
+	rejected := false
+
+	// The row lock serialises concurrent PATCH requests for the same upload,
+	// including ones handled by other prefork processes. It is held until the
+	// upload became a PDF, so a retried last chunk can't store it twice.
+	err := s.DB.WithContext(c.Context()).Transaction(func(tx *gorm.DB) error {
+		result := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(upload, "id = ?", id)
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return fiber.NewError(fiber.StatusNotFound, "Upload not found")
+		}
+		if result.Error != nil {
+			s.Log.Errorf("Failed to get upload by ID: %+v", result.Error)
+			return result.Error
+		}
+
+		if upload.IsExpired() {
+			return fiber.NewError(fiber.StatusGone, "Upload has expired")
+		}
+
+		if upload.PDFID != nil {
+			return fiber.NewError(fiber.StatusConflict, "Upload is already complete")
+		}
+
+		if upload.Offset != offset {
+			return fiber.NewError(fiber.StatusConflict, "Upload-Offset does not match the current offset")
+		}
+
+		written, err := s.appendChunk(upload, chunk)
+		if err != nil {
+			return err
+		}
+
+		upload.Offset += written
+		if err := tx.Model(upload).Update("upload_offset", upload.Offset).Error; err != nil {
+			return err
+		}
+
+		if upload.Offset != upload.Length {
+			return nil
+		}
+		err = s.finishUpload(c, tx, upload)
+		var fiberErr *fiber.Error
+		rejected = errors.As(err, &fiberErr) && fiberErr.Code == fiber.StatusBadRequest
+		return err
+	})
+
+	// Rejected files are removed along with the upload, once its row is
+	// no longer locked
+	if rejected {
+		if removeErr := s.removeUpload(c, upload); removeErr != nil {
+			s.Log.Errorf("Failed to remove rejected upload: %+v", removeErr)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return upload, nil
+}
+
+func (s *uploadService) TerminateUpload(c *fiber.Ctx, id string) error {
+	upload := new(model.Upload)
+
+	result := s.DB.WithContext(c.Context()).First(upload, "id = ?", id)
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return fiber.NewError(fiber.StatusNotFound, "Upload not found")
+	}
+	if result.Error != nil {
+		s.Log.Errorf("Failed to get upload by ID: %+v", result.Error)
+		return result.Error
+	}
+
+	return s.removeUpload(c, upload)
+}
+
+// appendChunk writes the chunk at the upload's current offset. Bytes left over
+// from an interrupted request are discarded first, and anything written is kept
+// even when the client disconnects midway so it can resume from there.
+func (s *uploadService) appendChunk(upload *model.Upload, chunk io.Reader) (int64, error) {
+	file, err := os.OpenFile(upload.FilePath, os.O_WRONLY, 0o600)
+	if err != nil {
+		s.Log.Errorf("Failed to open upload file: %+v", err)
+		return 0, fiber.NewError(fiber.StatusInternalServerError, "Failed to write upload")
+	}
+	defer file.Close()
+
+	if err := file.Truncate(upload.Offset); err != nil {
+		s.Log.Errorf("Failed to truncate upload file: %+v", err)
+		return 0, fiber.NewError(fiber.StatusInternalServerError, "Failed to write upload")
+	}
+
+	if _, err := file.Seek(upload.Offset, io.SeekStart); err != nil {
+		s.Log.Errorf("Failed to seek upload file: %+v", err)
+		return 0, fiber.NewError(fiber.StatusInternalServerError, "Failed to write upload")
+	}
+
+	remaining := upload.Length - upload.Offset
+	written, err := io.Copy(file, io.LimitReader(chunk, remaining+1))
+	if written > remaining {
+		if err := file.Truncate(upload.Offset); err != nil {
+			s.Log.Errorf("Failed to truncate upload file: %+v", err)
+		}
+		return 0, fiber.NewError(fiber.StatusRequestEntityTooLarge, "Chunk exceeds Upload-Length")
+	}
+	if err != nil {
+		s.Log.Warnf("Upload %s interrupted after %d bytes: %+v", upload.ID, written, err)
+	}
+
+	return written, nil
+}
+
+// finishUpload turns a complete upload into a PDF using the same validation as
+// a regular multipart upload, linking the two in the upload's transaction.
+func (s *uploadService) finishUpload(c *fiber.Ctx, tx *gorm.DB, upload *model.Upload) error {
+	pdf, err := s.PDFService.ImportFile(c, upload.FilePath, upload.OriginalFilename)
+	if err != nil {
+		return err
+	}
+
+	upload.PDFID = &pdf.ID
+	upload.FilePath = pdf.FilePath
+	if err := tx.Model(upload).Updates(map[string]interface{}{
+		"pdf_id":    pdf.ID,
+		"file_path": pdf.FilePath,
+	}).Error; err != nil {
+		s.Log.Errorf("Failed to link upload to PDF: %+v", err)
+		return fiber.NewError(fiber.StatusInternalServerError, "Failed to complete upload")
+	}
+
+	return nil
+}
+
+// removeUpload deletes the upload record and, unless it already became a PDF,
+// its partial file.
+func (s *uploadService) removeUpload(c *fiber.Ctx, upload *model.Upload) error {
+	if upload.PDFID == nil {
+		if err := os.Remove(upload.FilePath); err != nil && !os.IsNotExist(err) {
+			s.Log.Errorf("Failed to delete upload file: %+v", err)
+			return fiber.NewError(fiber.StatusInternalServerError, "Failed to delete upload")
+		}
+	}
+
+	if err := s.DB.WithContext(c.Context()).Delete(&model.Upload{}, "id = ?", upload.ID).Error; err != nil {
+		s.Log.Errorf("Failed to delete upload record: %+v", err)
+		return fiber.NewError(fiber.StatusInternalServerError, "Failed to delete upload")
+	}
+
+	return nil
+}
+
+// removeExpiredUploads is run opportunistically on creation so abandoned
+// uploads don't pile up on disk.
+func (s *uploadService) removeExpiredUploads(c *fiber.Ctx) {
+	var expired []model.Upload
+	result := s.DB.WithContext(c.Context()).
+		Where("pdf_id IS NULL AND expires_at < ?", time.Now()).
+		Limit(100).
+		Find(&expired)
+	if result.Error != nil {
+		s.Log.Errorf("Failed to get expired uploads: %+v", result.Error)
+		return
+	}
+
+	for i := range expired {
+		if err := s.removeUpload(c, &expired[i]); err != nil {
+			s.Log.Errorf("Failed to remove expired upload %s: %+v", expired[i].ID, err)
+		}
+	}
+
+	if len(expired) > 0 {
+		s.Log.Infof("Removed %d expired uploads", len(expired))
+	}
+}
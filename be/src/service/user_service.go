@@ -116,6 +116,7 @@ func (s *userService) CreateUser(c *fiber.Ctx, req *validation.CreateUser) (*mod
 		Email:    req.Email,
 		Password: hashedPassword,
 		Role:     req.Role,
+		Locale:   req.Locale,
 	}
 
 	result := s.DB.WithContext(c.Context()).Create(user)
@@ -136,7 +137,7 @@ func (s *userService) UpdateUser(c *fiber.Ctx, req *validation.UpdateUser, id st
 		return nil, err
 	}
 
-	if req.Email == "" && req.Name == "" && req.Password == "" {
+	if req.Email == "" && req.Name == "" && req.Password == "" && req.Locale == "" {
 		return nil, fiber.NewError(fiber.StatusBadRequest, "Invalid Request")
 	}
 
@@ -152,6 +153,7 @@ func (s *userService) UpdateUser(c *fiber.Ctx, req *validation.UpdateUser, id st
 		Name:     req.Name,
 		Password: req.Password,
 		Email:    req.Email,
+		Locale:   req.Locale,
 	}
 
 	result := s.DB.WithContext(c.Context()).Where("id = ?", id).Updates(updateBody)
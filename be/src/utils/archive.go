@@ -0,0 +1,54 @@
+package utils
+
+import (
+	"archive/zip"
+	"errors"
+	"io"
+	"path"
+	"strings"
+)
+
+var (
+	ErrUnsafePath        = errors.New("unsafe path in archive")
+	ErrSuspiciousArchive = errors.New("suspicious compression ratio")
+)
+
+// ArchiveEntryName checks a zip entry name and returns its base name. Names
+// that are absolute, use backslashes or climb out of the archive with ".."
+// are rejected rather than cleaned, since they only show up in crafted files.
+func ArchiveEntryName(name string) (string, error) {
+	if name == "" || strings.Contains(name, `\`) || path.IsAbs(name) {
+		return "", ErrUnsafePath
+	}
+
+	for _, segment := range strings.Split(name, "/") {
+		if segment == ".." {
+			return "", ErrUnsafePath
+		}
+	}
+
+	return path.Base(name), nil
+}
+
+// IsArchiveMetadata reports whether a zip entry is filesystem noise added by
+// archivers, such as macOS resource forks.
+func IsArchiveMetadata(name string) bool {
+	return strings.HasPrefix(name, "__MACOSX/") || strings.HasPrefix(path.Base(name), "._")
+}
+
+// OpenArchiveEntry opens a zip entry after checking its declared sizes against
+// maxSize and maxRatio. archive/zip fails reads that go past the declared
+// uncompressed size, so a forged header can't be used to get around these
+// checks.
+func OpenArchiveEntry(f *zip.File, maxSize int64, maxRatio uint64) (io.ReadCloser, error) {
+	if f.UncompressedSize64 > uint64(maxSize) {
+		return nil, ErrFileTooLarge
+	}
+
+	if f.CompressedSize64 == 0 && f.UncompressedSize64 > 0 ||
+		f.CompressedSize64 > 0 && f.UncompressedSize64/f.CompressedSize64 > maxRatio {
+		return nil, ErrSuspiciousArchive
+	}
+
+	return f.Open()
+}
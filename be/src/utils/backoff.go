@@ -0,0 +1,16 @@
+package utils
+
+import "time"
+
+// Backoff returns how long to wait after the given number of failed
+// attempts, doubling from base up to max.
+func Backoff(attempts int, base, max time.Duration) time.Duration {
+	delay := base
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+		if delay >= max {
+			return max
+		}
+	}
+	return delay
+}
@@ -0,0 +1,76 @@
+package utils
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hkdf"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+)
+
+var ErrInvalidSealedToken = errors.New("invalid sealed token")
+
+// SealToken encrypts and authenticates payload into an opaque token the
+// client can hold on to. The key is derived from secret and purpose, so
+// tokens minted for one purpose can't be replayed for another. Nothing is
+// stored server-side.
+func SealToken(secret, purpose string, payload interface{}) (string, error) {
+	aead, err := sealingAEAD(secret, purpose)
+	if err != nil {
+		return "", err
+	}
+
+	plaintext, err := json.Marshal(payload)
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+
+	sealed := aead.Seal(nonce, nonce, plaintext, []byte(purpose))
+	return base64.RawURLEncoding.EncodeToString(sealed), nil
+}
+
+// OpenToken verifies a token made by SealToken for the same purpose and
+// decodes its payload.
+func OpenToken(secret, purpose, token string, payload interface{}) error {
+	aead, err := sealingAEAD(secret, purpose)
+	if err != nil {
+		return err
+	}
+
+	sealed, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil || len(sealed) < aead.NonceSize() {
+		return ErrInvalidSealedToken
+	}
+
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, ciphertext, []byte(purpose))
+	if err != nil {
+		return ErrInvalidSealedToken
+	}
+
+	if err := json.Unmarshal(plaintext, payload); err != nil {
+		return ErrInvalidSealedToken
+	}
+	return nil
+}
+
+func sealingAEAD(secret, purpose string) (cipher.AEAD, error) {
+	key, err := hkdf.Key(sha256.New, []byte(secret), nil, "sealed-token:"+purpose, 32)
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
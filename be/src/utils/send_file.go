@@ -0,0 +1,287 @@
+package utils
+
+import (
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// maxRanges bounds how many ranges one request may ask for, so a client
+// can't make the server seek around a file thousands of times.
+const maxRanges = 16
+
+// FileResponse describes a stored file to send with SendFile.
+type FileResponse struct {
+	Path        string
+	Filename    string
+	ContentType string
+	// ETag is a strong validator without quotes, such as the content hash.
+	// Leave it empty to validate on modification time only.
+	ETag       string
+	Attachment bool
+	// CacheControl defaults to letting only the client cache the file, and
+	// only after revalidating it.
+	CacheControl string
+}
+
+type byteRange struct {
+	start, length int64
+}
+
+// SendFile sends a file with validators and byte range support: ETag and
+// Last-Modified with If-None-Match and If-Modified-Since, and Range with
+// If-Range, answering several ranges as multipart/byteranges.
+func SendFile(c *fiber.Ctx, f FileResponse) error {
+	file, err := os.Open(f.Path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return fiber.NewError(fiber.StatusNotFound, "File not found")
+		}
+		return err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	size := info.Size()
+	modTime := info.ModTime().UTC().Truncate(time.Second)
+
+	etag := ""
+	if f.ETag != "" {
+		etag = `"` + f.ETag + `"`
+		c.Set(fiber.HeaderETag, etag)
+	}
+	c.Set(fiber.HeaderLastModified, modTime.Format(http.TimeFormat))
+	c.Set(fiber.HeaderAcceptRanges, "bytes")
+	cacheControl := f.CacheControl
+	if cacheControl == "" {
+		cacheControl = "private, no-cache"
+	}
+	c.Set(fiber.HeaderCacheControl, cacheControl)
+
+	if notModified(c, etag, modTime) {
+		file.Close()
+		return c.SendStatus(fiber.StatusNotModified)
+	}
+
+	disposition := "inline"
+	if f.Attachment {
+		disposition = "attachment"
+	}
+	c.Set(fiber.HeaderContentDisposition, ContentDisposition(disposition, f.Filename))
+	c.Set(fiber.HeaderXContentTypeOptions, "nosniff")
+
+	rangeHeader := c.Get(fiber.HeaderRange)
+	if rangeHeader == "" || c.Method() != fiber.MethodGet || !ifRangeMatches(c, etag, modTime) {
+		c.Set(fiber.HeaderContentType, f.ContentType)
+		c.Context().SetBodyStream(file, int(size))
+		return nil
+	}
+
+	ranges, ok := parseRanges(rangeHeader, size)
+	if !ok {
+		// A malformed header is ignored, as if no range was asked for
+		c.Set(fiber.HeaderContentType, f.ContentType)
+		c.Context().SetBodyStream(file, int(size))
+		return nil
+	}
+	if len(ranges) == 0 {
+		file.Close()
+		c.Set(fiber.HeaderContentRange, fmt.Sprintf("bytes */%d", size))
+		return c.SendStatus(fiber.StatusRequestedRangeNotSatisfiable)
+	}
+
+	c.Status(fiber.StatusPartialContent)
+
+	if len(ranges) == 1 {
+		r := ranges[0]
+		c.Set(fiber.HeaderContentType, f.ContentType)
+		c.Set(fiber.HeaderContentRange, fmt.Sprintf("bytes %d-%d/%d", r.start, r.start+r.length-1, size))
+		c.Context().SetBodyStream(readCloser{io.NewSectionReader(file, r.start, r.length), file}, int(r.length))
+		return nil
+	}
+
+	body, writer := io.Pipe()
+	parts := multipart.NewWriter(writer)
+	c.Set(fiber.HeaderContentType, "multipart/byteranges; boundary="+parts.Boundary())
+
+	go func() {
+		defer file.Close()
+		writer.CloseWithError(writeRanges(parts, file, f.ContentType, ranges, size))
+	}()
+
+	c.Context().SetBodyStream(body, -1)
+	return nil
+}
+
+func writeRanges(parts *multipart.Writer, file *os.File, contentType string, ranges []byteRange, size int64) error {
+	for _, r := range ranges {
+		header := textproto.MIMEHeader{}
+		header.Set(fiber.HeaderContentType, contentType)
+		header.Set(fiber.HeaderContentRange, fmt.Sprintf("bytes %d-%d/%d", r.start, r.start+r.length-1, size))
+
+		part, err := parts.CreatePart(header)
+		if err != nil {
+			return err
+		}
+		if _, err := io.Copy(part, io.NewSectionReader(file, r.start, r.length)); err != nil {
+			return err
+		}
+	}
+	return parts.Close()
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// notModified evaluates If-None-Match, falling back to If-Modified-Since
+// only when the client sent no entity tags.
+func notModified(c *fiber.Ctx, etag string, modTime time.Time) bool {
+	if c.Method() != fiber.MethodGet && c.Method() != fiber.MethodHead {
+		return false
+	}
+
+	if inm := c.Get(fiber.HeaderIfNoneMatch); inm != "" {
+		return etag != "" && etagListMatches(inm, etag)
+	}
+
+	if ims := c.Get(fiber.HeaderIfModifiedSince); ims != "" {
+		t, err := http.ParseTime(ims)
+		return err == nil && !modTime.After(t)
+	}
+	return false
+}
+
+// etagListMatches compares with the weak comparison If-None-Match uses.
+func etagListMatches(list, etag string) bool {
+	for _, candidate := range strings.Split(list, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// ifRangeMatches reports whether a range request may be answered partially.
+// If-Range needs a strong match, so weak tags never match.
+func ifRangeMatches(c *fiber.Ctx, etag string, modTime time.Time) bool {
+	ifRange := c.Get(fiber.HeaderIfRange)
+	if ifRange == "" {
+		return true
+	}
+
+	if strings.HasPrefix(ifRange, `"`) {
+		return etag != "" && ifRange == etag
+	}
+	if strings.HasPrefix(ifRange, "W/") {
+		return false
+	}
+
+	t, err := http.ParseTime(ifRange)
+	return err == nil && t.Equal(modTime)
+}
+
+// parseRanges parses a "bytes=" Range header. It returns false when the
+// header is malformed, and no ranges when none of them can be satisfied.
+func parseRanges(header string, size int64) ([]byteRange, bool) {
+	spec, ok := strings.CutPrefix(header, "bytes=")
+	if !ok {
+		return nil, false
+	}
+
+	var ranges []byteRange
+	items := strings.Split(spec, ",")
+	if len(items) > maxRanges {
+		return nil, false
+	}
+
+	for _, item := range items {
+		first, last, ok := strings.Cut(strings.TrimSpace(item), "-")
+		if !ok {
+			return nil, false
+		}
+
+		var r byteRange
+		if first == "" {
+			// A suffix range: the last n bytes
+			n, err := strconv.ParseInt(last, 10, 64)
+			if err != nil || n < 0 {
+				return nil, false
+			}
+			if n == 0 || size == 0 {
+				continue
+			}
+			n = min(n, size)
+			r = byteRange{start: size - n, length: n}
+		} else {
+			start, err := strconv.ParseInt(first, 10, 64)
+			if err != nil || start < 0 {
+				return nil, false
+			}
+			end := size - 1
+			if last != "" {
+				if end, err = strconv.ParseInt(last, 10, 64); err != nil || end < start {
+					return nil, false
+				}
+			}
+			if start >= size {
+				continue
+			}
+			end = min(end, size-1)
+			r = byteRange{start: start, length: end - start + 1}
+		}
+
+		ranges = append(ranges, r)
+	}
+	return ranges, true
+}
+
+// ContentDisposition builds a Content-Disposition header value. Names that
+// aren't plain ASCII get an ASCII fallback plus an RFC 5987 filename*.
+func ContentDisposition(disposition, filename string) string {
+	fallback := strings.Map(func(r rune) rune {
+		if r < 0x20 || r > 0x7e || r == '"' || r == '\\' {
+			return '_'
+		}
+		return r
+	}, filename)
+
+	value := disposition + `; filename="` + fallback + `"`
+	if fallback != filename {
+		value += "; filename*=UTF-8''" + encodeRFC5987(filename)
+	}
+	return value
+}
+
+func encodeRFC5987(s string) string {
+	var b strings.Builder
+	for _, c := range []byte(s) {
+		if isAttrChar(c) {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+func isAttrChar(c byte) bool {
+	switch {
+	case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		return true
+	}
+	return strings.IndexByte("!#$&+-.^_`|~", c) >= 0
+}
@@ -0,0 +1,179 @@
+package utils
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"os"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+var (
+	ErrFileTooLarge    = errors.New("file exceeds the size limit")
+	ErrInvalidFileType = errors.New("invalid file type")
+)
+
+// maxDrain is how much unread body is discarded after an upload before
+// giving up on keeping the connection alive.
+const maxDrain = 64 * 1024
+
+type StoredFile struct {
+	Path     string
+	Size     int64
+	Hash     string
+	MIMEType string
+}
+
+// bodyStreamKey is the Locals key the body reader of a request is kept under.
+const bodyStreamKey = "bodyStream"
+
+// BodyStream returns the request body as a reader. With StreamRequestBody
+// enabled this reads straight from the connection instead of a buffered copy.
+// Every call for a request returns the same reader, which keeps returning
+// its error once the body ends: a chunked body read past its end would wait
+// for the next request on the connection instead.
+func BodyStream(c *fiber.Ctx) io.Reader {
+	if body, ok := c.Locals(bodyStreamKey).(io.Reader); ok {
+		return body
+	}
+
+	var body io.Reader
+	if stream := c.Request().BodyStream(); stream != nil {
+		body = &endReader{r: stream}
+	} else {
+		body = bytes.NewReader(c.Body())
+	}
+	c.Locals(bodyStreamKey, body)
+	return body
+}
+
+// endReader stops reading from r after its first error, io.EOF included.
+type endReader struct {
+	r   io.Reader
+	err error
+}
+
+func (e *endReader) Read(p []byte) (int, error) {
+	if e.err != nil {
+		return 0, e.err
+	}
+	n, err := e.r.Read(p)
+	e.err = err
+	return n, err
+}
+
+// FormFile is a file part read straight from the request body.
+type FormFile struct {
+	*multipart.Part
+	c    *fiber.Ctx
+	body io.Reader
+}
+
+// Close discards what is left of the request body so the connection can be
+// reused, or marks it to be closed when too much is left to read.
+func (f *FormFile) Close() error {
+	f.Part.Close()
+	return DrainBody(f.c, f.body)
+}
+
+// FormFileStream returns the first file part named field of a multipart
+// request without loading the form into memory. Parts before it are skipped.
+func FormFileStream(c *fiber.Ctx, field string) (*FormFile, error) {
+	reader, body, err := MultipartStream(c)
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			return nil, http.ErrMissingFile
+		}
+		if err != nil {
+			DrainBody(c, body)
+			return nil, err
+		}
+
+		if part.FormName() == field && part.FileName() != "" {
+			return &FormFile{Part: part, c: c, body: body}, nil
+		}
+		part.Close()
+	}
+}
+
+// MultipartStream returns a reader over the parts of a multipart request as
+// they arrive, along with the underlying body. Callers should pass the body
+// to DrainBody once they are done with the parts.
+func MultipartStream(c *fiber.Ctx) (*multipart.Reader, io.Reader, error) {
+	boundary := string(c.Request().Header.MultipartFormBoundary())
+	if boundary == "" {
+		return nil, nil, http.ErrNotMultipart
+	}
+
+	body := BodyStream(c)
+	return multipart.NewReader(body, boundary), body, nil
+}
+
+// DrainBody reads the rest of a streamed body. If more than a small amount
+// remains, the connection is closed after the response instead.
+func DrainBody(c *fiber.Ctx, body io.Reader) error {
+	n, err := io.Copy(io.Discard, io.LimitReader(body, maxDrain+1))
+	if err != nil || n > maxDrain {
+		c.Response().SetConnectionClose()
+	}
+	return nil
+}
+
+// StoreStream writes r to path while hashing it and sniffing its MIME type
+// from the first 512 bytes. It stops reading as soon as the type doesn't
+// match mimeType or more than maxSize bytes arrive, so memory use stays flat
+// whatever the size of the input. Nothing is left at path on failure.
+func StoreStream(r io.Reader, path string, maxSize int64, mimeType string) (*StoredFile, error) {
+	head := make([]byte, 512)
+	n, err := io.ReadFull(r, head)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return nil, err
+	}
+	head = head[:n]
+
+	detected := http.DetectContentType(head)
+	if detected != mimeType {
+		return nil, ErrInvalidFileType
+	}
+
+	tmpPath := path + ".part"
+	file, err := os.Create(tmpPath)
+	if err != nil {
+		return nil, err
+	}
+
+	hash := sha256.New()
+	body := io.LimitReader(io.MultiReader(bytes.NewReader(head), r), maxSize+1)
+
+	size, err := io.Copy(io.MultiWriter(file, hash), body)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil && size > maxSize {
+		err = ErrFileTooLarge
+	}
+	if err == nil {
+		err = os.Rename(tmpPath, path)
+	}
+	if err != nil {
+		os.Remove(tmpPath)
+		return nil, err
+	}
+
+	return &StoredFile{
+		Path:     path,
+		Size:     size,
+		Hash:     hex.EncodeToString(hash.Sum(nil)),
+		MIMEType: detected,
+	}, nil
+}
@@ -0,0 +1,41 @@
+package utils
+
+import (
+	"encoding/base64"
+	"errors"
+	"strings"
+)
+
+// ParseTusMetadata decodes a tus Upload-Metadata header: comma separated
+// pairs of a key and an optional base64 encoded value.
+func ParseTusMetadata(header string) (map[string]string, error) {
+	metadata := make(map[string]string)
+	if strings.TrimSpace(header) == "" {
+		return metadata, nil
+	}
+
+	for _, pair := range strings.Split(header, ",") {
+		parts := strings.Fields(pair)
+		if len(parts) == 0 || len(parts) > 2 {
+			return nil, errors.New("malformed metadata pair")
+		}
+
+		key := parts[0]
+		if _, exists := metadata[key]; exists {
+			return nil, errors.New("duplicate metadata key")
+		}
+
+		if len(parts) == 1 {
+			metadata[key] = ""
+			continue
+		}
+
+		value, err := base64.StdEncoding.DecodeString(parts[1])
+		if err != nil {
+			return nil, errors.New("metadata value is not valid base64")
+		}
+		metadata[key] = string(value)
+	}
+
+	return metadata, nil
+}
@@ -4,6 +4,7 @@ type Register struct {
 	Name     string `json:"name" validate:"required,max=50" example:"fake name"`
 	Email    string `json:"email" validate:"required,email,max=50" example:"fake@example.com"`
 	Password string `json:"password" validate:"required,min=8,max=20,password" example:"password1"`
+	Locale   string `json:"locale,omitempty" validate:"omitempty,oneof=en id ja" example:"en"`
 }
 
 type Login struct {
@@ -0,0 +1,7 @@
+package validation
+
+type BulkUpload struct {
+	Summarize  bool   `json:"summarize" example:"true"`
+	Language   string `json:"language" validate:"omitempty,oneof=auto id en ja" example:"auto"`
+	OutputType string `json:"output_type" validate:"omitempty,oneof=paragraph bullet pointer" example:"paragraph"`
+}
@@ -0,0 +1,7 @@
+package validation
+
+type QueryEmailOutbox struct {
+	Status string `validate:"omitempty,oneof=pending sending sent dead"`
+	Page   int    `validate:"omitempty,min=1"`
+	Limit  int    `validate:"omitempty,min=1,max=100"`
+}
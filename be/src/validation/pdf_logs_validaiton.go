@@ -7,6 +7,7 @@ type QueryPDFLog struct {
 	Sort       string `json:"sort" validate:"omitempty,oneof=date_desc date_asc a_z z_a"`
 	Language   string `json:"language" validate:"omitempty,oneof=auto id en ja"`
 	OutputType string `json:"output_type" validate:"omitempty,oneof=paragraph bullet pointer"`
+	Version    int    `json:"version" validate:"omitempty,min=1"`
 }
 
 func (q *QueryPDFLog) SetDefaults() {
@@ -4,6 +4,10 @@ type UploadPDF struct {
 	File interface{} `json:"file" validate:"required" swaggertype:"file" example:"document.pdf"`
 }
 
+type ImportPDF struct {
+	URL string `json:"url" validate:"required,url,max=2048" example:"https://arxiv.org/pdf/1706.03762"`
+}
+
 type QueryPDF struct {
 	Page   int    `validate:"omitempty,number,max=50"`
 	Limit  int    `validate:"omitempty,number,max=50"`
@@ -15,6 +19,86 @@ type SummarizePDF struct {
 }
 
 type SummarizeRequest struct {
-	Language   string `json:"language" validate:"required,oneof=auto id en ja"`
-	OutputType string `json:"output_type" validate:"required,oneof=paragraph bullet pointer"`
+	Language    string `json:"language" validate:"required,oneof=auto id en ja"`
+	OutputType  string `json:"output_type" validate:"required,oneof=paragraph bullet pointer"`
+	UnlockToken string `json:"unlock_token,omitempty" validate:"omitempty,max=1024"`
+	Mode        string `json:"mode,omitempty" validate:"omitempty,oneof=document chapters"`
+}
+
+type UnlockPDF struct {
+	Password string `json:"password" validate:"required,max=127" example:"secret"`
+}
+
+type PageRange struct {
+	Start int `json:"start" validate:"required,min=1" example:"1"`
+	End   int `json:"end" validate:"required,gtefield=Start" example:"10"`
+}
+
+type SplitPDF struct {
+	Ranges      []PageRange `json:"ranges" validate:"required,min=1,max=50,dive"`
+	UnlockToken string      `json:"unlock_token,omitempty" validate:"omitempty,max=1024"`
+}
+
+type MergePDFs struct {
+	PDFIDs   []string `json:"pdf_ids" validate:"required,min=2,max=20,dive,uuid" example:"550e8400-e29b-41d4-a716-446655440000"`
+	Filename string   `json:"filename,omitempty" validate:"omitempty,max=255" example:"merged.pdf"`
+	// UnlockTokens holds unlock tokens of protected files by PDF ID.
+	UnlockTokens map[string]string `json:"unlock_tokens,omitempty" validate:"omitempty,max=20,dive,max=1024"`
+}
+
+// RedactRegion is an area of a page in PDF points, from the bottom-left
+// corner.
+type RedactRegion struct {
+	Page int     `json:"page" validate:"required,min=1" example:"1"`
+	X0   float64 `json:"x0" validate:"gte=0" example:"72"`
+	Y0   float64 `json:"y0" validate:"gte=0" example:"700"`
+	X1   float64 `json:"x1" validate:"gte=0" example:"300"`
+	Y1   float64 `json:"y1" validate:"gte=0" example:"720"`
+}
+
+type RedactPDF struct {
+	Detectors []string `json:"detectors,omitempty" validate:"omitempty,max=6,dive,oneof=email phone nik my_number iban credit_card" example:"email,phone"`
+	// Texts are removed wherever they appear on a line, ignoring case.
+	Texts       []string       `json:"texts,omitempty" validate:"omitempty,max=100,dive,required,max=500" example:"Budi Santoso"`
+	Regions     []RedactRegion `json:"regions,omitempty" validate:"omitempty,max=500,dive"`
+	UnlockToken string         `json:"unlock_token,omitempty" validate:"omitempty,max=1024"`
+}
+
+type QueryExportSummaries struct {
+	PDFIDs []string `validate:"required,min=1,max=50,dive,uuid"`
+	Format string   `validate:"required,oneof=md docx pdf html"`
+}
+
+type QuerySignatures struct {
+	UnlockToken string `validate:"omitempty,max=1024"`
+}
+
+// QueryUnlockToken carries the unlock token needed to read what was taken
+// from a password-protected PDF.
+type QueryUnlockToken struct {
+	UnlockToken string `validate:"omitempty,max=1024"`
+}
+
+type QueryThumbnail struct {
+	Width       int    `validate:"omitempty,min=32,max=1024"`
+	Format      string `validate:"omitempty,oneof=png webp"`
+	UnlockToken string `validate:"omitempty,max=1024"`
+}
+
+type QueryImportArchive struct {
+	// OnConflict decides what happens to documents already in the library,
+	// skip by default.
+	OnConflict string `validate:"omitempty,oneof=skip duplicate"`
+}
+
+type EmailSummary struct {
+	Recipients []string `json:"recipients" validate:"required,min=1,max=10,dive,required,email,max=254" example:"budi@example.com"`
+	// Note is added above the summary.
+	Note      string `json:"note,omitempty" validate:"omitempty,max=2000" example:"Notes for tomorrow's meeting"`
+	AttachPDF bool   `json:"attach_pdf" example:"false"`
+}
+
+type QuerySummaryEmails struct {
+	Page  int `validate:"omitempty,min=1"`
+	Limit int `validate:"omitempty,min=1,max=100"`
 }
@@ -0,0 +1,13 @@
+package validation
+
+type CreateShare struct {
+	// ExpiresInHours defaults to a week and may be at most 90 days.
+	ExpiresInHours int    `json:"expires_in_hours" validate:"omitempty,min=1,max=2160" example:"72"`
+	Password       string `json:"password,omitempty" validate:"omitempty,min=4,max=72" example:"secret"`
+	MaxViews       *int   `json:"max_views,omitempty" validate:"omitempty,min=1,max=100000" example:"10"`
+}
+
+type QueryShareAccess struct {
+	Page  int `validate:"omitempty,min=1"`
+	Limit int `validate:"omitempty,min=1,max=100"`
+}
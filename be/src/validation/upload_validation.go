@@ -0,0 +1,7 @@
+package validation
+
+type CreateUpload struct {
+	Length   int64  `validate:"required,min=1"`
+	Filename string `validate:"required,max=255"`
+	Metadata string `validate:"omitempty,max=4096"`
+}
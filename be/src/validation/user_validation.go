@@ -5,12 +5,14 @@ type CreateUser struct {
 	Email    string `json:"email" validate:"required,email,max=50" example:"fake@example.com"`
 	Password string `json:"password" validate:"required,min=8,max=20,password" example:"password1"`
 	Role     string `json:"role" validate:"required,oneof=user admin,max=50" example:"user"`
+	Locale   string `json:"locale,omitempty" validate:"omitempty,oneof=en id ja" example:"en"`
 }
 
 type UpdateUser struct {
 	Name     string `json:"name,omitempty" validate:"omitempty,max=50" example:"fake name"`
 	Email    string `json:"email" validate:"omitempty,email,max=50" example:"fake@example.com"`
 	Password string `json:"password,omitempty" validate:"omitempty,min=8,max=20,password" example:"password1"`
+	Locale   string `json:"locale,omitempty" validate:"omitempty,oneof=en id ja" example:"en"`
 }
 
 type UpdatePassOrVerify struct {
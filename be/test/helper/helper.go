@@ -16,6 +16,10 @@ import (
 func ClearAll(db *gorm.DB) {
 	ClearToken(db)
 	ClearUsers(db)
+	ClearOutbox(db)
+	ClearUploads(db)
+	ClearPDFs(db)
+	ClearRateLimits(db)
 }
 
 func ClearUsers(db *gorm.DB) {
@@ -32,6 +36,36 @@ func ClearToken(db *gorm.DB) {
 	}
 }
 
+func ClearOutbox(db *gorm.DB) {
+	err := db.Where("id is not null").Delete(&model.EmailOutbox{}).Error
+	if err != nil {
+		logrus.Fatalf("Failed clear email outbox : %+v", err)
+	}
+}
+
+func ClearUploads(db *gorm.DB) {
+	err := db.Where("id is not null").Delete(&model.Upload{}).Error
+	if err != nil {
+		logrus.Fatalf("Failed clear upload data : %+v", err)
+	}
+}
+
+func ClearRateLimits(db *gorm.DB) {
+	err := db.Where("key is not null").Delete(&model.RateLimit{}).Error
+	if err != nil {
+		logrus.Fatalf("Failed clear rate limit data : %+v", err)
+	}
+}
+
+// ClearPDFs deletes every PDF, along with its versions, logs and everything
+// else removed with it.
+func ClearPDFs(db *gorm.DB) {
+	err := db.Where("id is not null").Delete(&model.PDF{}).Error
+	if err != nil {
+		logrus.Fatalf("Failed clear pdf data : %+v", err)
+	}
+}
+
 func CreateUser(db *gorm.DB, email, password, name string) {
 	hashedPassword, err := utils.HashPassword(password)
 	if err != nil {
@@ -165,3 +199,11 @@ func GetUserByID(db *gorm.DB, id string) (*model.User, error) {
 
 	return user, result.Error
 }
+
+func GetOutboxEmailsTo(db *gorm.DB, email string) ([]model.EmailOutbox, error) {
+	var emails []model.EmailOutbox
+
+	result := db.Where("recipients @> ?", `["`+email+`"]`).Find(&emails)
+
+	return emails, result.Error
+}
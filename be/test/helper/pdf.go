@@ -0,0 +1,151 @@
+package helper
+
+import (
+	"app/src/model"
+	"bytes"
+	"crypto/md5"
+	"crypto/rc4"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// PDF builds a valid one-page document showing text, so documents with
+// different text have different content hashes.
+func PDF(text string) []byte {
+	return buildPDF("", []byte(pageContent(text)))
+}
+
+// ProtectedPDF builds the document PDF does, encrypted with RC4 by the
+// standard security handler (revision 3) so it only opens with password.
+func ProtectedPDF(text, password string) []byte {
+	id := []byte("0123456789abcdef")
+	const permissions = int32(-3904)
+
+	ownerKey := md5.Sum(padPassword(password))
+	for i := 0; i < 50; i++ {
+		ownerKey = md5.Sum(ownerKey[:])
+	}
+	o := padPassword(password)
+	for i := 0; i < 20; i++ {
+		rc4XOR(xorKey(ownerKey[:], byte(i)), o)
+	}
+
+	digest := md5.New()
+	digest.Write(padPassword(password))
+	digest.Write(o)
+	binary.Write(digest, binary.LittleEndian, permissions)
+	digest.Write(id)
+	key := digest.Sum(nil)
+	for i := 0; i < 50; i++ {
+		sum := md5.Sum(key)
+		key = sum[:]
+	}
+
+	sum := md5.Sum(append(padPassword(""), id...))
+	u := sum[:]
+	for i := 0; i < 20; i++ {
+		rc4XOR(xorKey(key, byte(i)), u)
+	}
+	u = append(u, make([]byte, 16)...)
+
+	// The content stream is object 4, generation 0
+	objectKey := md5.Sum(append(append([]byte(nil), key...), 4, 0, 0, 0, 0))
+	content := []byte(pageContent(text))
+	rc4XOR(objectKey[:], content)
+
+	encrypt := fmt.Sprintf("<< /Filter /Standard /V 2 /R 3 /Length 128 /O <%x> /U <%x> /P %d >>", o, u, permissions)
+	return buildPDF(fmt.Sprintf(" /Encrypt 6 0 R /ID [<%x> <%x>]", id, id), content, encrypt)
+}
+
+func pageContent(text string) string {
+	return fmt.Sprintf("BT /F1 12 Tf 72 720 Td (%s) Tj ET", text)
+}
+
+// buildPDF lays out the page objects around content, followed by extra
+// objects numbered from 6, with trailer added to the trailer dictionary.
+func buildPDF(trailer string, content []byte, extra ...string) []byte {
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 /MediaBox [0 0 595 842] >>",
+		"<< /Type /Page /Parent 2 0 R /Contents 4 0 R /Resources << /Font << /F1 5 0 R >> >> >>",
+		fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", len(content), content),
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica >>",
+	}
+	objects = append(objects, extra...)
+
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.7\n%\xe2\xe3\xcf\xd3\n")
+
+	offsets := make([]int, len(objects))
+	for i, body := range objects {
+		offsets[i] = buf.Len()
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", i+1, body)
+	}
+
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R%s >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, trailer, xref)
+
+	return buf.Bytes()
+}
+
+var passwordPadding = []byte("\x28\xbf\x4e\x5e\x4e\x75\x8a\x41\x64\x00\x4e\x56\xff\xfa\x01\x08\x2e\x2e\x00\xb6\xd0\x68\x3e\x80\x2f\x0c\xa9\xfe\x64\x53\x69\x7a")
+
+func padPassword(password string) []byte {
+	return append([]byte(password), passwordPadding...)[:32]
+}
+
+func xorKey(key []byte, b byte) []byte {
+	out := make([]byte, len(key))
+	for i := range key {
+		out[i] = key[i] ^ b
+	}
+	return out
+}
+
+func rc4XOR(key, data []byte) {
+	cipher, _ := rc4.NewCipher(key)
+	cipher.XORKeyStream(data, data)
+}
+
+// ContentHash is the hash a stored file is known by.
+func ContentHash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// InsertPDF stores data as a clean file in dir and saves a PDF for it.
+func InsertPDF(db *gorm.DB, dir, filename string, data []byte) *model.PDF {
+	path := filepath.Join(dir, filename)
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		logrus.Fatalf("Failed to write pdf file : %+v", err)
+	}
+
+	pdf := &model.PDF{
+		Filename:         filename,
+		OriginalFilename: filename,
+		FilePath:         path,
+		FileSize:         int64(len(data)),
+		ContentHash:      ContentHash(data),
+		Version:          1,
+		PageCount:        1,
+		ScanStatus:       model.ScanStatusClean,
+		Language:         "en",
+		OutputType:       "paragraph",
+		SummaryStatus:    "pending",
+	}
+	if err := db.Create(pdf).Error; err != nil {
+		logrus.Fatalf("Failed to create pdf : %+v", err)
+	}
+	return pdf
+}
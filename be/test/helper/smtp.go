@@ -0,0 +1,157 @@
+package helper
+
+import (
+	"bufio"
+	"net"
+	"strings"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+// SMTPServer is a local SMTP server that keeps what it receives. Recipients
+// can be refused for good with a 550 reply, or for a number of attempts with
+// a 451 reply, which senders retry.
+type SMTPServer struct {
+	listener net.Listener
+
+	mu       sync.Mutex
+	rejected map[string]bool
+	failures map[string]int
+	messages []string
+}
+
+func NewSMTPServer() *SMTPServer {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		logrus.Fatalf("Failed to start smtp server : %+v", err)
+	}
+
+	server := &SMTPServer{
+		listener: listener,
+		rejected: map[string]bool{},
+		failures: map[string]int{},
+	}
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go server.serve(conn)
+		}
+	}()
+
+	return server
+}
+
+func (s *SMTPServer) Host() string {
+	return s.listener.Addr().(*net.TCPAddr).IP.String()
+}
+
+func (s *SMTPServer) Port() int {
+	return s.listener.Addr().(*net.TCPAddr).Port
+}
+
+func (s *SMTPServer) Close() {
+	s.listener.Close()
+}
+
+// Reject refuses every email to address for good.
+func (s *SMTPServer) Reject(address string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rejected[address] = true
+}
+
+// Fail refuses the next n emails to address for now.
+func (s *SMTPServer) Fail(address string, n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failures[address] = n
+}
+
+// Received returns the messages received so far, with their headers.
+func (s *SMTPServer) Received() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.messages...)
+}
+
+// ReceivedBy returns the messages received so far that were sent to address.
+func (s *SMTPServer) ReceivedBy(address string) []string {
+	var messages []string
+	for _, message := range s.Received() {
+		if strings.Contains(message, address) {
+			messages = append(messages, message)
+		}
+	}
+	return messages
+}
+
+// recipientReply is the reply to RCPT TO for address.
+func (s *SMTPServer) recipientReply(address string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.rejected[address] {
+		return "550 5.1.1 No such user"
+	}
+	if s.failures[address] > 0 {
+		s.failures[address]--
+		return "451 4.3.0 Try again later"
+	}
+	return "250 OK"
+}
+
+func (s *SMTPServer) serve(conn net.Conn) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	reply := func(line string) {
+		conn.Write([]byte(line + "\r\n"))
+	}
+
+	reply("220 localhost fake SMTP")
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+		command := strings.ToUpper(strings.TrimSpace(line))
+
+		switch {
+		case strings.HasPrefix(command, "EHLO"), strings.HasPrefix(command, "HELO"):
+			reply("250 localhost")
+		case strings.HasPrefix(command, "MAIL FROM:"):
+			reply("250 OK")
+		case strings.HasPrefix(command, "RCPT TO:"):
+			address := strings.Trim(strings.TrimSpace(line)[len("RCPT TO:"):], "<>")
+			reply(s.recipientReply(address))
+		case command == "DATA":
+			reply("354 End data with <CR><LF>.<CR><LF>")
+			var message strings.Builder
+			for {
+				dataLine, err := reader.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if dataLine == ".\r\n" {
+					break
+				}
+				message.WriteString(dataLine)
+			}
+			s.mu.Lock()
+			s.messages = append(s.messages, message.String())
+			s.mu.Unlock()
+			reply("250 OK")
+		case command == "RSET", command == "NOOP":
+			reply("250 OK")
+		case command == "QUIT":
+			reply("221 Bye")
+			return
+		default:
+			reply("502 Command not implemented")
+		}
+	}
+}
@@ -1,9 +1,11 @@
 package test
 
 import (
+	"app/src/config"
 	"app/src/database"
 	"app/src/router"
 	"app/src/utils"
+	"app/test/helper"
 
 	"github.com/gofiber/fiber/v2"
 	"gorm.io/gorm"
@@ -16,8 +18,17 @@ var App = fiber.New(fiber.Config{
 var DB *gorm.DB
 var Log = utils.Log
 
+// SMTP receives every email the app sends during tests.
+var SMTP *helper.SMTPServer
+
 func init() {
-	// TODO: You can modify host and database configuration for tests
+	SMTP = helper.NewSMTPServer()
+	config.SMTPHost = SMTP.Host()
+	config.SMTPPort = SMTP.Port()
+	config.SMTPUsername = ""
+	config.SMTPPassword = ""
+
+	// TODO: You can modify host and database configuration for tests
 	DB = database.Connect("localhost", "testdb")
 	router.Routes(App, DB)
 	App.Use(utils.NotFoundHandler)
@@ -0,0 +1,308 @@
+package integration
+
+import (
+	"app/src/archive"
+	"app/src/model"
+	"app/src/response"
+	"app/src/service"
+	"app/test"
+	"app/test/fixture"
+	"app/test/helper"
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestArchiveRoutes(t *testing.T) {
+	t.Cleanup(func() { os.RemoveAll("./storage") })
+
+	t.Run("POST /v1/exports", func(t *testing.T) {
+		t.Run("should return 403 error if user is not an admin", func(t *testing.T) {
+			helper.ClearAll(test.DB)
+			helper.InsertUser(test.DB, fixture.UserOne)
+
+			userOneAccessToken, err := fixture.AccessToken(fixture.UserOne)
+			assert.Nil(t, err)
+
+			request := httptest.NewRequest(http.MethodPost, "/v1/exports", nil)
+			request.Header.Set("Authorization", "Bearer "+userOneAccessToken)
+
+			apiResponse, err := test.App.Test(request)
+			assert.Nil(t, err)
+
+			assert.Equal(t, http.StatusForbidden, apiResponse.StatusCode)
+
+			var count int64
+			test.DB.Model(&model.AccountExport{}).Count(&count)
+			assert.Zero(t, count)
+		})
+
+		t.Run("should return 202 and queue the export for an admin", func(t *testing.T) {
+			helper.ClearAll(test.DB)
+			helper.InsertUser(test.DB, fixture.Admin)
+
+			adminAccessToken, err := fixture.AccessToken(fixture.Admin)
+			assert.Nil(t, err)
+
+			request := httptest.NewRequest(http.MethodPost, "/v1/exports", nil)
+			request.Header.Set("Authorization", "Bearer "+adminAccessToken)
+
+			apiResponse, err := test.App.Test(request)
+			assert.Nil(t, err)
+
+			assert.Equal(t, http.StatusAccepted, apiResponse.StatusCode)
+		})
+	})
+
+	t.Run("POST /v1/imports", func(t *testing.T) {
+		parentFile := helper.PDF("Annual report")
+		childV1 := helper.PDF("Annual report summary, draft")
+		childV2 := helper.PDF("Annual report summary")
+
+		parentID, childID := uuid.New(), uuid.New()
+		created := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+		summary := "A short summary."
+
+		// The child comes first, so its parent only gets a new id later
+		manifest := archive.Manifest{
+			Format:     archive.Format,
+			Version:    archive.Version,
+			ExportedAt: created,
+			PDFs: []archive.PDF{
+				{
+					ID:               childID,
+					ParentID:         &parentID,
+					OriginalFilename: "summary.pdf",
+					Version:          2,
+					Summary:          &summary,
+					Language:         "en",
+					OutputType:       "paragraph",
+					SummaryStatus:    "completed",
+					UploadDate:       created,
+					CreatedAt:        created,
+					Versions: []archive.FileVersion{
+						archiveVersion(childID, 1, childV1, created),
+						archiveVersion(childID, 2, childV2, created.Add(time.Hour)),
+					},
+					Logs: []archive.Log{
+						{Version: 1, Summary: "An earlier summary.", Language: "en", OutputType: "paragraph", CreatedAt: created},
+					},
+				},
+				{
+					ID:               parentID,
+					OriginalFilename: "report.pdf",
+					Version:          1,
+					Language:         "en",
+					OutputType:       "paragraph",
+					SummaryStatus:    "pending",
+					UploadDate:       created,
+					CreatedAt:        created,
+					Versions: []archive.FileVersion{
+						archiveVersion(parentID, 1, parentFile, created),
+					},
+				},
+			},
+		}
+		files := map[string][]byte{
+			archive.FileEntry(childID, 1):  childV1,
+			archive.FileEntry(childID, 2):  childV2,
+			archive.FileEntry(parentID, 1): parentFile,
+		}
+
+		var firstImport map[uuid.UUID]uuid.UUID
+
+		t.Run("should return 403 error if user is not an admin", func(t *testing.T) {
+			helper.ClearAll(test.DB)
+			helper.InsertUser(test.DB, fixture.UserOne)
+
+			statusCode, _ := importArchive(t, fixture.UserOne, zipArchive(t, manifest, files), "")
+
+			assert.Equal(t, http.StatusForbidden, statusCode)
+
+			var count int64
+			test.DB.Model(&model.PDF{}).Count(&count)
+			assert.Zero(t, count)
+		})
+
+		t.Run("should give every document a new id and restore links and history", func(t *testing.T) {
+			helper.ClearAll(test.DB)
+			helper.InsertUser(test.DB, fixture.Admin)
+
+			statusCode, res := importArchive(t, fixture.Admin, zipArchive(t, manifest, files), "")
+
+			assert.Equal(t, http.StatusOK, statusCode)
+			assert.Equal(t, 2, res.Imported)
+			assert.Zero(t, res.Skipped)
+			assert.Zero(t, res.Failed)
+
+			firstImport = importedIDs(t, res)
+			require.Len(t, firstImport, 2)
+			assert.NotEqual(t, childID, firstImport[childID])
+			assert.NotEqual(t, parentID, firstImport[parentID])
+
+			child := new(model.PDF)
+			require.NoError(t, test.DB.First(child, "id = ?", firstImport[childID]).Error)
+			require.NotNil(t, child.ParentID)
+			assert.Equal(t, firstImport[parentID], *child.ParentID)
+			assert.Equal(t, 2, child.Version)
+			assert.Equal(t, helper.ContentHash(childV2), child.ContentHash)
+			assert.True(t, child.CreatedAt.Equal(created))
+
+			var versions []model.PDFVersion
+			test.DB.Where("pdf_id = ?", child.ID).Order("version").Find(&versions)
+			require.Len(t, versions, 2)
+			assert.Equal(t, helper.ContentHash(childV1), versions[0].ContentHash)
+			assert.Equal(t, helper.ContentHash(childV2), versions[1].ContentHash)
+
+			var logs []model.PDFLog
+			test.DB.Where("pdf_id = ?", child.ID).Find(&logs)
+			require.Len(t, logs, 1)
+			assert.Equal(t, "An earlier summary.", logs[0].Summary)
+		})
+
+		t.Run("should skip documents already in the library", func(t *testing.T) {
+			statusCode, res := importArchive(t, fixture.Admin, zipArchive(t, manifest, files), "")
+
+			assert.Equal(t, http.StatusOK, statusCode)
+			assert.Zero(t, res.Imported)
+			assert.Equal(t, 2, res.Skipped)
+			for _, result := range res.Data {
+				require.NotNil(t, result.PDFID)
+				assert.Equal(t, firstImport[result.SourceID], *result.PDFID)
+			}
+
+			var count int64
+			test.DB.Model(&model.PDF{}).Count(&count)
+			assert.Equal(t, int64(2), count)
+		})
+
+		t.Run("should import them again with on_conflict=duplicate", func(t *testing.T) {
+			statusCode, res := importArchive(t, fixture.Admin, zipArchive(t, manifest, files), "duplicate")
+
+			assert.Equal(t, http.StatusOK, statusCode)
+			assert.Equal(t, 2, res.Imported)
+
+			ids := importedIDs(t, res)
+			assert.NotEqual(t, firstImport[childID], ids[childID])
+			assert.NotEqual(t, firstImport[parentID], ids[parentID])
+
+			child := new(model.PDF)
+			require.NoError(t, test.DB.First(child, "id = ?", ids[childID]).Error)
+			require.NotNil(t, child.ParentID)
+			assert.Equal(t, ids[parentID], *child.ParentID)
+
+			var count int64
+			test.DB.Model(&model.PDF{}).Count(&count)
+			assert.Equal(t, int64(4), count)
+		})
+
+		t.Run("should fail documents whose file doesn't match the manifest", func(t *testing.T) {
+			helper.ClearAll(test.DB)
+			helper.InsertUser(test.DB, fixture.Admin)
+
+			tampered := manifest
+			tampered.PDFs = []archive.PDF{manifest.PDFs[1]}
+			tampered.PDFs[0].Versions = []archive.FileVersion{
+				archiveVersion(parentID, 1, helper.PDF("Something else"), created),
+			}
+
+			statusCode, res := importArchive(t, fixture.Admin, zipArchive(t, tampered, files), "")
+
+			assert.Equal(t, http.StatusOK, statusCode)
+			assert.Zero(t, res.Imported)
+			assert.Equal(t, 1, res.Failed)
+			require.Len(t, res.Data, 1)
+			assert.Equal(t, service.ImportStatusFailed, res.Data[0].Status)
+			assert.Contains(t, res.Data[0].Reason, "doesn't match the manifest")
+
+			var count int64
+			test.DB.Model(&model.PDF{}).Count(&count)
+			assert.Zero(t, count)
+		})
+	})
+}
+
+func archiveVersion(id uuid.UUID, version int, data []byte, created time.Time) archive.FileVersion {
+	return archive.FileVersion{
+		Version:          version,
+		OriginalFilename: "file.pdf",
+		FileSize:         int64(len(data)),
+		ContentHash:      helper.ContentHash(data),
+		File:             archive.FileEntry(id, version),
+		CreatedAt:        created,
+	}
+}
+
+func zipArchive(t *testing.T, manifest archive.Manifest, files map[string][]byte) []byte {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+
+	for name, data := range files {
+		w, err := zw.Create(name)
+		require.NoError(t, err)
+		_, err = w.Write(data)
+		require.NoError(t, err)
+	}
+
+	w, err := zw.Create(archive.ManifestName)
+	require.NoError(t, err)
+	require.NoError(t, json.NewEncoder(w).Encode(manifest))
+
+	require.NoError(t, zw.Close())
+	return buf.Bytes()
+}
+
+func importArchive(t *testing.T, user *model.User, data []byte, onConflict string) (int, *response.ArchiveImportResponse) {
+	accessToken, err := fixture.AccessToken(user)
+	require.NoError(t, err)
+
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, err := form.CreateFormFile("file", "export.zip")
+	require.NoError(t, err)
+	_, err = part.Write(data)
+	require.NoError(t, err)
+	require.NoError(t, form.Close())
+
+	url := "/v1/imports"
+	if onConflict != "" {
+		url += "?on_conflict=" + onConflict
+	}
+	request := httptest.NewRequest(http.MethodPost, url, &body)
+	request.Header.Set("Content-Type", form.FormDataContentType())
+	request.Header.Set("Authorization", "Bearer "+accessToken)
+
+	apiResponse, err := test.App.Test(request, -1)
+	require.NoError(t, err)
+
+	raw, err := io.ReadAll(apiResponse.Body)
+	require.NoError(t, err)
+
+	responseBody := new(response.ArchiveImportResponse)
+	require.NoError(t, json.Unmarshal(raw, responseBody))
+
+	return apiResponse.StatusCode, responseBody
+}
+
+// importedIDs maps the source id of every imported document to its new id.
+func importedIDs(t *testing.T, res *response.ArchiveImportResponse) map[uuid.UUID]uuid.UUID {
+	ids := make(map[uuid.UUID]uuid.UUID)
+	for _, result := range res.Data {
+		if result.Status == service.ImportStatusImported {
+			require.NotNil(t, result.PDFID)
+			ids[result.SourceID] = *result.PDFID
+		}
+	}
+	return ids
+}
@@ -451,6 +451,10 @@ func TestAuthRoutes(t *testing.T) {
 
 			dbVerifyEmailTokenDoc, _ := helper.GetTokenByType(test.DB, fixture.UserOne.ID.String(), config.TokenTypeResetPassword)
 			assert.NotNil(t, dbVerifyEmailTokenDoc)
+
+			emails, err := helper.GetOutboxEmailsTo(test.DB, fixture.UserOne.Email)
+			assert.Nil(t, err)
+			assert.Len(t, emails, 1)
 		})
 
 		t.Run("should return 400 if email is missing", func(t *testing.T) {
@@ -649,6 +653,10 @@ func TestAuthRoutes(t *testing.T) {
 
 			dbVerifyEmailTokenDoc, _ := helper.GetTokenByType(test.DB, fixture.UserOne.ID.String(), config.TokenTypeVerifyEmail)
 			assert.NotNil(t, dbVerifyEmailTokenDoc)
+
+			emails, err := helper.GetOutboxEmailsTo(test.DB, fixture.UserOne.Email)
+			assert.Nil(t, err)
+			assert.Len(t, emails, 1)
 		})
 
 		t.Run("should return 401 error if access token is missing", func(t *testing.T) {
@@ -0,0 +1,232 @@
+package integration
+
+import (
+	"app/src/config"
+	"app/src/mail"
+	"app/src/model"
+	"app/src/response"
+	"app/src/service"
+	"app/src/utils"
+	"app/src/validation"
+	"app/test"
+	"app/test/fixture"
+	"app/test/helper"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// The outbox is sent by the app's background sender, which delivers to the
+// test SMTP server. Failed emails are made due again by moving their next
+// attempt forward, instead of waiting out the backoff.
+func TestEmailOutbox(t *testing.T) {
+	emailService := service.NewEmailService(test.DB, validation.Validator())
+
+	t.Run("should send an email queued in a committed transaction", func(t *testing.T) {
+		helper.ClearAll(test.DB)
+		helper.InsertUser(test.DB, fixture.UserOne)
+		received := len(test.SMTP.ReceivedBy(fixture.UserOne.Email))
+
+		bodyJSON, err := json.Marshal(validation.ForgotPassword{Email: fixture.UserOne.Email})
+		require.NoError(t, err)
+		request := httptest.NewRequest(http.MethodPost, "/v1/auth/forgot-password", strings.NewReader(string(bodyJSON)))
+		request.Header.Set("Content-Type", "application/json")
+		apiResponse, err := test.App.Test(request)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, apiResponse.StatusCode)
+
+		emails, err := helper.GetOutboxEmailsTo(test.DB, fixture.UserOne.Email)
+		require.NoError(t, err)
+		require.Len(t, emails, 1)
+
+		email := waitForOutbox(t, emails[0].ID.String(), model.OutboxStatusSent, 1)
+		assert.NotNil(t, email.SentAt)
+		assert.Nil(t, email.LastError)
+		assert.Len(t, test.SMTP.ReceivedBy(fixture.UserOne.Email), received+1)
+	})
+
+	t.Run("should not send an email queued in a rolled back transaction", func(t *testing.T) {
+		helper.ClearAll(test.DB)
+
+		errRollback := errors.New("rollback")
+		err := test.DB.Transaction(func(tx *gorm.DB) error {
+			if _, err := emailService.Queue(tx, outboxTestEmail("rolled-back@example.com")); err != nil {
+				return err
+			}
+			return errRollback
+		})
+		require.ErrorIs(t, err, errRollback)
+
+		emails, err := helper.GetOutboxEmailsTo(test.DB, "rolled-back@example.com")
+		require.NoError(t, err)
+		assert.Empty(t, emails)
+	})
+
+	t.Run("should back off after a failed attempt", func(t *testing.T) {
+		helper.ClearAll(test.DB)
+		test.SMTP.Fail("backoff@example.com", 1)
+
+		email := queueOutboxEmail(t, emailService, "backoff@example.com", 0)
+
+		email = waitForOutbox(t, email.ID.String(), model.OutboxStatusPending, 1)
+		require.NotNil(t, email.LastError)
+		assert.Contains(t, *email.LastError, "451")
+		assert.WithinDuration(t, time.Now().Add(config.EmailRetryBaseDelay), email.NextAttemptAt, 5*time.Second)
+		assert.Empty(t, test.SMTP.ReceivedBy("backoff@example.com"))
+
+		makeOutboxDue(t, email.ID.String())
+		email = waitForOutbox(t, email.ID.String(), model.OutboxStatusSent, 2)
+		assert.Nil(t, email.LastError)
+		assert.Len(t, test.SMTP.ReceivedBy("backoff@example.com"), 1)
+	})
+
+	t.Run("should keep retrying a server that fails several times", func(t *testing.T) {
+		helper.ClearAll(test.DB)
+		const failures = 3
+		test.SMTP.Fail("flaky@example.com", failures)
+
+		email := queueOutboxEmail(t, emailService, "flaky@example.com", 0)
+
+		for attempts := 1; attempts <= failures; attempts++ {
+			email = waitForOutbox(t, email.ID.String(), model.OutboxStatusPending, attempts)
+			delay := utils.Backoff(attempts, config.EmailRetryBaseDelay, config.EmailRetryMaxDelay)
+			assert.WithinDuration(t, time.Now().Add(delay), email.NextAttemptAt, 5*time.Second)
+			makeOutboxDue(t, email.ID.String())
+		}
+
+		waitForOutbox(t, email.ID.String(), model.OutboxStatusSent, failures+1)
+		assert.Len(t, test.SMTP.ReceivedBy("flaky@example.com"), 1)
+	})
+
+	t.Run("should give up after the last attempt", func(t *testing.T) {
+		helper.ClearAll(test.DB)
+		test.SMTP.Fail("last-attempt@example.com", 1)
+
+		email := queueOutboxEmail(t, emailService, "last-attempt@example.com", config.EmailOutboxMaxAttempts-1)
+
+		email = waitForOutbox(t, email.ID.String(), model.OutboxStatusDead, config.EmailOutboxMaxAttempts)
+		assert.NotNil(t, email.LastError)
+		assert.Empty(t, test.SMTP.ReceivedBy("last-attempt@example.com"))
+	})
+
+	t.Run("should give up straight away on a rejected recipient", func(t *testing.T) {
+		helper.ClearAll(test.DB)
+		test.SMTP.Reject("ghost@example.com")
+
+		email := queueOutboxEmail(t, emailService, "ghost@example.com", 0)
+
+		email = waitForOutbox(t, email.ID.String(), model.OutboxStatusDead, 1)
+		require.NotNil(t, email.LastError)
+		assert.Contains(t, *email.LastError, "550")
+	})
+}
+
+func TestEmailOutboxRoutes(t *testing.T) {
+	emailService := service.NewEmailService(test.DB, validation.Validator())
+
+	t.Run("POST /v1/emails/outbox/:emailId/retry", func(t *testing.T) {
+		t.Run("should return 200 and send a dead email again", func(t *testing.T) {
+			helper.ClearAll(test.DB)
+			helper.InsertUser(test.DB, fixture.Admin)
+			test.SMTP.Fail("retry@example.com", 1)
+
+			email := queueOutboxEmail(t, emailService, "retry@example.com", config.EmailOutboxMaxAttempts-1)
+			waitForOutbox(t, email.ID.String(), model.OutboxStatusDead, config.EmailOutboxMaxAttempts)
+
+			apiResponse := retryOutboxEmail(t, email.ID.String(), fixture.Admin)
+			require.Equal(t, http.StatusOK, apiResponse.StatusCode)
+
+			res := new(response.EmailOutboxResponse)
+			decodeBody(t, apiResponse, res)
+			// The sender may have picked it up already
+			assert.NotEqual(t, model.OutboxStatusDead, res.Data.Status)
+
+			waitForOutbox(t, email.ID.String(), model.OutboxStatusSent, 1)
+			assert.Len(t, test.SMTP.ReceivedBy("retry@example.com"), 1)
+		})
+
+		t.Run("should return 409 error if the email is not dead", func(t *testing.T) {
+			helper.ClearAll(test.DB)
+			helper.InsertUser(test.DB, fixture.Admin)
+
+			email := queueOutboxEmail(t, emailService, "sent@example.com", 0)
+			waitForOutbox(t, email.ID.String(), model.OutboxStatusSent, 1)
+
+			apiResponse := retryOutboxEmail(t, email.ID.String(), fixture.Admin)
+
+			assert.Equal(t, http.StatusConflict, apiResponse.StatusCode)
+		})
+
+		t.Run("should return 403 error if the user may not manage emails", func(t *testing.T) {
+			helper.ClearAll(test.DB)
+			helper.InsertUser(test.DB, fixture.UserOne)
+
+			email := queueOutboxEmail(t, emailService, "forbidden@example.com", 0)
+
+			apiResponse := retryOutboxEmail(t, email.ID.String(), fixture.UserOne)
+
+			assert.Equal(t, http.StatusForbidden, apiResponse.StatusCode)
+		})
+	})
+}
+
+func outboxTestEmail(to string) *mail.Email {
+	return &mail.Email{
+		To:      []string{to},
+		Subject: "Hello",
+		Text:    "Hello from the outbox",
+	}
+}
+
+// queueOutboxEmail queues an email to to that has already been tried
+// attempts times, in one committed transaction.
+func queueOutboxEmail(t *testing.T, emailService service.EmailService, to string, attempts int) *model.EmailOutbox {
+	var email *model.EmailOutbox
+	err := test.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if email, err = emailService.Queue(tx, outboxTestEmail(to)); err != nil {
+			return err
+		}
+		return tx.Model(&model.EmailOutbox{}).Where("id = ?", email.ID).Update("attempts", attempts).Error
+	})
+	require.NoError(t, err)
+	return email
+}
+
+// waitForOutbox waits for the background sender to leave an email in status
+// after the given number of attempts, and returns it.
+func waitForOutbox(t *testing.T, id, status string, attempts int) *model.EmailOutbox {
+	email := new(model.EmailOutbox)
+	require.Eventually(t, func() bool {
+		if err := test.DB.First(email, "id = ?", id).Error; err != nil {
+			return false
+		}
+		return email.Status == status && email.Attempts == attempts
+	}, 5*config.EmailOutboxPollInterval, 100*time.Millisecond, "email %s never became %s after %d attempts", id, status, attempts)
+	return email
+}
+
+func makeOutboxDue(t *testing.T, id string) {
+	err := test.DB.Model(&model.EmailOutbox{}).Where("id = ?", id).Update("next_attempt_at", time.Now()).Error
+	require.NoError(t, err)
+}
+
+func retryOutboxEmail(t *testing.T, id string, user *model.User) *http.Response {
+	accessToken, err := fixture.AccessToken(user)
+	require.NoError(t, err)
+
+	request := httptest.NewRequest(http.MethodPost, "/v1/emails/outbox/"+id+"/retry", nil)
+	request.Header.Set("Authorization", "Bearer "+accessToken)
+
+	apiResponse, err := test.App.Test(request)
+	require.NoError(t, err)
+	return apiResponse
+}
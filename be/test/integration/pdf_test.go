@@ -0,0 +1,451 @@
+package integration
+
+import (
+	"app/src/config"
+	"app/src/model"
+	"app/src/response"
+	"app/src/router"
+	"app/src/utils"
+	"app/src/validation"
+	"app/test"
+	"app/test/helper"
+	"bytes"
+	"encoding/json"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPDFVersionRoutes(t *testing.T) {
+	t.Cleanup(func() { os.RemoveAll("./storage") })
+
+	first := helper.PDF("Annual report, draft")
+	second := helper.PDF("Annual report")
+
+	t.Run("POST /v1/pdfs/:pdfId/versions", func(t *testing.T) {
+		t.Run("should attach a new file and keep the earlier one", func(t *testing.T) {
+			helper.ClearAll(test.DB)
+			pdf := uploadPDF(t, test.App, "/v1/pdfs", "report.pdf", first)
+
+			apiResponse := postFile(t, test.App, "/v1/pdfs/"+pdf.ID.String()+"/versions", "report-final.pdf", second)
+			assert.Equal(t, http.StatusCreated, apiResponse.StatusCode)
+
+			res := new(response.UploadPDFVersionResponse)
+			decodeBody(t, apiResponse, res)
+			assert.Equal(t, pdf.ID, res.ID)
+			assert.Equal(t, 2, res.Version)
+			assert.Equal(t, "report-final.pdf", res.OriginalFilename)
+
+			request := httptest.NewRequest(http.MethodGet, "/v1/pdfs/"+pdf.ID.String()+"/versions", nil)
+			apiResponse, err := test.App.Test(request)
+			assert.Nil(t, err)
+			assert.Equal(t, http.StatusOK, apiResponse.StatusCode)
+
+			versions := new(response.PDFVersionListResponse)
+			decodeBody(t, apiResponse, versions)
+			require.Len(t, versions.Data, 2)
+			assert.Equal(t, 2, versions.Data[0].Version)
+			assert.Equal(t, 1, versions.Data[1].Version)
+			assert.Equal(t, "report.pdf", versions.Data[1].OriginalFilename)
+		})
+
+		t.Run("should return 404 error if the PDF doesn't exist", func(t *testing.T) {
+			helper.ClearAll(test.DB)
+
+			apiResponse := postFile(t, test.App, "/v1/pdfs/00000000-0000-0000-0000-000000000000/versions", "report.pdf", second)
+
+			assert.Equal(t, http.StatusNotFound, apiResponse.StatusCode)
+		})
+	})
+
+	t.Run("GET /v1/pdfs/:id/view", func(t *testing.T) {
+		t.Run("should send the requested version of the file", func(t *testing.T) {
+			helper.ClearAll(test.DB)
+			pdf := uploadPDF(t, test.App, "/v1/pdfs", "report.pdf", first)
+			apiResponse := postFile(t, test.App, "/v1/pdfs/"+pdf.ID.String()+"/versions", "report.pdf", second)
+			require.Equal(t, http.StatusCreated, apiResponse.StatusCode)
+
+			for url, want := range map[string][]byte{
+				"/v1/pdfs/" + pdf.ID.String() + "/view":           second,
+				"/v1/pdfs/" + pdf.ID.String() + "/view?version=1": first,
+				"/v1/pdfs/" + pdf.ID.String() + "/view?version=2": second,
+			} {
+				request := httptest.NewRequest(http.MethodGet, url, nil)
+				apiResponse, err := test.App.Test(request, -1)
+				assert.Nil(t, err)
+				assert.Equal(t, http.StatusOK, apiResponse.StatusCode, url)
+
+				body, err := io.ReadAll(apiResponse.Body)
+				assert.Nil(t, err)
+				assert.Equal(t, want, body, url)
+			}
+		})
+
+		t.Run("should return 404 error if the version doesn't exist", func(t *testing.T) {
+			helper.ClearAll(test.DB)
+			pdf := uploadPDF(t, test.App, "/v1/pdfs", "report.pdf", first)
+
+			request := httptest.NewRequest(http.MethodGet, "/v1/pdfs/"+pdf.ID.String()+"/view?version=3", nil)
+			apiResponse, err := test.App.Test(request)
+			assert.Nil(t, err)
+
+			assert.Equal(t, http.StatusNotFound, apiResponse.StatusCode)
+		})
+	})
+
+	t.Run("GET /v1/pdfs/:pdf_id/log", func(t *testing.T) {
+		t.Run("should keep the summary of every version under that version", func(t *testing.T) {
+			helper.ClearAll(test.DB)
+			summarizer := &fakeSummarizer{}
+			app := summarizerApp(t, summarizer)
+
+			pdf := uploadPDF(t, app, "/v1/pdfs", "report.pdf", first)
+			summarizer.summary = "Summary of the draft."
+			assert.Equal(t, http.StatusOK, summarize(t, app, pdf.ID.String()).StatusCode)
+
+			apiResponse := postFile(t, app, "/v1/pdfs/"+pdf.ID.String()+"/versions", "report.pdf", second)
+			require.Equal(t, http.StatusCreated, apiResponse.StatusCode)
+			summarizer.summary = "Summary of the final report."
+			assert.Equal(t, http.StatusOK, summarize(t, app, pdf.ID.String()).StatusCode)
+
+			// Summarizing the same version again archives its previous summary too
+			summarizer.summary = "Another summary of the final report."
+			assert.Equal(t, http.StatusOK, summarize(t, app, pdf.ID.String()).StatusCode)
+
+			logs := pdfLogs(t, app, "/v1/pdfs/"+pdf.ID.String()+"/log?version=1")
+			require.Len(t, logs, 1)
+			assert.Equal(t, 1, logs[0].Version)
+			assert.Equal(t, "Summary of the draft.", logs[0].Summary)
+
+			logs = pdfLogs(t, app, "/v1/pdfs/"+pdf.ID.String()+"/log?version=2")
+			require.Len(t, logs, 1)
+			assert.Equal(t, 2, logs[0].Version)
+			assert.Equal(t, "Summary of the final report.", logs[0].Summary)
+
+			assert.Len(t, pdfLogs(t, app, "/v1/pdfs/"+pdf.ID.String()+"/log"), 2)
+		})
+	})
+}
+
+func TestUnlockRoutes(t *testing.T) {
+	t.Cleanup(func() { os.RemoveAll("./storage") })
+
+	t.Run("POST /v1/pdfs/:pdfId/unlock", func(t *testing.T) {
+		t.Run("should return 400 error if the PDF is not password protected", func(t *testing.T) {
+			helper.ClearAll(test.DB)
+			pdf := uploadPDF(t, test.App, "/v1/pdfs", "report.pdf", helper.PDF("Quarterly report"))
+
+			apiResponse := unlockPDF(t, test.App, pdf.ID.String(), "s3cret")
+
+			assert.Equal(t, http.StatusBadRequest, apiResponse.StatusCode)
+		})
+
+		t.Run("should return 400 error if the password is wrong", func(t *testing.T) {
+			helper.ClearAll(test.DB)
+			pdf := uploadPDF(t, test.App, "/v1/pdfs", "report.pdf", helper.ProtectedPDF("Quarterly report", "s3cret"))
+
+			apiResponse := unlockPDF(t, test.App, pdf.ID.String(), "guess")
+
+			assert.Equal(t, http.StatusBadRequest, apiResponse.StatusCode)
+			assert.Equal(t, http.StatusBadRequest, getOutline(t, pdf.ID.String(), ""))
+		})
+
+		t.Run("should return 200 and a token that opens the PDF", func(t *testing.T) {
+			helper.ClearAll(test.DB)
+			pdf := uploadPDF(t, test.App, "/v1/pdfs", "report.pdf", helper.ProtectedPDF("Quarterly report", "s3cret"))
+			assert.Zero(t, pdf.PageCount)
+
+			apiResponse := unlockPDF(t, test.App, pdf.ID.String(), "s3cret")
+			require.Equal(t, http.StatusOK, apiResponse.StatusCode)
+
+			res := new(response.UnlockPDFResponse)
+			decodeBody(t, apiResponse, res)
+			assert.NotEmpty(t, res.UnlockToken)
+			assert.True(t, res.ExpiresAt.After(time.Now()))
+
+			assert.Equal(t, http.StatusBadRequest, getOutline(t, pdf.ID.String(), ""))
+			assert.Equal(t, http.StatusOK, getOutline(t, pdf.ID.String(), res.UnlockToken))
+
+			// What couldn't be read at upload is filled in once unlocked
+			stored := new(model.PDF)
+			require.NoError(t, test.DB.First(stored, "id = ?", pdf.ID).Error)
+			assert.Equal(t, 1, stored.PageCount)
+		})
+
+		t.Run("should only accept the token for the PDF and version it was issued for", func(t *testing.T) {
+			helper.ClearAll(test.DB)
+			pdf := uploadPDF(t, test.App, "/v1/pdfs", "report.pdf", helper.ProtectedPDF("Quarterly report", "s3cret"))
+			other := uploadPDF(t, test.App, "/v1/pdfs", "other.pdf", helper.ProtectedPDF("Annual report", "s3cret"))
+
+			apiResponse := unlockPDF(t, test.App, pdf.ID.String(), "s3cret")
+			require.Equal(t, http.StatusOK, apiResponse.StatusCode)
+			res := new(response.UnlockPDFResponse)
+			decodeBody(t, apiResponse, res)
+
+			assert.Equal(t, http.StatusOK, getOutline(t, pdf.ID.String(), res.UnlockToken))
+			assert.Equal(t, http.StatusBadRequest, getOutline(t, other.ID.String(), res.UnlockToken))
+
+			// A new version changes the content hash the token was bound to
+			apiResponse = postFile(t, test.App, "/v1/pdfs/"+pdf.ID.String()+"/versions", "report.pdf",
+				helper.ProtectedPDF("Quarterly report, revised", "s3cret"))
+			require.Equal(t, http.StatusCreated, apiResponse.StatusCode)
+			assert.Equal(t, http.StatusBadRequest, getOutline(t, pdf.ID.String(), res.UnlockToken))
+		})
+
+		t.Run("should not count successful attempts against the limit", func(t *testing.T) {
+			helper.ClearAll(test.DB)
+			pdf := uploadPDF(t, test.App, "/v1/pdfs", "report.pdf", helper.ProtectedPDF("Quarterly report", "s3cret"))
+
+			for i := 0; i <= config.PDFUnlockMaxAttemptsPerIP; i++ {
+				assert.Equal(t, http.StatusOK, unlockPDF(t, test.App, pdf.ID.String(), "s3cret").StatusCode)
+			}
+		})
+
+		t.Run("should return 429 error after too many failed attempts", func(t *testing.T) {
+			helper.ClearAll(test.DB)
+			pdf := uploadPDF(t, test.App, "/v1/pdfs", "report.pdf", helper.ProtectedPDF("Quarterly report", "s3cret"))
+
+			for i := 0; i < config.PDFUnlockMaxAttemptsPerIP; i++ {
+				assert.Equal(t, http.StatusBadRequest, unlockPDF(t, test.App, pdf.ID.String(), "guess").StatusCode)
+			}
+			assert.Equal(t, http.StatusTooManyRequests, unlockPDF(t, test.App, pdf.ID.String(), "guess").StatusCode)
+
+			// Not even the right password gets through until the window ends
+			assert.Equal(t, http.StatusTooManyRequests, unlockPDF(t, test.App, pdf.ID.String(), "s3cret").StatusCode)
+
+			// Other processes share the counters
+			assert.Equal(t, http.StatusTooManyRequests, unlockPDF(t, newApp(), pdf.ID.String(), "guess").StatusCode)
+		})
+	})
+}
+
+func TestSummarizeRoutes(t *testing.T) {
+	t.Cleanup(func() { os.RemoveAll("./storage") })
+
+	t.Run("POST /v1/pdfs/:pdfId/summarize", func(t *testing.T) {
+		t.Run("should stream the whole file to the summarizer", func(t *testing.T) {
+			helper.ClearAll(test.DB)
+			summarizer := &fakeSummarizer{summary: "A short summary."}
+			app := summarizerApp(t, summarizer)
+
+			data := helper.PDF("Quarterly report")
+			pdf := uploadPDF(t, app, "/v1/pdfs", "report.pdf", data)
+
+			apiResponse := summarize(t, app, pdf.ID.String())
+			assert.Equal(t, http.StatusOK, apiResponse.StatusCode)
+
+			var res struct {
+				Data response.SummaryResponse `json:"data"`
+			}
+			decodeBody(t, apiResponse, &res)
+			assert.Equal(t, "A short summary.", res.Data.SummaryText)
+
+			require.Len(t, summarizer.requests, 1)
+			assert.Equal(t, data, summarizer.requests[0].file)
+			assert.Equal(t, pdf.ID.String(), summarizer.requests[0].fields["pdf_id"])
+			assert.Equal(t, "report.pdf", summarizer.requests[0].fields["original_filename"])
+		})
+
+		t.Run("should send the file again from the start when retrying", func(t *testing.T) {
+			helper.ClearAll(test.DB)
+			summarizer := &fakeSummarizer{summary: "A short summary.", failures: 1}
+			app := summarizerApp(t, summarizer)
+
+			data := helper.PDF("Quarterly report")
+			pdf := uploadPDF(t, app, "/v1/pdfs", "report.pdf", data)
+
+			apiResponse := summarize(t, app, pdf.ID.String())
+			assert.Equal(t, http.StatusOK, apiResponse.StatusCode)
+
+			require.Len(t, summarizer.requests, 2)
+			for _, req := range summarizer.requests {
+				assert.Equal(t, data, req.file)
+			}
+		})
+
+		t.Run("should not retry a request the summarizer rejects", func(t *testing.T) {
+			helper.ClearAll(test.DB)
+			summarizer := &fakeSummarizer{status: http.StatusTooManyRequests}
+			app := summarizerApp(t, summarizer)
+
+			pdf := uploadPDF(t, app, "/v1/pdfs", "report.pdf", helper.PDF("Quarterly report"))
+
+			apiResponse := summarize(t, app, pdf.ID.String())
+			assert.Equal(t, http.StatusTooManyRequests, apiResponse.StatusCode)
+			assert.Len(t, summarizer.requests, 1)
+		})
+	})
+}
+
+// fakeSummarizer stands in for the summarization service. It fails the
+// first failures requests and answers every other one with summary, or
+// with status when that is set.
+type fakeSummarizer struct {
+	mu       sync.Mutex
+	summary  string
+	failures int
+	status   int
+	requests []summarizerRequest
+}
+
+type summarizerRequest struct {
+	fields map[string]string
+	file   []byte
+}
+
+func (f *fakeSummarizer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	req := summarizerRequest{fields: make(map[string]string)}
+	reader, err := r.MultipartReader()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		value, err := io.ReadAll(part)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if part.FormName() == "file" {
+			req.file = value
+		} else {
+			req.fields[part.FormName()] = string(value)
+		}
+	}
+	f.requests = append(f.requests, req)
+
+	w.Header().Set("Content-Type", "application/json")
+	switch {
+	case len(f.requests) <= f.failures:
+		w.WriteHeader(http.StatusBadGateway)
+		json.NewEncoder(w).Encode(map[string]interface{}{"success": false, "error": "upstream model error"})
+	case f.status != 0:
+		w.WriteHeader(f.status)
+		json.NewEncoder(w).Encode(map[string]interface{}{"success": false, "error": "rate limit exceeded"})
+	default:
+		json.NewEncoder(w).Encode(map[string]interface{}{"success": true, "summary_text": f.summary})
+	}
+}
+
+// summarizerApp serves the routes with summaries coming from summarizer.
+func summarizerApp(t *testing.T, summarizer http.Handler) *fiber.App {
+	server := httptest.NewServer(summarizer)
+	t.Cleanup(server.Close)
+
+	summaryServiceURL := config.SummaryServiceURL
+	config.SummaryServiceURL = server.URL
+	defer func() { config.SummaryServiceURL = summaryServiceURL }()
+
+	return newApp()
+}
+
+// newApp serves the routes like test.App, as another process sharing the
+// database would.
+func newApp() *fiber.App {
+	app := fiber.New(fiber.Config{
+		CaseSensitive: true,
+		ErrorHandler:  utils.ErrorHandler,
+	})
+	router.Routes(app, test.DB)
+	app.Use(utils.NotFoundHandler)
+	return app
+}
+
+func postFile(t *testing.T, app *fiber.App, url, filename string, data []byte) *http.Response {
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, err := form.CreateFormFile("file", filename)
+	require.NoError(t, err)
+	_, err = part.Write(data)
+	require.NoError(t, err)
+	require.NoError(t, form.Close())
+
+	request := httptest.NewRequest(http.MethodPost, url, &body)
+	request.Header.Set("Content-Type", form.FormDataContentType())
+
+	apiResponse, err := app.Test(request, -1)
+	require.NoError(t, err)
+	return apiResponse
+}
+
+func uploadPDF(t *testing.T, app *fiber.App, url, filename string, data []byte) *response.UploadPDFResponse {
+	apiResponse := postFile(t, app, url, filename, data)
+	require.Equal(t, http.StatusCreated, apiResponse.StatusCode)
+
+	res := new(response.UploadPDFResponse)
+	decodeBody(t, apiResponse, res)
+	return res
+}
+
+func unlockPDF(t *testing.T, app *fiber.App, id, password string) *http.Response {
+	bodyJSON, err := json.Marshal(validation.UnlockPDF{Password: password})
+	require.NoError(t, err)
+
+	request := httptest.NewRequest(http.MethodPost, "/v1/pdfs/"+id+"/unlock", bytes.NewReader(bodyJSON))
+	request.Header.Set("Content-Type", "application/json")
+
+	apiResponse, err := app.Test(request, -1)
+	require.NoError(t, err)
+	return apiResponse
+}
+
+// getOutline reads the outline of a PDF, as one of the reads an unlock token
+// is needed for, and returns the status.
+func getOutline(t *testing.T, id, unlockToken string) int {
+	request := httptest.NewRequest(http.MethodGet, "/v1/pdfs/"+id+"/outline?unlock_token="+url.QueryEscape(unlockToken), nil)
+
+	apiResponse, err := test.App.Test(request)
+	require.NoError(t, err)
+	return apiResponse.StatusCode
+}
+
+func summarize(t *testing.T, app *fiber.App, id string) *http.Response {
+	request := httptest.NewRequest(http.MethodPost, "/v1/pdfs/"+id+"/summarize",
+		strings.NewReader(`{"language":"en","output_type":"paragraph"}`))
+	request.Header.Set("Content-Type", "application/json")
+
+	apiResponse, err := app.Test(request, -1)
+	require.NoError(t, err)
+	return apiResponse
+}
+
+func pdfLogs(t *testing.T, app *fiber.App, url string) []response.PDFLogResponse {
+	request := httptest.NewRequest(http.MethodGet, url, nil)
+	apiResponse, err := app.Test(request)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, apiResponse.StatusCode)
+
+	var res struct {
+		Data []response.PDFLogResponse `json:"data"`
+	}
+	decodeBody(t, apiResponse, &res)
+	return res.Data
+}
+
+func decodeBody(t *testing.T, apiResponse *http.Response, v interface{}) {
+	raw, err := io.ReadAll(apiResponse.Body)
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(raw, v))
+}
@@ -0,0 +1,252 @@
+package integration
+
+import (
+	"app/src/config"
+	"app/src/model"
+	"app/src/response"
+	"app/src/validation"
+	"app/test"
+	"app/test/helper"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShareRoutes(t *testing.T) {
+	t.Run("GET /v1/shared/:token", func(t *testing.T) {
+		t.Run("should return 200 and count a view if the link is valid", func(t *testing.T) {
+			helper.ClearAll(test.DB)
+			pdf := helper.InsertPDF(test.DB, t.TempDir(), "report.pdf", helper.PDF("Quarterly report"))
+			share := createShare(t, pdf, validation.CreateShare{})
+
+			apiResponse := openShared(t, "/v1/shared/"+share.Token, nil)
+
+			assert.Equal(t, http.StatusOK, apiResponse.StatusCode)
+			assert.Equal(t, 1, shareViewCount(t, share))
+			assert.Equal(t, []string{model.ShareAccessGranted}, shareOutcomes(t, share))
+		})
+
+		t.Run("should return 404 error if the token is not signed by the server", func(t *testing.T) {
+			helper.ClearAll(test.DB)
+
+			apiResponse := openShared(t, "/v1/shared/not-a-share-token", nil)
+
+			assert.Equal(t, http.StatusNotFound, apiResponse.StatusCode)
+		})
+
+		t.Run("should return 410 error and log the attempt if the link has expired", func(t *testing.T) {
+			helper.ClearAll(test.DB)
+			pdf := helper.InsertPDF(test.DB, t.TempDir(), "report.pdf", helper.PDF("Quarterly report"))
+			share := createShare(t, pdf, validation.CreateShare{})
+			test.DB.Model(&model.PDFShare{}).Where("id = ?", share.ID).Update("expires_at", time.Now().Add(-time.Minute))
+
+			apiResponse := openShared(t, "/v1/shared/"+share.Token, nil)
+
+			assert.Equal(t, http.StatusGone, apiResponse.StatusCode)
+			assert.Zero(t, shareViewCount(t, share))
+			assert.Equal(t, []string{model.ShareAccessExpired}, shareOutcomes(t, share))
+		})
+
+		t.Run("should return 410 error and log the attempt if the link was revoked", func(t *testing.T) {
+			helper.ClearAll(test.DB)
+			pdf := helper.InsertPDF(test.DB, t.TempDir(), "report.pdf", helper.PDF("Quarterly report"))
+			share := createShare(t, pdf, validation.CreateShare{})
+
+			request := httptest.NewRequest(http.MethodDelete, "/v1/pdfs/"+pdf.ID.String()+"/shares/"+share.ID.String(), nil)
+			apiResponse, err := test.App.Test(request)
+			assert.Nil(t, err)
+			require.Less(t, apiResponse.StatusCode, 300)
+
+			apiResponse = openShared(t, "/v1/shared/"+share.Token, nil)
+
+			assert.Equal(t, http.StatusGone, apiResponse.StatusCode)
+			assert.Zero(t, shareViewCount(t, share))
+			assert.Equal(t, []string{model.ShareAccessRevoked}, shareOutcomes(t, share))
+		})
+
+		t.Run("should ask for the password of a protected link", func(t *testing.T) {
+			helper.ClearAll(test.DB)
+			pdf := helper.InsertPDF(test.DB, t.TempDir(), "report.pdf", helper.PDF("Quarterly report"))
+			share := createShare(t, pdf, validation.CreateShare{Password: "s3cret"})
+
+			apiResponse := openShared(t, "/v1/shared/"+share.Token, nil)
+			assert.Equal(t, http.StatusUnauthorized, apiResponse.StatusCode)
+
+			apiResponse = openShared(t, "/v1/shared/"+share.Token, map[string]string{config.SharePasswordHeader: "guess"})
+			assert.Equal(t, http.StatusUnauthorized, apiResponse.StatusCode)
+
+			apiResponse = openShared(t, "/v1/shared/"+share.Token, map[string]string{config.SharePasswordHeader: "s3cret"})
+			assert.Equal(t, http.StatusOK, apiResponse.StatusCode)
+
+			assert.Equal(t, 1, shareViewCount(t, share))
+			assert.ElementsMatch(t, []string{
+				model.ShareAccessPasswordRequired,
+				model.ShareAccessWrongPassword,
+				model.ShareAccessGranted,
+			}, shareOutcomes(t, share))
+		})
+
+		t.Run("should never grant more views than the limit to concurrent requests", func(t *testing.T) {
+			helper.ClearAll(test.DB)
+			pdf := helper.InsertPDF(test.DB, t.TempDir(), "report.pdf", helper.PDF("Quarterly report"))
+			maxViews := 3
+			share := createShare(t, pdf, validation.CreateShare{MaxViews: &maxViews})
+
+			const requests = 10
+			statuses := make(chan int, requests)
+			var wg sync.WaitGroup
+			for i := 0; i < requests; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					request := httptest.NewRequest(http.MethodGet, "/v1/shared/"+share.Token, nil)
+					apiResponse, err := test.App.Test(request, -1)
+					if err != nil {
+						statuses <- 0
+						return
+					}
+					statuses <- apiResponse.StatusCode
+				}()
+			}
+			wg.Wait()
+			close(statuses)
+
+			granted, refused := 0, 0
+			for status := range statuses {
+				switch status {
+				case http.StatusOK:
+					granted++
+				case http.StatusGone:
+					refused++
+				}
+			}
+			assert.Equal(t, maxViews, granted)
+			assert.Equal(t, requests-maxViews, refused)
+			assert.Equal(t, maxViews, shareViewCount(t, share))
+
+			outcomes := shareOutcomes(t, share)
+			assert.Len(t, outcomes, requests)
+			viewLimit := 0
+			for _, outcome := range outcomes {
+				if outcome == model.ShareAccessViewLimit {
+					viewLimit++
+				}
+			}
+			assert.Equal(t, requests-maxViews, viewLimit)
+		})
+	})
+
+	t.Run("GET /v1/shared/:token/file", func(t *testing.T) {
+		t.Run("should count every response as a view, range requests included", func(t *testing.T) {
+			helper.ClearAll(test.DB)
+			data := helper.PDF("Quarterly report")
+			pdf := helper.InsertPDF(test.DB, t.TempDir(), "report.pdf", data)
+			share := createShare(t, pdf, validation.CreateShare{})
+			url := "/v1/shared/" + share.Token + "/file"
+
+			apiResponse := openShared(t, url, nil)
+			assert.Equal(t, http.StatusOK, apiResponse.StatusCode)
+			body, err := io.ReadAll(apiResponse.Body)
+			assert.Nil(t, err)
+			assert.Equal(t, data, body)
+			assert.Equal(t, 1, shareViewCount(t, share))
+
+			apiResponse = openShared(t, url, map[string]string{"Range": "bytes=100-199"})
+			assert.Equal(t, http.StatusPartialContent, apiResponse.StatusCode)
+			assert.Equal(t, 2, shareViewCount(t, share))
+
+			apiResponse = openShared(t, url, map[string]string{"Range": "bytes=0-0,1-"})
+			assert.Equal(t, http.StatusPartialContent, apiResponse.StatusCode)
+			assert.Equal(t, 3, shareViewCount(t, share))
+
+			assert.Equal(t, []string{
+				model.ShareAccessGranted, model.ShareAccessGranted, model.ShareAccessGranted,
+			}, shareOutcomes(t, share))
+		})
+
+		t.Run("should not serve ranges past the view limit", func(t *testing.T) {
+			helper.ClearAll(test.DB)
+			pdf := helper.InsertPDF(test.DB, t.TempDir(), "report.pdf", helper.PDF("Quarterly report"))
+			maxViews := 2
+			share := createShare(t, pdf, validation.CreateShare{MaxViews: &maxViews})
+			url := "/v1/shared/" + share.Token + "/file"
+
+			// Together these ranges would make up the whole file
+			for _, byteRange := range []string{"bytes=1-", "bytes=0-0"} {
+				apiResponse := openShared(t, url, map[string]string{"Range": byteRange})
+				assert.Equal(t, http.StatusPartialContent, apiResponse.StatusCode, byteRange)
+			}
+
+			apiResponse := openShared(t, url, map[string]string{"Range": "bytes=1-"})
+			assert.Equal(t, http.StatusGone, apiResponse.StatusCode)
+			assert.Equal(t, maxViews, shareViewCount(t, share))
+		})
+
+		t.Run("should log refused range requests too", func(t *testing.T) {
+			helper.ClearAll(test.DB)
+			pdf := helper.InsertPDF(test.DB, t.TempDir(), "report.pdf", helper.PDF("Quarterly report"))
+			share := createShare(t, pdf, validation.CreateShare{Password: "s3cret"})
+
+			apiResponse := openShared(t, "/v1/shared/"+share.Token+"/file", map[string]string{"Range": "bytes=100-199"})
+
+			assert.Equal(t, http.StatusUnauthorized, apiResponse.StatusCode)
+			assert.Equal(t, []string{model.ShareAccessPasswordRequired}, shareOutcomes(t, share))
+		})
+	})
+}
+
+func createShare(t *testing.T, pdf *model.PDF, req validation.CreateShare) *response.ShareResponse {
+	bodyJSON, err := json.Marshal(req)
+	require.NoError(t, err)
+
+	request := httptest.NewRequest(http.MethodPost, "/v1/pdfs/"+pdf.ID.String()+"/shares", strings.NewReader(string(bodyJSON)))
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("Accept", "application/json")
+
+	apiResponse, err := test.App.Test(request)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusCreated, apiResponse.StatusCode)
+
+	bytes, err := io.ReadAll(apiResponse.Body)
+	require.NoError(t, err)
+
+	share := new(response.ShareResponse)
+	require.NoError(t, json.Unmarshal(bytes, share))
+	return share
+}
+
+func openShared(t *testing.T, url string, headers map[string]string) *http.Response {
+	request := httptest.NewRequest(http.MethodGet, url, nil)
+	for key, value := range headers {
+		request.Header.Set(key, value)
+	}
+
+	apiResponse, err := test.App.Test(request, -1)
+	require.NoError(t, err)
+	return apiResponse
+}
+
+func shareViewCount(t *testing.T, share *response.ShareResponse) int {
+	stored := new(model.PDFShare)
+	require.NoError(t, test.DB.First(stored, "id = ?", share.ID).Error)
+	return stored.ViewCount
+}
+
+// shareOutcomes lists the outcomes in a share's access log, oldest first.
+func shareOutcomes(t *testing.T, share *response.ShareResponse) []string {
+	var outcomes []string
+	err := test.DB.Model(&model.PDFShareAccess{}).
+		Where("share_id = ?", share.ID).
+		Order("accessed_at").
+		Pluck("outcome", &outcomes).Error
+	require.NoError(t, err)
+	return outcomes
+}
@@ -0,0 +1,255 @@
+package integration
+
+import (
+	"app/src/config"
+	"app/src/model"
+	"app/src/response"
+	"app/src/validation"
+	"app/test"
+	"app/test/fixture"
+	"app/test/helper"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSummaryEmailRoutes(t *testing.T) {
+	t.Run("POST /v1/pdfs/:pdfId/summary/email", func(t *testing.T) {
+		t.Run("should return 202 and record the email", func(t *testing.T) {
+			helper.ClearAll(test.DB)
+			helper.InsertUser(test.DB, fixture.UserOne)
+			pdf := summarizedPDF(t)
+
+			apiResponse := emailSummary(t, pdf, validation.EmailSummary{
+				Recipients: []string{"alice@example.com"},
+				Note:       "For tomorrow's meeting",
+			})
+			require.Equal(t, http.StatusAccepted, apiResponse.StatusCode)
+
+			res := new(response.SummaryEmailResponse)
+			decodeBody(t, apiResponse, res)
+
+			stored := new(model.SummaryEmail)
+			require.NoError(t, test.DB.Preload("Delivery").First(stored, "id = ?", res.Data.ID).Error)
+			assert.Equal(t, pdf.ID, stored.PDFID)
+			assert.Equal(t, fixture.UserOne.ID, stored.UserID)
+			assert.Equal(t, model.EmailRecipients{"alice@example.com"}, stored.Recipients)
+			assert.Equal(t, 1, stored.RecipientCount)
+			assert.Equal(t, "Summary: report", stored.Subject)
+			assert.Equal(t, 1, stored.PDFVersion)
+			assert.False(t, stored.Attached)
+
+			require.NotNil(t, stored.Delivery)
+			assert.Equal(t, fixture.UserOne.Email, stored.Delivery.ReplyTo)
+			assert.Contains(t, stored.Delivery.TextBody, "For tomorrow's meeting")
+			assert.Contains(t, stored.Delivery.TextBody, "A short summary.")
+			assert.Empty(t, stored.Delivery.Attachments)
+
+			waitForOutbox(t, stored.Delivery.ID.String(), model.OutboxStatusSent, 1)
+			assert.Len(t, test.SMTP.ReceivedBy("alice@example.com"), 1)
+		})
+
+		t.Run("should send to each address once", func(t *testing.T) {
+			helper.ClearAll(test.DB)
+			helper.InsertUser(test.DB, fixture.UserOne)
+			pdf := summarizedPDF(t)
+
+			apiResponse := emailSummary(t, pdf, validation.EmailSummary{
+				Recipients: []string{"bob@example.com", "Bob@Example.com", "carol@example.com", "bob@example.com"},
+			})
+			require.Equal(t, http.StatusAccepted, apiResponse.StatusCode)
+
+			res := new(response.SummaryEmailResponse)
+			decodeBody(t, apiResponse, res)
+			assert.Equal(t, model.EmailRecipients{"bob@example.com", "carol@example.com"}, res.Data.Recipients)
+			assert.Equal(t, 2, res.Data.RecipientCount)
+
+			emails, err := helper.GetOutboxEmailsTo(test.DB, "bob@example.com")
+			require.NoError(t, err)
+			require.Len(t, emails, 1)
+			assert.Equal(t, model.EmailRecipients{"bob@example.com", "carol@example.com"}, emails[0].Recipients)
+		})
+
+		t.Run("should attach a PDF up to the size limit", func(t *testing.T) {
+			helper.ClearAll(test.DB)
+			helper.InsertUser(test.DB, fixture.UserOne)
+			pdf := summarizedPDF(t)
+			test.DB.Model(&model.PDF{}).Where("id = ?", pdf.ID).Update("file_size", config.SummaryEmailAttachmentMaxSize)
+
+			apiResponse := emailSummary(t, pdf, validation.EmailSummary{
+				Recipients: []string{"dave@example.com"},
+				AttachPDF:  true,
+			})
+			require.Equal(t, http.StatusAccepted, apiResponse.StatusCode)
+
+			res := new(response.SummaryEmailResponse)
+			decodeBody(t, apiResponse, res)
+			assert.True(t, res.Data.Attached)
+
+			emails, err := helper.GetOutboxEmailsTo(test.DB, "dave@example.com")
+			require.NoError(t, err)
+			require.Len(t, emails, 1)
+			require.Len(t, emails[0].Attachments, 1)
+			assert.Equal(t, pdf.FilePath, emails[0].Attachments[0].Path)
+			assert.Equal(t, "report.pdf", emails[0].Attachments[0].Filename)
+		})
+
+		t.Run("should return 400 error if the PDF is too large to attach", func(t *testing.T) {
+			helper.ClearAll(test.DB)
+			helper.InsertUser(test.DB, fixture.UserOne)
+			pdf := summarizedPDF(t)
+			test.DB.Model(&model.PDF{}).Where("id = ?", pdf.ID).Update("file_size", config.SummaryEmailAttachmentMaxSize+1)
+
+			apiResponse := emailSummary(t, pdf, validation.EmailSummary{
+				Recipients: []string{"dave@example.com"},
+				AttachPDF:  true,
+			})
+
+			assert.Equal(t, http.StatusBadRequest, apiResponse.StatusCode)
+			assert.Zero(t, summaryEmailCount(t))
+		})
+
+		t.Run("should return 429 error past the hourly limit", func(t *testing.T) {
+			helper.ClearAll(test.DB)
+			helper.InsertUser(test.DB, fixture.UserOne)
+			pdf := summarizedPDF(t)
+			// Sent over an hour ago, so not counted
+			insertSummaryEmails(t, pdf, 1, 1, time.Now().Add(-61*time.Minute))
+			insertSummaryEmails(t, pdf, config.SummaryEmailHourlyLimit-1, 1, time.Now().Add(-time.Minute))
+
+			req := validation.EmailSummary{Recipients: []string{"erin@example.com"}}
+			assert.Equal(t, http.StatusAccepted, emailSummary(t, pdf, req).StatusCode)
+			assert.Equal(t, http.StatusTooManyRequests, emailSummary(t, pdf, req).StatusCode)
+			assert.Equal(t, int64(config.SummaryEmailHourlyLimit+1), summaryEmailCount(t))
+		})
+
+		t.Run("should return 429 error past the daily recipient limit", func(t *testing.T) {
+			helper.ClearAll(test.DB)
+			helper.InsertUser(test.DB, fixture.UserOne)
+			pdf := summarizedPDF(t)
+			// Sent over a day ago, so not counted
+			insertSummaryEmails(t, pdf, 1, 10, time.Now().Add(-25*time.Hour))
+			insertSummaryEmails(t, pdf, 1, config.SummaryEmailDailyRecipientLimit-5, time.Now().Add(-2*time.Hour))
+
+			six := validation.EmailSummary{Recipients: exampleRecipients(6)}
+			five := validation.EmailSummary{Recipients: exampleRecipients(5)}
+			one := validation.EmailSummary{Recipients: exampleRecipients(1)}
+
+			assert.Equal(t, http.StatusTooManyRequests, emailSummary(t, pdf, six).StatusCode)
+			assert.Equal(t, http.StatusAccepted, emailSummary(t, pdf, five).StatusCode)
+			assert.Equal(t, http.StatusTooManyRequests, emailSummary(t, pdf, one).StatusCode)
+		})
+
+		t.Run("should let only one of two concurrent sends at the limit through", func(t *testing.T) {
+			helper.ClearAll(test.DB)
+			helper.InsertUser(test.DB, fixture.UserOne)
+			pdf := summarizedPDF(t)
+			insertSummaryEmails(t, pdf, config.SummaryEmailHourlyLimit-1, 1, time.Now().Add(-time.Minute))
+
+			const requests = 2
+			statuses := make(chan int, requests)
+			var wg sync.WaitGroup
+			for i := 0; i < requests; i++ {
+				request := emailSummaryRequest(t, pdf, validation.EmailSummary{Recipients: []string{"frank@example.com"}})
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					apiResponse, err := test.App.Test(request, -1)
+					if err != nil {
+						statuses <- 0
+						return
+					}
+					statuses <- apiResponse.StatusCode
+				}()
+			}
+			wg.Wait()
+			close(statuses)
+
+			var got []int
+			for status := range statuses {
+				got = append(got, status)
+			}
+			assert.ElementsMatch(t, []int{http.StatusAccepted, http.StatusTooManyRequests}, got)
+			assert.Equal(t, int64(config.SummaryEmailHourlyLimit), summaryEmailCount(t))
+		})
+
+		t.Run("should return 404 error if the PDF has no summary yet", func(t *testing.T) {
+			helper.ClearAll(test.DB)
+			helper.InsertUser(test.DB, fixture.UserOne)
+			pdf := helper.InsertPDF(test.DB, t.TempDir(), "report.pdf", helper.PDF("Quarterly report"))
+
+			apiResponse := emailSummary(t, pdf, validation.EmailSummary{Recipients: []string{"alice@example.com"}})
+
+			assert.Equal(t, http.StatusNotFound, apiResponse.StatusCode)
+		})
+	})
+}
+
+// summarizedPDF stores a PDF whose summary is done.
+func summarizedPDF(t *testing.T) *model.PDF {
+	pdf := helper.InsertPDF(test.DB, t.TempDir(), "report.pdf", helper.PDF("Quarterly report"))
+	err := test.DB.Model(&model.PDF{}).Where("id = ?", pdf.ID).Updates(map[string]interface{}{
+		"summary":        "A short summary.",
+		"summary_status": "completed",
+	}).Error
+	require.NoError(t, err)
+	return pdf
+}
+
+// insertSummaryEmails records count summary emails sent by UserOne at the
+// given time, each to the given number of recipients.
+func insertSummaryEmails(t *testing.T, pdf *model.PDF, count, recipientCount int, sentAt time.Time) {
+	for i := 0; i < count; i++ {
+		email := &model.SummaryEmail{
+			PDFID:          pdf.ID,
+			UserID:         fixture.UserOne.ID,
+			Recipients:     exampleRecipients(recipientCount),
+			RecipientCount: recipientCount,
+			Subject:        "Summary: report",
+			PDFVersion:     pdf.Version,
+		}
+		require.NoError(t, test.DB.Omit("Delivery").Create(email).Error)
+		require.NoError(t, test.DB.Model(email).Update("created_at", sentAt).Error)
+	}
+}
+
+func exampleRecipients(n int) []string {
+	addresses := make([]string, n)
+	for i := range addresses {
+		addresses[i] = fmt.Sprintf("recipient%d@example.com", i+1)
+	}
+	return addresses
+}
+
+func summaryEmailCount(t *testing.T) int64 {
+	var count int64
+	require.NoError(t, test.DB.Model(&model.SummaryEmail{}).Where("user_id = ?", fixture.UserOne.ID).Count(&count).Error)
+	return count
+}
+
+func emailSummary(t *testing.T, pdf *model.PDF, req validation.EmailSummary) *http.Response {
+	apiResponse, err := test.App.Test(emailSummaryRequest(t, pdf, req), -1)
+	require.NoError(t, err)
+	return apiResponse
+}
+
+func emailSummaryRequest(t *testing.T, pdf *model.PDF, req validation.EmailSummary) *http.Request {
+	bodyJSON, err := json.Marshal(req)
+	require.NoError(t, err)
+
+	accessToken, err := fixture.AccessToken(fixture.UserOne)
+	require.NoError(t, err)
+
+	request := httptest.NewRequest(http.MethodPost, "/v1/pdfs/"+pdf.ID.String()+"/summary/email", strings.NewReader(string(bodyJSON)))
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("Authorization", "Bearer "+accessToken)
+	return request
+}
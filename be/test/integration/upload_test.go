@@ -0,0 +1,262 @@
+package integration
+
+import (
+	"app/src/config"
+	"app/src/model"
+	"app/test"
+	"app/test/helper"
+	"bytes"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUploadRoutes(t *testing.T) {
+	t.Cleanup(func() { os.RemoveAll("./storage") })
+
+	data := helper.PDF("Quarterly report")
+
+	t.Run("POST /v1/uploads", func(t *testing.T) {
+		t.Run("should return 201 and the upload location", func(t *testing.T) {
+			helper.ClearAll(test.DB)
+
+			apiResponse := createUpload(t, len(data), "report.pdf", nil)
+
+			assert.Equal(t, http.StatusCreated, apiResponse.StatusCode)
+			assert.True(t, strings.HasPrefix(apiResponse.Header.Get("Location"), "/v1/uploads/"))
+			assert.Equal(t, "0", apiResponse.Header.Get("Upload-Offset"))
+			assert.NotEmpty(t, apiResponse.Header.Get("Upload-Expires"))
+		})
+
+		t.Run("should store the PDF when the body carries the whole file", func(t *testing.T) {
+			helper.ClearAll(test.DB)
+
+			apiResponse := createUpload(t, len(data), "report.pdf", data)
+
+			assert.Equal(t, http.StatusCreated, apiResponse.StatusCode)
+			assert.Equal(t, strconv.Itoa(len(data)), apiResponse.Header.Get("Upload-Offset"))
+			assert.NotEmpty(t, apiResponse.Header.Get("Upload-PDF-ID"))
+		})
+
+		t.Run("should return 400 error if Upload-Length is 0", func(t *testing.T) {
+			helper.ClearAll(test.DB)
+
+			apiResponse := createUpload(t, 0, "report.pdf", nil)
+
+			assert.Equal(t, http.StatusBadRequest, apiResponse.StatusCode)
+		})
+
+		t.Run("should return 400 error if the file is not a PDF", func(t *testing.T) {
+			helper.ClearAll(test.DB)
+
+			apiResponse := createUpload(t, len(data), "report.txt", nil)
+
+			assert.Equal(t, http.StatusBadRequest, apiResponse.StatusCode)
+		})
+
+		t.Run("should return 412 error without a supported Tus-Resumable header", func(t *testing.T) {
+			request := httptest.NewRequest(http.MethodPost, "/v1/uploads", nil)
+			request.Header.Set("Upload-Length", strconv.Itoa(len(data)))
+
+			apiResponse, err := test.App.Test(request)
+			assert.Nil(t, err)
+
+			assert.Equal(t, http.StatusPreconditionFailed, apiResponse.StatusCode)
+		})
+	})
+
+	t.Run("HEAD /v1/uploads/:uploadId", func(t *testing.T) {
+		t.Run("should return the offset and length of the upload", func(t *testing.T) {
+			helper.ClearAll(test.DB)
+			url := createUpload(t, len(data), "report.pdf", nil).Header.Get("Location")
+			require.Equal(t, http.StatusNoContent, patchUpload(t, url, 0, data[:100]).StatusCode)
+
+			apiResponse := headUpload(t, url)
+
+			assert.Equal(t, http.StatusOK, apiResponse.StatusCode)
+			assert.Equal(t, "100", apiResponse.Header.Get("Upload-Offset"))
+			assert.Equal(t, strconv.Itoa(len(data)), apiResponse.Header.Get("Upload-Length"))
+			assert.Equal(t, "no-store", apiResponse.Header.Get("Cache-Control"))
+		})
+
+		t.Run("should return 404 error if the upload doesn't exist", func(t *testing.T) {
+			helper.ClearAll(test.DB)
+
+			apiResponse := headUpload(t, "/v1/uploads/00000000-0000-0000-0000-000000000000")
+
+			assert.Equal(t, http.StatusNotFound, apiResponse.StatusCode)
+		})
+
+		t.Run("should return 410 error if the upload has expired", func(t *testing.T) {
+			helper.ClearAll(test.DB)
+			url := createUpload(t, len(data), "report.pdf", nil).Header.Get("Location")
+			expireUpload(t, url)
+
+			assert.Equal(t, http.StatusGone, headUpload(t, url).StatusCode)
+			assert.Equal(t, http.StatusGone, patchUpload(t, url, 0, data).StatusCode)
+		})
+	})
+
+	t.Run("PATCH /v1/uploads/:uploadId", func(t *testing.T) {
+		t.Run("should store the PDF once the last chunk arrives", func(t *testing.T) {
+			helper.ClearAll(test.DB)
+			url := createUpload(t, len(data), "report.pdf", nil).Header.Get("Location")
+
+			apiResponse := patchUpload(t, url, 0, data[:100])
+			assert.Equal(t, http.StatusNoContent, apiResponse.StatusCode)
+			assert.Equal(t, "100", apiResponse.Header.Get("Upload-Offset"))
+			assert.Empty(t, apiResponse.Header.Get("Upload-PDF-ID"))
+
+			apiResponse = patchUpload(t, url, 100, data[100:])
+			assert.Equal(t, http.StatusNoContent, apiResponse.StatusCode)
+			assert.Equal(t, strconv.Itoa(len(data)), apiResponse.Header.Get("Upload-Offset"))
+
+			pdf := new(model.PDF)
+			require.NoError(t, test.DB.First(pdf, "id = ?", apiResponse.Header.Get("Upload-PDF-ID")).Error)
+			assert.Equal(t, "report.pdf", pdf.OriginalFilename)
+			assert.Equal(t, helper.ContentHash(data), pdf.ContentHash)
+		})
+
+		t.Run("should return 409 error if the offset doesn't match", func(t *testing.T) {
+			helper.ClearAll(test.DB)
+			url := createUpload(t, len(data), "report.pdf", nil).Header.Get("Location")
+
+			apiResponse := patchUpload(t, url, 100, data[100:])
+
+			assert.Equal(t, http.StatusConflict, apiResponse.StatusCode)
+		})
+
+		t.Run("should return 413 error if the chunk goes past Upload-Length", func(t *testing.T) {
+			helper.ClearAll(test.DB)
+			url := createUpload(t, len(data), "report.pdf", nil).Header.Get("Location")
+
+			apiResponse := patchUpload(t, url, 0, append(append([]byte{}, data...), '\n'))
+
+			assert.Equal(t, http.StatusRequestEntityTooLarge, apiResponse.StatusCode)
+			assert.Equal(t, "0", headUpload(t, url).Header.Get("Upload-Offset"))
+		})
+
+		t.Run("should remove an upload that is not a valid PDF", func(t *testing.T) {
+			helper.ClearAll(test.DB)
+			garbage := bytes.Repeat([]byte("x"), 64)
+			url := createUpload(t, len(garbage), "report.pdf", nil).Header.Get("Location")
+
+			apiResponse := patchUpload(t, url, 0, garbage)
+
+			assert.Equal(t, http.StatusBadRequest, apiResponse.StatusCode)
+			assert.Equal(t, http.StatusNotFound, headUpload(t, url).StatusCode)
+		})
+
+		t.Run("should store the PDF only once when the last chunk is sent concurrently", func(t *testing.T) {
+			helper.ClearAll(test.DB)
+			url := createUpload(t, len(data), "report.pdf", nil).Header.Get("Location")
+			require.Equal(t, http.StatusNoContent, patchUpload(t, url, 0, data[:100]).StatusCode)
+
+			const requests = 5
+			statuses := make(chan int, requests)
+			var wg sync.WaitGroup
+			for i := 0; i < requests; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					statuses <- sendPatch(url, 100, data[100:])
+				}()
+			}
+			wg.Wait()
+			close(statuses)
+
+			completed := 0
+			for status := range statuses {
+				if status == http.StatusNoContent {
+					completed++
+				} else {
+					assert.Equal(t, http.StatusConflict, status)
+				}
+			}
+			assert.Equal(t, 1, completed)
+
+			// A retried empty request at the final offset must not store it again
+			assert.Equal(t, http.StatusConflict, patchUpload(t, url, int64(len(data)), nil).StatusCode)
+
+			var count int64
+			test.DB.Model(&model.PDF{}).Count(&count)
+			assert.Equal(t, int64(1), count)
+		})
+	})
+
+	t.Run("DELETE /v1/uploads/:uploadId", func(t *testing.T) {
+		t.Run("should remove the upload", func(t *testing.T) {
+			helper.ClearAll(test.DB)
+			url := createUpload(t, len(data), "report.pdf", nil).Header.Get("Location")
+
+			request := httptest.NewRequest(http.MethodDelete, url, nil)
+			request.Header.Set("Tus-Resumable", config.TusVersion)
+			apiResponse, err := test.App.Test(request)
+			assert.Nil(t, err)
+
+			assert.Equal(t, http.StatusNoContent, apiResponse.StatusCode)
+			assert.Equal(t, http.StatusNotFound, headUpload(t, url).StatusCode)
+		})
+	})
+}
+
+func createUpload(t *testing.T, length int, filename string, body []byte) *http.Response {
+	request := httptest.NewRequest(http.MethodPost, "/v1/uploads", bytes.NewReader(body))
+	request.Header.Set("Tus-Resumable", config.TusVersion)
+	request.Header.Set("Upload-Length", strconv.Itoa(length))
+	request.Header.Set("Upload-Metadata", "filename "+base64.StdEncoding.EncodeToString([]byte(filename)))
+	if body != nil {
+		request.Header.Set("Content-Type", "application/offset+octet-stream")
+	}
+
+	apiResponse, err := test.App.Test(request, -1)
+	require.NoError(t, err)
+	return apiResponse
+}
+
+func headUpload(t *testing.T, url string) *http.Response {
+	request := httptest.NewRequest(http.MethodHead, url, nil)
+	request.Header.Set("Tus-Resumable", config.TusVersion)
+
+	apiResponse, err := test.App.Test(request)
+	require.NoError(t, err)
+	return apiResponse
+}
+
+func patchUpload(t *testing.T, url string, offset int64, chunk []byte) *http.Response {
+	apiResponse, err := test.App.Test(patchRequest(url, offset, chunk), -1)
+	require.NoError(t, err)
+	return apiResponse
+}
+
+// sendPatch is patchUpload for other goroutines, which can't stop the test.
+func sendPatch(url string, offset int64, chunk []byte) int {
+	apiResponse, err := test.App.Test(patchRequest(url, offset, chunk), -1)
+	if err != nil {
+		return 0
+	}
+	return apiResponse.StatusCode
+}
+
+func patchRequest(url string, offset int64, chunk []byte) *http.Request {
+	request := httptest.NewRequest(http.MethodPatch, url, bytes.NewReader(chunk))
+	request.Header.Set("Tus-Resumable", config.TusVersion)
+	request.Header.Set("Content-Type", "application/offset+octet-stream")
+	request.Header.Set("Upload-Offset", strconv.FormatInt(offset, 10))
+	return request
+}
+
+func expireUpload(t *testing.T, url string) {
+	id := strings.TrimPrefix(url, "/v1/uploads/")
+	err := test.DB.Model(&model.Upload{}).Where("id = ?", id).Update("expires_at", time.Now().Add(-time.Minute)).Error
+	require.NoError(t, err)
+}
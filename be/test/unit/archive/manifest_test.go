@@ -0,0 +1,63 @@
+package archive_test
+
+import (
+	"app/src/archive"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func sampleManifest() *archive.Manifest {
+	id := uuid.New()
+	return &archive.Manifest{
+		Format:  archive.Format,
+		Version: archive.Version,
+		PDFs: []archive.PDF{{
+			ID:      id,
+			Version: 2,
+			Versions: []archive.FileVersion{
+				{Version: 1, File: archive.FileEntry(id, 1)},
+				{Version: 2, File: archive.FileEntry(id, 2)},
+			},
+		}},
+	}
+}
+
+func TestCheck(t *testing.T) {
+	t.Run("should accept an archive of this version", func(t *testing.T) {
+		m := sampleManifest()
+		assert.NoError(t, m.Check())
+		assert.Equal(t, 2, m.PDFs[0].Current().Version)
+	})
+
+	t.Run("should accept documents whose file was left out", func(t *testing.T) {
+		m := sampleManifest()
+		m.PDFs[0].Versions[1].File = ""
+		assert.NoError(t, m.Check())
+	})
+
+	t.Run("should reject other formats", func(t *testing.T) {
+		m := sampleManifest()
+		m.Format = "something-else"
+		assert.ErrorIs(t, m.Check(), archive.ErrUnknownFormat)
+	})
+
+	t.Run("should reject newer versions", func(t *testing.T) {
+		m := sampleManifest()
+		m.Version = archive.Version + 1
+		assert.Error(t, m.Check())
+	})
+
+	t.Run("should reject repeated documents", func(t *testing.T) {
+		m := sampleManifest()
+		m.PDFs = append(m.PDFs, m.PDFs[0])
+		assert.Error(t, m.Check())
+	})
+
+	t.Run("should reject files outside the document's folder", func(t *testing.T) {
+		m := sampleManifest()
+		m.PDFs[0].Versions[0].File = "../../etc/passwd"
+		assert.Error(t, m.Check())
+	})
+}
@@ -0,0 +1,231 @@
+package cms_test
+
+import (
+	"app/src/cms"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	oidData          = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 1}
+	oidSignedData    = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 2}
+	oidContentType   = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 3}
+	oidMessageDigest = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 4}
+	oidSigningTime   = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 5}
+	oidSHA256        = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 1}
+	oidECDSASHA256   = asn1.ObjectIdentifier{1, 2, 840, 10045, 4, 3, 2}
+	oidRSA           = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 1}
+)
+
+type contentInfo struct {
+	ContentType asn1.ObjectIdentifier
+	Content     asn1.RawValue
+}
+
+// explicit wraps der in an explicit [0] tag.
+func explicit(der []byte) asn1.RawValue {
+	return asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: der}
+}
+
+type signedData struct {
+	Version          int
+	DigestAlgorithms []pkix.AlgorithmIdentifier `asn1:"set"`
+	EncapContentInfo struct{ ContentType asn1.ObjectIdentifier }
+	Certificates     asn1.RawValue
+	SignerInfos      []signerInfo `asn1:"set"`
+}
+
+type signerInfo struct {
+	Version            int
+	SID                asn1.RawValue
+	DigestAlgorithm    pkix.AlgorithmIdentifier
+	SignedAttrs        asn1.RawValue `asn1:"optional"`
+	SignatureAlgorithm pkix.AlgorithmIdentifier
+	Signature          []byte
+}
+
+type attribute struct {
+	Type   asn1.ObjectIdentifier
+	Values []asn1.RawValue `asn1:"set"`
+}
+
+func mustMarshal(t *testing.T, v interface{}) []byte {
+	t.Helper()
+	der, err := asn1.Marshal(v)
+	require.NoError(t, err)
+	return der
+}
+
+// newCertificate issues a certificate for key, self-signed when parent is
+// nil.
+func newCertificate(t *testing.T, name string, key crypto.Signer, parent *x509.Certificate, parentKey crypto.Signer) *x509.Certificate {
+	t.Helper()
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name, Organization: []string{"Test"}},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+	}
+	if parent == nil {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+		parent, parentKey = template, key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, key.Public(), parentKey)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return cert
+}
+
+// sign builds a detached SignedData over content. With signedAttrs the
+// signature covers attributes carrying the content digest and signingTime.
+func sign(t *testing.T, content []byte, key crypto.Signer, signer *x509.Certificate, chain []*x509.Certificate, signedAttrs bool, signingTime time.Time) []byte {
+	t.Helper()
+
+	digest := sha256.Sum256(content)
+	signed := digest[:]
+
+	info := signerInfo{
+		Version: 1,
+		SID: asn1.RawValue{FullBytes: mustMarshal(t, struct {
+			Issuer asn1.RawValue
+			Serial *big.Int
+		}{asn1.RawValue{FullBytes: signer.RawIssuer}, signer.SerialNumber})},
+		DigestAlgorithm: pkix.AlgorithmIdentifier{Algorithm: oidSHA256},
+	}
+
+	if signedAttrs {
+		var attrs []byte
+		for _, attr := range []attribute{
+			{Type: oidContentType, Values: []asn1.RawValue{{FullBytes: mustMarshal(t, oidData)}}},
+			{Type: oidSigningTime, Values: []asn1.RawValue{{FullBytes: mustMarshal(t, signingTime.UTC())}}},
+			{Type: oidMessageDigest, Values: []asn1.RawValue{{FullBytes: mustMarshal(t, digest[:])}}},
+		} {
+			attrs = append(attrs, mustMarshal(t, attr)...)
+		}
+		info.SignedAttrs = asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: attrs}
+
+		set := mustMarshal(t, asn1.RawValue{Class: asn1.ClassUniversal, Tag: asn1.TagSet, IsCompound: true, Bytes: attrs})
+		sum := sha256.Sum256(set)
+		signed = sum[:]
+	}
+
+	switch key.(type) {
+	case *ecdsa.PrivateKey:
+		info.SignatureAlgorithm = pkix.AlgorithmIdentifier{Algorithm: oidECDSASHA256}
+	case *rsa.PrivateKey:
+		info.SignatureAlgorithm = pkix.AlgorithmIdentifier{Algorithm: oidRSA, Parameters: asn1.NullRawValue}
+	}
+	signature, err := key.Sign(rand.Reader, signed, crypto.SHA256)
+	require.NoError(t, err)
+	info.Signature = signature
+
+	var certs []byte
+	for _, cert := range append([]*x509.Certificate{signer}, chain...) {
+		certs = append(certs, cert.Raw...)
+	}
+
+	sd := signedData{
+		Version:          1,
+		DigestAlgorithms: []pkix.AlgorithmIdentifier{{Algorithm: oidSHA256}},
+		Certificates:     asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: certs},
+		SignerInfos:      []signerInfo{info},
+	}
+	sd.EncapContentInfo.ContentType = oidData
+
+	return mustMarshal(t, contentInfo{
+		ContentType: oidSignedData,
+		Content:     explicit(mustMarshal(t, sd)),
+	})
+}
+
+func TestSignedData(t *testing.T) {
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	ca := newCertificate(t, "Test Root", caKey, nil, nil)
+
+	signerKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	signerCert := newCertificate(t, "Jane Doe", signerKey, ca, caKey)
+
+	content := []byte("%PDF-1.7 signed bytes")
+	signingTime := time.Now().Truncate(time.Second)
+
+	t.Run("should verify signed attributes and the certificate chain", func(t *testing.T) {
+		der := sign(t, content, signerKey, signerCert, nil, true, signingTime)
+		// PDF signatures are zero padded to the size reserved for them
+		der = append(der, make([]byte, 64)...)
+
+		signed, err := cms.Parse(der)
+		require.NoError(t, err)
+		assert.Equal(t, "Jane Doe", signed.Signer.Subject.CommonName)
+		assert.Equal(t, crypto.SHA256, signed.Hash)
+		require.NotNil(t, signed.SigningTime)
+		assert.True(t, signed.SigningTime.Equal(signingTime))
+
+		assert.NoError(t, signed.Verify(content))
+
+		roots := x509.NewCertPool()
+		roots.AddCert(ca)
+		_, err = signed.VerifyChain(roots, signingTime)
+		assert.NoError(t, err)
+
+		_, err = signed.VerifyChain(x509.NewCertPool(), signingTime)
+		assert.Error(t, err)
+	})
+
+	t.Run("should verify signatures without signed attributes", func(t *testing.T) {
+		rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+		require.NoError(t, err)
+		rsaCert := newCertificate(t, "RSA Signer", rsaKey, ca, caKey)
+
+		signed, err := cms.Parse(sign(t, content, rsaKey, rsaCert, []*x509.Certificate{ca}, false, signingTime))
+		require.NoError(t, err)
+		assert.Nil(t, signed.SigningTime)
+		assert.NoError(t, signed.Verify(content))
+	})
+
+	t.Run("should detect changed content", func(t *testing.T) {
+		signed, err := cms.Parse(sign(t, content, signerKey, signerCert, nil, true, signingTime))
+		require.NoError(t, err)
+
+		assert.ErrorIs(t, signed.Verify([]byte("%PDF-1.7 changed bytes")), cms.ErrDigestMismatch)
+	})
+
+	t.Run("should detect signatures made with another key", func(t *testing.T) {
+		otherKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		require.NoError(t, err)
+
+		signed, err := cms.Parse(sign(t, content, otherKey, signerCert, nil, true, signingTime))
+		require.NoError(t, err)
+
+		assert.ErrorIs(t, signed.Verify(content), cms.ErrBadSignature)
+	})
+
+	t.Run("should reject data that isn't a signature", func(t *testing.T) {
+		_, err := cms.Parse([]byte("not a signature"))
+		assert.ErrorIs(t, err, cms.ErrMalformed)
+
+		_, err = cms.Parse(mustMarshal(t, contentInfo{
+			ContentType: oidData,
+			Content:     explicit(mustMarshal(t, []byte("data"))),
+		}))
+		assert.ErrorIs(t, err, cms.ErrUnsupported)
+	})
+}
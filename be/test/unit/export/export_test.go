@@ -0,0 +1,134 @@
+package export_test
+
+import (
+	"app/src/export"
+	"app/src/pdfdoc"
+	"archive/zip"
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func sampleSummary() export.Summary {
+	return export.Summary{
+		Title:     "Annual Report",
+		Filename:  "report.pdf",
+		Author:    "Budi",
+		PageCount: 12,
+		Version:   2,
+		Text: `The report covers <mark style="background-color: #2196F3; color: white;">revenue</mark> & costs.
+
+- Growth was <mark>steady</mark>
+- Costs fell`,
+		Chapters:    []export.Chapter{{Title: "Outlook", StartPage: 10, EndPage: 12, Text: "Plans for <mark>2027</mark>."}},
+		Language:    "en",
+		OutputType:  "paragraph",
+		GeneratedAt: time.Date(2026, 10, 18, 9, 30, 0, 0, time.UTC),
+	}
+}
+
+func write(t *testing.T, format string, summaries ...export.Summary) []byte {
+	var buf bytes.Buffer
+	require.NoError(t, export.Write(&buf, format, summaries))
+	return buf.Bytes()
+}
+
+func TestWriteMarkdown(t *testing.T) {
+	out := string(write(t, export.FormatMarkdown, sampleSummary()))
+
+	assert.True(t, strings.HasPrefix(out, "# Annual Report\n"))
+	assert.Contains(t, out, "The report covers **revenue** & costs.\n\n- Growth was **steady**\n- Costs fell\n")
+	assert.Contains(t, out, "### Outlook (pp. 10–12)\n\nPlans for **2027**.\n")
+	assert.Contains(t, out, "1. Budi. Annual Report. report.pdf, pp. 1–12.\n2. Outlook, pp. 10–12.\n")
+	assert.Contains(t, out, "- **Generated:** 2026-10-18 09:30 UTC\n")
+}
+
+func TestWriteHTML(t *testing.T) {
+	s := sampleSummary()
+	s.Title = "<script>alert(1)</script>"
+	out := string(write(t, export.FormatHTML, s))
+
+	assert.NotContains(t, out, "<script>")
+	assert.Contains(t, out, "<p>The report covers <strong>revenue</strong> &amp; costs.</p>")
+	assert.Contains(t, out, "<li>Growth was <strong>steady</strong></li>\n<li>Costs fell</li>")
+}
+
+func TestWriteDOCX(t *testing.T) {
+	out := write(t, export.FormatDOCX, sampleSummary())
+
+	archive, err := zip.NewReader(bytes.NewReader(out), int64(len(out)))
+	require.NoError(t, err)
+	var document string
+	for _, f := range archive.File {
+		if f.Name == "word/document.xml" {
+			r, err := f.Open()
+			require.NoError(t, err)
+			data, err := io.ReadAll(r)
+			require.NoError(t, err)
+			document = string(data)
+		}
+	}
+
+	assert.Contains(t, document, `<w:r><w:rPr><w:b/></w:rPr><w:t xml:space="preserve">revenue</w:t></w:r>`)
+	assert.Contains(t, document, `<w:pStyle w:val="ListBullet"/>`)
+	assert.Contains(t, document, "&amp; costs.")
+}
+
+func TestWritePDF(t *testing.T) {
+	t.Run("should write readable text in Latin and Japanese", func(t *testing.T) {
+		s := sampleSummary()
+		s.Text = "要約は<mark>売上</mark>について。 Café (draft)"
+		doc, err := pdfdoc.Open(write(t, export.FormatPDF, s))
+		require.NoError(t, err)
+
+		pages, err := doc.Pages()
+		require.NoError(t, err)
+		require.Len(t, pages, 1)
+		text, err := pages[0].Text()
+		require.NoError(t, err)
+		assert.Contains(t, text, "Annual Report")
+		assert.Contains(t, text, "要約は売上について。 Café (draft)")
+		assert.Contains(t, text, "Outlook (pp. 10–12)")
+	})
+
+	t.Run("should wrap long text and start each summary on a new page", func(t *testing.T) {
+		s := sampleSummary()
+		s.Text = strings.Repeat("lorem ipsum dolor sit amet ", 400)
+		doc, err := pdfdoc.Open(write(t, export.FormatPDF, s, sampleSummary()))
+		require.NoError(t, err)
+
+		pages, err := doc.Pages()
+		require.NoError(t, err)
+		require.Greater(t, len(pages), 2)
+		lines, err := pages[0].Lines()
+		require.NoError(t, err)
+		for _, line := range lines {
+			last := line.Words[len(line.Words)-1]
+			assert.LessOrEqual(t, last.X1, 595.28-56.7+0.5, line.Text)
+		}
+
+		text, err := pages[len(pages)-1].Text()
+		require.NoError(t, err)
+		assert.True(t, strings.HasPrefix(text, "Annual Report\n"))
+	})
+}
+
+func TestWriteUnsupportedFormat(t *testing.T) {
+	err := export.Write(io.Discard, "rtf", []export.Summary{sampleSummary()})
+	assert.ErrorIs(t, err, export.ErrUnsupportedFormat)
+}
+
+func TestWriteEmail(t *testing.T) {
+	var text, html bytes.Buffer
+	intro := []string{"Budi (budi@example.com) shared a summary of report.pdf with you.", "See <b>page 3</b>"}
+	require.NoError(t, export.WriteEmail(&text, &html, intro, sampleSummary()))
+
+	assert.True(t, strings.HasPrefix(text.String(), "Budi (budi@example.com) shared a summary of report.pdf with you.\n\nSee \\<b>page 3\\</b>\n\n# Annual Report\n"))
+	assert.Contains(t, html.String(), "<body>\n<p>Budi (budi@example.com) shared a summary of report.pdf with you.</p>\n<p>See &lt;b&gt;page 3&lt;/b&gt;</p>\n<article>")
+	assert.Contains(t, html.String(), "<title>Annual Report</title>")
+}
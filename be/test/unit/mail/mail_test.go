@@ -0,0 +1,64 @@
+package mail_test
+
+import (
+	"app/src/mail"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRender(t *testing.T) {
+	data := mail.Data{
+		Name:      "Budi <Santoso>",
+		URL:       "https://app.example.com/reset-password?token=abc&x=1",
+		ExpiresIn: 10,
+	}
+
+	t.Run("should render every template in every locale", func(t *testing.T) {
+		for _, locale := range mail.Locales {
+			for _, name := range mail.Names {
+				message, err := mail.Render(name, locale, data)
+				require.NoError(t, err, name+" in "+locale)
+
+				assert.NotEmpty(t, message.Subject)
+				assert.Contains(t, message.Text, data.URL)
+				assert.Contains(t, message.HTML, `<html lang="`+locale+`">`)
+				assert.Contains(t, message.HTML, `href="https://app.example.com/reset-password?token=abc&amp;x=1"`)
+			}
+		}
+	})
+
+	t.Run("should escape values in HTML only", func(t *testing.T) {
+		message, err := mail.Render(mail.TemplateResetPassword, "en", data)
+		require.NoError(t, err)
+
+		assert.Equal(t, "Reset your password", message.Subject)
+		assert.True(t, strings.HasPrefix(message.Text, "Hi Budi <Santoso>,\n"))
+		assert.Contains(t, message.HTML, "Hi Budi &lt;Santoso&gt;,")
+		assert.Contains(t, message.Text, "expires in 10 minutes")
+	})
+
+	t.Run("should pick the closest locale", func(t *testing.T) {
+		message, err := mail.Render(mail.TemplateVerifyEmail, "ja-JP", data)
+		require.NoError(t, err)
+		assert.Equal(t, "メールアドレスの確認", message.Subject)
+
+		message, err = mail.Render(mail.TemplateVerifyEmail, "fr", data)
+		require.NoError(t, err)
+		assert.Equal(t, "Verify your email address", message.Subject)
+	})
+
+	t.Run("should reject unknown templates", func(t *testing.T) {
+		_, err := mail.Render("welcome", "en", data)
+		assert.ErrorIs(t, err, mail.ErrUnknownTemplate)
+	})
+}
+
+func TestLocale(t *testing.T) {
+	assert.Equal(t, "id", mail.Locale("id"))
+	assert.Equal(t, "id", mail.Locale("id_ID"))
+	assert.Equal(t, "ja", mail.Locale(" JA "))
+	assert.Equal(t, mail.DefaultLocale, mail.Locale(""))
+}
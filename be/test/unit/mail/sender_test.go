@@ -0,0 +1,105 @@
+package mail_test
+
+import (
+	"app/src/mail"
+	"app/test/helper"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newFakeSMTP starts a local SMTP server that refuses the rejected
+// recipients with a 550 reply.
+func newFakeSMTP(t *testing.T, rejected ...string) *helper.SMTPServer {
+	server := helper.NewSMTPServer()
+	for _, address := range rejected {
+		server.Reject(address)
+	}
+	t.Cleanup(server.Close)
+	return server
+}
+
+func smtpSender(server *helper.SMTPServer) *mail.SMTPSender {
+	return mail.NewSMTPSender(server.Host(), server.Port(), "", "", "Docs <noreply@example.com>")
+}
+
+func TestSMTPSender(t *testing.T) {
+	t.Run("should deliver the text, HTML and attachments", func(t *testing.T) {
+		server := newFakeSMTP(t)
+
+		path := filepath.Join(t.TempDir(), "report.pdf")
+		require.NoError(t, os.WriteFile(path, []byte("%PDF-1.4 fake"), 0o600))
+
+		err := smtpSender(server).Send(&mail.Email{
+			To:      []string{"alice@example.com"},
+			ReplyTo: "bob@example.com",
+			Subject: "Summary: Report",
+			Text:    "Plain summary",
+			HTML:    "<p>HTML summary</p>",
+			Attachments: []mail.Attachment{{
+				Path:        path,
+				Filename:    "Q3 report.pdf",
+				ContentType: "application/pdf",
+			}},
+		})
+		require.NoError(t, err)
+
+		messages := server.Received()
+		require.Len(t, messages, 1)
+		message := messages[0]
+		assert.Contains(t, message, "To: alice@example.com")
+		assert.Contains(t, message, "Reply-To: bob@example.com")
+		assert.Contains(t, message, "Subject: Summary: Report")
+		assert.Contains(t, message, "Plain summary")
+		assert.Contains(t, message, "<p>HTML summary</p>")
+		assert.Contains(t, message, `filename="Q3 report.pdf"`)
+	})
+
+	t.Run("should treat a rejected recipient as permanent", func(t *testing.T) {
+		server := newFakeSMTP(t, "ghost@example.com")
+
+		err := smtpSender(server).Send(&mail.Email{
+			To:      []string{"ghost@example.com"},
+			Subject: "Hello",
+			Text:    "Hello",
+		})
+		require.Error(t, err)
+		assert.ErrorIs(t, err, mail.ErrRejected)
+		assert.True(t, mail.IsPermanent(err))
+		assert.Empty(t, server.Received())
+	})
+
+	t.Run("should retry when the server cannot be reached", func(t *testing.T) {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		port := listener.Addr().(*net.TCPAddr).Port
+		listener.Close()
+
+		sender := mail.NewSMTPSender("127.0.0.1", port, "", "", "noreply@example.com")
+		err = sender.Send(&mail.Email{To: []string{"alice@example.com"}, Subject: "Hello", Text: "Hello"})
+		require.Error(t, err)
+		assert.False(t, mail.IsPermanent(err))
+	})
+
+	t.Run("should treat a missing attachment as permanent", func(t *testing.T) {
+		server := newFakeSMTP(t)
+
+		err := smtpSender(server).Send(&mail.Email{
+			To:      []string{"alice@example.com"},
+			Subject: "Hello",
+			Text:    "Hello",
+			Attachments: []mail.Attachment{{
+				Path:        filepath.Join(t.TempDir(), "gone.pdf"),
+				Filename:    "gone.pdf",
+				ContentType: "application/pdf",
+			}},
+		})
+		require.Error(t, err)
+		assert.True(t, mail.IsPermanent(err))
+		assert.Empty(t, server.Received())
+	})
+}
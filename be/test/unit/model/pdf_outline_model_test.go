@@ -0,0 +1,40 @@
+package model_test
+
+import (
+	"app/src/model"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOutlineModel(t *testing.T) {
+	t.Run("should round trip through a JSONB column", func(t *testing.T) {
+		outline := model.Outline{
+			{Title: "Introduction", Level: 1, Page: 1},
+			{Title: "Scope", Level: 2, Page: 2},
+		}
+
+		value, err := outline.Value()
+		require.NoError(t, err)
+
+		var scanned model.Outline
+		require.NoError(t, scanned.Scan([]byte(value.(string))))
+		assert.Equal(t, outline, scanned)
+	})
+
+	t.Run("should store no outline as NULL", func(t *testing.T) {
+		value, err := model.Outline(nil).Value()
+		require.NoError(t, err)
+		assert.Nil(t, value)
+
+		var scanned model.ChapterSummaries
+		require.NoError(t, scanned.Scan(nil))
+		assert.Nil(t, scanned)
+	})
+
+	t.Run("should reject values that aren't JSON", func(t *testing.T) {
+		var scanned model.Outline
+		assert.Error(t, scanned.Scan(42))
+	})
+}
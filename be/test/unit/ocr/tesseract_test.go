@@ -0,0 +1,94 @@
+package ocr_test
+
+import (
+	"app/src/ocr"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const sampleTSV = "level\tpage_num\tblock_num\tpar_num\tline_num\tword_num\tleft\ttop\twidth\theight\tconf\ttext\n" +
+	"1\t1\t0\t0\t0\t0\t0\t0\t2480\t3508\t-1\t\n" +
+	"4\t1\t1\t1\t1\t0\t100\t100\t800\t40\t-1\t\n" +
+	"5\t1\t1\t1\t1\t1\t100\t100\t200\t40\t96.5\tLecture\n" +
+	"5\t1\t1\t1\t1\t2\t320\t100\t200\t40\t91.5\tnotes\n" +
+	"5\t1\t1\t1\t2\t1\t100\t150\t200\t40\t90\tWeek\n" +
+	"5\t1\t1\t1\t2\t2\t320\t150\t40\t40\t-1\t \n" +
+	"5\t1\t2\t1\t1\t1\t100\t300\t200\t40\t82\tSummary\n"
+
+func TestParseTSV(t *testing.T) {
+	t.Run("should rebuild lines and paragraphs", func(t *testing.T) {
+		result, err := ocr.ParseTSV(strings.NewReader(sampleTSV))
+
+		assert.NoError(t, err)
+		assert.Equal(t, "Lecture notes\nWeek\n\nSummary", result.Text)
+		assert.InDelta(t, 90, result.Confidence, 0.01)
+	})
+
+	t.Run("should return nothing for pages without words", func(t *testing.T) {
+		result, err := ocr.ParseTSV(strings.NewReader(strings.SplitN(sampleTSV, "\n", 3)[0]))
+
+		assert.NoError(t, err)
+		assert.Empty(t, result.Text)
+		assert.Zero(t, result.Confidence)
+	})
+}
+
+func TestTesseract(t *testing.T) {
+	dir := t.TempDir()
+	argsFile := filepath.Join(dir, "args")
+
+	// Stand-ins for the real tools, recording how they were called
+	render := writeScript(t, dir, "pdftoppm", `echo "$@" >> `+argsFile+`
+for last; do :; done
+touch "$last.png"`)
+	tsvFile := filepath.Join(dir, "page.tsv")
+	require.NoError(t, os.WriteFile(tsvFile, []byte(sampleTSV), 0o644))
+	recognize := writeScript(t, dir, "tesseract", `echo "$@" >> `+argsFile+`
+test -f "$1" || exit 1
+cat `+tsvFile)
+
+	t.Run("should render the page and read it", func(t *testing.T) {
+		provider := ocr.NewTesseract("eng+ind")
+		provider.RenderCommand = render
+		provider.Command = recognize
+
+		result, err := provider.Recognize(context.Background(), "/tmp/doc.pdf", 3)
+
+		require.NoError(t, err)
+		assert.Equal(t, "Lecture notes\nWeek\n\nSummary", result.Text)
+
+		args, _ := os.ReadFile(argsFile)
+		calls := strings.Split(strings.TrimSpace(string(args)), "\n")
+		require.Len(t, calls, 2)
+		assert.Contains(t, calls[0], "-f 3 -l 3 -r 300 -png -singlefile /tmp/doc.pdf")
+		assert.Contains(t, calls[1], "stdout -l eng+ind tsv")
+	})
+
+	t.Run("should report failing tools", func(t *testing.T) {
+		provider := ocr.NewTesseract("eng")
+		provider.RenderCommand = writeScript(t, dir, "broken", `echo "Syntax Error: Couldn't read xref table" >&2; exit 1`)
+
+		_, err := provider.Recognize(context.Background(), "/tmp/doc.pdf", 1)
+
+		assert.ErrorContains(t, err, "Couldn't read xref table")
+	})
+}
+
+func TestNoop(t *testing.T) {
+	result, err := ocr.Noop{}.Recognize(context.Background(), "/tmp/doc.pdf", 1)
+
+	assert.NoError(t, err)
+	assert.Empty(t, result.Text)
+}
+
+func writeScript(t *testing.T, dir, name, body string) string {
+	path := filepath.Join(dir, name)
+	require.NoError(t, os.WriteFile(path, []byte("#!/bin/sh\n"+body+"\n"), 0o755))
+	return path
+}
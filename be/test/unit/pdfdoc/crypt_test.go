@@ -0,0 +1,219 @@
+package pdfdoc_test
+
+import (
+	"app/src/pdfdoc"
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/md5"
+	"crypto/rc4"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/binary"
+	"fmt"
+	"hash"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var padding = []byte("\x28\xbf\x4e\x5e\x4e\x75\x8a\x41\x64\x00\x4e\x56\xff\xfa\x01\x08\x2e\x2e\x00\xb6\xd0\x68\x3e\x80\x2f\x0c\xa9\xfe\x64\x53\x69\x7a")
+
+const permissions = int32(-3904)
+
+func TestEncryption(t *testing.T) {
+	for _, r := range []int{3, 4, 6} {
+		t.Run(fmt.Sprintf("revision %d", r), func(t *testing.T) {
+			data := encryptedPDF(r, "user", "owner")
+
+			t.Run("should require a password", func(t *testing.T) {
+				_, err := pdfdoc.Open(data)
+				assert.ErrorIs(t, err, pdfdoc.ErrPasswordRequired)
+			})
+
+			t.Run("should reject a wrong password", func(t *testing.T) {
+				_, err := pdfdoc.OpenWithPassword(data, "guess")
+				assert.ErrorIs(t, err, pdfdoc.ErrIncorrectPassword)
+			})
+
+			for _, password := range []string{"user", "owner"} {
+				t.Run("should decrypt with the "+password+" password", func(t *testing.T) {
+					doc, err := pdfdoc.OpenWithPassword(data, password)
+					if !assert.NoError(t, err) {
+						return
+					}
+					assert.True(t, doc.Encrypted())
+					assert.Equal(t, "Secret Title", doc.Metadata().Title)
+
+					pages, err := doc.Pages()
+					assert.NoError(t, err)
+					contents, err := pages[0].Contents()
+					assert.NoError(t, err)
+					assert.Contains(t, string(contents), "(Hello) Tj")
+				})
+			}
+
+			t.Run("should open without a password when only an owner password is set", func(t *testing.T) {
+				doc, err := pdfdoc.Open(encryptedPDF(r, "", "owner"))
+				if assert.NoError(t, err) {
+					assert.Equal(t, "Secret Title", doc.Metadata().Title)
+				}
+			})
+		})
+	}
+}
+
+// encryptedPDF builds a one page document encrypted the way the standard
+// security handler describes.
+func encryptedPDF(r int, user, owner string) []byte {
+	id := []byte("0123456789abcdef")
+	var key, o, u []byte
+	encrypt := ""
+
+	switch r {
+	case 3, 4:
+		ownerKey := md5.Sum(pad(owner))
+		for i := 0; i < 50; i++ {
+			ownerKey = md5.Sum(ownerKey[:])
+		}
+		o = pad(user)
+		for i := 0; i < 20; i++ {
+			rc4XOR(xorBytes(ownerKey[:], byte(i)), o)
+		}
+
+		digest := md5.New()
+		digest.Write(pad(user))
+		digest.Write(o)
+		binary.Write(digest, binary.LittleEndian, permissions)
+		digest.Write(id)
+		key = digest.Sum(nil)
+		for i := 0; i < 50; i++ {
+			sum := md5.Sum(key)
+			key = sum[:]
+		}
+
+		sum := md5.Sum(append(append([]byte(nil), padding...), id...))
+		u = sum[:]
+		for i := 0; i < 20; i++ {
+			rc4XOR(xorBytes(key, byte(i)), u)
+		}
+		u = append(u, make([]byte, 16)...)
+
+		if r == 3 {
+			encrypt = fmt.Sprintf("<< /Filter /Standard /V 2 /R 3 /Length 128 /O <%x> /U <%x> /P %d >>", o, u, permissions)
+		} else {
+			encrypt = fmt.Sprintf("<< /Filter /Standard /V 4 /R 4 /Length 128 /CF << /StdCF << /CFM /AESV2 /Length 16 >> >> /StmF /StdCF /StrF /StdCF /O <%x> /U <%x> /P %d >>", o, u, permissions)
+		}
+	case 6:
+		key = []byte("0123456789abcdef0123456789abcdef")
+		uSalt, oSalt := []byte("uvalsaltukeysalt"), []byte("ovalsaltokeysalt")
+
+		u = append(hash6([]byte(user), uSalt[:8], nil), uSalt...)
+		ue := aesWrap(hash6([]byte(user), uSalt[8:], nil), key)
+		o = append(hash6([]byte(owner), oSalt[:8], u), oSalt...)
+		oe := aesWrap(hash6([]byte(owner), oSalt[8:], u), key)
+
+		encrypt = fmt.Sprintf("<< /Filter /Standard /V 5 /R 6 /Length 256 /CF << /StdCF << /CFM /AESV3 /Length 32 >> >> /StmF /StdCF /StrF /StdCF /O <%x> /U <%x> /OE <%x> /UE <%x> /P %d /Perms <%x> >>", o, u, oe, ue, permissions, make([]byte, 16))
+	}
+
+	content := seal(r, key, 4, []byte("BT /F1 12 Tf (Hello) Tj ET"))
+	title := seal(r, key, 5, []byte("Secret Title"))
+
+	return buildPDF(fmt.Sprintf("/Root 1 0 R /Info 5 0 R /Encrypt 6 0 R /ID [<%x> <%x>]", id, id),
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		"<< /Type /Page /Parent 2 0 R /Contents 4 0 R >>",
+		fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", len(content), content),
+		fmt.Sprintf("<< /Title <%x> >>", title),
+		encrypt,
+	)
+}
+
+// seal encrypts data belonging to object num with generation 0.
+func seal(r int, key []byte, num int, data []byte) []byte {
+	objectKey := key
+	if r < 6 {
+		digest := md5.New()
+		digest.Write(key)
+		digest.Write([]byte{byte(num), 0, 0, 0, 0})
+		if r == 4 {
+			digest.Write([]byte("sAlT"))
+		}
+		objectKey = digest.Sum(nil)
+	}
+
+	if r == 3 {
+		out := append([]byte(nil), data...)
+		rc4XOR(objectKey, out)
+		return out
+	}
+
+	n := aes.BlockSize - len(data)%aes.BlockSize
+	plain := append(append([]byte(nil), data...), bytes.Repeat([]byte{byte(n)}, n)...)
+	iv := []byte("initialisation16")
+	block, _ := aes.NewCipher(objectKey)
+	out := make([]byte, len(plain))
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(out, plain)
+	return append(iv, out...)
+}
+
+func hash6(password, salt, userKey []byte) []byte {
+	sum := sha256.Sum256(append(append(append([]byte(nil), password...), salt...), userKey...))
+	k := sum[:]
+
+	for round := 1; ; round++ {
+		k1 := bytes.Repeat(append(append(append([]byte(nil), password...), k...), userKey...), 64)
+		block, _ := aes.NewCipher(k[:16])
+		e := make([]byte, len(k1))
+		cipher.NewCBCEncrypter(block, k[16:32]).CryptBlocks(e, k1)
+
+		var h hash.Hash
+		switch mod3(e[:16]) {
+		case 0:
+			h = sha256.New()
+		case 1:
+			h = sha512.New384()
+		default:
+			h = sha512.New()
+		}
+		h.Write(e)
+		k = h.Sum(nil)
+
+		if round >= 64 && int(e[len(e)-1]) <= round-32 {
+			return k[:32]
+		}
+	}
+}
+
+// mod3 reduces a big-endian number modulo 3 digit by digit.
+func mod3(b []byte) int {
+	rem := 0
+	for _, c := range b {
+		rem = (rem*256 + int(c)) % 3
+	}
+	return rem
+}
+
+func aesWrap(kek, key []byte) []byte {
+	block, _ := aes.NewCipher(kek)
+	out := make([]byte, len(key))
+	cipher.NewCBCEncrypter(block, make([]byte, aes.BlockSize)).CryptBlocks(out, key)
+	return out
+}
+
+func pad(password string) []byte {
+	return append([]byte(password), padding...)[:32]
+}
+
+func xorBytes(key []byte, v byte) []byte {
+	out := make([]byte, len(key))
+	for i := range key {
+		out[i] = key[i] ^ v
+	}
+	return out
+}
+
+func rc4XOR(key, data []byte) {
+	c, _ := rc4.NewCipher(key)
+	c.XORKeyStream(data, data)
+}
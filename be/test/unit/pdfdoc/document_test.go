@@ -0,0 +1,224 @@
+package pdfdoc_test
+
+import (
+	"app/src/pdfdoc"
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOpen(t *testing.T) {
+	t.Run("should read pages and metadata", func(t *testing.T) {
+		doc, err := pdfdoc.Open(simplePDF(3, "<< /Title (Annual Report) /Author <FEFF00530069007400690020004E0075007200620061007900610020> /Producer (Writer\\0512\\051) /CreationDate (D:20240131235959+07'00') >>"))
+		assert.NoError(t, err)
+		assert.NoError(t, doc.Check())
+
+		assert.Equal(t, "1.7", doc.Version)
+		assert.Equal(t, 3, doc.NumPages())
+
+		meta := doc.Metadata()
+		assert.Equal(t, "Annual Report", meta.Title)
+		assert.Equal(t, "Siti Nurbaya", meta.Author)
+		assert.Equal(t, "Writer)2)", meta.Producer)
+		if assert.NotNil(t, meta.CreationDate) {
+			assert.True(t, meta.CreationDate.Equal(time.Date(2024, 1, 31, 16, 59, 59, 0, time.UTC)))
+		}
+
+		pages, _ := doc.Pages()
+		assert.Equal(t, [4]float64{0, 0, 595, 842}, pages[0].MediaBox)
+	})
+
+	t.Run("should fall back to XMP metadata", func(t *testing.T) {
+		xmp := `<x:xmpmeta xmlns:x="adobe:ns:meta/"><rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#">` +
+			`<rdf:Description xmlns:dc="http://purl.org/dc/elements/1.1/" xmlns:pdf="http://ns.adobe.com/pdf/1.3/" xmlns:xmp="http://ns.adobe.com/xap/1.0/" pdf:Producer="XMP Producer">` +
+			`<dc:title><rdf:Alt><rdf:li xml:lang="x-default">XMP Title</rdf:li></rdf:Alt></dc:title>` +
+			`<dc:creator><rdf:Seq><rdf:li>Ana</rdf:li><rdf:li>Budi</rdf:li></rdf:Seq></dc:creator>` +
+			`<xmp:CreateDate>2023-05-01T10:00:00Z</xmp:CreateDate>` +
+			`</rdf:Description></rdf:RDF></x:xmpmeta>`
+
+		data := buildPDF("/Root 1 0 R",
+			"<< /Type /Catalog /Pages 2 0 R /Metadata 4 0 R >>",
+			"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+			"<< /Type /Page /Parent 2 0 R >>",
+			fmt.Sprintf("<< /Type /Metadata /Subtype /XML /Length %d >>\nstream\n%s\nendstream", len(xmp), xmp),
+		)
+
+		doc, err := pdfdoc.Open(data)
+		assert.NoError(t, err)
+
+		meta := doc.Metadata()
+		assert.Equal(t, "XMP Title", meta.Title)
+		assert.Equal(t, "Ana, Budi", meta.Author)
+		assert.Equal(t, "XMP Producer", meta.Producer)
+		assert.NotNil(t, meta.CreationDate)
+	})
+
+	t.Run("should read compressed cross-reference and object streams", func(t *testing.T) {
+		doc, err := pdfdoc.Open(compressedPDF())
+		assert.NoError(t, err)
+		assert.Equal(t, 2, doc.NumPages())
+		assert.Equal(t, "Packed", doc.Metadata().Title)
+	})
+
+	t.Run("should rebuild a broken cross-reference table", func(t *testing.T) {
+		data := simplePDF(2, "")
+		i := bytes.LastIndex(data, []byte("startxref\n"))
+		copy(data[i+len("startxref\n"):], "9")
+
+		doc, err := pdfdoc.Open(data)
+		assert.NoError(t, err)
+		assert.Equal(t, 2, doc.NumPages())
+	})
+
+	t.Run("should reject files that are not PDFs", func(t *testing.T) {
+		_, err := pdfdoc.Open([]byte("just some text"))
+		assert.ErrorIs(t, err, pdfdoc.ErrNotPDF)
+	})
+
+	t.Run("should reject truncated files", func(t *testing.T) {
+		data := simplePDF(1, "")
+		_, err := pdfdoc.Open(data[:len(data)/2])
+		assert.ErrorIs(t, err, pdfdoc.ErrTruncated)
+	})
+
+	t.Run("should reject files without a document catalog", func(t *testing.T) {
+		_, err := pdfdoc.Open([]byte("%PDF-1.4\ngarbage\n%%EOF\n"))
+		assert.ErrorIs(t, err, pdfdoc.ErrCorrupt)
+	})
+
+	t.Run("should reject unsupported security handlers", func(t *testing.T) {
+		data := buildPDF("/Root 1 0 R /Encrypt 4 0 R",
+			"<< /Type /Catalog /Pages 2 0 R >>",
+			"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+			"<< /Type /Page /Parent 2 0 R >>",
+			"<< /Filter /Adobe.PubSec /V 4 /R 4 >>",
+		)
+		_, err := pdfdoc.Open(data)
+		assert.ErrorIs(t, err, pdfdoc.ErrUnsupportedEncryption)
+	})
+
+	t.Run("should report missing page content", func(t *testing.T) {
+		data := buildPDF("/Root 1 0 R",
+			"<< /Type /Catalog /Pages 2 0 R >>",
+			"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+			"<< /Type /Page /Parent 2 0 R /Contents 9 0 R >>",
+		)
+		doc, err := pdfdoc.Open(data)
+		assert.NoError(t, err)
+		assert.ErrorIs(t, doc.Check(), pdfdoc.ErrCorrupt)
+	})
+
+	t.Run("should reject a page tree loop", func(t *testing.T) {
+		data := buildPDF("/Root 1 0 R",
+			"<< /Type /Catalog /Pages 2 0 R >>",
+			"<< /Type /Pages /Kids [2 0 R] /Count 1 >>",
+		)
+		doc, err := pdfdoc.Open(data)
+		assert.NoError(t, err)
+		assert.ErrorIs(t, doc.Check(), pdfdoc.ErrCorrupt)
+	})
+
+	t.Run("should decode a content stream shared by many pages once", func(t *testing.T) {
+		doc, err := pdfdoc.Open(bombPDF(200, 1, 60<<20))
+		assert.NoError(t, err)
+
+		start := time.Now()
+		assert.NoError(t, doc.Check())
+		assert.Less(t, time.Since(start), 5*time.Second)
+	})
+
+	t.Run("should reject documents whose streams decode to too much", func(t *testing.T) {
+		doc, err := pdfdoc.Open(bombPDF(5, 5, 60<<20))
+		assert.NoError(t, err)
+		assert.ErrorIs(t, doc.Check(), pdfdoc.ErrCorrupt)
+	})
+}
+
+// bombPDF has the given number of pages drawing from the given number of
+// Flate-compressed content streams, each holding size spaces and used by the
+// pages in turn.
+func bombPDF(pages, streams, size int) []byte {
+	var compressed bytes.Buffer
+	w := zlib.NewWriter(&compressed)
+	w.Write(bytes.Repeat([]byte(" "), size))
+	w.Close()
+
+	objects := []string{"<< /Type /Catalog /Pages 2 0 R >>", ""}
+	for i := 0; i < streams; i++ {
+		objects = append(objects, fmt.Sprintf("<< /Length %d /Filter /FlateDecode >>\nstream\n%s\nendstream",
+			compressed.Len(), compressed.String()))
+	}
+	kids := ""
+	for i := 0; i < pages; i++ {
+		kids += fmt.Sprintf("%d 0 R ", len(objects)+1)
+		objects = append(objects, fmt.Sprintf("<< /Type /Page /Parent 2 0 R /Contents %d 0 R >>", 3+i%streams))
+	}
+	objects[1] = fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d /MediaBox [0 0 595 842] >>", kids, pages)
+
+	return buildPDF("/Root 1 0 R", objects...)
+}
+
+// compressedPDF stores its objects in a Flate-compressed object stream and
+// indexes them with a PNG-predicted cross-reference stream.
+func compressedPDF() []byte {
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R 4 0 R] /Count 2 >>",
+		"<< /Type /Page /Parent 2 0 R >>",
+		"<< /Type /Page /Parent 2 0 R >>",
+		"<< /Title (Packed) >>",
+	}
+
+	var header, body bytes.Buffer
+	for i, obj := range objects {
+		fmt.Fprintf(&header, "%d %d ", i+1, body.Len())
+		body.WriteString(obj + "\n")
+	}
+	objStm := deflate(append(header.Bytes(), body.Bytes()...))
+
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.5\n")
+	objStmOffset := buf.Len()
+	fmt.Fprintf(&buf, "6 0 obj\n<< /Type /ObjStm /N %d /First %d /Filter /FlateDecode /Length %d >>\nstream\n", len(objects), header.Len(), len(objStm))
+	buf.Write(objStm)
+	buf.WriteString("\nendstream\nendobj\n")
+
+	// Rows of type (1 byte), field 2 (2 bytes), field 3 (1 byte)
+	rows := [][]byte{{0, 0, 0, 0xff}}
+	for i := range objects {
+		rows = append(rows, []byte{2, 0, 6, byte(i)})
+	}
+	rows = append(rows, []byte{1, byte(objStmOffset >> 8), byte(objStmOffset), 0})
+	xrefOffset := buf.Len()
+	rows = append(rows, []byte{1, byte(xrefOffset >> 8), byte(xrefOffset), 0})
+
+	// PNG "Up" predictor on every row
+	var predicted []byte
+	prev := make([]byte, 4)
+	for _, row := range rows {
+		predicted = append(predicted, 2)
+		for i := range row {
+			predicted = append(predicted, row[i]-prev[i])
+		}
+		prev = row
+	}
+	xref := deflate(predicted)
+
+	fmt.Fprintf(&buf, "7 0 obj\n<< /Type /XRef /Size 8 /W [1 2 1] /Root 1 0 R /Info 5 0 R /Filter /FlateDecode /DecodeParms << /Predictor 12 /Columns 4 >> /Length %d >>\nstream\n", len(xref))
+	buf.Write(xref)
+	fmt.Fprintf(&buf, "\nendstream\nendobj\nstartxref\n%d\n%%%%EOF\n", xrefOffset)
+
+	return buf.Bytes()
+}
+
+func deflate(data []byte) []byte {
+	var buf bytes.Buffer
+	w := zlib.NewWriter(&buf)
+	w.Write(data)
+	w.Close()
+	return buf.Bytes()
+}
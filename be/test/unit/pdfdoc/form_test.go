@@ -0,0 +1,71 @@
+package pdfdoc_test
+
+import (
+	"app/src/pdfdoc"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormFields(t *testing.T) {
+	t.Run("should read field names, types, values and pages", func(t *testing.T) {
+		data := buildPDF("/Root 1 0 R",
+			"<< /Type /Catalog /Pages 2 0 R /AcroForm << /Fields [5 0 R 6 0 R 9 0 R 10 0 R 11 0 R 12 0 R] >> >>",
+			"<< /Type /Pages /Kids [3 0 R 4 0 R] /Count 2 /MediaBox [0 0 595 842] >>",
+			"<< /Type /Page /Parent 2 0 R /Annots [5 0 R 7 0 R 8 0 R] >>",
+			"<< /Type /Page /Parent 2 0 R /Annots [9 0 R] >>",
+			// 5: text field merged with its widget
+			"<< /FT /Tx /T (full_name) /V (Jane  Doe) /Subtype /Widget >>",
+			// 6: parent with two child fields that inherit the type
+			"<< /FT /Tx /T (address) /Kids [7 0 R 8 0 R] >>",
+			"<< /T (city) /V <FEFF30A630A330E0> /Parent 6 0 R /Subtype /Widget >>",
+			"<< /T (zip) /Parent 6 0 R /Subtype /Widget >>",
+			// 9: checkbox
+			"<< /FT /Btn /T (agree) /V /Yes /Subtype /Widget >>",
+			// 10: radio group whose widget points at its page with /P
+			"<< /FT /Btn /Ff 32768 /T (plan) /V /Pro /Kids [13 0 R] >>",
+			// 11: multiple choice list
+			"<< /FT /Ch /T (topics) /V [(Tax) (Audit)] >>",
+			// 12: push buttons have no value
+			"<< /FT /Btn /Ff 65536 /T (submit) >>",
+			"<< /Subtype /Widget /Parent 10 0 R /P 4 0 R >>",
+		)
+
+		doc, err := pdfdoc.Open(data)
+		require.NoError(t, err)
+
+		assert.Equal(t, []pdfdoc.FormField{
+			{Name: "full_name", Type: pdfdoc.FieldText, Value: "Jane  Doe", Page: 1},
+			{Name: "address.city", Type: pdfdoc.FieldText, Value: "ウィム", Page: 1},
+			{Name: "address.zip", Type: pdfdoc.FieldText, Value: "", Page: 1},
+			{Name: "agree", Type: pdfdoc.FieldCheckbox, Value: "Yes", Page: 2},
+			{Name: "plan", Type: pdfdoc.FieldRadio, Value: "Pro", Page: 2},
+			{Name: "topics", Type: pdfdoc.FieldChoice, Value: "Tax, Audit", Page: 0},
+		}, doc.FormFields())
+	})
+
+	t.Run("should stop on fields that contain themselves", func(t *testing.T) {
+		data := buildPDF("/Root 1 0 R",
+			"<< /Type /Catalog /Pages 2 0 R /AcroForm << /Fields [4 0 R] >> >>",
+			"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+			"<< /Type /Page /Parent 2 0 R >>",
+			"<< /FT /Tx /T (loop) /V (x) /Kids [4 0 R 5 0 R] >>",
+			"<< /T (child) >>",
+		)
+
+		doc, err := pdfdoc.Open(data)
+		require.NoError(t, err)
+
+		assert.Equal(t, []pdfdoc.FormField{
+			{Name: "loop.child", Type: pdfdoc.FieldText, Value: "x"},
+		}, doc.FormFields())
+	})
+
+	t.Run("should return nothing without a form", func(t *testing.T) {
+		doc, err := pdfdoc.Open(simplePDF(1, ""))
+		require.NoError(t, err)
+
+		assert.Empty(t, doc.FormFields())
+	})
+}
@@ -0,0 +1,52 @@
+package pdfdoc_test
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// buildPDF assembles a PDF from object bodies, numbering them from 1 and
+// writing a matching cross-reference table. trailer is the trailer
+// dictionary without /Size.
+func buildPDF(trailer string, objects ...string) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.7\n%\xe2\xe3\xcf\xd3\n")
+
+	offsets := make([]int, len(objects))
+	for i, body := range objects {
+		offsets[i] = buf.Len()
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", i+1, body)
+	}
+
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d %s >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, trailer, xref)
+
+	return buf.Bytes()
+}
+
+// simplePDF is a valid document with the given number of pages.
+func simplePDF(pages int, info string) []byte {
+	kids := ""
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"",
+		"<< /Length 8 >>\nstream\nBT ET q Q\nendstream",
+	}
+	for i := 0; i < pages; i++ {
+		kids += fmt.Sprintf("%d 0 R ", len(objects)+1)
+		objects = append(objects, "<< /Type /Page /Parent 2 0 R /Contents 3 0 R >>")
+	}
+	objects[1] = fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d /MediaBox [0 0 595 842] >>", kids, pages)
+
+	trailer := "/Root 1 0 R"
+	if info != "" {
+		objects = append(objects, info)
+		trailer += fmt.Sprintf(" /Info %d 0 R", len(objects))
+	}
+
+	return buildPDF(trailer, objects...)
+}
@@ -0,0 +1,44 @@
+package pdfdoc_test
+
+import (
+	"app/src/pdfdoc"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseDate(t *testing.T) {
+	t.Run("should parse a full date with offset", func(t *testing.T) {
+		date, ok := pdfdoc.ParseDate("D:20231231120000-05'30'")
+		assert.True(t, ok)
+		assert.True(t, date.Equal(time.Date(2023, 12, 31, 17, 30, 0, 0, time.UTC)))
+	})
+
+	t.Run("should accept a year only", func(t *testing.T) {
+		date, ok := pdfdoc.ParseDate("D:2021")
+		assert.True(t, ok)
+		assert.Equal(t, time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC), date)
+	})
+
+	t.Run("should reject invalid dates", func(t *testing.T) {
+		for _, s := range []string{"", "D:", "yesterday", "D:20231345"} {
+			_, ok := pdfdoc.ParseDate(s)
+			assert.False(t, ok, s)
+		}
+	})
+}
+
+func TestDecodeText(t *testing.T) {
+	t.Run("should decode UTF-16BE", func(t *testing.T) {
+		assert.Equal(t, "日本", pdfdoc.DecodeText(pdfdoc.String("\xfe\xff\x65\xe5\x67\x2c")))
+	})
+
+	t.Run("should decode UTF-8 with a byte order mark", func(t *testing.T) {
+		assert.Equal(t, "café", pdfdoc.DecodeText(pdfdoc.String("\xef\xbb\xbfcaf\xc3\xa9")))
+	})
+
+	t.Run("should decode PDFDocEncoding", func(t *testing.T) {
+		assert.Equal(t, "“quoted” – café", pdfdoc.DecodeText(pdfdoc.String("\x8dquoted\x8e \x85 caf\xe9")))
+	})
+}
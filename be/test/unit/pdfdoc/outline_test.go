@@ -0,0 +1,70 @@
+package pdfdoc_test
+
+import (
+	"app/src/pdfdoc"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOutline(t *testing.T) {
+	t.Run("should flatten the bookmark tree with levels and pages", func(t *testing.T) {
+		data := buildPDF("/Root 1 0 R",
+			"<< /Type /Catalog /Pages 2 0 R /Outlines 6 0 R /Dests << /intro [3 0 R /Fit] >> "+
+				"/Names << /Dests << /Kids [13 0 R] >> >> >>",
+			"<< /Type /Pages /Kids [3 0 R 4 0 R 5 0 R] /Count 3 /MediaBox [0 0 595 842] >>",
+			"<< /Type /Page /Parent 2 0 R >>",
+			"<< /Type /Page /Parent 2 0 R >>",
+			"<< /Type /Page /Parent 2 0 R >>",
+			"<< /Type /Outlines /First 7 0 R /Last 9 0 R /Count 3 >>",
+			// 7: named destination from the Dests dictionary
+			"<< /Title (Introduction) /Parent 6 0 R /Next 8 0 R /Dest /intro >>",
+			// 8: explicit destination with children
+			"<< /Title (Chapter  1\n Basics) /Parent 6 0 R /Prev 7 0 R /Next 9 0 R /First 10 0 R /Last 11 0 R /Dest [4 0 R /XYZ 0 800 0] >>",
+			// 9: GoTo action to a name tree destination
+			"<< /Title <FEFF00430068002000320020002D00206F225B57> /Parent 6 0 R /Prev 8 0 R /A << /S /GoTo /D (ch2) >> >>",
+			"<< /Title (Section 1.1) /Parent 8 0 R /Next 11 0 R /Dest [4 0 R /Fit] >>",
+			"<< /Title (Section 1.2) /Parent 8 0 R /Prev 10 0 R /A << /S /URI /URI (https://example.com) >> >>",
+			"",
+			"<< /Limits [(a) (z)] /Names [(appendix) [3 0 R /Fit] (ch2) << /D [5 0 R /Fit] >>] >>",
+		)
+
+		doc, err := pdfdoc.Open(data)
+		require.NoError(t, err)
+
+		assert.Equal(t, []pdfdoc.OutlineItem{
+			{Title: "Introduction", Level: 1, Page: 1},
+			{Title: "Chapter 1 Basics", Level: 1, Page: 2},
+			{Title: "Section 1.1", Level: 2, Page: 2},
+			{Title: "Section 1.2", Level: 2, Page: 0},
+			{Title: "Ch 2 - 漢字", Level: 1, Page: 3},
+		}, doc.Outline())
+	})
+
+	t.Run("should stop on looping siblings", func(t *testing.T) {
+		data := buildPDF("/Root 1 0 R",
+			"<< /Type /Catalog /Pages 2 0 R /Outlines 4 0 R >>",
+			"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+			"<< /Type /Page /Parent 2 0 R >>",
+			"<< /Type /Outlines /First 5 0 R >>",
+			"<< /Title (One) /Next 6 0 R /First 5 0 R /Dest [0 /Fit] >>",
+			"<< /Title (Two) /Next 5 0 R >>",
+		)
+
+		doc, err := pdfdoc.Open(data)
+		require.NoError(t, err)
+
+		assert.Equal(t, []pdfdoc.OutlineItem{
+			{Title: "One", Level: 1, Page: 1},
+			{Title: "Two", Level: 1, Page: 0},
+		}, doc.Outline())
+	})
+
+	t.Run("should return nothing without an outline", func(t *testing.T) {
+		doc, err := pdfdoc.Open(simplePDF(2, ""))
+		require.NoError(t, err)
+
+		assert.Empty(t, doc.Outline())
+	})
+}
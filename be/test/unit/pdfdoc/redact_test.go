@@ -0,0 +1,143 @@
+package pdfdoc_test
+
+import (
+	"app/src/pdfdoc"
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedact(t *testing.T) {
+	t.Run("should remove found text and keep the rest of the line in place", func(t *testing.T) {
+		page := firstPage(t, textPDF(helvetica, `BT /F1 12 Tf 72 720 Td [(Mail secret@example.com) -250 (today)] TJ ET`))
+		before, err := page.Lines()
+		require.NoError(t, err)
+
+		found, err := page.FindText(findWord("secret@example.com", "EMAIL"))
+		require.NoError(t, err)
+		require.Len(t, found, 1)
+		assert.Equal(t, "EMAIL", found[0].Tag)
+		assert.InDelta(t, before[0].Words[1].X0, found[0].Area.X0, 0.01)
+		assert.InDelta(t, before[0].Words[1].X1, found[0].Area.X1, 0.01)
+
+		redacted, result, err := page.Redact([]pdfdoc.Rect{found[0].Area})
+		require.NoError(t, err)
+		assert.Equal(t, len("secret@example.com"), result.Glyphs)
+
+		got := firstPageOf(t, reopen(t, []*pdfdoc.Page{redacted}))
+		contents, err := got.Contents()
+		require.NoError(t, err)
+		assert.NotContains(t, string(contents), "secret")
+
+		after, err := got.Lines()
+		require.NoError(t, err)
+		require.Len(t, after, 1)
+		require.Len(t, after[0].Words, 2)
+		assert.Equal(t, "Mail", after[0].Words[0].Text)
+		assert.Equal(t, "today", after[0].Words[1].Text)
+		assert.InDelta(t, before[0].Words[2].X0, after[0].Words[1].X0, 0.01)
+	})
+
+	t.Run("should redact text drawn by forms without changing the original", func(t *testing.T) {
+		page := firstPage(t, textPDF(helvetica, `BT /F1 12 Tf 72 720 Td (Page) Tj ET /Fm1 Do`))
+
+		found, err := page.FindText(findWord("Form", "TEXT"))
+		require.NoError(t, err)
+		require.Len(t, found, 1)
+
+		redacted, _, err := page.Redact([]pdfdoc.Rect{found[0].Area})
+		require.NoError(t, err)
+
+		text, err := firstPageOf(t, reopen(t, []*pdfdoc.Page{redacted})).Text()
+		require.NoError(t, err)
+		assert.Equal(t, "Page", text)
+
+		text, err = page.Text()
+		require.NoError(t, err)
+		assert.Equal(t, "Page\nForm", text)
+	})
+
+	t.Run("should drop images in the area and annotations", func(t *testing.T) {
+		content := "q 100 0 0 100 72 600 cm BI /W 1 /H 1 /BPC 8 /CS /G ID \x01 EI Q\n" +
+			"q 100 0 0 100 300 600 cm BI /W 1 /H 1 /BPC 8 /CS /G ID \x02 EI Q"
+		doc := buildPDF("/Root 1 0 R",
+			"<< /Type /Catalog /Pages 2 0 R >>",
+			"<< /Type /Pages /Kids [3 0 R] /Count 1 /MediaBox [0 0 612 792] >>",
+			"<< /Type /Page /Parent 2 0 R /Contents 4 0 R /Annots [5 0 R] >>",
+			fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", len(content), content),
+			"<< /Type /Annot /Subtype /Text /Rect [0 0 10 10] /Contents (Note) >>",
+		)
+		page := firstPage(t, doc)
+
+		redacted, result, err := page.Redact([]pdfdoc.Rect{{X0: 50, Y0: 650, X1: 120, Y1: 680}})
+		require.NoError(t, err)
+		assert.Equal(t, pdfdoc.RedactionResult{Images: 1, Annotations: 1}, result)
+
+		got := firstPageOf(t, reopen(t, []*pdfdoc.Page{redacted}))
+		assert.Nil(t, got.Dict["Annots"])
+		contents, err := got.Contents()
+		require.NoError(t, err)
+		assert.NotContains(t, string(contents), "\x01")
+		assert.Contains(t, string(contents), "ID \x02 EI")
+	})
+
+	t.Run("should drop filled form fields from pages with nothing to redact", func(t *testing.T) {
+		appearance := "BT /F1 10 Tf 2 4 Td (3201234567890001) Tj ET"
+		doc := buildPDF("/Root 1 0 R",
+			"<< /Type /Catalog /Pages 2 0 R /AcroForm << /Fields [5 0 R] >> >>",
+			"<< /Type /Pages /Kids [3 0 R] /Count 1 /MediaBox [0 0 612 792] >>",
+			"<< /Type /Page /Parent 2 0 R /Contents 4 0 R /Annots [5 0 R 7 0 R] >>",
+			"<< /Length 0 >>\nstream\n\nendstream",
+			"<< /Type /Annot /Subtype /Widget /FT /Tx /T (nik) /V (3201234567890001) /Rect [72 700 272 720] /AP << /N 6 0 R >> >>",
+			fmt.Sprintf("<< /Type /XObject /Subtype /Form /BBox [0 0 200 20] /Length %d >>\nstream\n%s\nendstream", len(appearance), appearance),
+			"<< /Type /Annot /Subtype /Text /Rect [0 0 10 10] /Contents (mail secret@example.com) >>",
+		)
+		page := firstPage(t, doc)
+
+		found, err := page.FindText(findWord("3201234567890001", "NIK"))
+		require.NoError(t, err)
+		assert.Empty(t, found)
+
+		stripped, annotations := page.WithoutAnnotations()
+		assert.Equal(t, 2, annotations)
+		assert.NotNil(t, page.Dict["Annots"])
+
+		var buf bytes.Buffer
+		require.NoError(t, pdfdoc.WritePages(&buf, []*pdfdoc.Page{stripped}))
+		assert.NotContains(t, buf.String(), "3201234567890001")
+		assert.NotContains(t, buf.String(), "secret@example.com")
+		assert.Nil(t, firstPage(t, buf.Bytes()).Dict["Annots"])
+	})
+}
+
+// findWord reports every occurrence of word in a line, tagged with tag.
+func findWord(word, tag string) func(line string) []pdfdoc.Span {
+	return func(line string) []pdfdoc.Span {
+		var spans []pdfdoc.Span
+		for start := 0; ; {
+			i := strings.Index(line[start:], word)
+			if i < 0 {
+				return spans
+			}
+			spans = append(spans, pdfdoc.Span{Start: start + i, End: start + i + len(word), Tag: tag})
+			start += i + len(word)
+		}
+	}
+}
+
+func firstPage(t *testing.T, data []byte) *pdfdoc.Page {
+	doc, err := pdfdoc.Open(data)
+	require.NoError(t, err)
+	return firstPageOf(t, doc)
+}
+
+func firstPageOf(t *testing.T, doc *pdfdoc.Document) *pdfdoc.Page {
+	pages, err := doc.Pages()
+	require.NoError(t, err)
+	require.NotEmpty(t, pages)
+	return pages[0]
+}
@@ -0,0 +1,93 @@
+package pdfdoc_test
+
+import (
+	"app/src/pdfdoc"
+	"bytes"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// signedPDF builds a document with one signature field whose byte range
+// leaves out exactly its Contents hex string.
+func signedPDF(contents string) []byte {
+	data := buildPDF("/Root 1 0 R",
+		"<< /Type /Catalog /Pages 2 0 R /AcroForm << /Fields [4 0 R] /SigFlags 3 >> >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 /MediaBox [0 0 595 842] >>",
+		"<< /Type /Page /Parent 2 0 R /Annots [4 0 R] >>",
+		"<< /FT /Sig /T (Signature1) /V 5 0 R /Subtype /Widget /Rect [0 0 0 0] >>",
+		"<< /Type /Sig /Filter /Adobe.PPKLite /SubFilter /adbe.pkcs7.detached /Name (Jane Doe) "+
+			"/Reason (Approved) /Location (Jakarta) /M (D:20260102030405Z) "+
+			"/ByteRange [0 0000000000 0000000000 0000000000] /Contents <"+contents+"> >>",
+	)
+
+	start := bytes.Index(data, []byte("/Contents <")) + len("/Contents ")
+	end := bytes.IndexByte(data[start:], '>') + start + 1
+	byteRange := fmt.Sprintf("[0 %010d %010d %010d]", start, end, len(data)-end)
+	return bytes.Replace(data, []byte("[0 0000000000 0000000000 0000000000]"), []byte(byteRange), 1)
+}
+
+func TestSignatures(t *testing.T) {
+	t.Run("should read the signature and the bytes it covers", func(t *testing.T) {
+		data := signedPDF("0A0B0C0000")
+		doc, err := pdfdoc.Open(data)
+		require.NoError(t, err)
+
+		sigs := doc.Signatures()
+		require.Len(t, sigs, 1)
+		sig := sigs[0]
+		assert.NoError(t, sig.Err)
+		assert.Equal(t, "Signature1", sig.FieldName)
+		assert.Equal(t, 1, sig.Page)
+		assert.Equal(t, "adbe.pkcs7.detached", sig.SubFilter)
+		assert.Equal(t, "Jane Doe", sig.Name)
+		assert.Equal(t, "Approved", sig.Reason)
+		assert.Equal(t, "Jakarta", sig.Location)
+		require.NotNil(t, sig.SigningTime)
+		assert.True(t, sig.SigningTime.Equal(time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)))
+		assert.Equal(t, []byte{0x0a, 0x0b, 0x0c, 0, 0}, sig.Contents)
+		assert.True(t, sig.CoversDocument)
+
+		content, err := doc.SignedContent(sig)
+		require.NoError(t, err)
+		assert.Equal(t, bytes.Replace(data, []byte("<0A0B0C0000>"), nil, 1), content)
+	})
+
+	t.Run("should notice updates appended after signing", func(t *testing.T) {
+		data := append(signedPDF("00"), "\n% incremental update\n"...)
+		doc, err := pdfdoc.Open(data)
+		require.NoError(t, err)
+
+		sigs := doc.Signatures()
+		require.Len(t, sigs, 1)
+		assert.NoError(t, sigs[0].Err)
+		assert.False(t, sigs[0].CoversDocument)
+	})
+
+	t.Run("should reject byte ranges that don't leave out the contents", func(t *testing.T) {
+		data := bytes.Replace(signedPDF("00"), []byte("/ByteRange [0 "), []byte("/ByteRange [1 "), 1)
+		doc, err := pdfdoc.Open(data)
+		require.NoError(t, err)
+
+		sigs := doc.Signatures()
+		require.Len(t, sigs, 1)
+		assert.ErrorIs(t, sigs[0].Err, pdfdoc.ErrInvalidByteRange)
+
+		_, err = doc.SignedContent(sigs[0])
+		assert.ErrorIs(t, err, pdfdoc.ErrInvalidByteRange)
+	})
+
+	t.Run("should skip unsigned signature fields", func(t *testing.T) {
+		doc, err := pdfdoc.Open(buildPDF("/Root 1 0 R",
+			"<< /Type /Catalog /Pages 2 0 R /AcroForm << /Fields [3 0 R] >> >>",
+			"<< /Type /Pages /Kids [] /Count 0 >>",
+			"<< /FT /Sig /T (Empty) >>",
+		))
+		require.NoError(t, err)
+
+		assert.Empty(t, doc.Signatures())
+	})
+}
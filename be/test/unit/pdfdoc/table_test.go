@@ -0,0 +1,98 @@
+package pdfdoc_test
+
+import (
+	"app/src/pdfdoc"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// line lays out cells at the given x positions on baseline y, giving every
+// character 5 units of a 10 unit font.
+func line(y float64, cells map[float64]string) pdfdoc.Line {
+	var xs []float64
+	for x := range cells {
+		xs = append(xs, x)
+	}
+	sort.Float64s(xs)
+
+	l := pdfdoc.Line{Y: y}
+	for _, x := range xs {
+		for _, text := range strings.Fields(cells[x]) {
+			width := float64(len(text) * 5)
+			l.Words = append(l.Words, pdfdoc.Word{Text: text, X0: x, X1: x + width, Y: y, Size: 10})
+			x += width + 3
+		}
+	}
+	return l
+}
+
+func TestFindTables(t *testing.T) {
+	t.Run("should line cells up into columns", func(t *testing.T) {
+		tables := pdfdoc.FindTables([]pdfdoc.Line{
+			line(720, map[float64]string{72: "Quarterly results"}),
+			line(700, map[float64]string{72: "Region", 200: "Revenue", 300: "Margin"}),
+			line(688, map[float64]string{72: "North America", 210: "1,200", 300: "12%"}),
+			line(676, map[float64]string{72: "Asia", 300: "9%"}),
+			line(664, map[float64]string{72: "Europe", 215: "870", 305: "7%"}),
+			line(630, map[float64]string{72: "Figures are unaudited."}),
+		})
+
+		require.Len(t, tables, 1)
+		assert.Equal(t, [][]string{
+			{"Region", "Revenue", "Margin"},
+			{"North America", "1,200", "12%"},
+			{"Asia", "", "9%"},
+			{"Europe", "870", "7%"},
+		}, tables[0].Rows)
+		assert.Equal(t, 700.0, tables[0].Top)
+		assert.Equal(t, 664.0, tables[0].Bottom)
+	})
+
+	t.Run("should split tables at wide vertical gaps", func(t *testing.T) {
+		tables := pdfdoc.FindTables([]pdfdoc.Line{
+			line(700, map[float64]string{72: "a", 200: "b"}),
+			line(688, map[float64]string{72: "c", 200: "d"}),
+			line(600, map[float64]string{72: "e", 200: "f"}),
+			line(588, map[float64]string{72: "g", 200: "h"}),
+		})
+
+		require.Len(t, tables, 2)
+		assert.Equal(t, [][]string{{"e", "f"}, {"g", "h"}}, tables[1].Rows)
+	})
+
+	t.Run("should ignore columns of running text", func(t *testing.T) {
+		tables := pdfdoc.FindTables([]pdfdoc.Line{
+			line(700, map[float64]string{72: "the quick brown fox jumps over", 320: "a lazy dog that sleeps all day"}),
+			line(688, map[float64]string{72: "and then it runs far away from", 320: "the farm where it was born and"}),
+		})
+
+		assert.Empty(t, tables)
+	})
+
+	t.Run("should need at least two rows", func(t *testing.T) {
+		tables := pdfdoc.FindTables([]pdfdoc.Line{
+			line(700, map[float64]string{72: "Page 3", 400: "Annual report"}),
+		})
+
+		assert.Empty(t, tables)
+	})
+}
+
+func TestPageTables(t *testing.T) {
+	doc, err := pdfdoc.Open(textPDF(helvetica, `BT /F1 10 Tf
+		72 700 Td (Item) Tj 150 0 Td (Qty) Tj
+		-150 -12 Td (Apples) Tj 150 0 Td (3) Tj
+		-150 -12 Td (Pears) Tj 150 0 Td (12) Tj ET`))
+	require.NoError(t, err)
+	pages, err := doc.Pages()
+	require.NoError(t, err)
+
+	tables, err := pages[0].Tables()
+	require.NoError(t, err)
+	require.Len(t, tables, 1)
+	assert.Equal(t, [][]string{{"Item", "Qty"}, {"Apples", "3"}, {"Pears", "12"}}, tables[0].Rows)
+}
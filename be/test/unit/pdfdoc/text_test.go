@@ -0,0 +1,133 @@
+package pdfdoc_test
+
+import (
+	"app/src/pdfdoc"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPageText(t *testing.T) {
+	t.Run("should extract lines top to bottom", func(t *testing.T) {
+		// The second line is drawn first
+		text := pageText(t, helvetica, `BT /F1 12 Tf 72 700 Td (Second line) Tj ET
+			BT /F1 12 Tf 72 720 Td (First line) Tj ET`)
+
+		assert.Equal(t, "First line\nSecond line", text)
+	})
+
+	t.Run("should follow text positioning operators", func(t *testing.T) {
+		text := pageText(t, helvetica, `BT /F1 12 Tf 14 TL 72 720 Td (One) Tj T* (Two) Tj
+			(Three) ' 0 -14 TD (Four) Tj 1 0 0 1 72 600 Tm (Five) Tj ET`)
+
+		assert.Equal(t, "One\nTwo\nThree\nFour\n\nFive", text)
+	})
+
+	t.Run("should split words on kerning gaps in TJ", func(t *testing.T) {
+		text := pageText(t, helvetica, `BT /F1 12 Tf 72 720 Td [(Hel) 20 (lo) -600 (world)] TJ ET`)
+
+		assert.Equal(t, "Hello world", text)
+	})
+
+	t.Run("should apply the current transformation matrix", func(t *testing.T) {
+		lines := pageLines(t, helvetica, `q 2 0 0 2 100 100 cm BT /F1 10 Tf 0 0 Td (Scaled) Tj ET Q`)
+
+		require.Len(t, lines, 1)
+		assert.InDelta(t, 100, lines[0].Words[0].X0, 0.01)
+		assert.InDelta(t, 100, lines[0].Y, 0.01)
+		assert.InDelta(t, 20, lines[0].Words[0].Size, 0.01)
+	})
+
+	t.Run("should decode Differences glyph names", func(t *testing.T) {
+		font := `<< /Type /Font /Subtype /Type1 /BaseFont /Times-Roman
+			/Encoding << /BaseEncoding /WinAnsiEncoding /Differences [1 /fi /eacute /uni20AC] >> >>`
+		text := pageText(t, font, `BT /F1 12 Tf 72 720 Td (\001ne caf\002 \003) Tj ET`)
+
+		assert.Equal(t, "fine café €", text)
+	})
+
+	t.Run("should map composite fonts through ToUnicode", func(t *testing.T) {
+		text := pageText(t, "", `BT /F1 12 Tf 72 720 Td <000100020003> Tj ET`)
+
+		assert.Equal(t, "日本語", text)
+	})
+
+	t.Run("should read text drawn by form XObjects", func(t *testing.T) {
+		text := pageText(t, helvetica, `BT /F1 12 Tf 72 720 Td (Page) Tj ET /Fm1 Do`)
+
+		assert.Equal(t, "Page\nForm", text)
+	})
+
+	t.Run("should skip inline image data", func(t *testing.T) {
+		text := pageText(t, helvetica, "BI /W 2 /H 1 /BPC 8 /CS /G ID \x01EI\x29 EI\nBT /F1 12 Tf 72 720 Td (After) Tj ET")
+
+		assert.Equal(t, "After", text)
+	})
+
+	t.Run("should return an empty string for pages without text", func(t *testing.T) {
+		doc, err := pdfdoc.Open(simplePDF(1, ""))
+		require.NoError(t, err)
+		pages, err := doc.Pages()
+		require.NoError(t, err)
+
+		text, err := pages[0].Text()
+		assert.NoError(t, err)
+		assert.Empty(t, text)
+	})
+}
+
+const helvetica = "<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /FirstChar 32 /LastChar 126 /Widths [" +
+	"278 278 355 556 556 889 667 191 333 333 389 584 278 333 278 278 556 556 556 556 556 556 556 556 556 556 " +
+	"278 278 584 584 584 556 1015 667 667 722 722 667 611 778 722 278 500 667 556 833 722 778 667 778 722 667 " +
+	"611 722 667 944 667 667 611 278 278 278 469 556 333 556 556 500 556 556 278 556 556 222 222 500 222 833 " +
+	"556 556 556 556 333 500 278 556 500 722 500 500 500 334 260 334 584] /Encoding /WinAnsiEncoding >>"
+
+// textPDF builds a one-page document drawing content with font as /F1. An
+// empty font uses a composite font with a ToUnicode map. The page also has a
+// form XObject /Fm1 that draws "Form".
+func textPDF(font, content string) []byte {
+	toUnicode := "/CIDInit /ProcSet findresource begin 12 dict begin begincmap\n" +
+		"1 begincodespacerange <0000> <FFFF> endcodespacerange\n" +
+		"1 beginbfchar <0001> <65E5> endbfchar\n" +
+		"1 beginbfrange <0002> <0003> [<672C> <8A9E>] endbfrange\n" +
+		"endcmap CMapName currentdict /CMap defineresource pop end end"
+	if font == "" {
+		font = "<< /Type /Font /Subtype /Type0 /BaseFont /Test /Encoding /Identity-H /DescendantFonts [7 0 R] /ToUnicode 8 0 R >>"
+	}
+	form := "BT /F1 12 Tf 0 0 Td (Form) Tj ET"
+
+	return buildPDF("/Root 1 0 R",
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Contents 4 0 R /Resources << /Font << /F1 5 0 R >> /XObject << /Fm1 6 0 R >> >> >>",
+		fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", len(content), content),
+		font,
+		fmt.Sprintf("<< /Type /XObject /Subtype /Form /BBox [0 0 200 50] /Matrix [1 0 0 1 72 702] /Resources << /Font << /F1 5 0 R >> >> /Length %d >>\nstream\n%s\nendstream", len(form), form),
+		"<< /Type /Font /Subtype /CIDFontType2 /BaseFont /Test /DW 1000 >>",
+		fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", len(toUnicode), toUnicode),
+	)
+}
+
+func pageLines(t *testing.T, font, content string) []pdfdoc.Line {
+	doc, err := pdfdoc.Open(textPDF(font, content))
+	require.NoError(t, err)
+	pages, err := doc.Pages()
+	require.NoError(t, err)
+
+	lines, err := pages[0].Lines()
+	require.NoError(t, err)
+	return lines
+}
+
+func pageText(t *testing.T, font, content string) string {
+	doc, err := pdfdoc.Open(textPDF(font, content))
+	require.NoError(t, err)
+	pages, err := doc.Pages()
+	require.NoError(t, err)
+
+	text, err := pages[0].Text()
+	require.NoError(t, err)
+	return text
+}
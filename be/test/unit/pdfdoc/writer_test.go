@@ -0,0 +1,105 @@
+package pdfdoc_test
+
+import (
+	"app/src/pdfdoc"
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWritePages(t *testing.T) {
+	t.Run("should write a subset of pages with inherited attributes", func(t *testing.T) {
+		doc, err := pdfdoc.Open(simplePDF(3, ""))
+		require.NoError(t, err)
+		pages, err := doc.Pages()
+		require.NoError(t, err)
+
+		written := reopen(t, pages[1:])
+
+		got, err := written.Pages()
+		require.NoError(t, err)
+		require.Len(t, got, 2)
+		assert.Equal(t, [4]float64{0, 0, 595, 842}, got[0].MediaBox)
+		contents, err := got[1].Contents()
+		require.NoError(t, err)
+		assert.Contains(t, string(contents), "BT ET q Q")
+	})
+
+	t.Run("should combine pages from several documents", func(t *testing.T) {
+		first, err := pdfdoc.Open(textPDF(helvetica, "BT /F1 12 Tf 72 720 Td (First) Tj ET /Fm1 Do"))
+		require.NoError(t, err)
+		second, err := pdfdoc.Open(textPDF("", "BT /F1 12 Tf 72 720 Td <000100020003> Tj ET"))
+		require.NoError(t, err)
+		firstPages, err := first.Pages()
+		require.NoError(t, err)
+		secondPages, err := second.Pages()
+		require.NoError(t, err)
+
+		written := reopen(t, []*pdfdoc.Page{firstPages[0], secondPages[0]})
+
+		got, err := written.Pages()
+		require.NoError(t, err)
+		require.Len(t, got, 2)
+		text, err := got[0].Text()
+		require.NoError(t, err)
+		assert.Equal(t, "First\nForm", text)
+		text, err = got[1].Text()
+		require.NoError(t, err)
+		assert.Equal(t, "日本語", text)
+	})
+
+	t.Run("should write decrypted copies of encrypted pages", func(t *testing.T) {
+		doc, err := pdfdoc.OpenWithPassword(encryptedPDF(4, "user", "owner"), "user")
+		require.NoError(t, err)
+		pages, err := doc.Pages()
+		require.NoError(t, err)
+
+		written := reopen(t, pages)
+
+		assert.False(t, written.Encrypted())
+		got, err := written.Pages()
+		require.NoError(t, err)
+		contents, err := got[0].Contents()
+		require.NoError(t, err)
+		assert.Contains(t, string(contents), "(Hello) Tj")
+	})
+
+	t.Run("should drop links to pages left out", func(t *testing.T) {
+		doc, err := pdfdoc.Open(buildPDF("/Root 1 0 R",
+			"<< /Type /Catalog /Pages 2 0 R >>",
+			"<< /Type /Pages /Kids [3 0 R 4 0 R] /Count 2 >>",
+			"<< /Type /Page /Parent 2 0 R /Annots [5 0 R] >>",
+			"<< /Type /Page /Parent 2 0 R >>",
+			"<< /Type /Annot /Subtype /Link /P 3 0 R /Dest [4 0 R /Fit] >>",
+		))
+		require.NoError(t, err)
+		pages, err := doc.Pages()
+		require.NoError(t, err)
+
+		written := reopen(t, pages[:1])
+
+		got, err := written.Pages()
+		require.NoError(t, err)
+		require.Len(t, got, 1)
+		link := written.Dict(written.Array(got[0].Dict["Annots"])[0])
+		require.NotNil(t, link)
+		assert.Equal(t, got[0].Ref, link["P"])
+		assert.Nil(t, written.Array(link["Dest"])[0])
+	})
+
+	t.Run("should refuse to write no pages", func(t *testing.T) {
+		err := pdfdoc.WritePages(new(bytes.Buffer), nil)
+		assert.ErrorIs(t, err, pdfdoc.ErrNoPages)
+	})
+}
+
+func reopen(t *testing.T, pages []*pdfdoc.Page) *pdfdoc.Document {
+	var buf bytes.Buffer
+	require.NoError(t, pdfdoc.WritePages(&buf, pages))
+
+	doc, err := pdfdoc.Open(buf.Bytes())
+	require.NoError(t, err)
+	return doc
+}
@@ -0,0 +1,120 @@
+package redact_test
+
+import (
+	"app/src/redact"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func builtins(t *testing.T) *redact.Redactor {
+	var detectors []redact.Detector
+	for _, name := range redact.Names() {
+		detector, err := redact.Builtin(name)
+		require.NoError(t, err)
+		detectors = append(detectors, detector)
+	}
+	return redact.New(detectors...)
+}
+
+func TestRedact(t *testing.T) {
+	r := builtins(t)
+
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{"email", "Contact budi.santoso@univ.ac.id for details.", "Contact [EMAIL_1] for details."},
+		{"international phone", "Call +62 812-3456-7890 today", "Call [PHONE_1] today"},
+		{"national phone", "電話: 03-1234-5678", "電話: [PHONE_1]"},
+		{"NIK", "NIK 3273014509900003 terdaftar", "NIK [NIK_1] terdaftar"},
+		{"My Number", "個人番号 1234 5678 9018", "個人番号 [MY_NUMBER_1]"},
+		{"IBAN", "Pay to GB82 WEST 1234 5698 7654 32.", "Pay to [IBAN_1]."},
+		{"credit card", "Card 4111-1111-1111-1111 expires", "Card [CARD_1] expires"},
+		{"repeated values share a placeholder", "a@example.com, b@example.com, a@example.com", "[EMAIL_1], [EMAIL_2], [EMAIL_1]"},
+	}
+
+	for _, tt := range tests {
+		t.Run("should redact "+tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, r.Redact(tt.text, new(redact.Redactions)))
+		})
+	}
+
+	for _, text := range []string{
+		"Submitted on 2024-10-18 at 14:30",
+		"Total Rp 1.500.000 for 12 items",
+		"Invalid NIK 1234567890123456",
+		"Almost a My Number: 1234 5678 9012",
+		"Version 10.2.1, page 123",
+	} {
+		t.Run("should leave "+text, func(t *testing.T) {
+			assert.Equal(t, text, r.Redact(text, new(redact.Redactions)))
+		})
+	}
+}
+
+func TestFind(t *testing.T) {
+	r := builtins(t)
+
+	text := "Mail a@example.com or card 4111 1111 1111 1111"
+	matches := r.Find(text)
+
+	require.Len(t, matches, 2)
+	assert.Equal(t, redact.Match{Start: 5, End: 18, Label: "EMAIL"}, matches[0])
+	assert.Equal(t, "CARD", matches[1].Label)
+	assert.Equal(t, "4111 1111 1111 1111", text[matches[1].Start:matches[1].End])
+	assert.Empty(t, r.Find("nothing personal"))
+}
+
+func TestRestore(t *testing.T) {
+	r := builtins(t)
+	found := new(redact.Redactions)
+
+	r.Redact("Email a@example.com or call 0812 3456 7890.", found)
+	// Later texts keep numbering where earlier ones stopped
+	assert.Equal(t, "[EMAIL_2] and [EMAIL_1]", r.Redact("b@example.com and a@example.com", found))
+
+	assert.Equal(t, 3, found.Len())
+	assert.Equal(t, map[string]int{"EMAIL": 2, "PHONE": 1}, found.Counts())
+	assert.Equal(t,
+		"Reach a@example.com, b@example.com or 0812 3456 7890; [EMAIL_9] is unknown.",
+		found.Restore("Reach [EMAIL_1], [EMAIL_2] or [PHONE_1]; [EMAIL_9] is unknown."))
+}
+
+func TestCustomPatterns(t *testing.T) {
+	t.Run("should load labelled patterns that win ties with built-ins", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "patterns")
+		require.NoError(t, os.WriteFile(path, []byte("# student numbers\n\nSTUDENT_ID=\\b\\d{2}[A-Z]\\d{6}\\b\nSTAFF = \\bS-\\d{4}\\b\n"), 0o600))
+
+		custom, err := redact.LoadPatterns(path)
+		require.NoError(t, err)
+		require.Len(t, custom, 2)
+
+		email, err := redact.Builtin(redact.Email)
+		require.NoError(t, err)
+		r := redact.New(append(custom, email)...)
+
+		found := new(redact.Redactions)
+		assert.Equal(t, "[STUDENT_ID_1] ([EMAIL_1]) and [STAFF_1]",
+			r.Redact("21K123456 (21K123456@x.ac.id) and S-0042", found))
+	})
+
+	t.Run("should reject bad lines", func(t *testing.T) {
+		for _, content := range []string{"no label here", "lower=\\d+", "BAD=[unclosed"} {
+			path := filepath.Join(t.TempDir(), "patterns")
+			require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+
+			_, err := redact.LoadPatterns(path)
+			assert.Error(t, err, content)
+		}
+	})
+
+	t.Run("should reject unknown built-ins", func(t *testing.T) {
+		_, err := redact.Builtin("passport")
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,105 @@
+package render_test
+
+import (
+	"app/src/render"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPdftoppm(t *testing.T) {
+	dir := t.TempDir()
+	argsFile := filepath.Join(dir, "args")
+
+	// Stand-ins for the real tools, recording how they were called
+	pdftoppm := writeScript(t, dir, "pdftoppm", `echo "$@" >> `+argsFile+`
+for last; do :; done
+printf 'PNG' > "$last.png"`)
+	cwebp := writeScript(t, dir, "cwebp", `echo "$@" >> `+argsFile+`
+test -f "$4" || exit 1
+printf 'WEBP' > "$6"`)
+
+	newRenderer := func() *render.Pdftoppm {
+		renderer := render.NewPdftoppm()
+		renderer.Command = pdftoppm
+		renderer.WebPCommand = cwebp
+		return renderer
+	}
+
+	readCalls := func(t *testing.T) []string {
+		args, err := os.ReadFile(argsFile)
+		require.NoError(t, err)
+		require.NoError(t, os.Remove(argsFile))
+		return strings.Split(strings.TrimSpace(string(args)), "\n")
+	}
+
+	t.Run("should render a page to PNG", func(t *testing.T) {
+		dest := filepath.Join(dir, "thumb.png")
+
+		err := newRenderer().Render(context.Background(), "/tmp/doc.pdf", dest, render.Options{
+			Page: 2, Width: 256, Format: render.FormatPNG,
+		})
+
+		require.NoError(t, err)
+		data, _ := os.ReadFile(dest)
+		assert.Equal(t, "PNG", string(data))
+
+		calls := readCalls(t)
+		require.Len(t, calls, 1)
+		assert.Contains(t, calls[0], "-f 2 -l 2 -scale-to-x 256 -scale-to-y -1 -png -singlefile /tmp/doc.pdf")
+	})
+
+	t.Run("should convert to WebP", func(t *testing.T) {
+		dest := filepath.Join(dir, "thumb.webp")
+
+		err := newRenderer().Render(context.Background(), "/tmp/doc.pdf", dest, render.Options{
+			Page: 1, Width: 512, Format: render.FormatWebP,
+		})
+
+		require.NoError(t, err)
+		data, _ := os.ReadFile(dest)
+		assert.Equal(t, "WEBP", string(data))
+
+		calls := readCalls(t)
+		require.Len(t, calls, 2)
+		assert.Contains(t, calls[0], "-png -singlefile /tmp/doc.pdf")
+		assert.NotContains(t, calls[0], "-upw")
+		assert.True(t, strings.HasPrefix(calls[1], "-quiet -q 80 "), calls[1])
+	})
+
+	t.Run("should reject unknown formats", func(t *testing.T) {
+		err := newRenderer().Render(context.Background(), "/tmp/doc.pdf", filepath.Join(dir, "thumb.gif"), render.Options{
+			Page: 1, Width: 256, Format: "gif",
+		})
+
+		assert.ErrorIs(t, err, render.ErrUnsupportedFormat)
+	})
+
+	t.Run("should report failing tools", func(t *testing.T) {
+		renderer := newRenderer()
+		renderer.Command = writeScript(t, dir, "broken", `echo "Syntax Error: Couldn't read xref table" >&2; exit 1`)
+
+		err := renderer.Render(context.Background(), "/tmp/doc.pdf", filepath.Join(dir, "broken.png"), render.Options{
+			Page: 1, Width: 256, Format: render.FormatPNG,
+		})
+
+		assert.ErrorContains(t, err, "Couldn't read xref table")
+		assert.NoFileExists(t, filepath.Join(dir, "broken.png"))
+	})
+}
+
+func TestContentType(t *testing.T) {
+	assert.Equal(t, "image/png", render.ContentType(render.FormatPNG))
+	assert.Equal(t, "image/webp", render.ContentType(render.FormatWebP))
+}
+
+func writeScript(t *testing.T, dir, name, body string) string {
+	path := filepath.Join(dir, name)
+	require.NoError(t, os.WriteFile(path, []byte("#!/bin/sh\n"+body+"\n"), 0o755))
+	return path
+}
@@ -0,0 +1,149 @@
+package scanner_test
+
+import (
+	"app/src/scanner"
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const eicar = `X5O!P%@AP[4\PZX54(P^)7CC)7}$EICAR-STANDARD-ANTIVIRUS-TEST-FILE!$H+H*`
+
+// fakeClamd answers INSTREAM commands like clamd does, flagging streams
+// that contain the EICAR test string and refusing streams over maxSize.
+func fakeClamd(t *testing.T, network, address string, maxSize int) string {
+	listener, err := net.Listen(network, address)
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go serveClamd(conn, maxSize)
+		}
+	}()
+
+	return network + "://" + listener.Addr().String()
+}
+
+func serveClamd(conn net.Conn, maxSize int) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+
+	command, err := r.ReadString(0)
+	if err != nil || command != "zINSTREAM\x00" {
+		conn.Write([]byte("UNKNOWN COMMAND\x00"))
+		return
+	}
+
+	var data bytes.Buffer
+	for {
+		var size uint32
+		if err := binary.Read(r, binary.BigEndian, &size); err != nil {
+			return
+		}
+		if size == 0 {
+			break
+		}
+		if data.Len()+int(size) > maxSize {
+			conn.Write([]byte("INSTREAM size limit exceeded. ERROR\x00"))
+			return
+		}
+		if _, err := io.CopyN(&data, r, int64(size)); err != nil {
+			return
+		}
+	}
+
+	if strings.Contains(data.String(), eicar) {
+		conn.Write([]byte("stream: Win.Test.EICAR_HDB-1 FOUND\x00"))
+		return
+	}
+	conn.Write([]byte("stream: OK\x00"))
+}
+
+func TestClamd(t *testing.T) {
+	address := fakeClamd(t, "tcp", "127.0.0.1:0", 1<<20)
+
+	newClamd := func(t *testing.T, address string) *scanner.Clamd {
+		clamd, err := scanner.NewClamd(address, 5*time.Second)
+		require.NoError(t, err)
+		// Small chunks make the fake reassemble the stream
+		clamd.ChunkSize = 16
+		return clamd
+	}
+
+	t.Run("should report clean files", func(t *testing.T) {
+		result, err := newClamd(t, address).Scan(context.Background(), strings.NewReader("%PDF-1.7 harmless"))
+
+		require.NoError(t, err)
+		assert.False(t, result.Infected)
+		assert.Empty(t, result.Signature)
+	})
+
+	t.Run("should report the signature of infected files", func(t *testing.T) {
+		result, err := newClamd(t, address).Scan(context.Background(), strings.NewReader("%PDF-1.7\n"+eicar))
+
+		require.NoError(t, err)
+		assert.True(t, result.Infected)
+		assert.Equal(t, "Win.Test.EICAR_HDB-1", result.Signature)
+	})
+
+	t.Run("should scan empty files", func(t *testing.T) {
+		result, err := newClamd(t, address).Scan(context.Background(), strings.NewReader(""))
+
+		require.NoError(t, err)
+		assert.False(t, result.Infected)
+	})
+
+	t.Run("should fail on daemon errors", func(t *testing.T) {
+		small := fakeClamd(t, "tcp", "127.0.0.1:0", 32)
+
+		_, err := newClamd(t, small).Scan(context.Background(), strings.NewReader(strings.Repeat("x", 64)))
+
+		assert.ErrorIs(t, err, scanner.ErrScanFailed)
+		assert.ErrorContains(t, err, "size limit exceeded")
+	})
+
+	t.Run("should fail when the daemon is unreachable", func(t *testing.T) {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		closed := "tcp://" + listener.Addr().String()
+		listener.Close()
+
+		_, err = newClamd(t, closed).Scan(context.Background(), strings.NewReader("data"))
+
+		assert.ErrorIs(t, err, scanner.ErrScanFailed)
+	})
+
+	t.Run("should connect over unix sockets", func(t *testing.T) {
+		socket := fakeClamd(t, "unix", filepath.Join(t.TempDir(), "clamd.sock"), 1<<20)
+
+		result, err := newClamd(t, socket).Scan(context.Background(), strings.NewReader(eicar))
+
+		require.NoError(t, err)
+		assert.True(t, result.Infected)
+	})
+}
+
+func TestNewClamd(t *testing.T) {
+	clamd, err := scanner.NewClamd("clamav:3310", time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, "tcp", clamd.Network)
+	assert.Equal(t, "clamav:3310", clamd.Address)
+
+	_, err = scanner.NewClamd("udp://clamav:3310", time.Minute)
+	assert.Error(t, err)
+}
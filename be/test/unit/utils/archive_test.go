@@ -0,0 +1,68 @@
+package utils_test
+
+import (
+	"app/src/utils"
+	"archive/zip"
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestArchiveEntryName(t *testing.T) {
+	t.Run("should return the base name of a nested entry", func(t *testing.T) {
+		name, err := utils.ArchiveEntryName("reports/2024/q1.pdf")
+		assert.NoError(t, err)
+		assert.Equal(t, "q1.pdf", name)
+	})
+
+	t.Run("should reject path traversal", func(t *testing.T) {
+		for _, name := range []string{"../evil.pdf", "docs/../../evil.pdf", "/etc/evil.pdf", `..\evil.pdf`, ""} {
+			_, err := utils.ArchiveEntryName(name)
+			assert.ErrorIs(t, err, utils.ErrUnsafePath, name)
+		}
+	})
+}
+
+func TestIsArchiveMetadata(t *testing.T) {
+	assert.True(t, utils.IsArchiveMetadata("__MACOSX/docs/._a.pdf"))
+	assert.True(t, utils.IsArchiveMetadata("docs/._a.pdf"))
+	assert.False(t, utils.IsArchiveMetadata("docs/a.pdf"))
+}
+
+func TestOpenArchiveEntry(t *testing.T) {
+	var buf bytes.Buffer
+	writer := zip.NewWriter(&buf)
+
+	small, _ := writer.Create("small.pdf")
+	small.Write([]byte("%PDF-1.4\nhello world"))
+
+	bomb, _ := writer.Create("bomb.pdf")
+	io.Copy(bomb, io.LimitReader(repeatReader(0), 1<<20))
+
+	assert.NoError(t, writer.Close())
+
+	archive, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	assert.NoError(t, err)
+
+	t.Run("should open an ordinary entry", func(t *testing.T) {
+		entry, err := utils.OpenArchiveEntry(archive.File[0], maxTestSize, 100)
+		assert.NoError(t, err)
+		defer entry.Close()
+
+		content, err := io.ReadAll(entry)
+		assert.NoError(t, err)
+		assert.Equal(t, "%PDF-1.4\nhello world", string(content))
+	})
+
+	t.Run("should reject a highly compressed entry", func(t *testing.T) {
+		_, err := utils.OpenArchiveEntry(archive.File[1], maxTestSize, 100)
+		assert.ErrorIs(t, err, utils.ErrSuspiciousArchive)
+	})
+
+	t.Run("should reject an entry larger than the limit", func(t *testing.T) {
+		_, err := utils.OpenArchiveEntry(archive.File[1], 1024, 10000)
+		assert.ErrorIs(t, err, utils.ErrFileTooLarge)
+	})
+}
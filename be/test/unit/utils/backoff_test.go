@@ -0,0 +1,20 @@
+package utils_test
+
+import (
+	"app/src/utils"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBackoff(t *testing.T) {
+	base, max := 30*time.Second, time.Hour
+
+	assert.Equal(t, 30*time.Second, utils.Backoff(1, base, max))
+	assert.Equal(t, time.Minute, utils.Backoff(2, base, max))
+	assert.Equal(t, 4*time.Minute, utils.Backoff(4, base, max))
+	assert.Equal(t, 32*time.Minute, utils.Backoff(7, base, max))
+	assert.Equal(t, max, utils.Backoff(8, base, max))
+	assert.Equal(t, max, utils.Backoff(100, base, max))
+}
@@ -0,0 +1,50 @@
+package utils_test
+
+import (
+	"app/src/utils"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsPublicAddr(t *testing.T) {
+	t.Run("should allow public addresses", func(t *testing.T) {
+		for _, addr := range []string{"8.8.8.8", "1.1.1.1", "2606:4700:4700::1111"} {
+			assert.True(t, utils.IsPublicAddr(netip.MustParseAddr(addr)), addr)
+		}
+	})
+
+	t.Run("should block internal and special-purpose addresses", func(t *testing.T) {
+		for _, addr := range []string{
+			"127.0.0.1", "10.0.0.1", "172.16.0.1", "192.168.1.1", "169.254.169.254",
+			"100.64.0.1", "0.0.0.0", "224.0.0.1", "255.255.255.255",
+			"::1", "::", "fe80::1", "fd00::1", "::ffff:127.0.0.1", "64:ff9b::a9fe:a9fe",
+			"2002:a9fe:a9fe::1", "2002:7f00:1::1", "2001:0:4136:e378:8000:63bf:f5ff:fffe",
+		} {
+			assert.False(t, utils.IsPublicAddr(netip.MustParseAddr(addr)), addr)
+		}
+	})
+}
+
+func TestNewPublicHTTPClient(t *testing.T) {
+	internal := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("secret"))
+	}))
+	defer internal.Close()
+
+	client := utils.NewPublicHTTPClient(5*time.Second, 5)
+
+	t.Run("should refuse to connect to loopback", func(t *testing.T) {
+		_, err := client.Get(internal.URL)
+		assert.ErrorIs(t, err, utils.ErrBlockedAddress)
+	})
+
+	t.Run("should refuse a host name resolving to loopback", func(t *testing.T) {
+		_, err := client.Get("http://localhost:" + internal.URL[len("http://127.0.0.1:"):])
+		assert.ErrorIs(t, err, utils.ErrBlockedAddress)
+	})
+}
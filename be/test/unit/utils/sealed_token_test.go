@@ -0,0 +1,49 @@
+package utils_test
+
+import (
+	"app/src/utils"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type sealedPayload struct {
+	ID       string `json:"id"`
+	Password string `json:"password"`
+}
+
+func TestSealToken(t *testing.T) {
+	payload := sealedPayload{ID: "pdf-1", Password: "s3cret"}
+
+	t.Run("should round-trip the payload", func(t *testing.T) {
+		token, err := utils.SealToken("secret", "unlock", payload)
+		assert.NoError(t, err)
+		assert.NotContains(t, token, "s3cret")
+
+		var got sealedPayload
+		assert.NoError(t, utils.OpenToken("secret", "unlock", token, &got))
+		assert.Equal(t, payload, got)
+	})
+
+	t.Run("should reject tokens for another purpose or secret", func(t *testing.T) {
+		token, err := utils.SealToken("secret", "unlock", payload)
+		assert.NoError(t, err)
+
+		var got sealedPayload
+		assert.ErrorIs(t, utils.OpenToken("secret", "share", token, &got), utils.ErrInvalidSealedToken)
+		assert.ErrorIs(t, utils.OpenToken("other", "unlock", token, &got), utils.ErrInvalidSealedToken)
+	})
+
+	t.Run("should reject tampered or malformed tokens", func(t *testing.T) {
+		token, err := utils.SealToken("secret", "unlock", payload)
+		assert.NoError(t, err)
+
+		tampered := []byte(token)
+		tampered[len(tampered)/2] ^= 'A' ^ 'B'
+
+		var got sealedPayload
+		assert.ErrorIs(t, utils.OpenToken("secret", "unlock", string(tampered), &got), utils.ErrInvalidSealedToken)
+		assert.ErrorIs(t, utils.OpenToken("secret", "unlock", "not a token!", &got), utils.ErrInvalidSealedToken)
+		assert.ErrorIs(t, utils.OpenToken("secret", "unlock", "", &got), utils.ErrInvalidSealedToken)
+	})
+}
@@ -0,0 +1,175 @@
+package utils_test
+
+import (
+	"app/src/utils"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const fileContent = "0123456789abcdefghij"
+
+func sendFileApp(t *testing.T) (*fiber.App, string) {
+	path := filepath.Join(t.TempDir(), "doc.pdf")
+	require.NoError(t, os.WriteFile(path, []byte(fileContent), 0o644))
+	modTime := time.Date(2026, 10, 18, 9, 0, 0, 0, time.UTC)
+	require.NoError(t, os.Chtimes(path, modTime, modTime))
+
+	app := fiber.New()
+	app.Get("/view", func(c *fiber.Ctx) error {
+		return utils.SendFile(c, utils.FileResponse{
+			Path:        path,
+			Filename:    "doc.pdf",
+			ContentType: "application/pdf",
+			ETag:        "abc123",
+		})
+	})
+	app.Get("/download", func(c *fiber.Ctx) error {
+		return utils.SendFile(c, utils.FileResponse{
+			Path:        path,
+			Filename:    "Résumé 2026.pdf",
+			ContentType: "application/pdf",
+			Attachment:  true,
+		})
+	})
+	return app, modTime.Format(http.TimeFormat)
+}
+
+func request(t *testing.T, app *fiber.App, path string, headers map[string]string) (*http.Response, string) {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return resp, string(body)
+}
+
+func TestSendFile(t *testing.T) {
+	app, lastModified := sendFileApp(t)
+
+	t.Run("should send the whole file with validators", func(t *testing.T) {
+		resp, body := request(t, app, "/view", nil)
+
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, fileContent, body)
+		assert.Equal(t, `"abc123"`, resp.Header.Get("ETag"))
+		assert.Equal(t, lastModified, resp.Header.Get("Last-Modified"))
+		assert.Equal(t, "bytes", resp.Header.Get("Accept-Ranges"))
+		assert.Equal(t, `inline; filename="doc.pdf"`, resp.Header.Get("Content-Disposition"))
+	})
+
+	t.Run("should answer matching If-None-Match with 304", func(t *testing.T) {
+		resp, body := request(t, app, "/view", map[string]string{"If-None-Match": `"other", W/"abc123"`})
+
+		assert.Equal(t, http.StatusNotModified, resp.StatusCode)
+		assert.Empty(t, body)
+	})
+
+	t.Run("should ignore If-Modified-Since when If-None-Match doesn't match", func(t *testing.T) {
+		resp, _ := request(t, app, "/view", map[string]string{
+			"If-None-Match":     `"other"`,
+			"If-Modified-Since": lastModified,
+		})
+
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+	})
+
+	t.Run("should answer If-Modified-Since with 304", func(t *testing.T) {
+		resp, _ := request(t, app, "/view", map[string]string{"If-Modified-Since": lastModified})
+
+		assert.Equal(t, http.StatusNotModified, resp.StatusCode)
+	})
+
+	t.Run("should send a single range", func(t *testing.T) {
+		resp, body := request(t, app, "/view", map[string]string{"Range": "bytes=5-9"})
+
+		assert.Equal(t, http.StatusPartialContent, resp.StatusCode)
+		assert.Equal(t, "56789", body)
+		assert.Equal(t, "bytes 5-9/20", resp.Header.Get("Content-Range"))
+		assert.Equal(t, "5", resp.Header.Get("Content-Length"))
+	})
+
+	t.Run("should send suffix and open-ended ranges", func(t *testing.T) {
+		_, body := request(t, app, "/view", map[string]string{"Range": "bytes=-3"})
+		assert.Equal(t, "hij", body)
+
+		_, body = request(t, app, "/view", map[string]string{"Range": "bytes=17-100"})
+		assert.Equal(t, "hij", body)
+	})
+
+	t.Run("should send several ranges as multipart/byteranges", func(t *testing.T) {
+		resp, body := request(t, app, "/view", map[string]string{"Range": "bytes=0-1, 10-11"})
+
+		assert.Equal(t, http.StatusPartialContent, resp.StatusCode)
+		mediaType, params, err := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+		require.NoError(t, err)
+		assert.Equal(t, "multipart/byteranges", mediaType)
+
+		reader := multipart.NewReader(strings.NewReader(body), params["boundary"])
+		var parts []string
+		for {
+			part, err := reader.NextPart()
+			if err == io.EOF {
+				break
+			}
+			require.NoError(t, err)
+			data, _ := io.ReadAll(part)
+			parts = append(parts, part.Header.Get("Content-Range")+" "+string(data))
+		}
+		assert.Equal(t, []string{"bytes 0-1/20 01", "bytes 10-11/20 ab"}, parts)
+	})
+
+	t.Run("should answer unsatisfiable ranges with 416", func(t *testing.T) {
+		resp, _ := request(t, app, "/view", map[string]string{"Range": "bytes=20-30"})
+
+		assert.Equal(t, http.StatusRequestedRangeNotSatisfiable, resp.StatusCode)
+		assert.Equal(t, "bytes */20", resp.Header.Get("Content-Range"))
+	})
+
+	t.Run("should ignore malformed ranges", func(t *testing.T) {
+		resp, body := request(t, app, "/view", map[string]string{"Range": "bytes=9-5"})
+
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, fileContent, body)
+	})
+
+	t.Run("should honour If-Range", func(t *testing.T) {
+		resp, _ := request(t, app, "/view", map[string]string{"Range": "bytes=0-1", "If-Range": `"abc123"`})
+		assert.Equal(t, http.StatusPartialContent, resp.StatusCode)
+
+		resp, _ = request(t, app, "/view", map[string]string{"Range": "bytes=0-1", "If-Range": lastModified})
+		assert.Equal(t, http.StatusPartialContent, resp.StatusCode)
+
+		resp, body := request(t, app, "/view", map[string]string{"Range": "bytes=0-1", "If-Range": `"stale"`})
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, fileContent, body)
+	})
+
+	t.Run("should encode non-ASCII attachment names", func(t *testing.T) {
+		resp, _ := request(t, app, "/download", nil)
+
+		assert.Equal(t, `attachment; filename="R_sum_ 2026.pdf"; filename*=UTF-8''R%C3%A9sum%C3%A9%202026.pdf`, resp.Header.Get("Content-Disposition"))
+		assert.Empty(t, resp.Header.Get("ETag"))
+	})
+}
+
+func TestContentDisposition(t *testing.T) {
+	assert.Equal(t, `inline; filename="report.pdf"`, utils.ContentDisposition("inline", "report.pdf"))
+	assert.Equal(t, `inline; filename="a_b_.pdf"; filename*=UTF-8''a%22b%5C.pdf`, utils.ContentDisposition("inline", `a"b\.pdf`))
+	assert.Equal(t, `attachment; filename="__.pdf"; filename*=UTF-8''%E6%97%A5%E6%9C%AC.pdf`, utils.ContentDisposition("attachment", "日本.pdf"))
+}
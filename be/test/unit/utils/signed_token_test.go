@@ -0,0 +1,58 @@
+package utils_test
+
+import (
+	"app/src/utils"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSignToken(t *testing.T) {
+	value := []byte("share-0123456789")
+
+	t.Run("should return the signed value", func(t *testing.T) {
+		token, err := utils.SignToken("secret", "share", value)
+		require.NoError(t, err)
+
+		again, err := utils.SignToken("secret", "share", value)
+		require.NoError(t, err)
+		assert.Equal(t, token, again)
+
+		got, err := utils.VerifySignedToken("secret", "share", token)
+		require.NoError(t, err)
+		assert.Equal(t, value, got)
+	})
+
+	t.Run("should reject tokens for another purpose or secret", func(t *testing.T) {
+		token, err := utils.SignToken("secret", "share", value)
+		require.NoError(t, err)
+
+		_, err = utils.VerifySignedToken("secret", "unlock", token)
+		assert.ErrorIs(t, err, utils.ErrInvalidSignedToken)
+		_, err = utils.VerifySignedToken("other", "share", token)
+		assert.ErrorIs(t, err, utils.ErrInvalidSignedToken)
+	})
+
+	t.Run("should reject tampered or malformed tokens", func(t *testing.T) {
+		token, err := utils.SignToken("secret", "share", value)
+		require.NoError(t, err)
+
+		encodedValue, encodedMAC, _ := strings.Cut(token, ".")
+		forged, err := utils.SignToken("secret", "share", []byte("share-9876543210"))
+		require.NoError(t, err)
+		forgedValue, _, _ := strings.Cut(forged, ".")
+
+		for _, bad := range []string{
+			forgedValue + "." + encodedMAC,
+			encodedValue + "." + encodedMAC[:len(encodedMAC)-2],
+			encodedValue,
+			"not a token!",
+			"",
+		} {
+			_, err := utils.VerifySignedToken("secret", "share", bad)
+			assert.ErrorIs(t, err, utils.ErrInvalidSignedToken, bad)
+		}
+	})
+}
@@ -0,0 +1,188 @@
+package utils_test
+
+import (
+	"app/src/config"
+	"app/src/middleware"
+	"app/src/utils"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+const maxTestSize = 10 * 1024 * 1024
+
+// pdfStream produces size bytes that sniff as a PDF without holding them in memory.
+func pdfStream(size int64) io.Reader {
+	header := "%PDF-1.4\n"
+	return io.MultiReader(
+		strings.NewReader(header),
+		io.LimitReader(repeatReader('a'), size-int64(len(header))),
+	)
+}
+
+type repeatReader byte
+
+func (r repeatReader) Read(p []byte) (int, error) {
+	for i := range p {
+		p[i] = byte(r)
+	}
+	return len(p), nil
+}
+
+func TestStoreStream(t *testing.T) {
+	t.Run("should store the file and return its hash", func(t *testing.T) {
+		content := []byte("%PDF-1.4\nhello world")
+		path := filepath.Join(t.TempDir(), "doc.pdf")
+
+		stored, err := utils.StoreStream(bytes.NewReader(content), path, maxTestSize, "application/pdf")
+		assert.NoError(t, err)
+
+		sum := sha256.Sum256(content)
+		assert.Equal(t, hex.EncodeToString(sum[:]), stored.Hash)
+		assert.Equal(t, int64(len(content)), stored.Size)
+
+		written, err := os.ReadFile(path)
+		assert.NoError(t, err)
+		assert.Equal(t, content, written)
+	})
+
+	t.Run("should reject a file with the wrong type", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "doc.pdf")
+
+		_, err := utils.StoreStream(strings.NewReader("just some text"), path, maxTestSize, "application/pdf")
+		assert.ErrorIs(t, err, utils.ErrInvalidFileType)
+		assert.NoFileExists(t, path)
+	})
+
+	t.Run("should stop reading once the size limit is exceeded", func(t *testing.T) {
+		dir := t.TempDir()
+		path := filepath.Join(dir, "doc.pdf")
+
+		_, err := utils.StoreStream(pdfStream(1<<40), path, 1024, "application/pdf")
+		assert.ErrorIs(t, err, utils.ErrFileTooLarge)
+
+		entries, _ := os.ReadDir(dir)
+		assert.Empty(t, entries)
+	})
+
+	t.Run("should store an upload below the request body limit", func(t *testing.T) {
+		url := startUploadServer(t)
+
+		assert.NoError(t, upload(url, 64*1024))
+	})
+
+	t.Run("should not buffer the upload in memory", func(t *testing.T) {
+		url := startUploadServer(t)
+		size := int64(8 * 1024 * 1024)
+
+		var before, after runtime.MemStats
+		runtime.GC()
+		runtime.ReadMemStats(&before)
+
+		assert.NoError(t, upload(url, size))
+
+		runtime.ReadMemStats(&after)
+		allocated := after.TotalAlloc - before.TotalAlloc
+		assert.Less(t, allocated, uint64(size/4), "allocated %d bytes for a %d byte upload", allocated, size)
+	})
+}
+
+// BenchmarkStreamingUpload sends concurrent multipart uploads through a
+// streaming fiber server. B/op stays roughly constant across file sizes,
+// showing bodies are not buffered, from below RequestBodyLimit to past it.
+func BenchmarkStreamingUpload(b *testing.B) {
+	url := startUploadServer(b)
+
+	for _, size := range []int64{256 << 10, 1 << 20, config.RequestBodyLimit, 8 << 20} {
+		b.Run(fmt.Sprintf("%dKB", size>>10), func(b *testing.B) {
+			b.ReportAllocs()
+			b.SetBytes(size)
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					if err := upload(url, size); err != nil {
+						b.Error(err)
+					}
+				}
+			})
+		})
+	}
+}
+
+func startUploadServer(tb testing.TB) string {
+	dir := tb.TempDir()
+	var count atomic.Int64
+
+	// The server is set up as in main, so bodies below RequestBodyLimit are
+	// read ahead the way they are in production
+	fiberConfig := config.FiberConfig()
+	fiberConfig.Prefork = false
+	fiberConfig.DisableStartupMessage = true
+
+	app := fiber.New(fiberConfig)
+	app.Use(middleware.BodyLimitConfig())
+	app.Post("/upload", func(c *fiber.Ctx) error {
+		part, err := utils.FormFileStream(c, "file")
+		if err != nil {
+			return fiber.ErrBadRequest
+		}
+		defer part.Close()
+
+		stored, err := utils.StoreStream(part, filepath.Join(dir, fmt.Sprintf("%d.pdf", count.Add(1))), maxTestSize, "application/pdf")
+		if err != nil {
+			return fiber.ErrBadRequest
+		}
+		defer os.Remove(stored.Path)
+
+		return c.SendStatus(fiber.StatusCreated)
+	})
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		tb.Fatal(err)
+	}
+	go app.Listener(listener)             //nolint:errcheck
+	tb.Cleanup(func() { app.Shutdown() }) //nolint:errcheck
+
+	return "http://" + listener.Addr().String() + "/upload"
+}
+
+func upload(url string, size int64) error {
+	body, writer := io.Pipe()
+	form := multipart.NewWriter(writer)
+
+	go func() {
+		part, err := form.CreateFormFile("file", fmt.Sprintf("%d.pdf", size))
+		if err == nil {
+			_, err = io.Copy(part, pdfStream(size))
+		}
+		if err == nil {
+			err = form.Close()
+		}
+		writer.CloseWithError(err)
+	}()
+
+	resp, err := http.Post(url, form.FormDataContentType(), body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,40 @@
+package utils_test
+
+import (
+	"app/src/utils"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseTusMetadata(t *testing.T) {
+	t.Run("should decode base64 values", func(t *testing.T) {
+		metadata, err := utils.ParseTusMetadata("filename ZG9jdW1lbnQucGRm,filetype YXBwbGljYXRpb24vcGRm")
+		assert.NoError(t, err)
+		assert.Equal(t, "document.pdf", metadata["filename"])
+		assert.Equal(t, "application/pdf", metadata["filetype"])
+	})
+
+	t.Run("should accept keys without a value", func(t *testing.T) {
+		metadata, err := utils.ParseTusMetadata("is_confidential,filename ZG9jdW1lbnQucGRm")
+		assert.NoError(t, err)
+		assert.Contains(t, metadata, "is_confidential")
+		assert.Equal(t, "", metadata["is_confidential"])
+	})
+
+	t.Run("should return an empty map for an empty header", func(t *testing.T) {
+		metadata, err := utils.ParseTusMetadata("")
+		assert.NoError(t, err)
+		assert.Empty(t, metadata)
+	})
+
+	t.Run("should reject invalid base64", func(t *testing.T) {
+		_, err := utils.ParseTusMetadata("filename not-base64!")
+		assert.Error(t, err)
+	})
+
+	t.Run("should reject duplicate keys", func(t *testing.T) {
+		_, err := utils.ParseTusMetadata("filename YQ==,filename Yg==")
+		assert.Error(t, err)
+	})
+}
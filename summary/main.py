@@ -46,10 +46,12 @@ class SummarizeResponse(BaseModel):
     success: bool
     error: str = ""
 
-def extract_text_from_pdf_bytes(pdf_bytes: bytes) -> str:
-    """Extract text from PDF bytes"""
+def extract_text_from_pdf_bytes(pdf_bytes: bytes, password: Optional[str] = None) -> str:
+    """Extract text from PDF bytes, decrypting with password when needed"""
     try:
         reader = PdfReader(io.BytesIO(pdf_bytes))
+        if reader.is_encrypted and not reader.decrypt(password or ""):
+            raise Exception("PDF is password protected")
         text = ""
         
         for page in reader.pages:
@@ -101,7 +103,7 @@ def get_target_language(lang_config: str, detected_lang: str) -> tuple:
     else:
         return detected_lang, "detected language"
 
-def summarize_text(text: str, target_lang: str, output_type: str) -> str:
+def summarize_text(text: str, target_lang: str, output_type: str, form_fields: Optional[str] = None) -> str:
     """Generate summary using Gemini AI based on config"""
     
     # Language instruction
@@ -132,6 +134,15 @@ def summarize_text(text: str, target_lang: str, output_type: str) -> str:
         - Highlight EXACTLY 5 MOST IMPORTANT terms using: <mark style="background-color: #2196F3; color: white;">term</mark>
         """
     
+    # Values filled into the document's form fields aren't part of its text
+    form_section = ""
+    if form_fields and form_fields.strip():
+        form_section = f"""
+    ---
+    Form field values (name: value):
+    {form_fields[:5000]}
+    """
+    
     prompt = f"""
     Summarize the following document in {lang_instruction}.
     
@@ -143,7 +154,7 @@ def summarize_text(text: str, target_lang: str, output_type: str) -> str:
     3. EXACTLY 5 highlighted terms total (no more, no less)
     4. Do NOT repeat highlights unnecessarily
     5. Avoid filler words
-    
+    {form_section}
     ---
     Document:
     {text[:15000]}
@@ -164,16 +175,20 @@ async def root():
 
 @app.post("/summarize", response_model=SummarizeResponse)
 async def summarize_pdf(
-    file: UploadFile = File(...),
+    file: Optional[UploadFile] = File(None),
     pdf_id: Optional[str] = Form(None),
     original_filename: Optional[str] = Form(None),
     file_size: Optional[str] = Form(None),
     language: str = Form("auto"),
-    output_type: str = Form("paragraph")
+    output_type: str = Form("paragraph"),
+    password: Optional[str] = Form(None),
+    text: Optional[str] = Form(None),
+    form_fields: Optional[str] = Form(None)
 ):
     """
     Endpoint untuk Golang Backend
     Terima file + config, return summary
+    Tanpa file (mis. teks sudah diredaksi), text wajib diisi
     """
     start_time = time.time()
     
@@ -185,19 +200,35 @@ async def summarize_pdf(
         print(f"  - Language Config: {language}")
         print(f"  - Output Type: {output_type}")
         
-        # Read file content
-        pdf_bytes = await file.read()
-        
-        if not pdf_bytes:
-            return SummarizeResponse(
-                summary_text="",
-                processing_time_ms=0,
-                success=False,
-                error="Empty file"
-            )
+        # Extract text, unless the backend already sent it (e.g. from OCR,
+        # or redacted, in which case the file isn't sent at all)
+        if text and text.strip():
+            print(f"  - Using text provided by backend")
+        else:
+            if file is None:
+                return SummarizeResponse(
+                    summary_text="",
+                    processing_time_ms=0,
+                    success=False,
+                    error="Either file or text is required"
+                )
+            
+            # Read file content
+            pdf_bytes = await file.read()
+            
+            if not pdf_bytes:
+                return SummarizeResponse(
+                    summary_text="",
+                    processing_time_ms=0,
+                    success=False,
+                    error="Empty file"
+                )
+            
+            text = extract_text_from_pdf_bytes(pdf_bytes, password)
         
-        # Extract text
-        text = extract_text_from_pdf_bytes(pdf_bytes)
+        # A filled-in form may have nothing but its field values
+        if not text.strip() and form_fields and form_fields.strip():
+            text, form_fields = form_fields, None
         
         if not text.strip():
             return SummarizeResponse(
@@ -217,7 +248,7 @@ async def summarize_pdf(
         print(f"  - Output Format: {output_type}")
         
         # Generate summary with config
-        summary = summarize_text(text, target_lang, output_type)
+        summary = summarize_text(text, target_lang, output_type, form_fields)
         
         # Calculate processing time
         processing_time = int((time.time() - start_time) * 1000)